@@ -0,0 +1,171 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"konzek-jun/dto"
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+	"konzek-jun/rbac"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler manages the personal API keys of the current user.
+type APIKeyHandler interface {
+	CreateAPIKey(ctx *fiber.Ctx) error
+	ListAPIKeys(ctx *fiber.Ctx) error
+	RevokeAPIKey(ctx *fiber.Ctx) error
+}
+
+type apiKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) APIKeyHandler {
+	return &apiKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// @Summary Creates an API key
+// @Description Creates a personal API key that is sent as "Authorization: ApiKey <key>". The key is only returned in this response. Its scopes are permissions the role of the user has; requests with the key need both.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param apiKey body dto.APIKeyRequest true "Name, scopes and optional expiry of the key"
+// @Success 201 {object} dto.APIKeyResponse "Created key with its secret"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 403 {object} globalerror.ErrorResponse "Forbidden"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /api-keys [post]
+func (h *apiKeyHandler) CreateAPIKey(ctx *fiber.Ctx) error {
+	loggerx.Info("CreateAPIKey function called")
+
+	userID, ok := middleware.UserID(ctx)
+	if !ok {
+		return unauthorized(ctx)
+	}
+	if _, isAPIKey := middleware.Scopes(ctx); isAPIKey {
+		return apiKeyError(ctx, http.StatusForbidden, "Authorization", "API keys cannot manage API keys, log in instead")
+	}
+
+	var request dto.APIKeyRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		log.Println("Request parsing error:", err)
+		return apiKeyError(ctx, http.StatusBadRequest, "APIKey", "Failed to process request")
+	}
+	if errors := globalerror.Validate(request); len(errors) > 0 && errors[0].HasError {
+		loggerx.Info("Invalid api key request")
+		return globalerror.HandleValidationErrors(ctx, errors)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return apiKeyError(ctx, http.StatusBadRequest, "expires_at", "expires_at must be in the future")
+	}
+
+	// A key cannot carry a permission the role of the user does not have
+	role, _ := middleware.Role(ctx)
+	for _, scope := range request.Scopes {
+		permission := rbac.Permission(scope)
+		if !permission.Valid() {
+			return apiKeyError(ctx, http.StatusBadRequest, "scopes", fmt.Sprintf("Unknown scope %s", scope))
+		}
+		if !rbac.Allowed(role, permission) {
+			return apiKeyError(ctx, http.StatusForbidden, "scopes", fmt.Sprintf("Your role does not have the permission %s", scope))
+		}
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(ctx.UserContext(), userID, request)
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	if err != nil {
+		return apiKeyError(ctx, http.StatusInternalServerError, "APIKey", "An error occurred while creating the API key")
+	}
+	return ctx.Status(http.StatusCreated).JSON(key)
+}
+
+// @Summary Lists API keys
+// @Description Lists the unrevoked API keys of the current user without their secrets
+// @Tags API Keys
+// @Produce json
+// @Success 200 {array} dto.APIKeyResponse "API keys"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /api-keys [get]
+func (h *apiKeyHandler) ListAPIKeys(ctx *fiber.Ctx) error {
+	loggerx.Info("ListAPIKeys function called")
+
+	userID, ok := middleware.UserID(ctx)
+	if !ok {
+		return unauthorized(ctx)
+	}
+	keys, err := h.apiKeyService.ListAPIKeys(ctx.UserContext(), userID)
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	if err != nil {
+		return apiKeyError(ctx, http.StatusInternalServerError, "APIKey", "An error occurred while listing API keys")
+	}
+	return ctx.Status(http.StatusOK).JSON(keys)
+}
+
+// @Summary Revokes an API key
+// @Description Revokes an API key of the current user; requests with the key are rejected at once
+// @Tags API Keys
+// @Param id path integer true "API key ID"
+// @Success 204 "Revoked"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 403 {object} globalerror.ErrorResponse "Forbidden"
+// @Failure 404 {object} globalerror.ErrorResponse "API key not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /api-keys/{id} [delete]
+func (h *apiKeyHandler) RevokeAPIKey(ctx *fiber.Ctx) error {
+	loggerx.Info("RevokeAPIKey function called")
+
+	userID, ok := middleware.UserID(ctx)
+	if !ok {
+		return unauthorized(ctx)
+	}
+	if _, isAPIKey := middleware.Scopes(ctx); isAPIKey {
+		return apiKeyError(ctx, http.StatusForbidden, "Authorization", "API keys cannot manage API keys, log in instead")
+	}
+	id, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil || id < 1 {
+		return apiKeyError(ctx, http.StatusBadRequest, "APIKey", "invalid API key id")
+	}
+
+	err = h.apiKeyService.RevokeAPIKey(ctx.UserContext(), userID, id)
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return apiKeyError(ctx, http.StatusNotFound, "APIKey", "API key not found")
+	}
+	if err != nil {
+		return apiKeyError(ctx, http.StatusInternalServerError, "APIKey", "An error occurred while revoking the API key")
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func apiKeyError(ctx *fiber.Ctx, status int, field, description string) error {
+	return ctx.Status(status).JSON(globalerror.ErrorResponse{
+		Status: int32(status),
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   field,
+				Description: description,
+			},
+		},
+	})
+}
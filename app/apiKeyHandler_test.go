@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"konzek-jun/dto"
+	"konzek-jun/middleware"
+	services "konzek-jun/mocks/service"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+)
+
+func TestAPIKeyHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	apiKeyMockService := services.NewMockAPIKeyService(ctrl)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyMockService)
+	var scopes []string
+	router := fiber.New()
+	router.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserIDKey, int64(1))
+		c.Locals(middleware.RoleKey, models.RoleViewer)
+		if scopes != nil {
+			c.Locals(middleware.ScopesKey, scopes)
+		}
+		return c.Next()
+	})
+	router.Post("/api/api-keys", apiKeyHandler.CreateAPIKey)
+	router.Get("/api/api-keys", apiKeyHandler.ListAPIKeys)
+	router.Delete("/api/api-keys/:id", apiKeyHandler.RevokeAPIKey)
+
+	send := func(method, target, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	apiKeyMockService.EXPECT().CreateAPIKey(gomock.Any(), int64(1), dto.APIKeyRequest{Name: "CI", Scopes: []string{"tasks:read"}}).
+		Return(dto.APIKeyResponse{ID: 1, Name: "CI", Key: "kzk_prefix_secret"}, nil)
+	assert.Equal(t, http.StatusCreated, send("POST", "/api/api-keys", `{"name":"CI","scopes":["tasks:read"]}`))
+
+	// Kapsam bilinen ve kullanıcının rolünde olan bir izin olmalıdır
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/api-keys", `{"name":"CI","scopes":["tasks:explode"]}`))
+	assert.Equal(t, http.StatusForbidden, send("POST", "/api/api-keys", `{"name":"CI","scopes":["tasks:write"]}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/api-keys", `{"name":"CI","scopes":[]}`))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "/api/api-keys", `{"name":"CI","scopes":["tasks:read"],"expires_at":"2020-01-01T00:00:00Z"}`))
+
+	apiKeyMockService.EXPECT().ListAPIKeys(gomock.Any(), int64(1)).Return([]dto.APIKeyResponse{{ID: 1, Name: "CI"}}, nil)
+	assert.Equal(t, http.StatusOK, send("GET", "/api/api-keys", ""))
+
+	apiKeyMockService.EXPECT().RevokeAPIKey(gomock.Any(), int64(1), int64(1)).Return(nil)
+	assert.Equal(t, http.StatusNoContent, send("DELETE", "/api/api-keys/1", ""))
+	apiKeyMockService.EXPECT().RevokeAPIKey(gomock.Any(), int64(1), int64(2)).Return(repository.ErrAPIKeyNotFound)
+	assert.Equal(t, http.StatusNotFound, send("DELETE", "/api/api-keys/2", ""))
+	apiKeyMockService.EXPECT().RevokeAPIKey(gomock.Any(), int64(1), int64(3)).Return(errors.New("database is down"))
+	assert.Equal(t, http.StatusInternalServerError, send("DELETE", "/api/api-keys/3", ""))
+
+	// API anahtarıyla gelen istekler anahtar oluşturamaz ve iptal edemez
+	scopes = []string{"tasks:read"}
+	assert.Equal(t, http.StatusForbidden, send("POST", "/api/api-keys", `{"name":"CI","scopes":["tasks:read"]}`))
+	assert.Equal(t, http.StatusForbidden, send("DELETE", "/api/api-keys/1", ""))
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"konzek-jun/dto"
 	"konzek-jun/globalerror"
 	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
 	"konzek-jun/services"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,19 +19,23 @@ import (
 type AuthHandler interface {
 	Login(ctx *fiber.Ctx) error
 	Register(ctx *fiber.Ctx) error
+	RefreshToken(ctx *fiber.Ctx) error
+	Logout(ctx *fiber.Ctx) error
+	LogoutAll(ctx *fiber.Ctx) error
+	RevokeUserSessions(ctx *fiber.Ctx) error
 }
 
 type authHandler struct {
-	authService services.AuthService
-	jwtService  services.JWTService
-	userService services.UserService
+	authService  services.AuthService
+	tokenService services.TokenService
+	userService  services.UserService
 }
 
-func NewAuthHandler(authService services.AuthService, jwtService services.JWTService, userService services.UserService) AuthHandler {
+func NewAuthHandler(authService services.AuthService, tokenService services.TokenService, userService services.UserService) AuthHandler {
 	return &authHandler{
-		authService: authService,
-		jwtService:  jwtService,
-		userService: userService,
+		authService:  authService,
+		tokenService: tokenService,
+		userService:  userService,
 	}
 }
 
@@ -40,9 +46,10 @@ func NewAuthHandler(authService services.AuthService, jwtService services.JWTSer
 // @Produce json
 // @Param email body string true "User email"
 // @Param password body string true "User password"
-// @Success 200 {object} dto.UserResponse "Logged in user information"
+// @Success 200 {object} dto.UserResponse "Logged in user information with an access and a refresh token"
 // @Failure 400 {object} globalerror.ErrorResponse "Bad request"
 // @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /auth/login [post]
 func (c *authHandler) Login(ctx *fiber.Ctx) error {
 	loggerx.Info("Login function called")
@@ -69,7 +76,10 @@ func (c *authHandler) Login(ctx *fiber.Ctx) error {
 	}
 
 	log.Printf("Verifying login request: Email - %s", loginRequest.Email)
-	err := c.authService.VerifyCredential(loginRequest.Email, loginRequest.Password)
+	err := c.authService.VerifyCredential(ctx.UserContext(), loginRequest.Email, loginRequest.Password)
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Login verification error: %s", err.Error()))
 		return ctx.Status(http.StatusUnauthorized).JSON(globalerror.ErrorResponse{
@@ -83,10 +93,11 @@ func (c *authHandler) Login(ctx *fiber.Ctx) error {
 		})
 	}
 
-	user, _ := c.userService.FindUserByEmail(loginRequest.Email)
+	user, _ := c.userService.FindUserByEmail(ctx.UserContext(), loginRequest.Email)
 
-	token := c.jwtService.GenerateToken(strconv.FormatInt(user.ID, 10))
-	user.Token = token
+	if err := c.issueTokens(ctx, user); err != nil {
+		return tokenError(ctx, "Login", err)
+	}
 	return ctx.Status(http.StatusOK).JSON(user)
 }
 
@@ -98,9 +109,10 @@ func (c *authHandler) Login(ctx *fiber.Ctx) error {
 // @Param email body string true "User email"
 // @Param password body string true "User password"
 // @Param name body string true "User name"
-// @Success 201 {object} dto.UserResponse "Registered user information"
+// @Success 201 {object} dto.UserResponse "Registered user information with an access and a refresh token"
 // @Failure 400 {object} globalerror.ErrorResponse "Bad request"
 // @Failure 422 {object} globalerror.ErrorResponse "Unprocessable entity"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /auth/register [post]
 func (c *authHandler) Register(ctx *fiber.Ctx) error {
 	loggerx.Info("Register function called")
@@ -127,7 +139,22 @@ func (c *authHandler) Register(ctx *fiber.Ctx) error {
 	}
 
 	log.Printf("Creating new user: Email - %s", registerRequest.Email)
-	user, err := c.userService.CreateUser(registerRequest)
+	user, err := c.userService.CreateUser(ctx.UserContext(), registerRequest)
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	if err != nil && !errors.Is(err, services.ErrUserExists) {
+		loggerx.Error(fmt.Sprintf("User creation error: %s", err.Error()))
+		return ctx.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Register",
+					Description: "An error occurred while creating the user",
+				},
+			},
+		})
+	}
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("User creation error: %s", err.Error()))
 		return ctx.Status(http.StatusUnprocessableEntity).JSON(globalerror.ErrorResponse{
@@ -141,7 +168,185 @@ func (c *authHandler) Register(ctx *fiber.Ctx) error {
 		})
 	}
 
-	token := c.jwtService.GenerateToken(strconv.FormatInt(user.ID, 10))
-	user.Token = token
+	if err := c.issueTokens(ctx, user); err != nil {
+		return tokenError(ctx, "Register", err)
+	}
 	return ctx.Status(http.StatusCreated).JSON(user)
 }
+
+// @Summary Renews an access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Every refresh token can be used once; using one again revokes all tokens issued since the login it came from.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param refresh body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} dto.TokenResponse "New token pair"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 401 {object} globalerror.ErrorResponse "The refresh token is invalid, expired or was already used"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /token/refresh [post]
+func (c *authHandler) RefreshToken(ctx *fiber.Ctx) error {
+	loggerx.Info("RefreshToken function called")
+
+	var refreshRequest dto.RefreshTokenRequest
+	if err := ctx.BodyParser(&refreshRequest); err != nil {
+		log.Println("Request parsing error:", err)
+		return ctx.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "RefreshToken",
+					Description: "Failed to process request",
+				},
+			},
+		})
+	}
+	if errors := globalerror.Validate(refreshRequest); len(errors) > 0 && errors[0].HasError {
+		return globalerror.HandleValidationErrors(ctx, errors)
+	}
+
+	pair, err := c.tokenService.RefreshTokens(ctx.UserContext(), refreshRequest.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		return ctx.Status(http.StatusUnauthorized).JSON(globalerror.ErrorResponse{
+			Status: http.StatusUnauthorized,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "RefreshToken",
+					Description: err.Error(),
+				},
+			},
+		})
+	}
+	if err != nil {
+		return tokenError(ctx, "RefreshToken", err)
+	}
+	return ctx.Status(http.StatusOK).JSON(dto.TokenResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    int64(pair.ExpiresIn.Seconds()),
+	})
+}
+
+// @Summary Logs out the current session
+// @Description Revokes the access token of the request, the other access tokens of its session and its refresh token
+// @Tags Authentication
+// @Success 204 "Logged out"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /logout [post]
+func (c *authHandler) Logout(ctx *fiber.Ctx) error {
+	loggerx.Info("Logout function called")
+
+	session, ok := middleware.CurrentSession(ctx)
+	if !ok {
+		return unauthorized(ctx)
+	}
+	if err := c.tokenService.Logout(ctx.UserContext(), session); err != nil {
+		return logoutError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Logs out every session
+// @Description Revokes the access and refresh tokens of every session of the current user. API keys cannot log out sessions.
+// @Tags Authentication
+// @Success 204 "Logged out"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 403 {object} globalerror.ErrorResponse "Forbidden"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /logout-all [post]
+func (c *authHandler) LogoutAll(ctx *fiber.Ctx) error {
+	loggerx.Info("LogoutAll function called")
+
+	userID, ok := middleware.UserID(ctx)
+	if !ok {
+		return unauthorized(ctx)
+	}
+	// A leaked API key cannot log its owner out
+	if _, isAPIKey := middleware.Scopes(ctx); isAPIKey {
+		return apiKeyError(ctx, http.StatusForbidden, "Authorization", "API keys cannot manage sessions, log in instead")
+	}
+	if err := c.tokenService.LogoutAll(ctx.UserContext(), userID); err != nil {
+		return logoutError(ctx, err)
+	}
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Revokes every session of a user
+// @Description Revokes the access and refresh tokens of every session of the user. Requires the users:manage permission; users log out their own sessions with /logout-all. API keys cannot revoke sessions.
+// @Tags Admin
+// @Param id path integer true "User ID"
+// @Success 204 "Sessions revoked"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 403 {object} globalerror.ErrorResponse "Forbidden"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/sessions [delete]
+func (c *authHandler) RevokeUserSessions(ctx *fiber.Ctx) error {
+	loggerx.Info("RevokeUserSessions function called")
+
+	if _, isAPIKey := middleware.Scopes(ctx); isAPIKey {
+		return apiKeyError(ctx, http.StatusForbidden, "Authorization", "API keys cannot manage sessions, log in instead")
+	}
+	userID, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil || userID < 1 {
+		return ctx.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "User",
+					Description: "invalid user id",
+				},
+			},
+		})
+	}
+
+	if err := c.tokenService.LogoutAll(ctx.UserContext(), userID); err != nil {
+		return logoutError(ctx, err)
+	}
+	loggerx.Info(fmt.Sprintf("Sessions of user %d revoked", userID))
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func logoutError(ctx *fiber.Ctx, err error) error {
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	loggerx.Error(fmt.Sprintf("Logout error: %s", err.Error()))
+	return ctx.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+		Status: http.StatusInternalServerError,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Logout",
+				Description: "An error occurred while revoking the tokens",
+			},
+		},
+	})
+}
+
+// issueTokens starts a new token family for user and adds the tokens to it.
+func (c *authHandler) issueTokens(ctx *fiber.Ctx, user *dto.UserResponse) error {
+	pair, err := c.tokenService.IssueTokens(ctx.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+	user.Token, user.RefreshToken = pair.AccessToken, pair.RefreshToken
+	user.ExpiresIn = int64(pair.ExpiresIn.Seconds())
+	return nil
+}
+
+func tokenError(ctx *fiber.Ctx, field string, err error) error {
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	loggerx.Error(fmt.Sprintf("Token error: %s", err.Error()))
+	return ctx.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+		Status: http.StatusInternalServerError,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   field,
+				Description: "An error occurred while issuing the tokens",
+			},
+		},
+	})
+}
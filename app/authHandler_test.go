@@ -3,16 +3,22 @@ package app
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
 	"konzek-jun/dto"
+	"konzek-jun/middleware"
 	services "konzek-jun/mocks/service"
+	"konzek-jun/models"
+	"konzek-jun/rbac"
+	x "konzek-jun/services"
 )
 
 func TestAuthHandler_Login(t *testing.T) {
@@ -21,11 +27,11 @@ func TestAuthHandler_Login(t *testing.T) {
 	defer ctrl.Finish()
 
 	authMockService := services.NewMockAuthService(ctrl)
-	jwtMockService := services.NewMockJWTService(ctrl)
+	tokenMockService := services.NewMockTokenService(ctrl)
 	userMockService := services.NewMockUserService(ctrl)
 
 	// Create AuthHandler instance
-	authHandler := NewAuthHandler(authMockService, jwtMockService, userMockService)
+	authHandler := NewAuthHandler(authMockService, tokenMockService, userMockService)
 	router := fiber.New()
 	router.Post("/api/login", authHandler.Login)
 	// Mock login request
@@ -43,13 +49,13 @@ func TestAuthHandler_Login(t *testing.T) {
 	}
 
 	// Mock AuthService.VerifyCredential to return no error
-	authMockService.EXPECT().VerifyCredential(loginRequest.Email, loginRequest.Password).Return(nil)
+	authMockService.EXPECT().VerifyCredential(gomock.Any(), loginRequest.Email, loginRequest.Password).Return(nil)
 
 	// Mock UserService.FindUserByEmail to return the mock user
-	userMockService.EXPECT().FindUserByEmail(loginRequest.Email).Return(&mockUser, nil)
+	userMockService.EXPECT().FindUserByEmail(gomock.Any(), loginRequest.Email).Return(&mockUser, nil)
 
-	// Mock JWTService.GenerateToken to return a token
-	jwtMockService.EXPECT().GenerateToken("1").Return("mock_token")
+	// Mock TokenService.IssueTokens to return a token pair
+	tokenMockService.EXPECT().IssueTokens(gomock.Any(), int64(1)).Return(x.TokenPair{AccessToken: "mock_token", RefreshToken: "mock_refresh", ExpiresIn: time.Minute}, nil)
 
 	// Prepare request
 	jsonData, _ := json.Marshal(loginRequest)
@@ -62,6 +68,13 @@ func TestAuthHandler_Login(t *testing.T) {
 	// Assert response status code
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
+	// Yanıtta iki token da bulunur
+	var body dto.UserResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "mock_token", body.Token)
+	assert.Equal(t, "mock_refresh", body.RefreshToken)
+	assert.Equal(t, int64(60), body.ExpiresIn)
+
 	// Assert response body
 	// Add more assertions as per your response structure
 	// Example: assert.Equal(t, expectedResponseBody, resp.Body)
@@ -73,11 +86,11 @@ func TestAuthHandler_Register(t *testing.T) {
 	defer ctrl.Finish()
 
 	authMockService := services.NewMockAuthService(ctrl)
-	jwtMockService := services.NewMockJWTService(ctrl)
+	tokenMockService := services.NewMockTokenService(ctrl)
 	userMockService := services.NewMockUserService(ctrl)
 
 	// Create AuthHandler instance
-	authHandler := NewAuthHandler(authMockService, jwtMockService, userMockService)
+	authHandler := NewAuthHandler(authMockService, tokenMockService, userMockService)
 	router := fiber.New()
 	router.Post("/api/register", authHandler.Register)
 	// Mock register request
@@ -93,10 +106,10 @@ func TestAuthHandler_Register(t *testing.T) {
 		Email: registerRequest.Email,
 	}
 	// Mock UserService.CreateUser to return no error
-	userMockService.EXPECT().CreateUser(registerRequest).Return(&mockUser, nil)
+	userMockService.EXPECT().CreateUser(gomock.Any(), registerRequest).Return(&mockUser, nil)
 
-	// Mock JWTService.GenerateToken to return a token
-	jwtMockService.EXPECT().GenerateToken(gomock.Any()).Return("mock_token")
+	// Mock TokenService.IssueTokens to return a token pair
+	tokenMockService.EXPECT().IssueTokens(gomock.Any(), int64(1)).Return(x.TokenPair{AccessToken: "mock_token", RefreshToken: "mock_refresh", ExpiresIn: time.Minute}, nil)
 
 	// Prepare request
 	jsonData, _ := json.Marshal(registerRequest)
@@ -113,3 +126,96 @@ func TestAuthHandler_Register(t *testing.T) {
 	// Add more assertions as per your response structure
 	// Example: assert.Equal(t, expectedResponseBody, resp.Body)
 }
+
+func TestAuthHandler_RefreshToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenMockService := services.NewMockTokenService(ctrl)
+	authHandler := NewAuthHandler(services.NewMockAuthService(ctrl), tokenMockService, services.NewMockUserService(ctrl))
+	router := fiber.New()
+	router.Post("/api/token/refresh", authHandler.RefreshToken)
+
+	refresh := func(body string) *http.Response {
+		req := httptest.NewRequest("POST", "/api/token/refresh", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Geçerli refresh token yeni bir çift alır
+	tokenMockService.EXPECT().RefreshTokens(gomock.Any(), "current").Return(x.TokenPair{AccessToken: "access", RefreshToken: "next", ExpiresIn: time.Minute}, nil)
+	resp := refresh(`{"refresh_token":"current"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var body dto.TokenResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, dto.TokenResponse{Token: "access", RefreshToken: "next", ExpiresIn: 60}, body)
+
+	// Kullanılmış ya da bilinmeyen token 401 alır
+	tokenMockService.EXPECT().RefreshTokens(gomock.Any(), "current").Return(x.TokenPair{}, x.ErrInvalidRefreshToken)
+	resp = refresh(`{"refresh_token":"current"}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = refresh(`{}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenMockService := services.NewMockTokenService(ctrl)
+	authHandler := NewAuthHandler(services.NewMockAuthService(ctrl), tokenMockService, services.NewMockUserService(ctrl))
+	session := x.Session{UserID: 1, TokenID: "jti", SessionID: "sid", ExpiresAt: time.Now().Add(time.Minute)}
+	role := models.RoleMember
+	var scopes []string
+	router := fiber.New()
+	router.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserIDKey, session.UserID)
+		c.Locals(middleware.SessionKey, session)
+		c.Locals(middleware.RoleKey, role)
+		if scopes != nil {
+			c.Locals(middleware.ScopesKey, scopes)
+		}
+		return c.Next()
+	})
+	router.Post("/api/logout", authHandler.Logout)
+	router.Post("/api/logout-all", authHandler.LogoutAll)
+	router.Delete("/api/admin/users/:id/sessions", rbac.Require(rbac.UsersManage), authHandler.RevokeUserSessions)
+
+	send := func(method, target string) int {
+		resp, err := router.Test(httptest.NewRequest(method, target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	// Sadece isteğin oturumu kapatılır
+	tokenMockService.EXPECT().Logout(gomock.Any(), session).Return(nil)
+	assert.Equal(t, http.StatusNoContent, send("POST", "/api/logout"))
+
+	tokenMockService.EXPECT().LogoutAll(gomock.Any(), int64(1)).Return(nil)
+	assert.Equal(t, http.StatusNoContent, send("POST", "/api/logout-all"))
+
+	tokenMockService.EXPECT().LogoutAll(gomock.Any(), int64(1)).Return(errors.New("database is down"))
+	assert.Equal(t, http.StatusInternalServerError, send("POST", "/api/logout-all"))
+
+	// Admin rotası users:manage ister, kendi oturumları da logout-all ile kapatılır
+	assert.Equal(t, http.StatusForbidden, send("DELETE", "/api/admin/users/1/sessions"))
+	assert.Equal(t, http.StatusForbidden, send("DELETE", "/api/admin/users/2/sessions"))
+
+	role = models.RoleAdmin
+	tokenMockService.EXPECT().LogoutAll(gomock.Any(), int64(2)).Return(nil)
+	assert.Equal(t, http.StatusNoContent, send("DELETE", "/api/admin/users/2/sessions"))
+	assert.Equal(t, http.StatusBadRequest, send("DELETE", "/api/admin/users/abc/sessions"))
+
+	// API anahtarı, izni olsa bile oturumları kapatamaz
+	scopes = []string{"tasks:read", "users:manage"}
+	assert.Equal(t, http.StatusForbidden, send("POST", "/api/logout-all"))
+	assert.Equal(t, http.StatusForbidden, send("DELETE", "/api/admin/users/1/sessions"))
+	assert.Equal(t, http.StatusForbidden, send("DELETE", "/api/admin/users/2/sessions"))
+}
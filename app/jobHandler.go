@@ -0,0 +1,240 @@
+package app
+
+import (
+	"errors"
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobHandler struct {
+	Service services.JobService
+}
+
+func NewJobHandler(service services.JobService) *JobHandler {
+	return &JobHandler{
+		Service: service,
+	}
+}
+
+// @Summary Retrieves the execution status of a job
+// @Description Retrieves the status, attempts, result and last error of a job submitted through POST /tasks
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Param id path integer true "Job ID"
+// @Success 200 {object} models.Job "Job object"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /jobs/{id} [get]
+func (h *JobHandler) GetJob(c *fiber.Ctx) error {
+	loggerx.Info("GetJob function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return jobNotFound(c)
+	}
+
+	job, err := h.Service.JobGet(c.UserContext(), ownerID, id)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrJobNotFound) {
+		return jobNotFound(c)
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Job",
+					Description: "An error occurred while loading the job",
+				},
+			},
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(job)
+}
+
+// Dead-letter endpoints are under /api/admin and cover the dead jobs of every
+// user.
+
+// @Summary Lists dead-lettered jobs
+// @Description Lists the jobs of every user that ran out of attempts or failed with a non-retryable error
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} models.Job "Dead jobs"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/jobs/dead [get]
+func (h *JobHandler) ListDeadJobs(c *fiber.Ctx) error {
+	loggerx.Info("ListDeadJobs function called")
+
+	jobs, err := h.Service.DeadJobList(c.UserContext())
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if err != nil {
+		return deadJobError(c, "An error occurred while listing dead jobs")
+	}
+	return c.Status(http.StatusOK).JSON(jobs)
+}
+
+// @Summary Retrieves a dead-lettered job
+// @Description Retrieves a dead job with its attempts and last error
+// @Tags Admin
+// @Produce json
+// @Param id path integer true "Job ID"
+// @Success 200 {object} models.Job "Dead job"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/jobs/dead/{id} [get]
+func (h *JobHandler) GetDeadJob(c *fiber.Ctx) error {
+	loggerx.Info("GetDeadJob function called")
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return jobNotFound(c)
+	}
+
+	job, err := h.Service.DeadJobGet(c.UserContext(), id)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrJobNotFound) {
+		return jobNotFound(c)
+	}
+	if err != nil {
+		return deadJobError(c, "An error occurred while loading the job")
+	}
+	return c.Status(http.StatusOK).JSON(job)
+}
+
+// @Summary Requeues a dead-lettered job
+// @Description Puts a dead job back to pending with a fresh set of attempts
+// @Tags Admin
+// @Produce json
+// @Param id path integer true "Job ID"
+// @Param Idempotency-Key header string false "Key that makes retries safe, a repeated request with the same key gets the first response"
+// @Success 202 {object} JobAcceptedResponse "Job requeued"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 409 {object} globalerror.ErrorResponse "A request with the same Idempotency-Key is still in progress"
+// @Failure 422 {object} globalerror.ErrorResponse "The Idempotency-Key was used for another request"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/jobs/dead/{id}/requeue [post]
+func (h *JobHandler) RequeueDeadJob(c *fiber.Ctx) error {
+	loggerx.Info("RequeueDeadJob function called")
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return jobNotFound(c)
+	}
+
+	err = h.Service.DeadJobRequeue(c.UserContext(), id)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrJobNotFound) {
+		return jobNotFound(c)
+	}
+	if err != nil {
+		return deadJobError(c, "An error occurred while requeueing the job")
+	}
+
+	c.Location("/api/jobs/" + strconv.Itoa(id))
+	return c.Status(http.StatusAccepted).JSON(JobAcceptedResponse{JobID: int64(id), JobStatus: models.JobStatusPending})
+}
+
+// @Summary Purges a dead-lettered job
+// @Description Deletes a dead job together with its task
+// @Tags Admin
+// @Param id path integer true "Job ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/jobs/dead/{id} [delete]
+func (h *JobHandler) PurgeDeadJob(c *fiber.Ctx) error {
+	loggerx.Info("PurgeDeadJob function called")
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return jobNotFound(c)
+	}
+
+	err = h.Service.DeadJobPurge(c.UserContext(), id)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrJobNotFound) {
+		return jobNotFound(c)
+	}
+	if err != nil {
+		return deadJobError(c, "An error occurred while purging the job")
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Purges all dead-lettered jobs
+// @Description Deletes the dead jobs of every user together with their tasks
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} PurgeResponse "Number of purged jobs"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/jobs/dead [delete]
+func (h *JobHandler) PurgeDeadJobs(c *fiber.Ctx) error {
+	loggerx.Info("PurgeDeadJobs function called")
+
+	purged, err := h.Service.DeadJobPurgeAll(c.UserContext())
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if err != nil {
+		return deadJobError(c, "An error occurred while purging dead jobs")
+	}
+	return c.Status(http.StatusOK).JSON(PurgeResponse{Purged: purged})
+}
+
+func deadJobError(c *fiber.Ctx, description string) error {
+	return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+		Status: http.StatusInternalServerError,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Job",
+				Description: description,
+			},
+		},
+	})
+}
+
+func jobNotFound(c *fiber.Ctx) error {
+	return c.Status(http.StatusNotFound).JSON(globalerror.ErrorResponse{
+		Status: http.StatusNotFound,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Job",
+				Description: "Job not found",
+			},
+		},
+	})
+}
+
+type JobAcceptedResponse struct {
+	JobID     int64            `json:"job_id"`
+	JobStatus models.JobStatus `json:"job_status"`
+}
+
+type PurgeResponse struct {
+	Purged int64 `json:"purged"`
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	services "konzek-jun/mocks/service"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobHandler_GetJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jobMockService := services.NewMockJobService(ctrl)
+	jh := NewJobHandler(jobMockService)
+	router := authenticatedRouter(1)
+	router.Get("/api/jobs/:id", jh.GetJob)
+
+	jobMockService.EXPECT().JobGet(gomock.Any(), int64(1), 7).Return(models.Job{ID: 7, Type: "echo", Status: models.JobStatusSucceeded, Attempts: 1}, nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/jobs/7", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job models.Job
+	json.NewDecoder(resp.Body).Decode(&job)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+}
+
+func TestJobHandler_GetJob_OtherUsersJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jobMockService := services.NewMockJobService(ctrl)
+	jh := NewJobHandler(jobMockService)
+	router := authenticatedRouter(2)
+	router.Get("/api/jobs/:id", jh.GetJob)
+
+	jobMockService.EXPECT().JobGet(gomock.Any(), int64(2), 7).Return(models.Job{}, repository.ErrJobNotFound)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/jobs/7", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestJobHandler_ListDeadJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jobMockService := services.NewMockJobService(ctrl)
+	jh := NewJobHandler(jobMockService)
+	router := authenticatedRouter(1)
+	router.Get("/api/admin/jobs/dead", jh.ListDeadJobs)
+
+	// Admin bütün kullanıcıların dead job'larını görür
+	jobMockService.EXPECT().DeadJobList(gomock.Any()).Return([]models.Job{{ID: 7, OwnerID: 2, Status: models.JobStatusDead, Attempts: 3, LastError: "boom"}}, nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/admin/jobs/dead", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jobs []models.Job
+	json.NewDecoder(resp.Body).Decode(&jobs)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "boom", jobs[0].LastError)
+}
+
+func TestJobHandler_RequeueDeadJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jobMockService := services.NewMockJobService(ctrl)
+	jh := NewJobHandler(jobMockService)
+	router := authenticatedRouter(1)
+	router.Post("/api/admin/jobs/dead/:id/requeue", jh.RequeueDeadJob)
+
+	jobMockService.EXPECT().DeadJobRequeue(gomock.Any(), 7).Return(nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodPost, "/api/admin/jobs/dead/7/requeue", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "/api/jobs/7", resp.Header.Get("Location"))
+}
+
+func TestJobHandler_PurgeDeadJob_NotDead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jobMockService := services.NewMockJobService(ctrl)
+	jh := NewJobHandler(jobMockService)
+	router := authenticatedRouter(1)
+	router.Delete("/api/admin/jobs/dead/:id", jh.PurgeDeadJob)
+
+	jobMockService.EXPECT().DeadJobPurge(gomock.Any(), 7).Return(repository.ErrJobNotFound)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodDelete, "/api/admin/jobs/dead/7", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"net/http"
+
+	"konzek-jun/loggerx"
+	"konzek-jun/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// KeyHandler publishes the public keys access tokens are signed with, so
+// that other services can verify them without a shared secret.
+type KeyHandler interface {
+	JWKS(ctx *fiber.Ctx) error
+}
+
+type keyHandler struct {
+	jwtService services.JWTService
+}
+
+func NewKeyHandler(jwtService services.JWTService) KeyHandler {
+	return &keyHandler{
+		jwtService: jwtService,
+	}
+}
+
+// @Summary Lists the token verification keys
+// @Description Returns the public keys access tokens are verified with as a JSON Web Key Set. The kid header of a token names its key. The set is empty when tokens are signed with HS256.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} services.JSONWebKeySet "Public keys"
+// @Router /.well-known/jwks.json [get]
+func (h *keyHandler) JWKS(ctx *fiber.Ctx) error {
+	loggerx.Info("JWKS function called")
+
+	// Keys only change on a restart, and the old key stays listed for a while
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.Status(http.StatusOK).JSON(h.jwtService.JWKS())
+}
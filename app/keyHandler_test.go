@@ -0,0 +1,38 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	services "konzek-jun/mocks/service"
+	x "konzek-jun/services"
+)
+
+func TestKeyHandler_JWKS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	jwtMockService := services.NewMockJWTService(ctrl)
+	keyHandler := NewKeyHandler(jwtMockService)
+	router := fiber.New()
+	router.Get("/.well-known/jwks.json", keyHandler.JWKS)
+
+	jwks := x.JSONWebKeySet{Keys: []x.JSONWebKey{{KeyType: "OKP", Use: "sig", Algorithm: "EdDSA", KeyID: "kid", Curve: "Ed25519", X: "key"}}}
+	jwtMockService.EXPECT().JWKS().Return(jwks)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "public, max-age=300", resp.Header.Get(fiber.HeaderCacheControl))
+	var body x.JSONWebKeySet
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, jwks, body)
+}
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/scheduler"
+	"konzek-jun/services"
+	"konzek-jun/worker"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ScheduleHandler struct {
+	Service services.ScheduleService
+	// Registry is used to reject schedules with an unknown job type. Optional.
+	Registry *worker.Registry
+}
+
+func NewScheduleHandler(service services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{
+		Service: service,
+	}
+}
+
+// @Summary Creates a schedule
+// @Description Creates a task from the template on a cron expression, on a fixed interval or once at run_at
+// @Tags Schedules
+// @Accept json
+// @Produce json
+// @Param schedule body models.Schedule true "Schedule to create"
+// @Success 201 {object} models.Schedule "Created schedule"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /schedules [post]
+func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
+	loggerx.Info("CreateSchedule function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	var schedule models.Schedule
+	if err := c.BodyParser(&schedule); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Schedule",
+					Description: "Failed to process request",
+				},
+			},
+		})
+	}
+	schedule.OwnerID = ownerID
+	schedule.NextRunAt = nil
+	schedule.LastRunAt = nil
+
+	if errors := globalerror.Validate(schedule); len(errors) > 0 && errors[0].HasError {
+		return globalerror.HandleValidationErrors(c, errors)
+	}
+
+	if schedule.JobType != "" && h.Registry != nil && !h.Registry.Has(schedule.JobType) {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "job_type",
+					Description: fmt.Sprintf("Unknown job type %q", schedule.JobType),
+				},
+			},
+		})
+	}
+
+	created, err := h.Service.ScheduleCreate(c.UserContext(), schedule)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	var specErr *scheduler.SpecError
+	if errors.As(err, &specErr) {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   specErr.Field,
+					Description: specErr.Err.Error(),
+				},
+			},
+		})
+	}
+	if err != nil {
+		return scheduleError(c, "An error occurred while creating the schedule")
+	}
+
+	loggerx.Info("Schedule created successfully")
+	c.Location("/api/schedules/" + strconv.Itoa(created.ID))
+	return c.Status(http.StatusCreated).JSON(created)
+}
+
+// @Summary Retrieves all schedules
+// @Description Retrieves all schedules of the user with their next run time
+// @Tags Schedules
+// @Produce json
+// @Success 200 {array} models.Schedule "List of schedules"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /schedules [get]
+func (h *ScheduleHandler) GetAllSchedules(c *fiber.Ctx) error {
+	loggerx.Info("GetAllSchedules function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	schedules, err := h.Service.ScheduleGetAll(c.UserContext(), ownerID)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if err != nil {
+		return scheduleError(c, "An error occurred while fetching the schedules")
+	}
+	return c.Status(http.StatusOK).JSON(schedules)
+}
+
+// @Summary Retrieves a schedule by its ID
+// @Description Retrieves a schedule by its ID
+// @Tags Schedules
+// @Produce json
+// @Param id path integer true "Schedule ID"
+// @Success 200 {object} models.Schedule "Schedule object"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /schedules/{id} [get]
+func (h *ScheduleHandler) GetSchedule(c *fiber.Ctx) error {
+	loggerx.Info("GetSchedule function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return scheduleNotFound(c)
+	}
+
+	schedule, err := h.Service.ScheduleGetByID(c.UserContext(), ownerID, id)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrScheduleNotFound) {
+		return scheduleNotFound(c)
+	}
+	if err != nil {
+		return scheduleError(c, "An error occurred while loading the schedule")
+	}
+	return c.Status(http.StatusOK).JSON(schedule)
+}
+
+// @Summary Deletes a schedule
+// @Description Deletes a schedule; tasks it already created are kept
+// @Tags Schedules
+// @Param id path integer true "Schedule ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /schedules/{id} [delete]
+func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
+	loggerx.Info("DeleteSchedule function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return scheduleNotFound(c)
+	}
+
+	err = h.Service.ScheduleDelete(c.UserContext(), ownerID, id)
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrScheduleNotFound) {
+		return scheduleNotFound(c)
+	}
+	if err != nil {
+		return scheduleError(c, "An error occurred while deleting the schedule")
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// @Summary Pauses a schedule
+// @Description Stops a schedule from creating tasks until it is resumed
+// @Tags Schedules
+// @Produce json
+// @Param id path integer true "Schedule ID"
+// @Success 200 {object} models.Schedule "Paused schedule"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /schedules/{id}/pause [post]
+func (h *ScheduleHandler) PauseSchedule(c *fiber.Ctx) error {
+	loggerx.Info("PauseSchedule function called")
+	return h.setPaused(c, true)
+}
+
+// @Summary Resumes a schedule
+// @Description Resumes a paused schedule from now on; runs missed while it was paused are skipped
+// @Tags Schedules
+// @Produce json
+// @Param id path integer true "Schedule ID"
+// @Success 200 {object} models.Schedule "Resumed schedule"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /schedules/{id}/resume [post]
+func (h *ScheduleHandler) ResumeSchedule(c *fiber.Ctx) error {
+	loggerx.Info("ResumeSchedule function called")
+	return h.setPaused(c, false)
+}
+
+func (h *ScheduleHandler) setPaused(c *fiber.Ctx, paused bool) error {
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return scheduleNotFound(c)
+	}
+
+	var schedule models.Schedule
+	if paused {
+		schedule, err = h.Service.SchedulePause(c.UserContext(), ownerID, id)
+	} else {
+		schedule, err = h.Service.ScheduleResume(c.UserContext(), ownerID, id)
+	}
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrScheduleNotFound) {
+		return scheduleNotFound(c)
+	}
+	if err != nil {
+		return scheduleError(c, "An error occurred while updating the schedule")
+	}
+	return c.Status(http.StatusOK).JSON(schedule)
+}
+
+func scheduleNotFound(c *fiber.Ctx) error {
+	return c.Status(http.StatusNotFound).JSON(globalerror.ErrorResponse{
+		Status: http.StatusNotFound,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Schedule",
+				Description: "Schedule not found",
+			},
+		},
+	})
+}
+
+func scheduleError(c *fiber.Ctx, description string) error {
+	return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+		Status: http.StatusInternalServerError,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Schedule",
+				Description: description,
+			},
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	services "konzek-jun/mocks/service"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	x "konzek-jun/services"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleHandler_CreateSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scheduleMockService := services.NewMockScheduleService(ctrl)
+	sh := NewScheduleHandler(scheduleMockService)
+	router := authenticatedRouter(1)
+	router.Post("/api/schedules", sh.CreateSchedule)
+
+	schedule := models.Schedule{Name: "Nightly", Cron: "0 3 * * *", Title: "Nightly", Content: "Nightly cleanup"}
+	expected := schedule
+	expected.OwnerID = 1
+	scheduleMockService.EXPECT().ScheduleCreate(gomock.Any(), expected).Return(models.Schedule{ID: 4, OwnerID: 1, Name: "Nightly"}, nil)
+
+	body, _ := json.Marshal(schedule)
+	req := httptest.NewRequest(http.MethodPost, "/api/schedules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/api/schedules/4", resp.Header.Get("Location"))
+}
+
+func TestScheduleHandler_CreateSchedule_InvalidSpec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Gerçek servis kullanılır, geçersiz cron veritabanına ulaşmadan reddedilir
+	sh := NewScheduleHandler(x.NewScheduleService(repository.NewMemoryScheduleRepository()))
+	router := authenticatedRouter(1)
+	router.Post("/api/schedules", sh.CreateSchedule)
+
+	body, _ := json.Marshal(models.Schedule{Name: "Broken", Cron: "every day", Title: "Broken", Content: "Broken"})
+	req := httptest.NewRequest(http.MethodPost, "/api/schedules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestScheduleHandler_PauseSchedule_OtherUsersSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scheduleMockService := services.NewMockScheduleService(ctrl)
+	sh := NewScheduleHandler(scheduleMockService)
+	router := authenticatedRouter(2)
+	router.Post("/api/schedules/:id/pause", sh.PauseSchedule)
+
+	scheduleMockService.EXPECT().SchedulePause(gomock.Any(), int64(2), 4).Return(models.Schedule{}, repository.ErrScheduleNotFound)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodPost, "/api/schedules/4/pause", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"konzek-jun/dto"
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+	"net/http"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bulkChunkSize is how many operations of a best_effort bulk request run on
+// one worker.
+const bulkChunkSize = 100
+
+// @Summary Creates, updates and deletes tasks in one request
+// @Description Runs up to 1000 operations. In atomic mode all of them are applied in one transaction or none; in best_effort mode, the default, they are spread across the worker pool and succeed or fail on their own. Every operation has a result with the status it would have had as a request of its own.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param bulk body dto.BulkTaskRequest true "Operations"
+// @Param Idempotency-Key header string false "Key that makes retries safe, a repeated request with the same key and body gets the first response"
+// @Success 200 {object} dto.BulkTaskResponse "Every operation succeeded"
+// @Success 207 {object} dto.BulkTaskResponse "Some operations of a best_effort request failed"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request, or an atomic request with an invalid operation"
+// @Failure 404 {object} dto.BulkTaskResponse "An atomic request failed on a missing task"
+// @Failure 409 {object} dto.BulkTaskResponse "An atomic request failed on an illegal transition or open prerequisites, or a request with the same Idempotency-Key is still in progress"
+// @Failure 412 {object} dto.BulkTaskResponse "An atomic request failed on a stale version"
+// @Failure 422 {object} globalerror.ErrorResponse "The Idempotency-Key was used for another request"
+// @Failure 500 {object} dto.BulkTaskResponse "Internal server error"
+// @Router /tasks/bulk [post]
+func (h *TaskHandler) BulkTasks(c *fiber.Ctx) error {
+	loggerx.Info("BulkTasks function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	var request dto.BulkTaskRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Bulk",
+					Description: "Failed to process request",
+				},
+			},
+		})
+	}
+	if errors := globalerror.Validate(request); len(errors) > 0 && errors[0].HasError {
+		return globalerror.HandleValidationErrors(c, errors)
+	}
+	if request.Mode == "" {
+		request.Mode = dto.BulkModeBestEffort
+	}
+	atomic := request.Mode == dto.BulkModeAtomic
+
+	response := dto.BulkTaskResponse{Mode: request.Mode, Results: make([]dto.BulkTaskResult, len(request.Operations))}
+	var operations []services.BulkTaskOperation
+	var indexes []int
+	for i, requested := range request.Operations {
+		response.Results[i].Index = i
+		operation, invalid := h.bulkOperation(requested)
+		if invalid != nil {
+			response.Results[i].Status = http.StatusBadRequest
+			response.Results[i].Error = invalid
+			continue
+		}
+		operations = append(operations, operation)
+		indexes = append(indexes, i)
+	}
+
+	// When an atomic request has an invalid operation, none of them runs
+	if atomic && len(operations) < len(request.Operations) {
+		for _, i := range indexes {
+			status, body := bulkError(services.ErrBulkRolledBack)
+			response.Results[i].Status, response.Results[i].Error = status, &body
+		}
+		return h.bulkResponse(c, response, http.StatusBadRequest)
+	}
+
+	chunkSize := bulkChunkSize
+	if atomic {
+		// The operations of one transaction run on the same worker
+		chunkSize = len(operations)
+	}
+	ctx := c.UserContext()
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, h.MaxWorkerNum)
+	for start := 0; start < len(operations); start += chunkSize {
+		end := min(start+chunkSize, len(operations))
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(chunk []services.BulkTaskOperation, chunkIndexes []int) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			var results []services.BulkTaskResult
+			err := h.run(ctx, func(ctx context.Context) error {
+				results = h.Service.TaskBulk(ctx, ownerID, chunk, atomic)
+				return nil
+			})
+			for j, i := range chunkIndexes {
+				result := &response.Results[i]
+				if err != nil {
+					status, body := bulkError(err)
+					result.Status, result.Error = status, &body
+					continue
+				}
+				h.bulkResult(result, chunk[j].Op, results[j])
+			}
+		}(operations[start:end], indexes[start:end])
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for _, result := range response.Results {
+		if result.Error == nil {
+			continue
+		}
+		if !atomic {
+			status = http.StatusMultiStatus
+			break
+		}
+		// An atomic request takes the status of the operation that rolled it back
+		if result.Status != http.StatusFailedDependency {
+			status = result.Status
+			break
+		}
+	}
+	return h.bulkResponse(c, response, status)
+}
+
+// bulkOperation checks an operation of a bulk request like the endpoint of
+// the operation would check its request.
+func (h *TaskHandler) bulkOperation(requested dto.BulkTaskOperation) (services.BulkTaskOperation, *globalerror.ErrorResponse) {
+	task := requested.Task
+	task.Id, task.Version = requested.ID, requested.Version
+	operation := services.BulkTaskOperation{Op: services.BulkOp(requested.Op), Task: task}
+
+	invalid := func(field, description string) *globalerror.ErrorResponse {
+		return &globalerror.ErrorResponse{
+			Status:      http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{{FieldName: field, Description: description}},
+		}
+	}
+	switch operation.Op {
+	case services.BulkCreate:
+		if requested.ID != 0 || requested.Version != 0 {
+			return operation, invalid("id", "id and version cannot be set when creating a task")
+		}
+		if task.JobType != "" && h.Registry != nil && !h.Registry.Has(task.JobType) {
+			return operation, invalid("job_type", fmt.Sprintf("Unknown job type %q", task.JobType))
+		}
+	case services.BulkUpdate, services.BulkDelete:
+		if requested.ID < 1 {
+			return operation, invalid("id", "invalid task id")
+		}
+		if operation.Op == services.BulkDelete {
+			return operation, nil
+		}
+	default:
+		return operation, invalid("op", "op must be one of create update delete")
+	}
+	if errors := globalerror.Validate(task); len(errors) > 0 && errors[0].HasError {
+		body := globalerror.ValidationErrorResponse(errors)
+		return operation, &body
+	}
+	return operation, nil
+}
+
+func (h *TaskHandler) bulkResult(result *dto.BulkTaskResult, op services.BulkOp, outcome services.BulkTaskResult) {
+	if outcome.Err != nil {
+		status, body := bulkError(outcome.Err)
+		result.Status, result.Error = status, &body
+		return
+	}
+	result.ID, result.Version = outcome.Task.Id, outcome.Task.Version
+	result.Status = http.StatusOK
+	if op == services.BulkCreate {
+		result.Status = http.StatusCreated
+	}
+}
+
+func (h *TaskHandler) bulkResponse(c *fiber.Ctx, response dto.BulkTaskResponse, status int) error {
+	for _, result := range response.Results {
+		if result.Error != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	loggerx.Info(fmt.Sprintf("Bulk request done: %d succeeded, %d failed", response.Succeeded, response.Failed))
+	return c.Status(status).JSON(response)
+}
+
+// bulkError is the status and body an operation that failed with err would
+// have had as a request of its own.
+func bulkError(err error) (int, globalerror.ErrorResponse) {
+	status, field, description := http.StatusInternalServerError, "Task", "An error occurred while processing the operation"
+	var transitionErr *services.InvalidTransitionError
+	var openErr *services.OpenPrerequisitesError
+	var saturated *PoolSaturatedError
+	switch {
+	case errors.Is(err, services.ErrBulkRolledBack):
+		status, field, description = http.StatusFailedDependency, "Bulk", err.Error()
+	case errors.Is(err, repository.ErrTaskNotFound):
+		status, description = http.StatusNotFound, "Task not found"
+	case errors.Is(err, repository.ErrVersionConflict):
+		status, field, description = http.StatusPreconditionFailed, "version", "The task has been changed since it was loaded"
+	case errors.As(err, &transitionErr):
+		status, field, description = http.StatusConflict, "status", transitionErr.Error()
+	case errors.As(err, &openErr):
+		status, field, description = http.StatusConflict, "status", openErr.Error()
+	case errors.As(err, &saturated):
+		status, field, description = http.StatusServiceUnavailable, "Request", "The server is busy, please retry later"
+	case errors.Is(err, context.DeadlineExceeded):
+		status, field, description = http.StatusGatewayTimeout, "Request", "The request timed out"
+	case errors.Is(err, context.Canceled):
+		status, field, description = http.StatusServiceUnavailable, "Request", "The request was cancelled"
+	}
+	return status, globalerror.ErrorResponse{
+		Status:      int32(status),
+		ErrorDetail: []globalerror.ErrorResponseDetail{{FieldName: field, Description: description}},
+	}
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"konzek-jun/dto"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	x "konzek-jun/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func bulkRouter(t *testing.T) (*fiber.App, *repository.MemoryTaskRepository, int64) {
+	users := repository.NewMemoryUserRepository()
+	owner, err := users.InsertUser(context.Background(), models.User{Name: "Importer", Email: "importer@example.com", Password: "testpass"})
+	if err != nil {
+		t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
+	}
+	tasks := repository.NewMemoryTaskRepository()
+	taskService := x.NewTaskService(tasks)
+	taskService.UnitOfWork = repository.NewMemoryUnitOfWork(tasks, users)
+
+	router := authenticatedRouter(owner.ID)
+	router.Post("/api/tasks/bulk", NewTaskHandler(taskService, 5).BulkTasks)
+	return router, tasks, owner.ID
+}
+
+func postBulk(t *testing.T, router *fiber.App, request dto.BulkTaskRequest) (int, dto.BulkTaskResponse) {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/bulk", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response dto.BulkTaskResponse
+	data, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("Yanıt okunamadı: %s", data)
+	}
+	return resp.StatusCode, response
+}
+
+func TestTaskHandler_BulkTasks_BestEffort(t *testing.T) {
+	router, tasks, ownerID := bulkRouter(t)
+	existing, _ := tasks.Insert(context.Background(), models.Task{OwnerID: ownerID, Title: "Existing", Content: "Content", Status: models.TaskStatusTodo})
+
+	// Birden fazla worker'a dağılacak kadar task eklenir
+	var operations []dto.BulkTaskOperation
+	for i := 0; i < 250; i++ {
+		operations = append(operations, dto.BulkTaskOperation{Op: "create", Task: models.Task{Title: "Imported", Content: "Content"}})
+	}
+	operations = append(operations,
+		dto.BulkTaskOperation{Op: "update", ID: int(existing), Version: 1, Task: models.Task{Title: "Renamed", Content: "Content", Status: models.TaskStatusInProgress}},
+		dto.BulkTaskOperation{Op: "delete", ID: 9999},
+		dto.BulkTaskOperation{Op: "create", Task: models.Task{Title: "x"}},
+	)
+
+	status, response := postBulk(t, router, dto.BulkTaskRequest{Operations: operations})
+	assert.Equal(t, http.StatusMultiStatus, status)
+	assert.Equal(t, dto.BulkModeBestEffort, response.Mode)
+	assert.Equal(t, 251, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.Equal(t, http.StatusOK, response.Results[250].Status)
+	assert.Equal(t, 2, response.Results[250].Version)
+	assert.Equal(t, http.StatusNotFound, response.Results[251].Status)
+	assert.Equal(t, int32(http.StatusNotFound), response.Results[251].Error.Status)
+	assert.Equal(t, http.StatusBadRequest, response.Results[252].Status)
+
+	all, _ := tasks.GetAll(context.Background(), ownerID)
+	assert.Len(t, all, 251)
+}
+
+func TestTaskHandler_BulkTasks_Atomic(t *testing.T) {
+	router, tasks, ownerID := bulkRouter(t)
+	existing, _ := tasks.Insert(context.Background(), models.Task{OwnerID: ownerID, Title: "Existing", Content: "Content", Status: models.TaskStatusTodo})
+
+	// Eski sürüm bütün isteği geri aldırır
+	status, response := postBulk(t, router, dto.BulkTaskRequest{Mode: dto.BulkModeAtomic, Operations: []dto.BulkTaskOperation{
+		{Op: "create", Task: models.Task{Title: "Imported", Content: "Content"}},
+		{Op: "update", ID: int(existing), Version: 7, Task: models.Task{Title: "Renamed", Content: "Content"}},
+	}})
+	assert.Equal(t, http.StatusPreconditionFailed, status)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Results[1].Status)
+	all, _ := tasks.GetAll(context.Background(), ownerID)
+	assert.Len(t, all, 1)
+
+	// Geçersiz işlem varsa hiçbiri çalıştırılmaz
+	status, response = postBulk(t, router, dto.BulkTaskRequest{Mode: dto.BulkModeAtomic, Operations: []dto.BulkTaskOperation{
+		{Op: "create", Task: models.Task{Title: "Imported", Content: "Content"}},
+		{Op: "archive", ID: int(existing)},
+	}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, http.StatusFailedDependency, response.Results[0].Status)
+	assert.Equal(t, http.StatusBadRequest, response.Results[1].Status)
+	all, _ = tasks.GetAll(context.Background(), ownerID)
+	assert.Len(t, all, 1)
+
+	status, response = postBulk(t, router, dto.BulkTaskRequest{Mode: dto.BulkModeAtomic, Operations: []dto.BulkTaskOperation{
+		{Op: "create", Task: models.Task{Title: "Imported", Content: "Content"}},
+		{Op: "delete", ID: int(existing), Version: 1},
+	}})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2, response.Succeeded)
+	all, _ = tasks.GetAll(context.Background(), ownerID)
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, response.Results[0].ID, all[0].Id)
+	}
+}
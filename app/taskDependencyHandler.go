@@ -0,0 +1,200 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"konzek-jun/dto"
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// @Summary Adds a task dependency
+// @Description Makes the task depend on another task. The task is blocked while the prerequisite is open and its job is not run before the prerequisite's job has succeeded.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path integer true "Task ID"
+// @Param dependency body dto.TaskDependencyRequest true "Prerequisite task"
+// @Success 201 {object} models.Task "Task object"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 409 {object} globalerror.ErrorResponse "Dependency cycle"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /tasks/{id}/dependencies [post]
+func (h *TaskHandler) AddDependency(c *fiber.Ctx) error {
+	loggerx.Info("AddDependency function called")
+
+	return h.changeDependency(c, http.StatusCreated, h.Service.TaskAddDependency)
+}
+
+// @Summary Removes a task dependency
+// @Description Removes a dependency; the task is unblocked when it has no open prerequisites left
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path integer true "Task ID"
+// @Param dependency body dto.TaskDependencyRequest true "Prerequisite task"
+// @Success 200 {object} models.Task "Task object"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /tasks/{id}/dependencies [delete]
+func (h *TaskHandler) RemoveDependency(c *fiber.Ctx) error {
+	loggerx.Info("RemoveDependency function called")
+
+	return h.changeDependency(c, http.StatusOK, h.Service.TaskRemoveDependency)
+}
+
+func (h *TaskHandler) changeDependency(c *fiber.Ctx, status int, change func(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error)) error {
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return invalidTaskID(c)
+	}
+
+	var request dto.TaskDependencyRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Dependency",
+					Description: "Failed to process request",
+				},
+			},
+		})
+	}
+
+	if errors := globalerror.Validate(request); len(errors) > 0 && errors[0].HasError {
+		return globalerror.HandleValidationErrors(c, errors)
+	}
+
+	var task models.Task
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		task, err = change(ctx, ownerID, id, request.DependsOnID)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	}
+	if errors.Is(err, repository.ErrDependencyNotFound) {
+		return c.Status(http.StatusNotFound).JSON(globalerror.ErrorResponse{
+			Status: http.StatusNotFound,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "depends_on_id",
+					Description: "Task dependency not found",
+				},
+			},
+		})
+	}
+	var cycleErr *services.DependencyCycleError
+	if errors.As(err, &cycleErr) {
+		return dependencyConflict(c, "depends_on_id", cycleErr)
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Dependency",
+					Description: "An error occurred while changing the task dependencies",
+				},
+			},
+		})
+	}
+
+	loggerx.Info("Task dependencies changed successfully")
+	return c.Status(status).JSON(task)
+}
+
+// @Summary Retrieves the dependency graph of a task
+// @Description Retrieves the tasks the task transitively depends on, the tasks that transitively depend on it and the edges between them
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path integer true "Task ID"
+// @Success 200 {object} models.TaskGraph "Task graph"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /tasks/{id}/graph [get]
+func (h *TaskHandler) GetTaskGraph(c *fiber.Ctx) error {
+	loggerx.Info("GetTaskGraph function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return invalidTaskID(c)
+	}
+
+	var graph models.TaskGraph
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		graph, err = h.Service.TaskGraph(ctx, ownerID, id)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Task",
+					Description: "An error occurred while loading the task graph",
+				},
+			},
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(graph)
+}
+
+func invalidTaskID(c *fiber.Ctx) error {
+	return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+		Status: http.StatusBadRequest,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Task",
+				Description: "invalid task id",
+			},
+		},
+	})
+}
+
+func dependencyConflict(c *fiber.Ctx, field string, err error) error {
+	return c.Status(http.StatusConflict).JSON(globalerror.ErrorResponse{
+		Status: http.StatusConflict,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   field,
+				Description: err.Error(),
+			},
+		},
+	})
+}
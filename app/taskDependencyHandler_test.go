@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"konzek-jun/globalerror"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	x "konzek-jun/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskHandler_AddDependency(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/dependencies", td.AddDependency)
+
+	mockService.EXPECT().TaskAddDependency(gomock.Any(), int64(1), 2, 1).Return(models.Task{Id: 2, OwnerID: 1, Status: models.TaskStatusBlocked}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/2/dependencies", bytes.NewReader([]byte(`{"depends_on_id":1}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var task models.Task
+	json.NewDecoder(resp.Body).Decode(&task)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, models.TaskStatusBlocked, task.Status)
+}
+
+func TestTaskHandler_AddDependency_Cycle(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/dependencies", td.AddDependency)
+
+	mockService.EXPECT().TaskAddDependency(gomock.Any(), int64(1), 1, 2).Return(models.Task{}, &x.DependencyCycleError{TaskID: 1, DependsOnID: 2, Path: []int{2, 1}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/1/dependencies", bytes.NewReader([]byte(`{"depends_on_id":2}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body globalerror.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "depends_on_id", body.ErrorDetail[0].FieldName)
+}
+
+func TestTaskHandler_RemoveDependency_NotFound(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Delete("/api/tasks/:id/dependencies", td.RemoveDependency)
+
+	mockService.EXPECT().TaskRemoveDependency(gomock.Any(), int64(1), 2, 3).Return(models.Task{}, repository.ErrDependencyNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/tasks/2/dependencies", bytes.NewReader([]byte(`{"depends_on_id":3}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTaskHandler_TransitionTask_OpenPrerequisites(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/transition", td.TransitionTask)
+
+	mockService.EXPECT().TaskTransition(gomock.Any(), int64(1), 2, 0, models.TaskStatusDone).Return(models.Task{}, &x.OpenPrerequisitesError{TaskID: 2, Open: []int{1}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/2/transition", bytes.NewReader([]byte(`{"status":"done"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestTaskHandler_GetTaskGraph(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/:id/graph", td.GetTaskGraph)
+
+	graph := models.TaskGraph{
+		TaskID:     2,
+		Upstream:   []models.Task{{Id: 1, OwnerID: 1, Title: "Parent", Content: "Parent", Status: models.TaskStatusDone}},
+		Downstream: []models.Task{},
+		Edges:      []models.TaskDependency{{TaskID: 2, DependsOnID: 1}},
+	}
+	mockService.EXPECT().TaskGraph(gomock.Any(), int64(1), 2).Return(graph, nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/2/graph", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body models.TaskGraph
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, graph, body)
+}
@@ -1,74 +1,118 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"konzek-jun/dto"
 	"konzek-jun/globalerror"
 	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
 	"konzek-jun/models"
+	"konzek-jun/repository"
 	"konzek-jun/services"
+	"konzek-jun/worker"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type TaskHandler struct {
 	Service      services.TaskService
-	WorkerPool   chan struct{}
+	WorkerPool   *WorkerPool
 	MaxWorkerNum int
+	// Registry is used to reject tasks with an unknown job type. Optional.
+	Registry *worker.Registry
 }
 
 func NewTaskHandler(service services.TaskService, maxWorkerNum int) *TaskHandler {
 	return &TaskHandler{
 		Service:      service,
 		MaxWorkerNum: maxWorkerNum,
-		WorkerPool:   make(chan struct{}, maxWorkerNum),
+		WorkerPool:   NewWorkerPool(maxWorkerNum, maxWorkerNum*10, 2*time.Second),
 	}
 }
 
-func (h *TaskHandler) acquireWorker() {
-	h.WorkerPool <- struct{}{}
+// acquireWorker waits for a free worker and gives up when ctx is done or the
+// pool is saturated.
+func (h *TaskHandler) acquireWorker(ctx context.Context) error {
+	return h.WorkerPool.Acquire(ctx)
 }
 
 func (h *TaskHandler) releaseWorker() {
-	<-h.WorkerPool
+	h.WorkerPool.Release()
+}
+
+// run executes fn on a worker and waits for it until ctx is done. fn gets the
+// same ctx, so its queries are cancelled when the handler stops waiting.
+func (h *TaskHandler) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := h.acquireWorker(ctx); err != nil {
+		return err
+	}
+
+	resultChan := make(chan error, 1)
+	go func() {
+		defer h.releaseWorker()
+		resultChan <- fn(ctx)
+	}()
+
+	select {
+	case err := <-resultChan:
+		// The driver reports a cancelled query with its own error
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // @Summary Retrieves all tasks
-// @Description Retrieves all tasks
+// @Description Retrieves all tasks matching the filters in one page, in the envelope of /tasks/page; the number of matches is also in the X-Total-Count header
 // @Tags Tasks
 // @Accept json
 // @Produce json
-// @Success 200 {object} []models.Task "List of tasks"
+// @Param status query string false "Comma separated statuses, e.g. todo,in_progress"
+// @Param search query string false "Full-text search over title and content"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created at or before (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_from query string false "Updated at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_to query string false "Updated at or before (RFC 3339 or YYYY-MM-DD)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -created_at,title"
+// @Success 200 {object} models.TaskPage "List of tasks"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
 // @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /tasks [get]
 func (h *TaskHandler) GetAllTask(c *fiber.Ctx) error {
 	loggerx.Info("GetAllTask function called")
 
-	resultChan := make(chan *[]models.Task)
-
-	defer close(resultChan)
-
-	go func() {
-
-		h.acquireWorker()
-		defer func() {
-			h.releaseWorker()
-
-		}()
-
-		result, err := h.Service.TaskGetAll()
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
 
-		if err != nil {
-			resultChan <- nil
-			return
-		}
+	filter, detail := taskFilterFromQuery(c)
+	if detail != nil {
+		return badTaskQuery(c, *detail)
+	}
 
-		resultChan <- &result
-	}()
-	result := <-resultChan
-	if result == nil {
+	var result []models.Task
+	var total int64
+	err := h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		result, total, err = h.Service.TaskSearch(ctx, ownerID, filter)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if err != nil {
 
 		log.Println("Error fetching tasks")
 
@@ -83,8 +127,15 @@ func (h *TaskHandler) GetAllTask(c *fiber.Ctx) error {
 		})
 	}
 
+	if result == nil {
+		result = []models.Task{}
+	}
 	loggerx.Info("Tasks fetched successfully")
-	return c.Status(http.StatusOK).JSON(result)
+	c.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	return c.Status(http.StatusOK).JSON(models.TaskPage{
+		Tasks:      result,
+		Pagination: models.Pagination{PageSize: len(result), Total: total},
+	})
 
 }
 
@@ -94,38 +145,54 @@ func (h *TaskHandler) GetAllTask(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param task body models.Task true "Task object to create"
+// @Param Idempotency-Key header string false "Key that makes retries safe, a repeated request with the same key and body gets the first response"
 // @Success 201 {object} EmptyResponse "Empty response"
+// @Success 202 {object} JobAcceptedResponse "Task has a job_type and was queued for execution"
 // @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 409 {object} globalerror.ErrorResponse "A request with the same Idempotency-Key is still in progress"
+// @Failure 422 {object} globalerror.ErrorResponse "The Idempotency-Key was used for another request"
 // @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /tasks [post]
 func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 	loggerx.Info("CreateTask function called")
 	var task models.Task
-	resultChan := make(chan bool)
-	defer close(resultChan)
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
 
 	if err := c.BodyParser(&task); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(err.Error())
 	}
+	task.OwnerID = ownerID
 
 	if errors := globalerror.Validate(task); len(errors) > 0 && errors[0].HasError {
 		return globalerror.HandleValidationErrors(c, errors)
 	}
-	go func() {
-		h.acquireWorker()
-		defer h.releaseWorker()
 
-		err := h.Service.TaskInsert(task)
-		if err != nil {
-			fmt.Println("girdi")
-			resultChan <- true
-			return
-		}
-		resultChan <- false
-	}()
-	result := <-resultChan
+	if task.JobType != "" && h.Registry != nil && !h.Registry.Has(task.JobType) {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "job_type",
+					Description: fmt.Sprintf("Unknown job type %q", task.JobType),
+				},
+			},
+		})
+	}
 
-	if result {
+	var id int64
+	err := h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		id, err = h.Service.TaskInsert(ctx, task)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
 			Status: http.StatusInternalServerError,
 			ErrorDetail: []globalerror.ErrorResponseDetail{
@@ -138,6 +205,14 @@ func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 	}
 	loggerx.Info("Task created successfully")
 
+	if task.JobType != "" {
+		c.Location(fmt.Sprintf("/api/jobs/%d", id))
+		return c.Status(http.StatusAccepted).JSON(JobAcceptedResponse{
+			JobID:     id,
+			JobStatus: models.JobStatusPending,
+		})
+	}
+
 	return c.Status(http.StatusCreated).JSON(nil)
 }
 
@@ -147,37 +222,44 @@ func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path integer true "Task ID to delete"
+// @Param If-Match header string false "ETag of the task, the task is only deleted if it still has this version"
 // @Success 200 {object} EmptyResponse "Empty response"
 // @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 412 {object} globalerror.ErrorResponse "The task has another version"
 // @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /tasks/{id} [delete]
 func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
 	loggerx.Info("DeleteTask function called")
-	resultChan := make(chan *[]models.Task)
-	defer close(resultChan)
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
 
 	strId := c.Params("id")
 	id, err := strconv.Atoi(strId)
-	fmt.Println(id)
 	if err != nil {
 		return err
 	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return invalidIfMatch(c)
+	}
 
-	go func() {
-		h.acquireWorker()
-		defer h.releaseWorker()
-
-		err := h.Service.TaskDelete(id)
-		if err != nil {
-			resultChan <- nil
-			return
-		}
-		resultChan <- &[]models.Task{}
-	}()
-
-	result := <-resultChan
-
-	if result == nil {
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		return h.Service.TaskDelete(ctx, ownerID, id, version)
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if err != nil {
 		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
 			Status: http.StatusBadRequest,
 			ErrorDetail: []globalerror.ErrorResponseDetail{
@@ -199,36 +281,64 @@ func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param task body models.Task true "Updated task object"
-// @Success 201 {object} EmptyResponse "Empty response"
+// @Param If-Match header string false "ETag of the task, the task is only updated if it still has this version"
+// @Success 200 {object} EmptyResponse "Empty response, the ETag header has the new version"
 // @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 409 {object} globalerror.ErrorResponse "Illegal status transition or open prerequisites"
+// @Failure 412 {object} globalerror.ErrorResponse "The task has another version"
 // @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /tasks [put]
 func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 	loggerx.Info("UpdateTask function called")
 	var updatedTask models.Task
-	resultChan := make(chan *[]models.Task)
-	defer close(resultChan)
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
 
 	if err := c.BodyParser(&updatedTask); err != nil {
 		return fiber.NewError(http.StatusBadRequest, "Geçersiz gövde")
 	}
+	updatedTask.OwnerID = ownerID
+	// The version comes from the If-Match header, not from the body
+	version, err := ifMatch(c)
+	if err != nil {
+		return invalidIfMatch(c)
+	}
+	updatedTask.Version = version
 
 	if errors := globalerror.Validate(updatedTask); len(errors) > 0 && errors[0].HasError {
 		return globalerror.HandleValidationErrors(c, errors)
 	}
-	go func() {
-		h.acquireWorker()
-		defer h.releaseWorker()
-
-		err := h.Service.TaskUpdate(updatedTask)
-		if err != nil {
-			resultChan <- nil
-			return
+	var task models.Task
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		task, err = h.Service.TaskUpdate(ctx, updatedTask)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if version == 0 {
+			return concurrentUpdate(c)
 		}
-		resultChan <- &[]models.Task{}
-	}()
-	result := <-resultChan
-	if result == nil {
+		return versionConflict(c)
+	}
+	var transitionErr *services.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return invalidTransition(c, transitionErr)
+	}
+	var openErr *services.OpenPrerequisitesError
+	if errors.As(err, &openErr) {
+		return dependencyConflict(c, "status", openErr)
+	}
+	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
 			Status: http.StatusInternalServerError,
 			ErrorDetail: []globalerror.ErrorResponseDetail{
@@ -241,6 +351,7 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 	}
 
 	loggerx.Info("Task updated successfully")
+	c.Set(fiber.HeaderETag, etag(task.Version))
 	return c.Status(http.StatusOK).JSON(fiber.Map{"success": true})
 }
 
@@ -250,13 +361,18 @@ func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path integer true "Task ID to retrieve"
-// @Success 200 {object} models.Task "Task object"
+// @Success 200 {object} models.Task "Task object, the ETag header has its version"
 // @Failure 404 {object} globalerror.ErrorResponse "Not found"
 // @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /tasks/{id} [get]
 func (h *TaskHandler) GetByID(c *fiber.Ctx) error {
 	loggerx.Info("GetByID function called")
 
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
 	strID := c.Params("id")
 	id, err := strconv.Atoi(strID)
 	if err != nil {
@@ -271,49 +387,152 @@ func (h *TaskHandler) GetByID(c *fiber.Ctx) error {
 		})
 	}
 
-	resultChan := make(chan *models.Task)
+	var task models.Task
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		task, err = h.Service.TaskGetByID(ctx, ownerID, id)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if err == nil {
+		loggerx.Info("Task loaded successfully")
+		c.Set(fiber.HeaderETag, etag(task.Version))
+		return c.Status(http.StatusOK).JSON(task)
+	} else if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	} else {
+		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Task",
+					Description: "An error occurred while loading the task",
+				},
+			},
+		})
+	}
+}
 
-	defer close(resultChan)
+// @Summary Moves a task to another status
+// @Description Moves a task to another status if the transition is allowed
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path integer true "Task ID to transition"
+// @Param transition body dto.TaskTransitionRequest true "Target status"
+// @Param If-Match header string false "ETag of the task, the task is only transitioned if it still has this version"
+// @Success 200 {object} models.Task "Task object"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 409 {object} globalerror.ErrorResponse "Illegal transition, open prerequisites or a concurrent change"
+// @Failure 412 {object} globalerror.ErrorResponse "The task has another version than If-Match"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /tasks/{id}/transition [post]
+func (h *TaskHandler) TransitionTask(c *fiber.Ctx) error {
+	loggerx.Info("TransitionTask function called")
 
-	go func() {
-		h.acquireWorker()
-		defer h.releaseWorker()
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
 
-		model, err := h.Service.TaskGetByID(id)
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Task",
+					Description: "invalid task id",
+				},
+			},
+		})
+	}
 
-		if err != nil {
-			resultChan <- nil
-			return
-		}
+	var request dto.TaskTransitionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Transition",
+					Description: "Failed to process request",
+				},
+			},
+		})
+	}
 
-		resultChan <- &model
-	}()
+	if errors := globalerror.Validate(request); len(errors) > 0 && errors[0].HasError {
+		return globalerror.HandleValidationErrors(c, errors)
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return invalidIfMatch(c)
+	}
 
-	result := <-resultChan
-	if result != nil {
-		loggerx.Info("Task loaded successfully")
-		return c.Status(http.StatusOK).JSON(result)
-	} else {
+	var task models.Task
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		task, err = h.Service.TaskTransition(ctx, ownerID, id, version, request.Status)
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if version == 0 {
+			return concurrentUpdate(c)
+		}
+		return versionConflict(c)
+	}
+	var transitionErr *services.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return invalidTransition(c, transitionErr)
+	}
+	var openErr *services.OpenPrerequisitesError
+	if errors.As(err, &openErr) {
+		return dependencyConflict(c, "status", openErr)
+	}
+	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
 			Status: http.StatusInternalServerError,
 			ErrorDetail: []globalerror.ErrorResponseDetail{
 				{
 					FieldName:   "Task",
-					Description: "An error occurred while loading the task",
+					Description: "An error occurred while transitioning the task",
 				},
 			},
 		})
 	}
+
+	loggerx.Info("Task transitioned successfully")
+	c.Set(fiber.HeaderETag, etag(task.Version))
+	return c.Status(http.StatusOK).JSON(task)
 }
 
 // @Summary Retrieves all tasks with pagination
-// @Description Retrieves all tasks with pagination
+// @Description Retrieves a page of the tasks matching the filters, with the total count. Without page the listing is paged by cursor: pass next_cursor as after or prev_cursor as before; cursor pages support a single sort field.
 // @Tags Tasks
 // @Accept json
 // @Produce json
-// @Param page query integer false "Page number"
-// @Param pageSize query integer false "Number of tasks per page"
-// @Success 200 {object} EmptyResponse "Empty response"
+// @Param page query integer false "Page number, starting at 1"
+// @Param pageSize query integer false "Number of tasks per page (max 100)"
+// @Param after query string false "Cursor of the page to continue after"
+// @Param before query string false "Cursor of the page to continue before"
+// @Param limit query integer false "Number of tasks per cursor page (max 100)"
+// @Param status query string false "Comma separated statuses, e.g. todo,in_progress"
+// @Param search query string false "Full-text search over title and content"
+// @Param created_from query string false "Created at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created at or before (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_from query string false "Updated at or after (RFC 3339 or YYYY-MM-DD)"
+// @Param updated_to query string false "Updated at or before (RFC 3339 or YYYY-MM-DD)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -created_at,title"
+// @Success 200 {object} models.TaskPage "Page of tasks"
 // @Failure 400 {object} globalerror.ErrorResponse "Bad request"
 // @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
 // @Router /tasks/page [get]
@@ -321,6 +540,11 @@ func (h *TaskHandler) GetAllTaskWithPagination(c *fiber.Ctx) error {
 
 	loggerx.Info("GetAllTaskWithPagination function called")
 
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
 	params := new(PaginationParams)
 	if err := c.QueryParser(params); err != nil {
 		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
@@ -334,7 +558,31 @@ func (h *TaskHandler) GetAllTaskWithPagination(c *fiber.Ctx) error {
 		})
 	}
 
-	tasks, err := h.Service.GetAllTaskWithPagination(params.Page, params.PageSize)
+	filter, detail := taskFilterFromQuery(c)
+	if detail != nil {
+		return badTaskQuery(c, *detail)
+	}
+
+	if params.Page == 0 && len(filter.Sort) > 1 {
+		return badTaskQuery(c, globalerror.ErrorResponseDetail{FieldName: "sort", Description: "cursor pages can be sorted by a single field"})
+	}
+
+	var page models.TaskPage
+	err := h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		if params.Page != 0 {
+			page, err = h.Service.GetAllTaskWithPagination(ctx, ownerID, filter, params.Page, params.PageSize)
+		} else {
+			page, err = h.Service.TaskCursorPage(ctx, ownerID, filter, params.After, params.Before, params.Limit)
+		}
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return badTaskQuery(c, globalerror.ErrorResponseDetail{FieldName: "cursor", Description: err.Error()})
+	}
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
 			Status: http.StatusInternalServerError,
@@ -349,14 +597,148 @@ func (h *TaskHandler) GetAllTaskWithPagination(c *fiber.Ctx) error {
 
 	loggerx.Info("Tasks fetched successfully")
 
-	return c.JSON(fiber.Map{
-		"tasks": tasks,
+	return c.JSON(page)
+}
+
+func unauthorized(c *fiber.Ctx) error {
+	return c.Status(http.StatusUnauthorized).JSON(globalerror.ErrorResponse{
+		Status: http.StatusUnauthorized,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Authorization",
+				Description: "No authenticated user",
+			},
+		},
+	})
+}
+
+// isAborted reports whether a request stopped before its work was done.
+func isAborted(err error) bool {
+	var saturated *PoolSaturatedError
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || errors.As(err, &saturated)
+}
+
+// requestAborted answers a request that stopped before its work was done:
+// 504 when the route deadline passed, 503 with Retry-After when the worker
+// pool is saturated and 503 when the server is shutting down.
+func requestAborted(c *fiber.Ctx, err error) error {
+	status := http.StatusServiceUnavailable
+	description := "The request was cancelled"
+	var saturated *PoolSaturatedError
+	if errors.As(err, &saturated) {
+		seconds := int(math.Ceil(saturated.RetryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		description = "The server is busy, please retry later"
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+		description = "The request timed out"
+	}
+	return c.Status(status).JSON(globalerror.ErrorResponse{
+		Status: int32(status),
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Request",
+				Description: description,
+			},
+		},
+	})
+}
+
+func taskNotFound(c *fiber.Ctx) error {
+	return c.Status(http.StatusNotFound).JSON(globalerror.ErrorResponse{
+		Status: http.StatusNotFound,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Task",
+				Description: "Task not found",
+			},
+		},
+	})
+}
+
+func invalidTransition(c *fiber.Ctx, err *services.InvalidTransitionError) error {
+	return c.Status(http.StatusConflict).JSON(globalerror.ErrorResponse{
+		Status: http.StatusConflict,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "status",
+				Description: err.Error(),
+			},
+		},
+	})
+}
+
+// etag is the ETag of a task with version.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch returns the task version of the If-Match header. A missing header
+// and "*" give 0, which skips the version check. Only a single strong ETag is
+// accepted.
+func ifMatch(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	tag, err := strconv.Unquote(header)
+	if err != nil || !strings.HasPrefix(header, `"`) {
+		return 0, fmt.Errorf("invalid If-Match header %q", header)
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid If-Match header %q", header)
+	}
+	return version, nil
+}
+
+func invalidIfMatch(c *fiber.Ctx) error {
+	return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+		Status: http.StatusBadRequest,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "If-Match",
+				Description: "If-Match must be a single ETag of the task",
+			},
+		},
+	})
+}
+
+func versionConflict(c *fiber.Ctx) error {
+	return c.Status(http.StatusPreconditionFailed).JSON(globalerror.ErrorResponse{
+		Status: http.StatusPreconditionFailed,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "If-Match",
+				Description: "The task has been changed since it was loaded",
+			},
+		},
+	})
+}
+
+// concurrentUpdate answers a write without If-Match that lost the race
+// against another write of the same task.
+func concurrentUpdate(c *fiber.Ctx) error {
+	return c.Status(http.StatusConflict).JSON(globalerror.ErrorResponse{
+		Status: http.StatusConflict,
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   "Task",
+				Description: "The task was changed by another request, please retry",
+			},
+		},
 	})
 }
 
 type PaginationParams struct {
-	Page     int `query:"page"`
-	PageSize int `query:"pageSize"`
+	Page     int    `query:"page"`
+	PageSize int    `query:"pageSize"`
+	After    string `query:"after"`
+	Before   string `query:"before"`
+	Limit    int    `query:"limit"`
 }
 
 type EmptyResponse struct {
@@ -2,14 +2,16 @@ package app
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
+	"konzek-jun/globalerror"
+	"konzek-jun/middleware"
 	services "konzek-jun/mocks/service"
 	"konzek-jun/models"
 	"konzek-jun/repository"
 	x "konzek-jun/services"
-	"log"
+	"konzek-jun/worker"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -29,17 +31,27 @@ func setup(t *testing.T) func() {
 	return func() { defer ctrl.Finish() }
 }
 
+// authenticatedRouter JWT middleware'inin yaptığı gibi kullanıcı id'sini context'e koyar
+func authenticatedRouter(userID int64) *fiber.App {
+	router := fiber.New()
+	router.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.UserIDKey, userID)
+		return c.Next()
+	})
+	return router
+}
+
 func TestTaskHandler_CreateTask(t *testing.T) {
 	trd := setup(t)
 	defer trd()
 
 	td := NewTaskHandler(mockService, 5)
-	router := fiber.New()
+	router := authenticatedRouter(1)
 	router.Post("/api/tasks", td.CreateTask)
 
-	mockService.EXPECT().TaskInsert(gomock.Any()).Return(nil)
+	mockService.EXPECT().TaskInsert(gomock.Any(), models.Task{OwnerID: 1, Title: "Test Task", Content: "Test Content", Status: models.TaskStatusDone}).Return(int64(1), nil)
 
-	task := models.Task{Title: "Test Task", Content: "Test Content", Status: true}
+	task := models.Task{Title: "Test Task", Content: "Test Content", Status: models.TaskStatusDone}
 
 	jsonData, err := json.Marshal(task)
 	if err != nil {
@@ -62,20 +74,72 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 	}
 }
 
+func TestTaskHandler_CreateTask_Job(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	registry := worker.NewRegistry()
+	worker.RegisterBuiltins(registry)
+	td := NewTaskHandler(mockService, 5)
+	td.Registry = registry
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks", td.CreateTask)
+
+	mockService.EXPECT().TaskInsert(gomock.Any(), gomock.Any()).Return(int64(42), nil)
+
+	body := `{"title":"Echo Job","content":"Echo Content","job_type":"echo","payload":{"hello":"world"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accepted JobAcceptedResponse
+	json.NewDecoder(resp.Body).Decode(&accepted)
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "/api/jobs/42", resp.Header.Get("Location"))
+	assert.Equal(t, int64(42), accepted.JobID)
+	assert.Equal(t, models.JobStatusPending, accepted.JobStatus)
+}
+
+func TestTaskHandler_CreateTask_UnknownJobType(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	td.Registry = worker.NewRegistry()
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks", td.CreateTask)
+
+	body := `{"title":"Mystery Job","content":"Mystery Content","job_type":"mystery"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
 func TestUpdateTaskHandler(t *testing.T) {
 	trd := setup(t)
 	defer trd()
 
 	td := NewTaskHandler(mockService, 5)
-	mockService.EXPECT().TaskUpdate(gomock.Any()).Return(nil)
-	router := fiber.New()
+	mockService.EXPECT().TaskUpdate(gomock.Any(), gomock.Any()).Return(models.Task{Id: 1, Version: 2}, nil)
+	router := authenticatedRouter(1)
 	router.Put("/api/tasks", td.UpdateTask)
 
 	task := models.Task{
 		Id:      1,
 		Title:   "Updated Task Title",
 		Content: "Updated Task Content",
-		Status:  true,
+		Status:  models.TaskStatusDone,
 	}
 
 	taskJSON, err := json.Marshal(task)
@@ -92,24 +156,23 @@ func TestUpdateTaskHandler(t *testing.T) {
 	}
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
 
 	fmt.Println("Test başarılı. Geçti mesajı alındı.")
 }
 
 func TestTaskStream(t *testing.T) {
 
-	db, err := sql.Open("postgres", "dbname=konzek user=postgres password=test host=localhost port=5432 sslmode=disable")
+	// Postgres yerine bellekteki repository'ler kullanılır
+	owner, err := repository.NewMemoryUserRepository().InsertUser(context.Background(), models.User{Name: "Stream User", Email: "stream@example.com", Password: "testpass"})
 	if err != nil {
-		log.Fatalf("Veritabanına bağlanırken hata oluştu: %v", err)
+		t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
 	}
-	defer db.Close()
-
-	clearDatabase(db)
-	taskRepo := repository.NewTaskRepository(db)
+	taskRepo := repository.NewMemoryTaskRepository()
 	taskService := x.NewTaskService(taskRepo)
 	taskHandler := NewTaskHandler(taskService, 5)
 
-	router := fiber.New()
+	router := authenticatedRouter(owner.ID)
 	router.Get("/api/tasks/:id", taskHandler.GetByID)
 	router.Post("/api/tasks", taskHandler.CreateTask)
 	router.Delete("/api/tasks/:id", taskHandler.DeleteTask)
@@ -117,31 +180,397 @@ func TestTaskStream(t *testing.T) {
 	// Task oluştur
 	task := models.Task{
 		Content: "Test Content",
-		Status:  true,
+		Status:  models.TaskStatusDone,
 		Title:   "xxxxxxxx",
 	}
 	taskJSON, _ := json.Marshal(task)
 	req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewBuffer(taskJSON))
 	req.Header.Set("Content-Type", "application/json")
 	resp1, _ := router.Test(req)
-	// Bekleme süresi (30 saniye)
-	time.Sleep(20 * time.Second)
 
-	req2 := httptest.NewRequest(http.MethodGet, "/api/tasks/8", nil)
+	req2 := httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil)
 	resp2, _ := router.Test(req2)
 
-	req3 := httptest.NewRequest(http.MethodDelete, "/api/tasks/8", nil)
+	req3 := httptest.NewRequest(http.MethodDelete, "/api/tasks/1", nil)
 	resp3, _ := router.Test(req3)
 
-	fmt.Println(resp2)
 	assert.Equal(t, http.StatusCreated, resp1.StatusCode)
 	assert.Equal(t, http.StatusOK, resp2.StatusCode)
 	assert.Equal(t, http.StatusOK, resp3.StatusCode)
 
 }
-func clearDatabase(db *sql.DB) {
-	_, err := db.Exec("DELETE FROM tasks")
+
+func TestTaskHandler_GetByID_OtherUsersTask(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(2)
+	router.Get("/api/tasks/:id", td.GetByID)
+
+	// 1 numaralı task başka bir kullanıcıya ait
+	mockService.EXPECT().TaskGetByID(gomock.Any(), int64(2), 1).Return(models.Task{}, repository.ErrTaskNotFound)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTaskHandler_DeleteTask_OtherUsersTask(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(2)
+	router.Delete("/api/tasks/:id", td.DeleteTask)
+
+	mockService.EXPECT().TaskDelete(gomock.Any(), int64(2), 1, 0).Return(repository.ErrTaskNotFound)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodDelete, "/api/tasks/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTaskHandler_UpdateTask_OtherUsersTask(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(2)
+	router.Put("/api/tasks", td.UpdateTask)
+
+	// Body'deki owner_id dikkate alınmaz, her zaman oturumdaki kullanıcı kullanılır
+	mockService.EXPECT().TaskUpdate(gomock.Any(), models.Task{Id: 1, OwnerID: 2, Title: "Stolen Task", Content: "Stolen Content", Status: models.TaskStatusDone}).Return(models.Task{}, repository.ErrTaskNotFound)
+
+	taskJSON, _ := json.Marshal(models.Task{Id: 1, OwnerID: 1, Title: "Stolen Task", Content: "Stolen Content", Status: models.TaskStatusDone})
+	req := httptest.NewRequest(http.MethodPut, "/api/tasks", bytes.NewReader(taskJSON))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTaskHandler_UpdateTask_VersionConflict(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Put("/api/tasks", td.UpdateTask)
+
+	update := func(ifMatch string) *http.Response {
+		req := httptest.NewRequest(http.MethodPut, "/api/tasks", bytes.NewReader([]byte(`{"id":1,"title":"Title","content":"Content","status":"done"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// If-Match'teki sürüm eskiyse 412 döner
+	mockService.EXPECT().TaskUpdate(gomock.Any(), gomock.Any()).Return(models.Task{}, repository.ErrVersionConflict)
+	assert.Equal(t, http.StatusPreconditionFailed, update(`"3"`).StatusCode)
+
+	// If-Match olmadan araya giren bir yazmaya yenilirse 409 döner
+	mockService.EXPECT().TaskUpdate(gomock.Any(), gomock.Any()).Return(models.Task{}, repository.ErrVersionConflict)
+	assert.Equal(t, http.StatusConflict, update("").StatusCode)
+}
+
+func TestTaskHandler_TransitionTask(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/transition", td.TransitionTask)
+
+	mockService.EXPECT().TaskTransition(gomock.Any(), int64(1), 1, 0, models.TaskStatusInProgress).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusInProgress}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/1/transition", bytes.NewReader([]byte(`{"status":"in_progress"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTaskHandler_TransitionTask_Illegal(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/transition", td.TransitionTask)
+
+	mockService.EXPECT().TaskTransition(gomock.Any(), int64(1), 1, 0, models.TaskStatusDone).Return(models.Task{}, &x.InvalidTransitionError{From: models.TaskStatusCancelled, To: models.TaskStatusDone})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/1/transition", bytes.NewReader([]byte(`{"status":"done"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body globalerror.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, int32(http.StatusConflict), body.Status)
+	assert.Equal(t, "status", body.ErrorDetail[0].FieldName)
+}
+
+func TestTaskHandler_TransitionTask_UnknownStatus(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/transition", td.TransitionTask)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks/1/transition", bytes.NewReader([]byte(`{"status":"archived"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := router.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTaskHandler_TransitionTask_VersionConflict(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Post("/api/tasks/:id/transition", td.TransitionTask)
+
+	transition := func(ifMatch string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/api/tasks/1/transition", bytes.NewReader([]byte(`{"status":"done"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// If-Match'teki sürüm eskiyse 412 döner
+	mockService.EXPECT().TaskTransition(gomock.Any(), int64(1), 1, 3, models.TaskStatusDone).Return(models.Task{}, repository.ErrVersionConflict)
+	assert.Equal(t, http.StatusPreconditionFailed, transition(`"3"`).StatusCode)
+
+	// If-Match olmadan araya giren bir yazmaya yenilirse 409 döner
+	mockService.EXPECT().TaskTransition(gomock.Any(), int64(1), 1, 0, models.TaskStatusDone).Return(models.Task{}, repository.ErrVersionConflict)
+	assert.Equal(t, http.StatusConflict, transition("").StatusCode)
+
+	assert.Equal(t, http.StatusBadRequest, transition(`W/"3"`).StatusCode)
+}
+
+func TestTaskHandler_GetAllTask_Unauthenticated(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := fiber.New()
+	router.Get("/api/tasks", td.GetAllTask)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestTaskHandler_IfMatch(t *testing.T) {
+	owner, err := repository.NewMemoryUserRepository().InsertUser(context.Background(), models.User{Name: "Editor", Email: "editor@example.com", Password: "testpass"})
+	if err != nil {
+		t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
+	}
+	taskRepo := repository.NewMemoryTaskRepository()
+	id, err := taskRepo.Insert(context.Background(), models.Task{OwnerID: owner.ID, Title: "Draft", Content: "Content", Status: models.TaskStatusTodo})
+	if err != nil {
+		t.Fatalf("Task eklenirken hata oluştu: %v", err)
+	}
+	taskHandler := NewTaskHandler(x.NewTaskService(taskRepo), 5)
+
+	router := authenticatedRouter(owner.ID)
+	router.Get("/api/tasks/:id", taskHandler.GetByID)
+	router.Put("/api/tasks", taskHandler.UpdateTask)
+	router.Delete("/api/tasks/:id", taskHandler.DeleteTask)
+
+	request := func(method, target, ifMatch string, body interface{}) *http.Response {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, target, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	path := fmt.Sprintf("/api/tasks/%d", id)
+	update := models.Task{Id: int(id), Title: "Final", Content: "Content", Status: models.TaskStatusInProgress}
+
+	resp := request(http.MethodGet, path, "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	// İki editörden ilki kazanır, ikincisi eski sürümle 412 alır
+	resp = request(http.MethodPut, "/api/tasks", `"1"`, update)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	resp = request(http.MethodPut, "/api/tasks", `"1"`, update)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp = request(http.MethodDelete, path, `"1"`, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	// Gövdedeki sürüm If-Match'in yerini tutmaz
+	stale := update
+	stale.Version = 1
+	resp = request(http.MethodPut, "/api/tasks", `"2"`, stale)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+
+	resp = request(http.MethodPut, "/api/tasks", `W/"3"`, update)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = request(http.MethodDelete, path, `"3"`, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = request(http.MethodDelete, path, `"3"`, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = request(http.MethodPut, "/api/tasks", "*", update)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTaskHandler_GetByID_Timeout(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/:id", middleware.RequestTimeout(20*time.Millisecond), td.GetByID)
+
+	// Servis, istek context'i iptal edilene kadar bekler
+	mockService.EXPECT().TaskGetByID(gomock.Any(), int64(1), 1).DoAndReturn(func(ctx context.Context, ownerID int64, id int) (models.Task, error) {
+		<-ctx.Done()
+		return models.Task{}, ctx.Err()
+	})
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestTaskHandler_GetByID_NoFreeWorker(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 1)
+	// Tek worker meşgul, istek süresi dolunca vazgeçilmeli
+	td.WorkerPool.Acquire(context.Background())
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/:id", middleware.RequestTimeout(20*time.Millisecond), td.GetByID)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestTaskHandler_GetAllTask_ServerShutdown(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	base, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Use(middleware.BaseContext(base))
+	router.Get("/api/tasks", td.GetAllTask)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestTaskHandler_GetByID_PoolSaturated(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 1)
+	td.WorkerPool = NewWorkerPool(1, 0, time.Second)
+	td.WorkerPool.RetryAfter = 3 * time.Second
+	// Tek worker meşgul ve bekleme kuyruğu yok, istek hemen reddedilmeli
+	td.WorkerPool.Acquire(context.Background())
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/:id", td.GetByID)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil))
 	if err != nil {
-		log.Fatalf("Veritabanını temizlerken hata oluştu: %v", err)
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("Retry-After"))
+}
+
+func TestTaskHandler_GetAllTaskWithPagination_PoolSaturated(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 1)
+	td.WorkerPool = NewWorkerPool(1, 0, time.Second)
+	td.WorkerPool.RetryAfter = 3 * time.Second
+	// Sayfalı listeler de diğer okumalar gibi worker havuzundan geçmeli
+	td.WorkerPool.Acquire(context.Background())
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/page", td.GetAllTaskWithPagination)
+
+	for _, target := range []string{"/api/tasks/page?page=1", "/api/tasks/page?limit=10"} {
+		resp, err := router.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, target)
+		assert.Equal(t, "3", resp.Header.Get("Retry-After"), target)
 	}
 }
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/dto"
+	"konzek-jun/globalerror"
+	"konzek-jun/jsonpatch"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// invalidPatchedTaskError is returned when the patched task does not pass
+// validation.
+type invalidPatchedTaskError struct {
+	errors []globalerror.CustomValidationError
+}
+
+func (e *invalidPatchedTaskError) Error() string {
+	return fmt.Sprintf("patched task is invalid: %d field errors", len(e.errors))
+}
+
+// @Summary Partially updates a task
+// @Description Applies a JSON Merge Patch (RFC 7396, application/merge-patch+json or application/json) or a JSON Patch (RFC 6902, application/json-patch+json) to the title, content and status of a task. The patched task is validated as a whole.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param id path integer true "Task ID to patch"
+// @Param If-Match header string false "ETag of the task, the task is only patched if it still has this version"
+// @Param patch body dto.TaskPatchDocument true "Merge patch or JSON Patch operations"
+// @Success 200 {object} models.Task "Patched task, the ETag header has its new version"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 404 {object} globalerror.ErrorResponse "Not found"
+// @Failure 409 {object} globalerror.ErrorResponse "Failed test operation, illegal status transition or open prerequisites"
+// @Failure 412 {object} globalerror.ErrorResponse "The task has another version"
+// @Failure 415 {object} globalerror.ErrorResponse "Unsupported patch format"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /tasks/{id} [patch]
+func (h *TaskHandler) PatchTask(c *fiber.Ctx) error {
+	loggerx.Info("PatchTask function called")
+
+	ownerID, ok := middleware.OwnerID(c)
+	if !ok {
+		return unauthorized(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Task",
+					Description: "invalid task id",
+				},
+			},
+		})
+	}
+	version, err := ifMatch(c)
+	if err != nil {
+		return invalidIfMatch(c)
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case mergePatchContentType, fiber.MIMEApplicationJSON:
+		apply = jsonpatch.Merge
+	case jsonPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		return c.Status(http.StatusUnsupportedMediaType).JSON(globalerror.ErrorResponse{
+			Status: http.StatusUnsupportedMediaType,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Content-Type",
+					Description: fmt.Sprintf("Content-Type must be %s or %s", mergePatchContentType, jsonPatchContentType),
+				},
+			},
+		})
+	}
+	// Fiber reuses the body after the request, so the worker gets a copy
+	body := append([]byte(nil), c.Body()...)
+
+	var task models.Task
+	err = h.run(c.UserContext(), func(ctx context.Context) error {
+		var err error
+		task, err = h.Service.TaskPatch(ctx, ownerID, id, version, func(task models.Task) (models.Task, error) {
+			return patchTask(task, body, apply)
+		})
+		return err
+	})
+	if isAborted(err) {
+		return requestAborted(c, err)
+	}
+	if errors.Is(err, repository.ErrTaskNotFound) {
+		return taskNotFound(c)
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if version == 0 {
+			return concurrentUpdate(c)
+		}
+		return versionConflict(c)
+	}
+	var invalidErr *invalidPatchedTaskError
+	if errors.As(err, &invalidErr) {
+		return globalerror.HandleValidationErrors(c, invalidErr.errors)
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return c.Status(http.StatusConflict).JSON(globalerror.ErrorResponse{
+			Status: http.StatusConflict,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Patch",
+					Description: err.Error(),
+				},
+			},
+		})
+	}
+	if errors.Is(err, jsonpatch.ErrInvalidPatch) {
+		return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Patch",
+					Description: err.Error(),
+				},
+			},
+		})
+	}
+	var transitionErr *services.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return invalidTransition(c, transitionErr)
+	}
+	var openErr *services.OpenPrerequisitesError
+	if errors.As(err, &openErr) {
+		return dependencyConflict(c, "status", openErr)
+	}
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Task",
+					Description: "An error occurred while patching the task",
+				},
+			},
+		})
+	}
+
+	loggerx.Info("Task patched successfully")
+	c.Set(fiber.HeaderETag, etag(task.Version))
+	return c.Status(http.StatusOK).JSON(task)
+}
+
+// patchTask applies patch to the patchable fields of task and validates the
+// result.
+func patchTask(task models.Task, patch []byte, apply func(doc, patch []byte) ([]byte, error)) (models.Task, error) {
+	doc, err := json.Marshal(dto.TaskPatchDocument{Title: task.Title, Content: task.Content, Status: task.Status})
+	if err != nil {
+		return models.Task{}, err
+	}
+	patched, err := apply(doc, patch)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	// A patch cannot change fields such as id, owner_id and version
+	var document dto.TaskPatchDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&document); err != nil {
+		return models.Task{}, fmt.Errorf("%w: %v", jsonpatch.ErrInvalidPatch, err)
+	}
+	if errors := globalerror.Validate(document); len(errors) > 0 && errors[0].HasError {
+		return models.Task{}, &invalidPatchedTaskError{errors: errors}
+	}
+
+	task.Title, task.Content, task.Status = document.Title, document.Content, document.Status
+	return task, nil
+}
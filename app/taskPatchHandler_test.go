@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	x "konzek-jun/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskHandler_PatchTask(t *testing.T) {
+	owner, err := repository.NewMemoryUserRepository().InsertUser(context.Background(), models.User{Name: "Patcher", Email: "patcher@example.com", Password: "testpass"})
+	if err != nil {
+		t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
+	}
+	taskRepo := repository.NewMemoryTaskRepository()
+	id, err := taskRepo.Insert(context.Background(), models.Task{OwnerID: owner.ID, Title: "Draft", Content: "Content", Status: models.TaskStatusTodo})
+	if err != nil {
+		t.Fatalf("Task eklenirken hata oluştu: %v", err)
+	}
+	taskHandler := NewTaskHandler(x.NewTaskService(taskRepo), 5)
+
+	router := authenticatedRouter(owner.ID)
+	router.Patch("/api/tasks/:id", taskHandler.PatchTask)
+
+	path := fmt.Sprintf("/api/tasks/%d", id)
+	patch := func(target, contentType, ifMatch, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	stored := func() models.Task {
+		task, err := taskRepo.GetByID(context.Background(), owner.ID, int(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	// Sadece gönderilen alan değişir
+	resp := patch(path, mergePatchContentType, `"1"`, `{"title":"Final"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	var task models.Task
+	body, _ := io.ReadAll(resp.Body)
+	assert.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "Final", task.Title)
+	assert.Equal(t, "Content", task.Content)
+	assert.Equal(t, models.TaskStatusTodo, task.Status)
+
+	resp = patch(path, jsonPatchContentType, "", `[{"op":"test","path":"/title","value":"Final"},{"op":"replace","path":"/status","value":"in_progress"}]`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	assert.Equal(t, models.TaskStatusInProgress, stored().Status)
+
+	// Eski sürüm, başarısız test ve yama sonrası geçersiz task değişiklik yapmaz
+	resp = patch(path, mergePatchContentType, `"2"`, `{"title":"Stale"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	resp = patch(path, jsonPatchContentType, "", `[{"op":"test","path":"/title","value":"Draft"},{"op":"replace","path":"/title","value":"Lost"}]`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = patch(path, mergePatchContentType, "", `{"content":null}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = patch(path, mergePatchContentType, "", `{"status":"archived"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = patch(path, jsonPatchContentType, "", `[{"op":"add","path":"/owner_id","value":2}]`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = patch(path, jsonPatchContentType, "", `{"title":"Wrong format"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = patch(path, "text/plain", "", `{"title":"Text"}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	assert.Equal(t, "Final", stored().Title)
+	assert.Equal(t, 3, stored().Version)
+
+	// Durum geçiş kuralları yamada da geçerlidir
+	resp = patch(path, "application/json", "", `{"status":"blocked"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = patch(path, "application/json", "", `{"status":"done"}`)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = patch("/api/tasks/999", mergePatchContentType, "", `{"title":"Missing"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"konzek-jun/globalerror"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSearchLength keeps full-text queries to a sane size.
+const maxSearchLength = 200
+
+// taskFilterFromQuery reads the task list filters from the query string. It
+// returns the offending parameter when one is invalid.
+func taskFilterFromQuery(c *fiber.Ctx) (models.TaskFilter, *globalerror.ErrorResponseDetail) {
+	var filter models.TaskFilter
+
+	for _, value := range splitQuery(c.Query("status")) {
+		status := models.TaskStatus(value)
+		switch status {
+		case models.TaskStatusTodo, models.TaskStatusInProgress, models.TaskStatusBlocked, models.TaskStatusDone, models.TaskStatusCancelled:
+			filter.Statuses = append(filter.Statuses, status)
+		default:
+			return filter, &globalerror.ErrorResponseDetail{FieldName: "status", Description: fmt.Sprintf("unknown status %q", value)}
+		}
+	}
+
+	filter.Search = strings.TrimSpace(c.Query("search"))
+	if len(filter.Search) > maxSearchLength {
+		return filter, &globalerror.ErrorResponseDetail{FieldName: "search", Description: fmt.Sprintf("search must be at most %d characters", maxSearchLength)}
+	}
+
+	dates := []struct {
+		name   string
+		target **time.Time
+		end    bool
+	}{
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+		{"updated_from", &filter.UpdatedFrom, false},
+		{"updated_to", &filter.UpdatedTo, true},
+	}
+	for _, date := range dates {
+		value := c.Query(date.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseQueryTime(value, date.end)
+		if err != nil {
+			return filter, &globalerror.ErrorResponseDetail{FieldName: date.name, Description: "expected an RFC 3339 time or a YYYY-MM-DD date"}
+		}
+		*date.target = &parsed
+	}
+
+	for _, value := range splitQuery(c.Query("sort")) {
+		sort := models.TaskSort{Field: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+		if !repository.SortableTaskField(sort.Field) {
+			return filter, &globalerror.ErrorResponseDetail{FieldName: "sort", Description: fmt.Sprintf("cannot sort by %q", sort.Field)}
+		}
+		filter.Sort = append(filter.Sort, sort)
+	}
+	return filter, nil
+}
+
+// parseQueryTime accepts an RFC 3339 time or a date. A date used as the end
+// of a range covers the whole day.
+func parseQueryTime(value string, end bool) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+	}
+	return parsed, nil
+}
+
+func splitQuery(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func badTaskQuery(c *fiber.Ctx, detail globalerror.ErrorResponseDetail) error {
+	return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+		Status:      http.StatusBadRequest,
+		ErrorDetail: []globalerror.ErrorResponseDetail{detail},
+	})
+}
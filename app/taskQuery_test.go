@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"konzek-jun/globalerror"
+	"konzek-jun/models"
+	x "konzek-jun/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskHandler_GetAllTask_Filters(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks", td.GetAllTask)
+
+	createdFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2024, 1, 31, 23, 59, 59, 999999999, time.UTC)
+	filter := models.TaskFilter{
+		Statuses:    []models.TaskStatus{models.TaskStatusTodo, models.TaskStatusInProgress},
+		Search:      "sales report",
+		CreatedFrom: &createdFrom,
+		CreatedTo:   &createdTo,
+		Sort:        []models.TaskSort{{Field: "created_at", Desc: true}, {Field: "title"}},
+	}
+	mockService.EXPECT().TaskSearch(gomock.Any(), int64(1), filter).Return([]models.Task{{Id: 1, OwnerID: 1, Title: "Report"}}, int64(1), nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks?status=todo,in_progress&search=sales+report&created_from=2024-01-01&created_to=2024-01-31&sort=-created_at,title", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var page models.TaskPage
+	json.NewDecoder(resp.Body).Decode(&page)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-Total-Count"))
+	// /api/tasks/page ile aynı zarf döner
+	assert.Len(t, page.Tasks, 1)
+	assert.Equal(t, models.Pagination{PageSize: 1, Total: 1}, page.Pagination)
+}
+
+func TestTaskHandler_GetAllTask_UnknownSortField(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks", td.GetAllTask)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks?sort=password", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body globalerror.ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "sort", body.ErrorDetail[0].FieldName)
+}
+
+func TestTaskHandler_GetAllTaskWithPagination(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/page", td.GetAllTaskWithPagination)
+
+	filter := models.TaskFilter{Statuses: []models.TaskStatus{models.TaskStatusDone}}
+	page := models.TaskPage{
+		Tasks:      []models.Task{{Id: 3, OwnerID: 1, Title: "Task 3", Status: models.TaskStatusDone}},
+		Pagination: models.Pagination{Page: 2, PageSize: 1, Total: 2, TotalPages: 2},
+	}
+	mockService.EXPECT().GetAllTaskWithPagination(gomock.Any(), int64(1), filter, 2, 1).Return(page, nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/page?page=2&pageSize=1&status=done", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body models.TaskPage
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, page, body)
+}
+
+func TestTaskHandler_GetAllTaskWithPagination_Cursor(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/page", td.GetAllTaskWithPagination)
+
+	filter := models.TaskFilter{Sort: []models.TaskSort{{Field: "created_at", Desc: true}}}
+	page := models.TaskPage{
+		Tasks:      []models.Task{{Id: 9, OwnerID: 1, Title: "Task 9"}},
+		Pagination: models.Pagination{PageSize: 1, Total: 4, NextCursor: "next", PrevCursor: "prev"},
+	}
+	mockService.EXPECT().TaskCursorPage(gomock.Any(), int64(1), filter, "abc", "", 1).Return(page, nil)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/page?after=abc&limit=1&sort=-created_at", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body models.TaskPage
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "next", body.Pagination.NextCursor)
+	assert.Equal(t, "prev", body.Pagination.PrevCursor)
+}
+
+func TestTaskHandler_GetAllTaskWithPagination_InvalidCursor(t *testing.T) {
+	trd := setup(t)
+	defer trd()
+
+	td := NewTaskHandler(mockService, 5)
+	router := authenticatedRouter(1)
+	router.Get("/api/tasks/page", td.GetAllTaskWithPagination)
+
+	mockService.EXPECT().TaskCursorPage(gomock.Any(), int64(1), models.TaskFilter{}, "broken", "", 0).Return(models.TaskPage{}, x.ErrInvalidCursor)
+
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/tasks/page?after=broken", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"konzek-jun/dto"
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserHandler serves the admin endpoints that manage users.
+type UserHandler interface {
+	ListUsers(ctx *fiber.Ctx) error
+	SetUserRole(ctx *fiber.Ctx) error
+}
+
+type userHandler struct {
+	userService  services.UserService
+	tokenService services.TokenService
+}
+
+func NewUserHandler(userService services.UserService, tokenService services.TokenService) UserHandler {
+	return &userHandler{
+		userService:  userService,
+		tokenService: tokenService,
+	}
+}
+
+// @Summary Lists all users
+// @Description Lists every user with their role
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} dto.UserResponse "Users"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 403 {object} globalerror.ErrorResponse "Forbidden"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/users [get]
+func (h *userHandler) ListUsers(ctx *fiber.Ctx) error {
+	loggerx.Info("ListUsers function called")
+
+	users, err := h.userService.ListUsers(ctx.UserContext())
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "User",
+					Description: "An error occurred while listing users",
+				},
+			},
+		})
+	}
+	return ctx.Status(http.StatusOK).JSON(users)
+}
+
+// @Summary Changes the role of a user
+// @Description Sets the role of the user and logs out all of their sessions, so that the new role applies at once
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path integer true "User ID"
+// @Param role body dto.UserRoleRequest true "New role"
+// @Success 200 {object} dto.UserResponse "Updated user"
+// @Failure 400 {object} globalerror.ErrorResponse "Bad request"
+// @Failure 401 {object} globalerror.ErrorResponse "Unauthorized"
+// @Failure 403 {object} globalerror.ErrorResponse "Forbidden"
+// @Failure 404 {object} globalerror.ErrorResponse "User not found"
+// @Failure 500 {object} globalerror.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/role [put]
+func (h *userHandler) SetUserRole(ctx *fiber.Ctx) error {
+	loggerx.Info("SetUserRole function called")
+
+	userID, err := strconv.ParseInt(ctx.Params("id"), 10, 64)
+	if err != nil || userID < 1 {
+		return ctx.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "User",
+					Description: "invalid user id",
+				},
+			},
+		})
+	}
+
+	var request dto.UserRoleRequest
+	if err := ctx.BodyParser(&request); err != nil {
+		log.Println("Request parsing error:", err)
+		return ctx.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+			Status: http.StatusBadRequest,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Role",
+					Description: "Failed to process request",
+				},
+			},
+		})
+	}
+	if errors := globalerror.Validate(request); len(errors) > 0 && errors[0].HasError {
+		loggerx.Info("Invalid role request")
+		return globalerror.HandleValidationErrors(ctx, errors)
+	}
+
+	user, err := h.userService.SetUserRole(ctx.UserContext(), userID, request.Role)
+	if isAborted(err) {
+		return requestAborted(ctx, err)
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ctx.Status(http.StatusNotFound).JSON(globalerror.ErrorResponse{
+			Status: http.StatusNotFound,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "User",
+					Description: "User not found",
+				},
+			},
+		})
+	}
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Role",
+					Description: "An error occurred while changing the role",
+				},
+			},
+		})
+	}
+
+	// Tokens that carry the old role are revoked
+	if err := h.tokenService.LogoutAll(ctx.UserContext(), userID); err != nil {
+		return logoutError(ctx, err)
+	}
+	loggerx.Info(fmt.Sprintf("Role of user %d changed to %s", userID, request.Role))
+	return ctx.Status(http.StatusOK).JSON(user)
+}
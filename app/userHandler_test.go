@@ -0,0 +1,69 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"konzek-jun/dto"
+	services "konzek-jun/mocks/service"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+)
+
+func TestUserHandler_SetUserRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userMockService := services.NewMockUserService(ctrl)
+	tokenMockService := services.NewMockTokenService(ctrl)
+	userHandler := NewUserHandler(userMockService, tokenMockService)
+	router := authenticatedRouter(1)
+	router.Put("/api/admin/users/:id/role", userHandler.SetUserRole)
+
+	put := func(target, body string) int {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	// Rol değişince kullanıcının oturumları kapatılır
+	userMockService.EXPECT().SetUserRole(gomock.Any(), int64(2), models.RoleViewer).
+		Return(&dto.UserResponse{ID: 2, Role: models.RoleViewer}, nil)
+	tokenMockService.EXPECT().LogoutAll(gomock.Any(), int64(2)).Return(nil)
+	assert.Equal(t, http.StatusOK, put("/api/admin/users/2/role", `{"role":"viewer"}`))
+
+	userMockService.EXPECT().SetUserRole(gomock.Any(), int64(3), models.RoleAdmin).Return(nil, repository.ErrUserNotFound)
+	assert.Equal(t, http.StatusNotFound, put("/api/admin/users/3/role", `{"role":"admin"}`))
+
+	userMockService.EXPECT().SetUserRole(gomock.Any(), int64(2), models.RoleAdmin).Return(nil, errors.New("database is down"))
+	assert.Equal(t, http.StatusInternalServerError, put("/api/admin/users/2/role", `{"role":"admin"}`))
+
+	// Bilinmeyen rol ve geçersiz id servise ulaşmaz
+	assert.Equal(t, http.StatusBadRequest, put("/api/admin/users/2/role", `{"role":"owner"}`))
+	assert.Equal(t, http.StatusBadRequest, put("/api/admin/users/abc/role", `{"role":"admin"}`))
+}
+
+func TestUserHandler_ListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userMockService := services.NewMockUserService(ctrl)
+	userHandler := NewUserHandler(userMockService, services.NewMockTokenService(ctrl))
+	router := authenticatedRouter(1)
+	router.Get("/api/admin/users", userHandler.ListUsers)
+
+	userMockService.EXPECT().ListUsers(gomock.Any()).Return([]dto.UserResponse{{ID: 1, Role: models.RoleAdmin}}, nil)
+	resp, err := router.Test(httptest.NewRequest(http.MethodGet, "/api/admin/users", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"konzek-jun/prometheus"
+)
+
+// PoolSaturatedError is returned when a request could not get a worker: the
+// wait queue was full or no worker became free within the acquire timeout.
+type PoolSaturatedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *PoolSaturatedError) Error() string {
+	return fmt.Sprintf("worker pool saturated, retry after %s", e.RetryAfter)
+}
+
+// WorkerPool limits how many requests run at once. Requests that find every
+// worker busy wait in a bounded queue for at most AcquireTimeout.
+type WorkerPool struct {
+	workers chan struct{}
+	queue   chan struct{}
+	// AcquireTimeout is how long a queued request waits for a worker.
+	AcquireTimeout time.Duration
+	// RetryAfter is sent to rejected clients in the Retry-After header.
+	RetryAfter time.Duration
+}
+
+func NewWorkerPool(workers, queueSize int, acquireTimeout time.Duration) *WorkerPool {
+	prometheus.SetWorkerPoolSize(workers)
+	return &WorkerPool{
+		workers:        make(chan struct{}, workers),
+		queue:          make(chan struct{}, queueSize),
+		AcquireTimeout: acquireTimeout,
+		RetryAfter:     time.Second,
+	}
+}
+
+// Acquire takes a worker. It returns a *PoolSaturatedError when the request
+// has to be rejected and ctx.Err() when ctx is done first.
+func (p *WorkerPool) Acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case p.workers <- struct{}{}:
+		p.observe()
+		return nil
+	default:
+	}
+
+	select {
+	case p.queue <- struct{}{}:
+	default:
+		return p.reject()
+	}
+	p.observe()
+	defer func() {
+		<-p.queue
+		p.observe()
+	}()
+
+	timer := time.NewTimer(p.AcquireTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.workers <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return p.reject()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *WorkerPool) Release() {
+	<-p.workers
+	p.observe()
+}
+
+// InUse is the number of busy workers.
+func (p *WorkerPool) InUse() int {
+	return len(p.workers)
+}
+
+// Waiting is the number of requests queued for a worker.
+func (p *WorkerPool) Waiting() int {
+	return len(p.queue)
+}
+
+func (p *WorkerPool) reject() error {
+	prometheus.IncWorkerPoolRejections()
+	return &PoolSaturatedError{RetryAfter: p.RetryAfter}
+}
+
+func (p *WorkerPool) observe() {
+	prometheus.SetWorkerPoolUsage(p.InUse(), p.Waiting())
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPool_QueuedRequestGetsReleasedWorker(t *testing.T) {
+	pool := NewWorkerPool(1, 1, time.Second)
+	assert.NoError(t, pool.Acquire(context.Background()))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- pool.Acquire(context.Background())
+	}()
+
+	// İkinci istek kuyrukta beklemeli
+	assert.Eventually(t, func() bool { return pool.Waiting() == 1 }, time.Second, time.Millisecond)
+	pool.Release()
+
+	assert.NoError(t, <-acquired)
+	assert.Equal(t, 1, pool.InUse())
+	assert.Equal(t, 0, pool.Waiting())
+}
+
+func TestWorkerPool_RejectsWhenQueueIsFull(t *testing.T) {
+	pool := NewWorkerPool(1, 0, time.Second)
+	assert.NoError(t, pool.Acquire(context.Background()))
+
+	err := pool.Acquire(context.Background())
+
+	var saturated *PoolSaturatedError
+	assert.ErrorAs(t, err, &saturated)
+}
+
+func TestWorkerPool_RejectsAfterAcquireTimeout(t *testing.T) {
+	pool := NewWorkerPool(1, 1, 10*time.Millisecond)
+	assert.NoError(t, pool.Acquire(context.Background()))
+
+	err := pool.Acquire(context.Background())
+
+	var saturated *PoolSaturatedError
+	assert.ErrorAs(t, err, &saturated)
+	assert.Equal(t, 0, pool.Waiting())
+}
+
+func TestWorkerPool_GivesUpWhenContextIsDone(t *testing.T) {
+	pool := NewWorkerPool(1, 1, time.Second)
+	assert.NoError(t, pool.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, pool.Acquire(ctx), context.DeadlineExceeded)
+}
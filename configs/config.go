@@ -0,0 +1,491 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnv ayar dosyasının yolunu verir; --config flag'i bunu ezer
+const ConfigEnv = "KONZEK_CONFIG"
+
+// Storage değerleri
+const (
+	// StoragePostgres verileri Postgres'te tutar
+	StoragePostgres = "postgres"
+	// StorageMemory verileri sadece süreç ayaktayken bellekte tutar; testler
+	// ve veritabanı olmadan yerel geliştirme içindir
+	StorageMemory = "memory"
+	// StorageSQLite verileri tek bir SQLite dosyasında tutar; yanında
+	// Postgres çalıştırılamayan edge ve demo kurulumları içindir
+	StorageSQLite = "sqlite"
+)
+
+// Config uygulamanın tüm ayarlarıdır. Öncelik sırası: varsayılanlar,
+// YAML dosyası, ortam değişkenleri ve komut satırı flag'leri.
+type Config struct {
+	Storage     string            `yaml:"storage"`
+	Server      ServerConfig      `yaml:"server"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Database    DatabaseConfig    `yaml:"database"`
+	SQLite      SQLiteConfig      `yaml:"sqlite"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Workers     WorkersConfig     `yaml:"workers"`
+	Admin       AdminConfig       `yaml:"admin"`
+}
+
+// ServerConfig HTTP sunucusunun portu ve route bazındaki süre sınırlarıdır
+type ServerConfig struct {
+	Port         int           `yaml:"port"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	AdminTimeout time.Duration `yaml:"admin_timeout"`
+}
+
+// MetricsConfig Prometheus endpoint'inin dinlediği porttur
+type MetricsConfig struct {
+	Port int `yaml:"port"`
+}
+
+// DatabaseConfig Postgres bağlantı ayarlarıdır. URL verilirse diğer
+// bağlantı alanları kullanılmaz.
+type DatabaseConfig struct {
+	URL             string        `yaml:"url"`
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	// RetryAttempts geçici hatalarda bir sorgunun toplam deneme sayısıdır
+	RetryAttempts int `yaml:"retry_attempts"`
+}
+
+// AdminConfig ilk admin kullanıcısıdır. Email verilirse kullanıcı açılışta
+// admin yapılır, yoksa Password ile oluşturulur; memory storage'da her
+// açılışta oluşturulur.
+type AdminConfig struct {
+	Email    string `yaml:"email"`
+	Password string `yaml:"password"`
+}
+
+// SQLiteConfig storage sqlite iken kullanılan veritabanı dosyasıdır
+type SQLiteConfig struct {
+	Path string `yaml:"path"`
+}
+
+// JWT imzalama algoritmaları
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// JWTConfig token imzalama ayarlarıdır. HS256 ile token'lar Secret ile,
+// RS256 ve EdDSA ile PrivateKeyFile'daki PEM anahtarla imzalanır.
+// VerificationKeyFiles'taki açık anahtarlar yalnızca doğrulamada kullanılır;
+// anahtar değiştirilirken eski anahtar buraya eklenir. HS256'dan asimetrik
+// bir algoritmaya geçerken Secret ile imzalanmış token'lar yalnızca
+// HS256Until'e kadar kabul edilir; HS256Until verilmeden Secret asimetrik
+// algoritmalarla kullanılamaz. TTL
+// access token'ların, RefreshTTL refresh token'ların geçerlilik süresidir.
+// RevocationSync, diğer sunucularda iptal edilen token'ların ne sıklıkla
+// okunacağıdır.
+type JWTConfig struct {
+	Algorithm            string        `yaml:"algorithm"`
+	Secret               string        `yaml:"secret"`
+	PrivateKeyFile       string        `yaml:"private_key_file"`
+	VerificationKeyFiles []string      `yaml:"verification_key_files"`
+	HS256Until           time.Time     `yaml:"hs256_until"`
+	Issuer               string        `yaml:"issuer"`
+	TTL                  time.Duration `yaml:"ttl"`
+	RefreshTTL           time.Duration `yaml:"refresh_ttl"`
+	RevocationSync       time.Duration `yaml:"revocation_sync"`
+}
+
+// RateLimitConfig IP başına Window süresinde en fazla Max isteğe izin verir
+type RateLimitConfig struct {
+	Max    int           `yaml:"max"`
+	Window time.Duration `yaml:"window"`
+}
+
+// IdempotencyConfig Idempotency-Key ile gelen isteklerin yanıtlarının ne
+// kadar süre tekrar gönderileceğidir
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// WorkersConfig HTTP worker havuzu ve arka plan job worker'larının ayarlarıdır
+type WorkersConfig struct {
+	HTTP           int           `yaml:"http"`
+	Queue          int           `yaml:"queue"`
+	AcquireTimeout time.Duration `yaml:"acquire_timeout"`
+	Jobs           int           `yaml:"jobs"`
+}
+
+// Default varsayılan ayarları döner. JWT secret'ın ve anahtarların
+// varsayılanı yoktur.
+func Default() Config {
+	return Config{
+		Storage: StoragePostgres,
+		Server: ServerConfig{
+			Port:         8080,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			AdminTimeout: 30 * time.Second,
+		},
+		Metrics: MetricsConfig{Port: 2222},
+		Database: DatabaseConfig{
+			Host:          "localhost",
+			Port:          5432,
+			User:          "postgres",
+			Name:          "konzek",
+			SSLMode:       "require",
+			MaxOpenConns:  25,
+			MaxIdleConns:  25,
+			RetryAttempts: 3,
+		},
+		SQLite: SQLiteConfig{Path: "konzek.db"},
+		JWT: JWTConfig{
+			Algorithm:      JWTAlgorithmHS256,
+			Issuer:         "admin",
+			TTL:            15 * time.Minute,
+			RefreshTTL:     30 * 24 * time.Hour,
+			RevocationSync: 10 * time.Second,
+		},
+		RateLimit:   RateLimitConfig{Max: 5, Window: time.Second},
+		Idempotency: IdempotencyConfig{TTL: 24 * time.Hour},
+		Workers: WorkersConfig{
+			HTTP:           5,
+			Queue:          50,
+			AcquireTimeout: 2 * time.Second,
+			Jobs:           5,
+		},
+	}
+}
+
+// setting ortam değişkeni ve flag olarak verilebilen bir ayardır
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	// value *string, *[]string, *int, *time.Duration veya *time.Time
+	// olabilir; listeler virgülle ayrılır, zamanlar RFC 3339 biçimindedir
+	value interface{}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"KONZEK_STORAGE", "storage", "postgres, sqlite veya memory", &c.Storage},
+		{"KONZEK_PORT", "port", "HTTP port", &c.Server.Port},
+		{"KONZEK_READ_TIMEOUT", "read-timeout", "okuma isteklerinin süre sınırı", &c.Server.ReadTimeout},
+		{"KONZEK_WRITE_TIMEOUT", "write-timeout", "yazma isteklerinin süre sınırı", &c.Server.WriteTimeout},
+		{"KONZEK_ADMIN_TIMEOUT", "admin-timeout", "admin isteklerinin süre sınırı", &c.Server.AdminTimeout},
+		{"KONZEK_METRICS_PORT", "metrics-port", "Prometheus port", &c.Metrics.Port},
+		{"KONZEK_DB_URL", "db-url", "Postgres bağlantı adresi, verilirse diğer db ayarları kullanılmaz", &c.Database.URL},
+		{"KONZEK_DB_HOST", "db-host", "Postgres host", &c.Database.Host},
+		{"KONZEK_DB_PORT", "db-port", "Postgres port", &c.Database.Port},
+		{"KONZEK_DB_USER", "db-user", "Postgres kullanıcısı", &c.Database.User},
+		{"KONZEK_DB_PASSWORD", "db-password", "Postgres şifresi", &c.Database.Password},
+		{"KONZEK_DB_NAME", "db-name", "veritabanı adı", &c.Database.Name},
+		{"KONZEK_DB_SSLMODE", "db-sslmode", "disable, require, verify-ca veya verify-full", &c.Database.SSLMode},
+		{"KONZEK_DB_MAX_OPEN_CONNS", "db-max-open-conns", "en fazla açık bağlantı", &c.Database.MaxOpenConns},
+		{"KONZEK_DB_MAX_IDLE_CONNS", "db-max-idle-conns", "en fazla boşta bağlantı", &c.Database.MaxIdleConns},
+		{"KONZEK_DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "bir bağlantının en uzun ömrü, 0 sınırsız", &c.Database.ConnMaxLifetime},
+		{"KONZEK_DB_RETRY_ATTEMPTS", "db-retry-attempts", "geçici hatalarda toplam deneme sayısı", &c.Database.RetryAttempts},
+		{"KONZEK_SQLITE_PATH", "sqlite-path", "SQLite veritabanı dosyası", &c.SQLite.Path},
+		{"KONZEK_JWT_ALGORITHM", "jwt-algorithm", "HS256, RS256 veya EdDSA", &c.JWT.Algorithm},
+		{"KONZEK_JWT_SECRET", "jwt-secret", "HS256 token imzalama anahtarı", &c.JWT.Secret},
+		{"KONZEK_JWT_PRIVATE_KEY_FILE", "jwt-private-key-file", "RS256 veya EdDSA imzalama anahtarının PEM dosyası", &c.JWT.PrivateKeyFile},
+		{"KONZEK_JWT_VERIFICATION_KEY_FILES", "jwt-verification-key-files", "yalnızca doğrulamada kullanılan PEM dosyaları, virgülle ayrılır", &c.JWT.VerificationKeyFiles},
+		{"KONZEK_JWT_HS256_UNTIL", "jwt-hs256-until", "RS256 veya EdDSA'ya geçerken HS256 token'ların kabul edildiği son an, RFC 3339", &c.JWT.HS256Until},
+		{"KONZEK_JWT_ISSUER", "jwt-issuer", "token issuer", &c.JWT.Issuer},
+		{"KONZEK_JWT_TTL", "jwt-ttl", "access token geçerlilik süresi", &c.JWT.TTL},
+		{"KONZEK_JWT_REFRESH_TTL", "jwt-refresh-ttl", "refresh token geçerlilik süresi", &c.JWT.RefreshTTL},
+		{"KONZEK_JWT_REVOCATION_SYNC", "jwt-revocation-sync", "iptal edilen token listesinin yenilenme aralığı", &c.JWT.RevocationSync},
+		{"KONZEK_RATE_LIMIT_MAX", "rate-limit-max", "IP başına pencere içinde en fazla istek", &c.RateLimit.Max},
+		{"KONZEK_RATE_LIMIT_WINDOW", "rate-limit-window", "rate limit penceresi", &c.RateLimit.Window},
+		{"KONZEK_IDEMPOTENCY_TTL", "idempotency-ttl", "Idempotency-Key yanıtlarının saklanma süresi", &c.Idempotency.TTL},
+		{"KONZEK_HTTP_WORKERS", "http-workers", "aynı anda çalışan HTTP worker sayısı", &c.Workers.HTTP},
+		{"KONZEK_HTTP_QUEUE", "http-queue", "worker bekleyen en fazla istek", &c.Workers.Queue},
+		{"KONZEK_HTTP_ACQUIRE_TIMEOUT", "http-acquire-timeout", "worker bekleme süresi, dolunca 503 döner", &c.Workers.AcquireTimeout},
+		{"KONZEK_JOB_WORKERS", "job-workers", "arka plan job worker sayısı", &c.Workers.Jobs},
+		{"KONZEK_ADMIN_EMAIL", "admin-email", "açılışta admin yapılan kullanıcının emaili", &c.Admin.Email},
+		{"KONZEK_ADMIN_PASSWORD", "admin-password", "admin kullanıcısı yoksa oluşturulurken kullanılan şifre", &c.Admin.Password},
+	}
+}
+
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch value := s.value.(type) {
+	case *string:
+		*value = raw
+	case *[]string:
+		*value = nil
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*value = append(*value, item)
+			}
+		}
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q bir sayı değil", raw)
+		}
+		*value = n
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q bir süre değil, örneğin 5s veya 1m30s olmalı", raw)
+		}
+		*value = d
+	case *time.Time:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return fmt.Errorf("%q bir zaman değil, örneğin 2026-01-02T15:04:05Z olmalı", raw)
+		}
+		*value = t
+	}
+	return nil
+}
+
+// Load ayarları varsayılanlar, ayar dosyası, ortam değişkenleri ve args'taki
+// flag'lerden sırayla okur ve flag'lerden sonra kalan argümanları döner.
+func Load(args []string, getenv func(string) string) (Config, []string, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	// Flag'ler en son uygulanır, önce sadece toplanırlar
+	flags := flag.NewFlagSet("konzek", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configPath := flags.String("config", getenv(ConfigEnv), "YAML ayar dosyası")
+	var pending []func() error
+	for _, s := range settings {
+		s := s
+		flags.Func(s.flag, s.usage, func(raw string) error {
+			pending = append(pending, func() error {
+				if err := s.set(raw); err != nil {
+					return fmt.Errorf("--%s: %w", s.flag, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return cfg, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		raw := getenv(s.env)
+		if raw == "" {
+			continue
+		}
+		if err := s.set(raw); err != nil {
+			return cfg, nil, fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+
+	for _, apply := range pending {
+		if err := apply(); err != nil {
+			return cfg, nil, err
+		}
+	}
+	return cfg, flags.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("ayar dosyası açılamadı: %w", err)
+	}
+	defer file.Close()
+
+	// Yanlış yazılmış anahtarlar sessizce yok sayılmasın
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("ayar dosyası %s okunamadı: %w", path, err)
+	}
+	return nil
+}
+
+// Validate tüm ayarları kontrol eder ve bulunan bütün hataları birlikte döner
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.Port), "server.port 1 ile 65535 arasında olmalı, %d verildi", c.Server.Port)
+	check(validPort(c.Metrics.Port), "metrics.port 1 ile 65535 arasında olmalı, %d verildi", c.Metrics.Port)
+	check(c.Server.Port != c.Metrics.Port, "server.port ve metrics.port aynı olamaz (%d)", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server.read_timeout pozitif olmalı")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout pozitif olmalı")
+	check(c.Server.AdminTimeout > 0, "server.admin_timeout pozitif olmalı")
+
+	if c.Storage != StorageMemory {
+		if err := c.ValidateStorage(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch c.JWT.Algorithm {
+	case JWTAlgorithmHS256:
+		check(c.JWT.Secret != "", "jwt.secret zorunlu, KONZEK_JWT_SECRET ile verilebilir")
+	case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+		check(c.JWT.PrivateKeyFile != "", "jwt.algorithm %s iken jwt.private_key_file zorunlu", c.JWT.Algorithm)
+		// Eski HS256 token'lar yalnızca açıkça verilen bir süre boyunca kabul edilir
+		check(c.JWT.Secret == "" || !c.JWT.HS256Until.IsZero(), "jwt.algorithm %s iken jwt.secret yalnızca jwt.hs256_until ile verilebilir", c.JWT.Algorithm)
+		check(c.JWT.HS256Until.IsZero() || c.JWT.Secret != "", "jwt.hs256_until için jwt.secret de verilmeli")
+	default:
+		check(false, "jwt.algorithm HS256, RS256 veya EdDSA olmalı, %q verildi", c.JWT.Algorithm)
+	}
+	check(c.JWT.Secret == "" || len(c.JWT.Secret) >= 32, "jwt.secret en az 32 karakter olmalı")
+	// Örnek dosyalardaki yer tutucu gerçek bir anahtar değildir
+	check(!strings.HasPrefix(strings.ToLower(c.JWT.Secret), "change-me"), "jwt.secret örnek değer bırakılmış, rastgele bir anahtar verilmeli")
+	check(c.JWT.Issuer != "", "jwt.issuer boş olamaz")
+	check(c.JWT.TTL > 0, "jwt.ttl pozitif olmalı")
+	check(c.JWT.RefreshTTL > c.JWT.TTL, "jwt.refresh_ttl jwt.ttl'den uzun olmalı")
+	check(c.JWT.RevocationSync > 0, "jwt.revocation_sync pozitif olmalı")
+
+	check(c.RateLimit.Max > 0, "rate_limit.max pozitif olmalı, %d verildi", c.RateLimit.Max)
+	check(c.RateLimit.Window > 0, "rate_limit.window pozitif olmalı")
+	check(c.Idempotency.TTL > 0, "idempotency.ttl pozitif olmalı")
+
+	check(c.Workers.HTTP > 0, "workers.http pozitif olmalı, %d verildi", c.Workers.HTTP)
+	check(c.Workers.Queue >= 0, "workers.queue negatif olamaz")
+	check(c.Workers.AcquireTimeout > 0, "workers.acquire_timeout pozitif olmalı")
+	check(c.Workers.Jobs > 0, "workers.jobs pozitif olmalı, %d verildi", c.Workers.Jobs)
+
+	check(c.Admin.Email != "" || c.Admin.Password == "", "admin.password için admin.email de verilmeli")
+	check(c.Admin.Password == "" || len(c.Admin.Password) >= 6, "admin.password en az 6 karakter olmalı")
+	check(c.Storage != StorageMemory || c.Admin.Email == "" || c.Admin.Password != "",
+		"storage %s iken admin kullanıcısı her açılışta oluşturulur, admin.password zorunlu", StorageMemory)
+
+	return errors.Join(errs...)
+}
+
+// ValidateStorage sadece seçilen veritabanının ayarlarını kontrol eder;
+// migrate komutu diğer ayarlara ihtiyaç duymaz
+func (c Config) ValidateStorage() error {
+	switch c.Storage {
+	case StoragePostgres:
+		return c.Database.Validate()
+	case StorageSQLite:
+		return c.SQLite.Validate()
+	case StorageMemory:
+		return fmt.Errorf("storage %s iken şema yoktur", StorageMemory)
+	}
+	return fmt.Errorf("storage %s, %s veya %s olmalı, %q verildi", StoragePostgres, StorageSQLite, StorageMemory, c.Storage)
+}
+
+// Validate Postgres bağlantı ayarlarını kontrol eder
+func (d DatabaseConfig) Validate() error {
+	var errs []error
+	if d.URL == "" {
+		if d.Host == "" {
+			errs = append(errs, errors.New("database.host boş olamaz"))
+		}
+		if !validPort(d.Port) {
+			errs = append(errs, fmt.Errorf("database.port 1 ile 65535 arasında olmalı, %d verildi", d.Port))
+		}
+		if d.User == "" {
+			errs = append(errs, errors.New("database.user boş olamaz"))
+		}
+		if d.Name == "" {
+			errs = append(errs, errors.New("database.name boş olamaz"))
+		}
+		switch d.SSLMode {
+		case "disable", "require", "verify-ca", "verify-full":
+		default:
+			errs = append(errs, fmt.Errorf("database.sslmode disable, require, verify-ca veya verify-full olmalı, %q verildi", d.SSLMode))
+		}
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database.max_open_conns ve database.max_idle_conns negatif olamaz"))
+	}
+	if d.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("database.conn_max_lifetime negatif olamaz"))
+	}
+	if d.RetryAttempts < 1 {
+		errs = append(errs, fmt.Errorf("database.retry_attempts en az 1 olmalı, %d verildi", d.RetryAttempts))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate SQLite ayarlarını ve sürücünün derlemede olup olmadığını kontrol eder
+func (s SQLiteConfig) Validate() error {
+	var errs []error
+	if s.Path == "" {
+		errs = append(errs, errors.New("sqlite.path boş olamaz"))
+	}
+	if !sqliteAvailable() {
+		errs = append(errs, errors.New("bu derlemede SQLite sürücüsü yok, uygulama -tags sqlite ile derlenmeli"))
+	}
+	return errors.Join(errs...)
+}
+
+// DSN lib/pq'nun anladığı bağlantı bilgisini döner
+func (d DatabaseConfig) DSN() string {
+	if d.URL != "" {
+		return d.URL
+	}
+	params := []struct{ key, value string }{
+		{"host", d.Host},
+		{"port", strconv.Itoa(d.Port)},
+		{"user", d.User},
+		{"password", d.Password},
+		{"dbname", d.Name},
+		{"sslmode", d.SSLMode},
+	}
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		// Boşluk veya tırnak içeren şifreler de doğru okunsun
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p.value)
+		parts = append(parts, fmt.Sprintf("%s='%s'", p.key, value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// DSN SQLite sürücüsüne verilen bağlantı bilgisidir. Foreign key'ler açılır,
+// başka bir yazma sürerken hata yerine beklenir.
+func (s SQLiteConfig) DSN() string {
+	return s.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+}
+
+// Usage flag'lerin açıklamasını w'ya yazar
+func Usage(w io.Writer) {
+	cfg := Default()
+	fmt.Fprintf(w, "  --config\n\tYAML ayar dosyası (%s)\n", ConfigEnv)
+	for _, s := range cfg.settings() {
+		fmt.Fprintf(w, "  --%s\n\t%s (%s)\n", s.flag, s.usage, s.env)
+	}
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
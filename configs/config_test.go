@@ -0,0 +1,241 @@
+package configs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"konzek-jun/configs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, args, err := configs.Load(nil, env(nil))
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, configs.Default(), cfg)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+server:
+  port: 9000
+  read_timeout: 3s
+database:
+  host: db.internal
+  name: fromfile
+jwt:
+  issuer: file
+`)
+
+	// Dosya varsayılanları, ortam değişkenleri dosyayı, flag'ler de ortam değişkenlerini ezer
+	cfg, args, err := configs.Load(
+		[]string{"--config", path, "--port", "9100", "--jwt-ttl", "15m", "migrate", "up"},
+		env(map[string]string{
+			"KONZEK_PORT":    "9050",
+			"KONZEK_DB_NAME": "fromenv",
+		}),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	assert.Equal(t, 9100, cfg.Server.Port)
+	assert.Equal(t, 3*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, "fromenv", cfg.Database.Name)
+	assert.Equal(t, "file", cfg.JWT.Issuer)
+	assert.Equal(t, 15*time.Minute, cfg.JWT.TTL)
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	path := writeConfig(t, "metrics:\n  port: 3333\n")
+
+	cfg, _, err := configs.Load(nil, env(map[string]string{configs.ConfigEnv: path}))
+	assert.NoError(t, err)
+	assert.Equal(t, 3333, cfg.Metrics.Port)
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Run("unknown key in file", func(t *testing.T) {
+		path := writeConfig(t, "server:\n  prot: 9000\n")
+		_, _, err := configs.Load([]string{"--config", path}, env(nil))
+		assert.ErrorContains(t, err, "prot")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, _, err := configs.Load([]string{"--config", filepath.Join(t.TempDir(), "nope.yaml")}, env(nil))
+		assert.Error(t, err)
+	})
+
+	t.Run("bad env value", func(t *testing.T) {
+		_, _, err := configs.Load(nil, env(map[string]string{"KONZEK_HTTP_WORKERS": "many"}))
+		assert.ErrorContains(t, err, "KONZEK_HTTP_WORKERS")
+	})
+
+	t.Run("bad flag value", func(t *testing.T) {
+		_, _, err := configs.Load([]string{"--rate-limit-window", "soon"}, env(nil))
+		assert.ErrorContains(t, err, "--rate-limit-window")
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		_, _, err := configs.Load([]string{"--wokers", "3"}, env(nil))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	cfg := configs.Default()
+	cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.JWT.Secret = ""
+	cfg.Server.Port = 70000
+	cfg.Database.SSLMode = "prefer"
+	cfg.Workers.HTTP = 0
+	err := cfg.Validate()
+	if !assert.Error(t, err) {
+		return
+	}
+	// Bütün hatalar tek seferde raporlanır
+	assert.ErrorContains(t, err, "server.port")
+	assert.ErrorContains(t, err, "database.sslmode")
+	assert.ErrorContains(t, err, "jwt.secret")
+	assert.ErrorContains(t, err, "workers.http")
+
+	// Örnek değer anahtar olarak kabul edilmez
+	cfg = configs.Default()
+	cfg.JWT.Secret = "change-me-to-a-random-32-byte-secret"
+	assert.ErrorContains(t, cfg.Validate(), "jwt.secret")
+}
+
+func TestValidateJWTAlgorithm(t *testing.T) {
+	cfg := configs.Default()
+	cfg.JWT.Algorithm = configs.JWTAlgorithmRS256
+	assert.ErrorContains(t, cfg.Validate(), "jwt.private_key_file")
+
+	// Asimetrik anahtarla secret zorunlu değildir
+	cfg.JWT.PrivateKeyFile = "jwt.pem"
+	assert.NoError(t, cfg.Validate())
+
+	// Secret yalnızca HS256'dan geçiş süresi verildiyse kullanılabilir
+	cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	assert.ErrorContains(t, cfg.Validate(), "jwt.hs256_until")
+	cfg.JWT.HS256Until = time.Now().Add(time.Hour)
+	assert.NoError(t, cfg.Validate())
+	cfg.JWT.Secret = ""
+	assert.ErrorContains(t, cfg.Validate(), "jwt.hs256_until")
+	cfg.JWT.HS256Until = time.Time{}
+
+	cfg.JWT.Algorithm = "none"
+	assert.ErrorContains(t, cfg.Validate(), "jwt.algorithm")
+}
+
+func TestLoadJWTKeyFiles(t *testing.T) {
+	cfg, _, err := configs.Load(
+		[]string{"--jwt-verification-key-files", "old.pem, older.pem"},
+		env(map[string]string{"KONZEK_JWT_ALGORITHM": "EdDSA", "KONZEK_JWT_PRIVATE_KEY_FILE": "jwt.pem", "KONZEK_JWT_HS256_UNTIL": "2026-01-02T15:04:05Z"}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, configs.JWTAlgorithmEdDSA, cfg.JWT.Algorithm)
+	assert.Equal(t, "jwt.pem", cfg.JWT.PrivateKeyFile)
+	assert.Equal(t, []string{"old.pem", "older.pem"}, cfg.JWT.VerificationKeyFiles)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), cfg.JWT.HS256Until)
+
+	_, _, err = configs.Load(nil, env(map[string]string{"KONZEK_JWT_HS256_UNTIL": "tomorrow"}))
+	assert.ErrorContains(t, err, "KONZEK_JWT_HS256_UNTIL")
+}
+
+func TestValidateMemoryStorage(t *testing.T) {
+	cfg := configs.Default()
+	cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	cfg.Database.Host = ""
+	assert.ErrorContains(t, cfg.Validate(), "database.host")
+
+	// Bellekte çalışırken veritabanı ayarlarına bakılmaz
+	cfg.Storage = configs.StorageMemory
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Storage = "mongo"
+	assert.ErrorContains(t, cfg.Validate(), "storage")
+}
+
+func TestValidateAdmin(t *testing.T) {
+	cfg := configs.Default()
+	cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	cfg.Admin.Password = "secret"
+	assert.ErrorContains(t, cfg.Validate(), "admin.email")
+
+	// Var olan kullanıcı şifresiz admin yapılabilir
+	cfg.Admin = configs.AdminConfig{Email: "admin@example.com"}
+	assert.NoError(t, cfg.Validate())
+
+	// Bellekte kullanıcı her açılışta oluşturulduğu için şifre zorunludur
+	cfg.Storage = configs.StorageMemory
+	assert.ErrorContains(t, cfg.Validate(), "admin.password")
+	cfg.Admin.Password = "short"
+	assert.ErrorContains(t, cfg.Validate(), "admin.password")
+	cfg.Admin.Password = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	loaded, _, err := configs.Load([]string{"--admin-email", "admin@example.com"}, env(map[string]string{"KONZEK_ADMIN_PASSWORD": "secret"}))
+	assert.NoError(t, err)
+	assert.Equal(t, configs.AdminConfig{Email: "admin@example.com", Password: "secret"}, loaded.Admin)
+}
+
+func TestValidateSQLiteStorage(t *testing.T) {
+	cfg := configs.Default()
+	cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	cfg.Storage = configs.StorageSQLite
+	cfg.Database.Host = ""
+	cfg.SQLite.Path = ""
+
+	// Postgres ayarlarına bakılmaz, dosya yolu zorunludur
+	err := cfg.ValidateStorage()
+	assert.ErrorContains(t, err, "sqlite.path")
+	assert.NotContains(t, err.Error(), "database.host")
+
+	cfg.Storage = configs.StorageMemory
+	assert.Error(t, cfg.ValidateStorage())
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	cfg := configs.Default()
+	cfg.Storage = configs.StorageSQLite
+	assert.Equal(t, "sqlite", string(cfg.Dialect()))
+	assert.Contains(t, cfg.SQLite.DSN(), "konzek.db?")
+	assert.Contains(t, cfg.SQLite.DSN(), "foreign_keys(1)")
+}
+
+func TestDatabaseValidateWithURL(t *testing.T) {
+	cfg := configs.Default().Database
+	cfg.Host = ""
+	assert.Error(t, cfg.Validate())
+
+	cfg.URL = "postgres://postgres@localhost/konzek"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestDSN(t *testing.T) {
+	cfg := configs.Default().Database
+	cfg.Password = `it's a secret`
+	assert.Equal(t, `host='localhost' port='5432' user='postgres' password='it\'s a secret' dbname='konzek' sslmode='require'`, cfg.DSN())
+
+	cfg.URL = "postgres://postgres@localhost/konzek"
+	assert.Equal(t, cfg.URL, cfg.DSN())
+}
@@ -1,5 +0,0 @@
-package configs
-
-func EnvMongoURI() string {
-	return ""
-}
@@ -1,74 +1,79 @@
 package configs
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"konzek-jun/migrations"
 
 	_ "github.com/lib/pq"
 )
 
-var db *sql.DB
+// sqliteDriver configs/sqlite.go'da -tags sqlite ile kaydedilir
+const sqliteDriver = "sqlite"
 
-func ConnectDB() *sql.DB {
-
-	dbURI := EnvPostgresURI()
-
-	conn, err := sql.Open("postgres", dbURI)
-	if err != nil {
-		log.Fatalf("Veritabanına bağlanırken hata oluştu: %v\n", err)
+// Dialect seçilen veritabanının migration dizinidir
+func (c Config) Dialect() migrations.Dialect {
+	if c.Storage == StorageSQLite {
+		return migrations.SQLite
 	}
+	return migrations.Postgres
+}
 
-	err = conn.Ping()
-	if err != nil {
-		log.Fatalf("Veritabanına ping atılırken hata oluştu: %v\n", err)
+// OpenDB seçilen veritabanına bağlanır, şemaya dokunmaz
+func OpenDB(cfg Config) (*sql.DB, error) {
+	var conn *sql.DB
+	var err error
+	if cfg.Storage == StorageSQLite {
+		conn, err = sql.Open(sqliteDriver, cfg.SQLite.DSN())
+		if err != nil {
+			return nil, fmt.Errorf("veritabanına bağlanırken hata oluştu: %w", err)
+		}
+		// SQLite aynı anda tek yazıcıya izin verir, bağlantılar sıraya girer
+		conn.SetMaxOpenConns(1)
+	} else {
+		conn, err = sql.Open("postgres", cfg.Database.DSN())
+		if err != nil {
+			return nil, fmt.Errorf("veritabanına bağlanırken hata oluştu: %w", err)
+		}
+		conn.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+		conn.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+		conn.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
 	}
 
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS tasks (
-		id SERIAL PRIMARY KEY,
-		title TEXT NOT NULL,
-		content TEXT,
-		status BOOLEAN
-	)
-`
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("veritabanına ping atılırken hata oluştu: %w", err)
+	}
+	return conn, nil
+}
 
-	_, err = conn.Exec(createTableSQL)
+// ConnectDB veritabanına bağlanır ve bekleyen migration'ları uygular
+func ConnectDB(cfg Config) (*sql.DB, error) {
+	conn, err := OpenDB(cfg)
 	if err != nil {
-		log.Fatalf("Tablo oluşturulurken hata oluştu: %v\n", err)
+		return nil, err
 	}
 
-	createUserTableSQL := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(100) NOT NULL,
-		email VARCHAR(100) UNIQUE NOT NULL,
-		password VARCHAR(100) NOT NULL
-	)
-`
-	_, err = conn.Exec(createUserTableSQL)
+	// Aynı anda başlayan replikalar advisory lock ile sıraya girer
+	migrator, err := migrations.New(conn, cfg.Dialect())
 	if err != nil {
-		log.Fatalf("users tablosu oluşturulurken hata oluştu: %v\n", err)
+		conn.Close()
+		return nil, fmt.Errorf("migration'lar okunurken hata oluştu: %w", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("migration'lar uygulanırken hata oluştu: %w", err)
 	}
 
-	return conn
-}
-
-func EnvPostgresURI() string {
-
-	host := "postgres"
-	port := "5432"
-	user := "postgres"
-	password := "test"
-	dbname := "konzek"
-
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+	return conn, nil
 }
 
-func GetDB() *sql.DB {
-	if db == nil {
-		db = ConnectDB()
+func sqliteAvailable() bool {
+	for _, driver := range sql.Drivers() {
+		if driver == sqliteDriver {
+			return true
+		}
 	}
-	return db
+	return false
 }
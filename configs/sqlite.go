@@ -0,0 +1,6 @@
+//go:build sqlite
+
+package configs
+
+// Saf Go SQLite sürücüsü, CGO_ENABLED=0 ile derlenebilir
+import _ "modernc.org/sqlite"
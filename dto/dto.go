@@ -1,6 +1,9 @@
 package dto
 
 import (
+	"time"
+
+	"konzek-jun/globalerror"
 	"konzek-jun/models"
 )
 
@@ -28,10 +31,32 @@ type UpdateUserRequest struct {
 }
 
 type UserResponse struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Token string `json:"token,omitempty"`
+	ID    int64       `json:"id"`
+	Name  string      `json:"name"`
+	Email string      `json:"email"`
+	Role  models.Role `json:"role,omitempty"`
+	// Token is the access token, RefreshToken renews it after ExpiresIn
+	// seconds.
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+}
+
+// UserRoleRequest changes the role of a user.
+type UserRoleRequest struct {
+	Role models.Role `json:"role" form:"role" validate:"required,oneof=admin member viewer"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" validate:"required"`
+}
+
+// TokenResponse is a new access token and the refresh token that replaces
+// the one sent.
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
 func NewUserResponse(user models.User) UserResponse {
@@ -39,5 +64,94 @@ func NewUserResponse(user models.User) UserResponse {
 		ID:    user.ID,
 		Email: user.Email,
 		Name:  user.Name,
+		Role:  user.Role,
+	}
+}
+
+type TaskTransitionRequest struct {
+	Status models.TaskStatus `json:"status" form:"status" validate:"required,oneof=todo in_progress blocked done cancelled"`
+}
+
+type TaskDependencyRequest struct {
+	DependsOnID int `json:"depends_on_id" form:"depends_on_id" validate:"required,min=1"`
+}
+
+// TaskPatchDocument is the part of a task a PATCH request can change. The
+// patch is applied to it and the result is validated as a whole.
+type TaskPatchDocument struct {
+	Title   string            `json:"title" validate:"required,min=2"`
+	Content string            `json:"content" validate:"required,min=2"`
+	Status  models.TaskStatus `json:"status" validate:"required,oneof=todo in_progress blocked done cancelled"`
+}
+
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+// BulkTaskRequest runs several task operations in one request. In atomic
+// mode either all operations are applied or none; best_effort, the default,
+// applies every operation that succeeds.
+type BulkTaskRequest struct {
+	Mode       string              `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkTaskOperation `json:"operations" validate:"required,min=1,max=1000"`
+}
+
+// BulkTaskOperation creates Task, updates task ID with the fields of Task or
+// deletes task ID. A Version other than 0 works like If-Match.
+type BulkTaskOperation struct {
+	Op      string      `json:"op" validate:"required,oneof=create update delete"`
+	ID      int         `json:"id,omitempty"`
+	Version int         `json:"version,omitempty"`
+	Task    models.Task `json:"task"`
+}
+
+// BulkTaskResult is the outcome of the operation at Index. Status is the
+// HTTP status the operation would have had as a request of its own.
+type BulkTaskResult struct {
+	Index   int                        `json:"index"`
+	Status  int                        `json:"status"`
+	ID      int                        `json:"id,omitempty"`
+	Version int                        `json:"version,omitempty"`
+	Error   *globalerror.ErrorResponse `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Mode      string           `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTaskResult `json:"results"`
+}
+
+// APIKeyRequest creates an API key limited to Scopes. Without ExpiresAt the
+// key is valid until it is revoked.
+type APIKeyRequest struct {
+	Name      string     `json:"name" form:"name" validate:"required,min=1,max=100"`
+	Scopes    []string   `json:"scopes" form:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" form:"expires_at"`
+}
+
+// APIKeyResponse describes an API key. Key is the key itself; it is only
+// returned once, when the key is created.
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func NewAPIKeyResponse(key models.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
 	}
 }
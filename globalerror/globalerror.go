@@ -24,6 +24,7 @@ var ValidationErrorDescriptionMap = map[string]string{
 	"min":       "Your value should be greater than ",
 	"required":  "Your value is mandatory",
 	"acceptAge": "Your value should be greater than 18",
+	"oneof":     "Your value should be one of ",
 }
 
 type CustomValidationError struct {
@@ -53,6 +54,11 @@ func Validate(data interface{}) []CustomValidationError {
 }
 
 func HandleValidationErrors(c *fiber.Ctx, errors []CustomValidationError) error {
+	return c.Status(http.StatusBadRequest).JSON(ValidationErrorResponse(errors))
+}
+
+// ValidationErrorResponse is the body HandleValidationErrors sends.
+func ValidationErrorResponse(errors []CustomValidationError) ErrorResponse {
 	var errorResponse ErrorResponse
 	var errorDetailList []ErrorResponseDetail
 
@@ -65,5 +71,5 @@ func HandleValidationErrors(c *fiber.Ctx, errors []CustomValidationError) error
 	errorResponse.Status = http.StatusBadRequest
 	errorResponse.ErrorDetail = errorDetailList
 
-	return c.Status(http.StatusBadRequest).JSON(errorResponse)
+	return errorResponse
 }
@@ -0,0 +1,294 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON values.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned for a patch that is malformed or cannot be
+	// applied to the document, e.g. because a path does not exist.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed is returned when a "test" operation does not match.
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Merge applies the merge patch to doc. Members of patch replace the members
+// of doc, null removes them and objects are merged recursively.
+func Merge(doc, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+			continue
+		}
+		object[key] = merge(object[key], value)
+	}
+	return object
+}
+
+// Operation is one step of a JSON Patch.
+type Operation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From string `json:"from"`
+	// Value is nil when the operation has no value member.
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies the operations of patch to doc in order. Either all of them
+// are applied or, on the first error, none.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	var operations []Operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i, operation := range operations {
+		var err error
+		target, err = apply(target, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func apply(doc interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		value, err := operation.value()
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "add" {
+			return add(doc, path, value)
+		}
+		if operation.Op == "replace" {
+			return replace(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "copy" {
+			return add(doc, path, clone(value))
+		}
+		if strings.HasPrefix(operation.Path+"/", operation.From+"/") && operation.Path != operation.From {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, operation.Op)
+	}
+}
+
+func (o Operation) value() (interface{}, error) {
+	if o.Value == nil {
+		return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+	}
+	var value interface{}
+	if err := json.Unmarshal(o.Value, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return value, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: path %q does not start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for i, token := range tokens {
+		tokens[i] = unescape.Replace(token)
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func child(node interface{}, token string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		value, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPatch, token)
+		}
+		return value, nil
+	case []interface{}:
+		i, err := index(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		return n[i], nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not in an object or array", ErrInvalidPatch, token)
+	}
+}
+
+// index parses an array index that has to be between 0 and max.
+func index(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return i, nil
+}
+
+// edit calls fn with the container the last token of path points into and
+// stores what fn returns in place of it.
+func edit(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	next, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if next, err = edit(next, path[1:], fn); err != nil {
+		return nil, err
+	}
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		n[path[0]] = next
+	case []interface{}:
+		i, _ := index(path[0], len(n)-1)
+		n[i] = next
+	}
+	return doc, nil
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return edit(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+			return p, nil
+		case []interface{}:
+			i := len(p)
+			if token != "-" {
+				var err error
+				if i, err = index(token, len(p)); err != nil {
+					return nil, err
+				}
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or array", ErrInvalidPatch, token)
+		}
+	})
+}
+
+func replace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return edit(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		if _, err := child(parent, token); err != nil {
+			return nil, err
+		}
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[token] = value
+		case []interface{}:
+			i, _ := index(token, len(p)-1)
+			p[i] = value
+		}
+		return parent, nil
+	})
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	return edit(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		if _, err := child(parent, token); err != nil {
+			return nil, err
+		}
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			delete(p, token)
+		case []interface{}:
+			i, _ := index(token, len(p)-1)
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return parent, nil
+	})
+}
+
+func clone(value interface{}) interface{} {
+	data, _ := json.Marshal(value)
+	var copied interface{}
+	json.Unmarshal(data, &copied)
+	return copied
+}
@@ -0,0 +1,83 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	// RFC 7396 Appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		got, err := Merge([]byte(c.doc), []byte(c.patch))
+		if assert.NoError(t, err, c.patch) {
+			assert.JSONEq(t, c.want, string(got), c.patch)
+		}
+	}
+
+	_, err := Merge([]byte(`{}`), []byte(`{"a":`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	// RFC 6902 Appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{`{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`, `{"foo":{"bar":1},"baz":{"bar":2}}`},
+	}
+	for _, c := range cases {
+		got, err := Apply([]byte(c.doc), []byte(c.patch))
+		if assert.NoError(t, err, c.patch) {
+			assert.JSONEq(t, c.want, string(got), c.patch)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	cases := []struct{ doc, patch string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/01","value":"qux"}]`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`},
+		{`{"foo":"bar"}`, `[{"op":"fly","path":"/foo"}]`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"foo","value":1}]`},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`},
+		{`{"foo":"bar"}`, `{"op":"add","path":"/baz","value":"qux"}`},
+	}
+	for _, c := range cases {
+		_, err := Apply([]byte(c.doc), []byte(c.patch))
+		assert.ErrorIs(t, err, ErrInvalidPatch, c.patch)
+	}
+
+	_, err := Apply([]byte(`{"baz":"qux"}`), []byte(`[{"op":"test","path":"/baz","value":"bar"}]`))
+	assert.ErrorIs(t, err, ErrTestFailed)
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"time"
 
@@ -10,9 +18,13 @@ import (
 	"konzek-jun/configs"
 	"konzek-jun/loggerx"
 	"konzek-jun/middleware"
+	"konzek-jun/migrations"
 	"konzek-jun/prometheus"
+	"konzek-jun/rbac"
 	"konzek-jun/repository"
+	"konzek-jun/scheduler"
 	"konzek-jun/services"
+	"konzek-jun/worker"
 
 	_ "konzek-jun/docs"
 
@@ -28,46 +40,148 @@ import (
 // @description	This is an Task Api just for concurent Task
 // @termsOfService	http://swagger.io/terms/
 func main() {
-	prometheus.InitPrometheus()
 	loggerx.Init()
 
-	go func() {
-		if err := http.ListenAndServe(":2222", promhttp.Handler()); err != nil {
-			fmt.Println("Prometheus sunucusunu başlatırken hata oluştu:", err)
+	// Ayarlar sırasıyla varsayılanlar, ayar dosyası, ortam değişkenleri ve flag'lerden okunur
+	cfg, args, err := configs.Load(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "usage: konzek [flags] [migrate <command>]")
+		configs.Usage(os.Stderr)
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ayarlar okunurken hata oluştu:", err)
+		os.Exit(2)
+	}
+
+	// "migrate up|down|status|create" şemayı yönetir, sunucuyu başlatmaz
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := cfg.ValidateStorage(); err != nil {
+			fmt.Fprintf(os.Stderr, "Ayarlar geçersiz:\n%v\n", err)
+			os.Exit(2)
 		}
-	}()
+		open := func() (*sql.DB, error) { return configs.OpenDB(cfg) }
+		if err := migrations.Run(context.Background(), args[1:], cfg.Dialect(), open, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Bilinmeyen komut: %s\n", args[0])
+		os.Exit(2)
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Ayarlar geçersiz:\n%v\n", err)
+		os.Exit(2)
+	}
+
+	// İmzalama ve doğrulama anahtarları sunucu başlamadan okunur
+	jwtService, err := services.NewJWTService(cfg.JWT)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "JWT anahtarları okunamadı: %v\n", err)
+		os.Exit(2)
+	}
+
+	prometheus.InitPrometheus()
 
 	go func() {
-		for {
-			prometheus.HandleHTTPRequest()
-			time.Sleep(30 * time.Second) // 10 saniye bekleyin
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", cfg.Metrics.Port), promhttp.Handler()); err != nil {
+			fmt.Println("Prometheus sunucusunu başlatırken hata oluştu:", err)
 		}
 	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go scheduler.Every(ctx, 30*time.Second, prometheus.HandleHTTPRequest)
+
 	appRoute := fiber.New()
 	appRoute.Get("/swagger/*", swagger.HandlerDefault)
-	db := configs.ConnectDB()
+	store, err := openStorage(cfg)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	defer store.close()
+
+	// Yeni bir kurulumda rolleri yönetecek ilk admin
+	if cfg.Admin.Email != "" {
+		if err := services.SeedAdmin(ctx, store.users, cfg.Admin.Email, cfg.Admin.Password); err != nil {
+			log.Fatalf("Admin kullanıcısı oluşturulamadı: %v\n", err)
+		}
+	}
+
+	taskService := services.NewTaskService(store.tasks)
+	taskService.UnitOfWork = store.unitOfWork
+	td := app.NewTaskHandler(taskService, cfg.Workers.HTTP)
+	// Worker bulamayan istek kuyrukta bekler; kuyruk doluysa veya süre dolarsa 503 alır
+	td.WorkerPool = app.NewWorkerPool(cfg.Workers.HTTP, cfg.Workers.Queue, cfg.Workers.AcquireTimeout)
+
+	// Job'lar HTTP isteklerinden bağımsız olarak arka planda çalıştırılır
+	jobRegistry := worker.NewRegistry()
+	worker.RegisterBuiltins(jobRegistry)
+	td.Registry = jobRegistry
+
+	jobPool := worker.NewPool(store.jobs, jobRegistry, cfg.Workers.Jobs)
+	jobPoolDone := make(chan struct{})
+	go func() {
+		jobPool.Run(ctx)
+		close(jobPoolDone)
+	}()
+
+	jobHandler := app.NewJobHandler(services.NewJobService(store.jobs))
+
+	// Zamanı gelen schedule'lar task olarak oluşturulur, job'ları worker havuzu çalıştırır
+	schedulerDone := make(chan struct{})
+	go func() {
+		scheduler.New(store.schedules).Run(ctx)
+		close(schedulerDone)
+	}()
 
-	defer db.Close()
+	scheduleHandler := app.NewScheduleHandler(services.NewScheduleService(store.schedules))
+	scheduleHandler.Registry = jobRegistry
 
-	taskRepository := repository.NewTaskRepository(db)
+	// Süresi dolan Idempotency-Key yanıtları ve token iptalleri silinir
+	go scheduler.Every(ctx, time.Hour, func() {
+		if _, err := store.idempotency.DeleteExpired(ctx, time.Now()); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while deleting expired idempotency keys: %v", err))
+		}
+		if _, err := store.tokenRevocations.DeleteExpired(ctx, time.Now()); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while deleting expired token revocations: %v", err))
+		}
+	})
 
-	td := app.NewTaskHandler(services.NewTaskService(taskRepository), 5)
+	authService := services.NewAuthService(store.users)
 
-	authService := services.NewAuthService(repository.NewUserRepo(db))
+	// Çıkış yapılan token'lar bellekte tutulur, diğer sunucuların iptalleri düzenli olarak okunur
+	revocations := services.NewRevocationList(store.tokenRevocations)
+	go scheduler.Every(ctx, cfg.JWT.RevocationSync, func() { revocations.Sync(ctx) })
 
-	jwtService := services.NewJWTService()
+	// Access token'lar kısa ömürlüdür, refresh token'lar her kullanımda yenilenir
+	tokenService := services.NewTokenService(jwtService, store.refreshTokens, store.users, revocations, cfg.JWT.TTL, cfg.JWT.RefreshTTL)
 
-	userService := services.NewUserService(repository.NewUserRepo(db))
+	userService := services.NewUserService(store.users, store.unitOfWork)
 
-	authHandler := app.NewAuthHandler(authService, jwtService, userService)
+	authHandler := app.NewAuthHandler(authService, tokenService, userService)
+	userHandler := app.NewUserHandler(userService, tokenService)
+	keyHandler := app.NewKeyHandler(jwtService)
+
+	// Script'ler ve CI parola yerine kişisel API anahtarı kullanır
+	apiKeyService := services.NewAPIKeyService(store.apiKeys, store.users)
+	apiKeyHandler := app.NewAPIKeyHandler(apiKeyService)
 
 	appRoute.Use(recover.New())
 
-	jwtMiddleware := middleware.NewJWTMiddleware(services.NewJWTService())
+	// Kapanışta devam eden isteklerin sorguları da iptal edilir
+	appRoute.Use(middleware.BaseContext(ctx))
+
+	jwtMiddleware := middleware.NewJWTMiddleware(jwtService)
+	jwtMiddleware.Revocations = revocations
+	jwtMiddleware.APIKeys = apiKeyService
 
 	appRoute.Use(limiter.New(limiter.Config{
-		Max:        5, // Maximum 5 requests per second
-		Expiration: 1, // Expire limiter after 1 second
+		Max:        cfg.RateLimit.Max,    // Maximum requests per window
+		Expiration: cfg.RateLimit.Window, // Window length
 		KeyGenerator: func(ctx *fiber.Ctx) string {
 			return ctx.IP() // Generate unique key based on client IP address
 		},
@@ -80,14 +194,12 @@ func main() {
 
 	appRoute.Use(func(ctx *fiber.Ctx) error {
 		// Middleware'i atlamak istediğimiz endpointlerin adları
-		skipEndpoints := []string{"/api/register", "/api/login", "/metrics", "/swagger-ui/index.html"}
+		skipEndpoints := []string{"/api/register", "/api/login", "/api/token/refresh", "/.well-known/jwks.json", "/metrics", "/swagger-ui/index.html"}
 
 		// Endpoint adını kontrol et
 		for _, skipEndpoint := range skipEndpoints {
-			fmt.Println(ctx.Path())
 			if ctx.Path() == skipEndpoint {
 				// Middleware'i atla
-				fmt.Println("Atladı:", skipEndpoint)
 				return ctx.Next()
 			}
 		}
@@ -97,13 +209,175 @@ func main() {
 	})
 	appRoute.Use(prometheus.MeasureRequestDuration)
 
-	appRoute.Post("/api/tasks", td.CreateTask)
-	appRoute.Get("/api/tasks", td.GetAllTask)
-	appRoute.Get("/api/tasks/page", td.GetAllTaskWithPagination)
-	appRoute.Delete("/api/tasks/:id", td.DeleteTask)
-	appRoute.Get("/api/tasks/:id", td.GetByID)
-	appRoute.Put("/api/tasks", td.UpdateTask)
-	appRoute.Post("/api/register", authHandler.Register)
-	appRoute.Post("/api/login", authHandler.Login)
-	appRoute.Listen(":8080")
+	// Route bazında süre sınırları; süre dolunca veritabanı sorguları da iptal edilir
+	readTimeout := middleware.RequestTimeout(cfg.Server.ReadTimeout)
+	writeTimeout := middleware.RequestTimeout(cfg.Server.WriteTimeout)
+	adminTimeout := middleware.RequestTimeout(cfg.Server.AdminTimeout)
+
+	// Idempotency-Key ile tekrarlanan istekler ilk yanıtı alır, task iki kez oluşturulmaz
+	idempotency := middleware.Idempotency(middleware.IdempotencyConfig{
+		Store:       store.idempotency,
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Server.WriteTimeout,
+	})
+	// Admin istekleri daha uzun sürebilir, anahtar istek bitene kadar tutulur
+	adminIdempotency := middleware.Idempotency(middleware.IdempotencyConfig{
+		Store:       store.idempotency,
+		TTL:         cfg.Idempotency.TTL,
+		LockTimeout: cfg.Server.AdminTimeout,
+	})
+
+	// Her route kendi iznini ister, izinler rbac paketinde tanımlıdır
+	read := rbac.Require(rbac.TasksRead)
+	write := rbac.Require(rbac.TasksWrite)
+	remove := rbac.Require(rbac.TasksDelete)
+
+	appRoute.Post("/api/tasks", writeTimeout, write, idempotency, td.CreateTask)
+	appRoute.Post("/api/tasks/bulk", writeTimeout, write, idempotency, td.BulkTasks)
+	appRoute.Get("/api/tasks", readTimeout, read, td.GetAllTask)
+	appRoute.Get("/api/tasks/page", readTimeout, read, td.GetAllTaskWithPagination)
+	appRoute.Delete("/api/tasks/:id", writeTimeout, remove, td.DeleteTask)
+	appRoute.Get("/api/tasks/:id", readTimeout, read, td.GetByID)
+	appRoute.Put("/api/tasks", writeTimeout, write, td.UpdateTask)
+	appRoute.Patch("/api/tasks/:id", writeTimeout, write, td.PatchTask)
+	appRoute.Post("/api/tasks/:id/transition", writeTimeout, write, td.TransitionTask)
+	appRoute.Post("/api/tasks/:id/dependencies", writeTimeout, write, td.AddDependency)
+	appRoute.Delete("/api/tasks/:id/dependencies", writeTimeout, write, td.RemoveDependency)
+	appRoute.Get("/api/tasks/:id/graph", readTimeout, read, td.GetTaskGraph)
+
+	// Adminler aynı handler'larla başka bir kullanıcının task'larını yönetir
+	userTasks := appRoute.Group("/api/admin/users/:userId/tasks", rbac.Require(rbac.TasksManageAll), middleware.ActAsOwner("userId"))
+	userTasks.Post("", writeTimeout, idempotency, td.CreateTask)
+	userTasks.Post("/bulk", writeTimeout, idempotency, td.BulkTasks)
+	userTasks.Get("", readTimeout, td.GetAllTask)
+	userTasks.Get("/page", readTimeout, td.GetAllTaskWithPagination)
+	userTasks.Delete("/:id", writeTimeout, td.DeleteTask)
+	userTasks.Get("/:id", readTimeout, td.GetByID)
+	userTasks.Put("", writeTimeout, td.UpdateTask)
+	userTasks.Patch("/:id", writeTimeout, td.PatchTask)
+	userTasks.Post("/:id/transition", writeTimeout, td.TransitionTask)
+	userTasks.Post("/:id/dependencies", writeTimeout, td.AddDependency)
+	userTasks.Delete("/:id/dependencies", writeTimeout, td.RemoveDependency)
+	userTasks.Get("/:id/graph", readTimeout, td.GetTaskGraph)
+
+	appRoute.Get("/.well-known/jwks.json", readTimeout, keyHandler.JWKS)
+	appRoute.Post("/api/register", writeTimeout, authHandler.Register)
+	appRoute.Post("/api/login", writeTimeout, authHandler.Login)
+	appRoute.Post("/api/token/refresh", writeTimeout, authHandler.RefreshToken)
+	appRoute.Post("/api/logout", writeTimeout, authHandler.Logout)
+	appRoute.Post("/api/logout-all", writeTimeout, authHandler.LogoutAll)
+	appRoute.Post("/api/api-keys", writeTimeout, apiKeyHandler.CreateAPIKey)
+	appRoute.Get("/api/api-keys", readTimeout, apiKeyHandler.ListAPIKeys)
+	appRoute.Delete("/api/api-keys/:id", writeTimeout, apiKeyHandler.RevokeAPIKey)
+	appRoute.Delete("/api/admin/users/:id/sessions", adminTimeout, rbac.Require(rbac.UsersManage), authHandler.RevokeUserSessions)
+	appRoute.Get("/api/admin/users", adminTimeout, rbac.Require(rbac.UsersManage), userHandler.ListUsers)
+	appRoute.Put("/api/admin/users/:id/role", adminTimeout, rbac.Require(rbac.UsersManage), userHandler.SetUserRole)
+	appRoute.Get("/api/jobs/:id", readTimeout, rbac.Require(rbac.JobsRead), jobHandler.GetJob)
+	appRoute.Get("/api/admin/jobs/dead", adminTimeout, rbac.Require(rbac.JobsAdmin), jobHandler.ListDeadJobs)
+	appRoute.Delete("/api/admin/jobs/dead", adminTimeout, rbac.Require(rbac.JobsAdmin), jobHandler.PurgeDeadJobs)
+	appRoute.Get("/api/admin/jobs/dead/:id", adminTimeout, rbac.Require(rbac.JobsAdmin), jobHandler.GetDeadJob)
+	appRoute.Delete("/api/admin/jobs/dead/:id", adminTimeout, rbac.Require(rbac.JobsAdmin), jobHandler.PurgeDeadJob)
+	appRoute.Post("/api/admin/jobs/dead/:id/requeue", adminTimeout, rbac.Require(rbac.JobsAdmin), adminIdempotency, jobHandler.RequeueDeadJob)
+	appRoute.Post("/api/schedules", writeTimeout, rbac.Require(rbac.SchedulesWrite), scheduleHandler.CreateSchedule)
+	appRoute.Get("/api/schedules", readTimeout, rbac.Require(rbac.SchedulesRead), scheduleHandler.GetAllSchedules)
+	appRoute.Get("/api/schedules/:id", readTimeout, rbac.Require(rbac.SchedulesRead), scheduleHandler.GetSchedule)
+	appRoute.Delete("/api/schedules/:id", writeTimeout, rbac.Require(rbac.SchedulesWrite), scheduleHandler.DeleteSchedule)
+	appRoute.Post("/api/schedules/:id/pause", writeTimeout, rbac.Require(rbac.SchedulesWrite), scheduleHandler.PauseSchedule)
+	appRoute.Post("/api/schedules/:id/resume", writeTimeout, rbac.Require(rbac.SchedulesWrite), scheduleHandler.ResumeSchedule)
+
+	go func() {
+		<-ctx.Done()
+		appRoute.Shutdown()
+	}()
+
+	appRoute.Listen(fmt.Sprintf(":%d", cfg.Server.Port))
+
+	// Çalışan job'ların sonuçlarının kaydedilmesini bekle
+	stop()
+	<-jobPoolDone
+	<-schedulerDone
+}
+
+// storage uygulamanın kullandığı repository'lerdir
+type storage struct {
+	tasks     repository.TaskRepository
+	users     repository.UserRepository
+	jobs      repository.JobQueue
+	schedules repository.ScheduleRepository
+	// idempotency Idempotency-Key ile gelen isteklerin yanıtlarını tutar
+	idempotency repository.IdempotencyRepository
+	// refreshTokens verilen refresh token'ların hash'lerini tutar
+	refreshTokens repository.RefreshTokenRepository
+	// tokenRevocations çıkış yapılan token'ları ve oturumları süreleri dolana kadar tutar
+	tokenRevocations repository.TokenRevocationRepository
+	// apiKeys kullanıcıların kişisel API anahtarlarını hash'lenmiş olarak tutar
+	apiKeys repository.APIKeyRepository
+	// unitOfWork task ve kullanıcı repository çağrılarını tek transaction'da çalıştırır
+	unitOfWork repository.UnitOfWork
+	close      func()
+}
+
+// openStorage cfg.Storage'a göre Postgres'e veya SQLite dosyasına bağlanır
+// ya da bellekteki repository'leri oluşturur
+func openStorage(cfg configs.Config) (storage, error) {
+	if cfg.Storage == configs.StorageMemory {
+		// Veriler süreç kapanınca kaybolur
+		loggerx.Info("Using in-memory storage, data is lost on shutdown")
+		jobs := repository.NewMemoryJobQueue()
+		tasks := repository.NewMemoryTaskRepository()
+		tasks.Jobs = jobs
+		users := repository.NewMemoryUserRepository()
+		schedules := repository.NewMemoryScheduleRepository()
+		schedules.TaskRepository = tasks
+		return storage{
+			tasks:            tasks,
+			users:            users,
+			jobs:             jobs,
+			schedules:        schedules,
+			idempotency:      repository.NewMemoryIdempotencyRepository(),
+			refreshTokens:    repository.NewMemoryRefreshTokenRepository(),
+			tokenRevocations: repository.NewMemoryTokenRevocationRepository(),
+			apiKeys:          repository.NewMemoryAPIKeyRepository(),
+			unitOfWork:       repository.NewMemoryUnitOfWork(tasks, users),
+			close:            func() {},
+		}, nil
+	}
+
+	db, err := configs.ConnectDB(cfg)
+	if err != nil {
+		return storage{}, err
+	}
+
+	if cfg.Storage == configs.StorageSQLite {
+		loggerx.Info(fmt.Sprintf("Using SQLite storage at %s", cfg.SQLite.Path))
+		return storage{
+			tasks:            repository.NewSQLiteTaskRepository(db),
+			users:            repository.NewSQLiteUserRepository(db),
+			jobs:             repository.NewSQLiteJobQueue(db),
+			schedules:        repository.NewSQLiteScheduleRepository(db),
+			idempotency:      repository.NewSQLiteIdempotencyRepository(db),
+			refreshTokens:    repository.NewSQLiteRefreshTokenRepository(db),
+			tokenRevocations: repository.NewSQLiteTokenRevocationRepository(db),
+			apiKeys:          repository.NewSQLiteAPIKeyRepository(db),
+			unitOfWork:       repository.NewSQLiteTxManager(db),
+			close:            func() { db.Close() },
+		}, nil
+	}
+
+	tasks := repository.NewTaskRepository(db)
+	tasks.Retry.MaxAttempts = cfg.Database.RetryAttempts
+	txManager := repository.NewTxManager(db)
+	txManager.Retry.MaxAttempts = cfg.Database.RetryAttempts
+	return storage{
+		tasks:            tasks,
+		users:            repository.NewUserRepo(db),
+		jobs:             repository.NewJobQueue(db),
+		schedules:        repository.NewScheduleRepository(db),
+		idempotency:      repository.NewIdempotencyRepository(db),
+		refreshTokens:    repository.NewRefreshTokenRepository(db),
+		tokenRevocations: repository.NewTokenRevocationRepository(db),
+		apiKeys:          repository.NewAPIKeyRepository(db),
+		unitOfWork:       txManager,
+		close:            func() { db.Close() },
+	}, nil
 }
@@ -1,18 +1,53 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
 	"konzek-jun/services"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gofiber/fiber/v2"
 )
 
+// UserIDKey is the fiber.Ctx local under which the authenticated user's id is stored.
+const UserIDKey = "user_id"
+
+// SessionKey is the fiber.Ctx local under which the services.Session of the
+// request's token is stored.
+const SessionKey = "session"
+
+// RoleKey is the fiber.Ctx local under which the role of the authenticated
+// user is stored.
+const RoleKey = "role"
+
+// ScopesKey is the fiber.Ctx local under which the scopes of the API key a
+// request was authenticated with are stored.
+const ScopesKey = "scopes"
+
+// APIKeyScheme starts Authorization headers that carry an API key instead of
+// an access token.
+const APIKeyScheme = "ApiKey "
+
+// OwnerIDKey is the fiber.Ctx local under which ActAsOwner stores the id of
+// the user whose resources a request works on.
+const OwnerIDKey = "owner_id"
+
 type JWTMiddleware struct {
 	jwtService services.JWTService
+	// Revocations rejects logged out tokens. Optional.
+	Revocations *services.RevocationList
+	// APIKeys authenticates requests with an "ApiKey <key>" Authorization
+	// header. Optional.
+	APIKeys services.APIKeyService
 }
 
 func NewJWTMiddleware(jwtService services.JWTService) *JWTMiddleware {
@@ -36,14 +71,68 @@ func (m *JWTMiddleware) AuthorizeJWT(c *fiber.Ctx) error {
 		})
 	}
 
+	if key, ok := strings.CutPrefix(authHeader, APIKeyScheme); ok && m.APIKeys != nil {
+		return m.authorizeAPIKey(c, strings.TrimSpace(key))
+	}
+
 	token := m.jwtService.ValidateToken(authHeader)
 	if token != nil && token.Valid {
 		claims := token.Claims.(jwt.MapClaims)
-		log.Println("Claim[user_id]: ", claims["user_id"])
 		log.Println("Claim[issuer] :", claims["issuer"])
-		return c.Next()
+
+		claimUserID, _ := claims["user_id"].(string)
+		tokenID, _ := claims["jti"].(string)
+		sessionID, _ := claims["sid"].(string)
+		expiresAt, _ := claims["exp"].(float64)
+		claimRole, _ := claims["role"].(string)
+		role := models.Role(claimRole)
+		userID, err := strconv.ParseInt(claimUserID, 10, 64)
+		// Old tokens without jti and sid cannot be revoked, so they are rejected
+		revoked := tokenID == "" || sessionID == "" || m.Revocations != nil && m.Revocations.IsRevoked(tokenID, sessionID)
+		// Tokens without a role have to be refreshed
+		if err == nil && !revoked && role.Valid() {
+			c.Locals(UserIDKey, userID)
+			c.Locals(RoleKey, role)
+			c.Locals(SessionKey, services.Session{
+				UserID:    userID,
+				TokenID:   tokenID,
+				SessionID: sessionID,
+				ExpiresAt: time.Unix(int64(expiresAt), 0),
+			})
+			return c.Next()
+		}
+	}
+
+	return invalidToken(c)
+}
+
+// authorizeAPIKey authenticates the request as the user of key, with the
+// role the user has now and the scopes of the key.
+func (m *JWTMiddleware) authorizeAPIKey(c *fiber.Ctx, key string) error {
+	apiKey, user, err := m.APIKeys.Authenticate(c.UserContext(), key)
+	if err != nil && !errors.Is(err, services.ErrInvalidAPIKey) {
+		loggerx.Error(fmt.Sprintf("Error while checking api key: %v", err))
+		return c.Status(http.StatusInternalServerError).JSON(globalerror.ErrorResponse{
+			Status: http.StatusInternalServerError,
+			ErrorDetail: []globalerror.ErrorResponseDetail{
+				{
+					FieldName:   "Authorization",
+					Description: "An error occurred while checking the API key",
+				},
+			},
+		})
 	}
+	if err != nil || !user.Role.Valid() {
+		return invalidToken(c)
+	}
+
+	c.Locals(UserIDKey, user.ID)
+	c.Locals(RoleKey, user.Role)
+	c.Locals(ScopesKey, apiKey.Scopes)
+	return c.Next()
+}
 
+func invalidToken(c *fiber.Ctx) error {
 	return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
 		Status: http.StatusBadRequest,
 		ErrorDetail: []globalerror.ErrorResponseDetail{
@@ -54,3 +143,60 @@ func (m *JWTMiddleware) AuthorizeJWT(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// UserID returns the id of the user authenticated by AuthorizeJWT.
+func UserID(c *fiber.Ctx) (int64, bool) {
+	userID, ok := c.Locals(UserIDKey).(int64)
+	return userID, ok && userID > 0
+}
+
+// CurrentSession returns the session of the token authenticated by
+// AuthorizeJWT.
+func CurrentSession(c *fiber.Ctx) (services.Session, bool) {
+	session, ok := c.Locals(SessionKey).(services.Session)
+	return session, ok && session.UserID > 0
+}
+
+// Role returns the role of the user authenticated by AuthorizeJWT.
+func Role(c *fiber.Ctx) (models.Role, bool) {
+	role, ok := c.Locals(RoleKey).(models.Role)
+	return role, ok && role.Valid()
+}
+
+// Scopes returns the scopes of the API key the request was authenticated
+// with. ok is false for requests authenticated with an access token.
+func Scopes(c *fiber.Ctx) ([]string, bool) {
+	scopes, ok := c.Locals(ScopesKey).([]string)
+	return scopes, ok
+}
+
+// OwnerID returns the id of the user whose resources the request works on.
+// That is the authenticated user, unless ActAsOwner chose another one.
+func OwnerID(c *fiber.Ctx) (int64, bool) {
+	if ownerID, ok := c.Locals(OwnerIDKey).(int64); ok {
+		return ownerID, ownerID > 0
+	}
+	return UserID(c)
+}
+
+// ActAsOwner makes the user id in the route parameter param the owner of the
+// request, so that the handlers behind it work on that user's resources. It
+// has to run after a check that the authenticated user may do so.
+func ActAsOwner(param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, err := strconv.ParseInt(c.Params(param), 10, 64)
+		if err != nil || ownerID <= 0 {
+			return c.Status(http.StatusBadRequest).JSON(globalerror.ErrorResponse{
+				Status: http.StatusBadRequest,
+				ErrorDetail: []globalerror.ErrorResponseDetail{
+					{
+						FieldName:   param,
+						Description: "Invalid user ID",
+					},
+				},
+			})
+		}
+		c.Locals(OwnerIDKey, ownerID)
+		return c.Next()
+	}
+}
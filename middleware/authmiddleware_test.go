@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"konzek-jun/configs"
+	"konzek-jun/dto"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/services"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func newTestJWTService(t *testing.T) services.JWTService {
+	jwtService, err := services.NewJWTService(configs.JWTConfig{Secret: testSecret, Issuer: "test", TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtService
+}
+
+func TestJWTMiddleware_Revocations(t *testing.T) {
+	jwtService := newTestJWTService(t)
+	jwtMiddleware := NewJWTMiddleware(jwtService)
+	jwtMiddleware.Revocations = services.NewRevocationList(repository.NewMemoryTokenRevocationRepository())
+
+	router := fiber.New()
+	router.Use(jwtMiddleware.AuthorizeJWT)
+	router.Get("/api/session", func(c *fiber.Ctx) error {
+		session, ok := CurrentSession(c)
+		if !ok {
+			return c.SendStatus(http.StatusUnauthorized)
+		}
+		return c.SendString(session.SessionID)
+	})
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/session", nil)
+		req.Header.Set("Authorization", token)
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	first := jwtService.GenerateToken("1", "laptop", models.RoleMember)
+	second := jwtService.GenerateToken("1", "laptop", models.RoleMember)
+	other := jwtService.GenerateToken("1", "phone", models.RoleMember)
+	assert.Equal(t, http.StatusOK, get(first))
+
+	// İptal edilen token reddedilir, aynı oturumun diğer token'ı geçerlidir
+	claims := jwtService.ValidateToken(first).Claims.(jwt.MapClaims)
+	err := jwtMiddleware.Revocations.Revoke(context.Background(), []models.TokenRevocation{{TokenID: claims["jti"].(string), UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, get(first))
+	assert.Equal(t, http.StatusOK, get(second))
+
+	// İptal edilen oturumun bütün token'ları reddedilir
+	err = jwtMiddleware.Revocations.Revoke(context.Background(), []models.TokenRevocation{{TokenID: "laptop", UserID: 1, ExpiresAt: time.Now().Add(time.Minute)}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, get(second))
+	assert.Equal(t, http.StatusOK, get(other))
+
+	// jti ve sid taşımayan token'lar kabul edilmez
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte(testSecret))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, get(legacy))
+}
+
+func TestJWTMiddleware_Role(t *testing.T) {
+	jwtService := newTestJWTService(t)
+	router := fiber.New()
+	router.Use(NewJWTMiddleware(jwtService).AuthorizeJWT)
+	router.Get("/api/role", func(c *fiber.Ctx) error {
+		role, _ := Role(c)
+		return c.SendString(string(role))
+	})
+	get := func(token string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/role", nil)
+		req.Header.Set("Authorization", token)
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get(jwtService.GenerateToken("1", "laptop", models.RoleViewer))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "viewer", body)
+
+	// Rolü olmayan ya da bilinmeyen rol taşıyan token'lar kabul edilmez
+	status, _ = get(jwtService.GenerateToken("1", "laptop", ""))
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get(jwtService.GenerateToken("1", "laptop", "root"))
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestActAsOwner(t *testing.T) {
+	router := fiber.New()
+	router.Use(func(c *fiber.Ctx) error {
+		c.Locals(UserIDKey, int64(1))
+		return c.Next()
+	})
+	owner := func(c *fiber.Ctx) error {
+		ownerID, _ := OwnerID(c)
+		return c.SendString(strconv.FormatInt(ownerID, 10))
+	}
+	router.Get("/api/tasks", owner)
+	router.Get("/api/admin/users/:userId/tasks", ActAsOwner("userId"), owner)
+	get := func(path string) (int, string) {
+		resp, err := router.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// Varsayılan sahip giriş yapan kullanıcıdır
+	status, body := get("/api/tasks")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "1", body)
+
+	status, body = get("/api/admin/users/2/tasks")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "2", body)
+
+	status, _ = get("/api/admin/users/abc/tasks")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestJWTMiddleware_APIKey(t *testing.T) {
+	users := repository.NewMemoryUserRepository()
+	user, err := users.InsertUser(context.Background(), models.User{Name: "CI", Email: "ci@example.com", Password: "testpass", Role: models.RoleViewer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeys := services.NewAPIKeyService(repository.NewMemoryAPIKeyRepository(), users)
+	created, err := apiKeys.CreateAPIKey(context.Background(), user.ID, dto.APIKeyRequest{Name: "CI", Scopes: []string{"tasks:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwtMiddleware := NewJWTMiddleware(newTestJWTService(t))
+	jwtMiddleware.APIKeys = apiKeys
+	router := fiber.New()
+	router.Use(jwtMiddleware.AuthorizeJWT)
+	router.Get("/api/whoami", func(c *fiber.Ctx) error {
+		userID, _ := UserID(c)
+		role, _ := Role(c)
+		scopes, _ := Scopes(c)
+		return c.JSON(fiber.Map{"user_id": userID, "role": role, "scopes": scopes})
+	})
+	get := func(authorization string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+		req.Header.Set("Authorization", authorization)
+		resp, err := router.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// Anahtar sahibinin rolü ve anahtarın kapsamı isteğe eklenir
+	status, body := get(APIKeyScheme + created.Key)
+	assert.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"user_id":1,"role":"viewer","scopes":["tasks:read"]}`, body)
+
+	status, _ = get(APIKeyScheme + "kzk_" + created.Prefix + "_wrong")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// İptal edilen anahtar hemen reddedilir
+	assert.NoError(t, apiKeys.RevokeAPIKey(context.Background(), user.ID, created.ID))
+	status, _ = get(APIKeyScheme + created.Key)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
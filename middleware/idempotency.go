@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyKeyHeader names the request header a client sets to make
+	// retries of a request safe.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses that were stored for an
+	// earlier request with the same key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers that are stored with the body.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation, fiber.HeaderETag}
+
+// IdempotencyConfig configures Idempotency.
+type IdempotencyConfig struct {
+	Store repository.IdempotencyRepository
+	// TTL is how long a response is replayed.
+	TTL time.Duration
+	// LockTimeout is how long a request holds its key before it has a
+	// response, so that the key is freed if the process dies.
+	LockTimeout time.Duration
+	// PollInterval is how often a duplicate request checks whether the
+	// first one is done.
+	PollInterval time.Duration
+}
+
+// Idempotency stores the first response to a request with an
+// Idempotency-Key header per user and key, and sends it again to requests
+// repeating the key with the same method, path and body. A request reusing
+// the key for another payload gets 422. Duplicates arriving while the first
+// request runs wait for its response until their own context is done.
+// Responses with a 5xx status are not stored, so that they can be retried.
+// It has to run after AuthorizeJWT.
+func Idempotency(cfg IdempotencyConfig) fiber.Handler {
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = time.Minute
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 50 * time.Millisecond
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		userID, ok := UserID(c)
+		if key == "" || !ok {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return idempotencyError(c, http.StatusBadRequest, fmt.Sprintf("%s cannot be longer than %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+		}
+
+		ctx := c.UserContext()
+		claim := models.IdempotencyRecord{UserID: userID, Key: key, Fingerprint: fingerprint(c)}
+		for {
+			claim.ExpiresAt = time.Now().Add(cfg.LockTimeout)
+			stored, claimed, err := cfg.Store.Claim(ctx, claim)
+			if err != nil {
+				loggerx.Error(fmt.Sprintf("Error while claiming idempotency key: %v", err))
+				return idempotencyError(c, http.StatusInternalServerError, "An error occurred while checking the idempotency key")
+			}
+			if claimed {
+				return respond(c, cfg, claim)
+			}
+			if stored.Fingerprint != claim.Fingerprint {
+				return idempotencyError(c, http.StatusUnprocessableEntity, fmt.Sprintf("%s was already used for another request", IdempotencyKeyHeader))
+			}
+			if stored.Status != 0 {
+				return replay(c, stored)
+			}
+
+			// Wait until the first request is done
+			select {
+			case <-ctx.Done():
+				return idempotencyError(c, http.StatusConflict, "A request with this idempotency key is still in progress")
+			case <-time.After(cfg.PollInterval):
+			}
+		}
+	}
+}
+
+// respond runs the request of a claimed key and stores its response.
+func respond(c *fiber.Ctx, cfg IdempotencyConfig, claim models.IdempotencyRecord) error {
+	// Release the key or store the response even when the request is cancelled
+	ctx := context.WithoutCancel(c.UserContext())
+
+	err := c.Next()
+	status := c.Response().StatusCode()
+	if err != nil || status >= http.StatusInternalServerError {
+		if releaseErr := cfg.Store.Release(ctx, claim); releaseErr != nil {
+			loggerx.Error(fmt.Sprintf("Error while releasing idempotency key: %v", releaseErr))
+		}
+		return err
+	}
+
+	response := models.IdempotencyRecord{
+		Status:    status,
+		Body:      append([]byte(nil), c.Response().Body()...),
+		Headers:   make(map[string]string),
+		ExpiresAt: time.Now().Add(cfg.TTL),
+	}
+	for _, name := range replayedHeaders {
+		if value := c.GetRespHeader(name); value != "" {
+			response.Headers[name] = value
+		}
+	}
+	if err := cfg.Store.Complete(ctx, claim, response); err != nil {
+		// The response is sent anyway, the key is freed when its lock expires
+		loggerx.Error(fmt.Sprintf("Error while storing idempotent response: %v", err))
+	}
+	return nil
+}
+
+func replay(c *fiber.Ctx, stored models.IdempotencyRecord) error {
+	loggerx.Info(fmt.Sprintf("Replaying response for idempotency key %q", stored.Key))
+	for name, value := range stored.Headers {
+		c.Set(name, value)
+	}
+	c.Set(IdempotentReplayedHeader, "true")
+	return c.Status(stored.Status).Send(stored.Body)
+}
+
+// fingerprint identifies the payload of a request: its method, path and body.
+func fingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.OriginalURL()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func idempotencyError(c *fiber.Ctx, status int, description string) error {
+	return c.Status(status).JSON(globalerror.ErrorResponse{
+		Status: int32(status),
+		ErrorDetail: []globalerror.ErrorResponseDetail{
+			{
+				FieldName:   IdempotencyKeyHeader,
+				Description: description,
+			},
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"konzek-jun/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func idempotentRouter(userID int64, handler fiber.Handler) *fiber.App {
+	router := fiber.New()
+	router.Use(func(c *fiber.Ctx) error {
+		c.Locals(UserIDKey, userID)
+		return c.Next()
+	})
+	router.Post("/api/tasks", Idempotency(IdempotencyConfig{
+		Store:        repository.NewMemoryIdempotencyRepository(),
+		TTL:          time.Hour,
+		PollInterval: 5 * time.Millisecond,
+	}), handler)
+	return router
+}
+
+func post(t *testing.T, router *fiber.App, key, body string) (*http.Response, string) {
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	resp, err := router.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responseBody, _ := io.ReadAll(resp.Body)
+	return resp, string(responseBody)
+}
+
+func TestIdempotency(t *testing.T) {
+	var inserts atomic.Int32
+	router := idempotentRouter(1, func(c *fiber.Ctx) error {
+		id := inserts.Add(1)
+		c.Set(fiber.HeaderLocation, "/api/tasks/"+strconv.Itoa(int(id)))
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"id": id})
+	})
+
+	resp, body := post(t, router, "key-1", `{"title":"First"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Empty(t, resp.Header.Get(IdempotentReplayedHeader))
+
+	// Aynı anahtar ve gövde kaydedilen yanıtı alır
+	resp, body = post(t, router, "key-1", `{"title":"First"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"id":1}`, body)
+	assert.Equal(t, "/api/tasks/1", resp.Header.Get(fiber.HeaderLocation))
+	assert.Equal(t, "application/json", resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, "true", resp.Header.Get(IdempotentReplayedHeader))
+
+	// Aynı anahtar başka bir gövdeyle kullanılamaz
+	resp, _ = post(t, router, "key-1", `{"title":"Other"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	// Anahtarsız istekler her seferinde çalışır
+	post(t, router, "", `{"title":"First"}`)
+	post(t, router, "", `{"title":"First"}`)
+	assert.Equal(t, int32(3), inserts.Load())
+
+	resp, _ = post(t, router, strings.Repeat("k", maxIdempotencyKeyLength+1), `{"title":"First"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, int32(3), inserts.Load())
+}
+
+func TestIdempotency_ConcurrentDuplicates(t *testing.T) {
+	var inserts atomic.Int32
+	router := idempotentRouter(1, func(c *fiber.Ctx) error {
+		inserts.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"title": "Concurrent"})
+	})
+
+	// Aynı anda gelen kopyalar ilk isteğin yanıtını bekler
+	var wg sync.WaitGroup
+	statuses := make([]int, 10)
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/tasks", strings.NewReader(`{"title":"Concurrent"}`))
+			req.Header.Set(IdempotencyKeyHeader, "concurrent")
+			if resp, err := router.Test(req, -1); err == nil {
+				statuses[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inserts.Load())
+	for _, status := range statuses {
+		assert.Equal(t, http.StatusCreated, status)
+	}
+}
+
+func TestIdempotency_ServerErrorIsNotStored(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotentRouter(1, func(c *fiber.Ctx) error {
+		if calls.Add(1) == 1 {
+			return c.Status(http.StatusServiceUnavailable).JSON(fiber.Map{"error": "busy"})
+		}
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"title": "Retried"})
+	})
+
+	// 5xx yanıtından sonra aynı anahtarla tekrar denenebilir
+	resp, _ := post(t, router, "retry", `{"title":"Retried"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp, _ = post(t, router, "retry", `{"title":"Retried"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp, _ = post(t, router, "retry", `{"title":"Retried"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BaseContext makes the user context of every request a child of base, so
+// in-flight requests and their queries are cancelled when base is done,
+// e.g. when the server shuts down.
+func BaseContext(base context.Context) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(base)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// RequestTimeout limits how long a route may take. Handlers pass
+// c.UserContext() to the services, so the deadline also cancels the
+// database calls of the request.
+func RequestTimeout(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
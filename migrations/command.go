@@ -0,0 +1,152 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultDir is where migrate create writes new files, relative to the
+// repository root, in the subdirectory of the dialect. The binary only knows
+// the files that were embedded when it was built.
+const DefaultDir = "migrations/sql"
+
+const usage = `usage: migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [n]        revert the last n applied migrations (default 1)
+  status          list migrations and when they were applied
+  create <name>   add empty up and down files to -dir (default ` + DefaultDir + `/<dialect>)`
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Run executes the migrate command line against a database of dialect. open
+// is only called by the commands that need the database.
+func Run(ctx context.Context, args []string, dialect Dialect, open func() (*sql.DB, error), out io.Writer) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	dir := flags.String("dir", filepath.Join(DefaultDir, string(dialect)), "directory for migrate create")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New("usage: migrate create <name>")
+		}
+		files, err := Create(*dir, args[1])
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Fprintf(out, "created %s\n", file)
+		}
+		return nil
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) != 1 {
+			return errors.New(usage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(usage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+	default:
+		return errors.New(usage)
+	}
+
+	db, err := open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := New(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	default:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(out, "%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	}
+}
+
+// Create writes empty up and down files for a new migration to dir, with
+// the version after the highest one there, and returns their paths.
+func Create(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("migration name %q may only contain a-z, 0-9 and _", name)
+	}
+	existing, err := Load(os.DirFS(dir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %04d_%s %s\n", version, name, direction)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
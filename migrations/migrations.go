@@ -0,0 +1,322 @@
+// Package migrations keeps the database schema in versioned SQL files that
+// are embedded in the binary. Every migration has an up and a down file
+// named <version>_<name>.up.sql and <version>_<name>.down.sql in the
+// directory of its dialect; applied versions are recorded in the
+// schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"io/fs"
+	"konzek-jun/loggerx"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed sql
+var embedded embed.FS
+
+// Dialect is the SQL flavour of a database. Every dialect has its own
+// migrations in sql/<dialect>.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// DefaultLockKey is the advisory lock that serializes migrations between
+// replicas starting at the same time.
+const DefaultLockKey int64 = 727_001
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied; AppliedAt is nil for a
+// pending migration.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies Migrations to DB.
+type Migrator struct {
+	DB         *sql.DB
+	Dialect    Dialect
+	Migrations []Migration
+	// LockKey is the Postgres advisory lock; SQLite is only used by a single
+	// node and is not locked.
+	LockKey int64
+}
+
+// New returns a Migrator for the migrations of dialect embedded in the binary.
+func New(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	if dialect != Postgres && dialect != SQLite {
+		return nil, fmt.Errorf("unknown migration dialect %q", dialect)
+	}
+	files, err := fs.Sub(embedded, path.Join("sql", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+	all, err := Load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Dialect: dialect, Migrations: all, LockKey: DefaultLockKey}, nil
+}
+
+// Load reads the migrations in the root of fsys, ordered by version. Every
+// version needs both an up and a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	halves := make(map[int64]int)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		halves[version]++
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if halves[migration.Version] != 2 {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.Migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			err := run(ctx, conn, migration.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			loggerx.Info(fmt.Sprintf("Applied migration %04d_%s", migration.Version, migration.Name))
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// the reverted ones.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.Migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.Migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			err := run(ctx, conn, migration.Down, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			loggerx.Info(fmt.Sprintf("Reverted migration %04d_%s", migration.Version, migration.Name))
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration with the time it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := Status{Migration: migration}
+		if appliedAt, ok := done[migration.Version]; ok {
+			appliedAt := appliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// locked runs fn on one connection while holding the migration advisory
+// lock. Other replicas wait in pg_advisory_lock and then find nothing left
+// to apply.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.Dialect == SQLite {
+		if err := m.ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.LockKey); err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.LockKey); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while releasing migration lock: %v", err))
+			// The lock lives as long as the session; drop the connection
+			// instead of returning it to the pool with the lock held
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	appliedAt := "TIMESTAMPTZ NOT NULL DEFAULT now()"
+	if m.Dialect == SQLite {
+		appliedAt = "TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP"
+	}
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(200) NOT NULL,
+			applied_at `+appliedAt+`
+		)`)
+	return err
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt timestamp
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = appliedAt.Time
+	}
+	return done, rows.Err()
+}
+
+// timestamp scans a time that SQLite drivers may return as text.
+type timestamp struct {
+	time.Time
+}
+
+func (t *timestamp) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case time.Time:
+		t.Time = value
+		return nil
+	case string:
+		return t.parse(value)
+	case []byte:
+		return t.parse(string(value))
+	}
+	return fmt.Errorf("cannot scan %T into a timestamp", src)
+}
+
+func (t *timestamp) parse(value string) error {
+	parsed, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		parsed, err = time.Parse(time.RFC3339Nano, value)
+	}
+	t.Time = parsed
+	return err
+}
+
+// run executes a migration script and records it in one transaction, so a
+// failing script leaves neither the schema change nor the record behind.
+func run(ctx context.Context, conn *sql.Conn, script string, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,97 @@
+package migrations_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"konzek-jun/migrations"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range []migrations.Dialect{migrations.Postgres, migrations.SQLite} {
+		migrator, err := migrations.New(nil, dialect)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Sürümler 1'den başlayıp boşluksuz artar
+		for i, migration := range migrator.Migrations {
+			assert.Equal(t, int64(i+1), migration.Version)
+			assert.NotEmpty(t, migration.Up)
+			assert.NotEmpty(t, migration.Down)
+		}
+		assert.Equal(t, "create_users", migrator.Migrations[0].Name)
+	}
+
+	_, err := migrations.New(nil, "oracle")
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("OrdersByVersion", func(t *testing.T) {
+		loaded, err := migrations.Load(fstest.MapFS{
+			"0010_b.up.sql":   {Data: []byte("SELECT 10")},
+			"0010_b.down.sql": {Data: []byte("")},
+			"0002_a.up.sql":   {Data: []byte("SELECT 2")},
+			"0002_a.down.sql": {Data: []byte("SELECT -2")},
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, loaded, 2) {
+			assert.Equal(t, migrations.Migration{Version: 2, Name: "a", Up: "SELECT 2", Down: "SELECT -2"}, loaded[0])
+			assert.Equal(t, int64(10), loaded[1].Version)
+		}
+	})
+
+	t.Run("RequiresDownFile", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{"0001_a.up.sql": {Data: []byte("SELECT 1")}})
+		assert.ErrorContains(t, err, "0001_a")
+	})
+
+	t.Run("RejectsUnknownFiles", func(t *testing.T) {
+		_, err := migrations.Load(fstest.MapFS{"notes.txt": {Data: []byte("")}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sql")
+
+	files, err := migrations.Create(dir, "add_labels")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "0001_add_labels.up.sql"), filepath.Join(dir, "0001_add_labels.down.sql")}, files)
+
+	// Bir sonraki migration en yüksek sürümün devamıdır
+	files, err = migrations.Create(dir, "Add_Comments")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0002_add_comments.up.sql"), files[0])
+
+	loaded, err := migrations.Load(os.DirFS(dir))
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+
+	_, err = migrations.Create(dir, "drop table; --")
+	assert.Error(t, err)
+}
+
+func TestRun_CreateDoesNotOpenDatabase(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	open := func() (*sql.DB, error) {
+		t.Fatal("create must not connect to the database")
+		return nil, nil
+	}
+
+	err := migrations.Run(context.Background(), []string{"-dir", dir, "create", "add_labels"}, migrations.Postgres, open, &out)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "0001_add_labels.up.sql")
+
+	assert.Error(t, migrations.Run(context.Background(), []string{"sideways"}, migrations.Postgres, open, &out))
+	assert.Error(t, migrations.Run(context.Background(), []string{"down", "zero"}, migrations.Postgres, open, &out))
+}
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS tasks (
+	id SERIAL PRIMARY KEY,
+	owner_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	content TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'todo'
+);
+
+-- Installations created before tasks had an owner
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_id ON tasks (owner_id);
+
+-- status used to be a BOOLEAN: true becomes done, false becomes todo
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'tasks' AND column_name = 'status' AND data_type = 'boolean') THEN
+		ALTER TABLE tasks ALTER COLUMN status TYPE VARCHAR(20)
+			USING (CASE WHEN status THEN 'done' ELSE 'todo' END);
+	END IF;
+END $$;
+UPDATE tasks SET status = 'todo' WHERE status IS NULL;
+ALTER TABLE tasks ALTER COLUMN status SET DEFAULT 'todo';
+ALTER TABLE tasks ALTER COLUMN status SET NOT NULL;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+	CHECK (status IN ('todo', 'in_progress', 'blocked', 'done', 'cancelled'));
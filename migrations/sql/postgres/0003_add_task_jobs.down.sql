@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_tasks_running_leases;
+DROP INDEX IF EXISTS idx_tasks_pending_jobs;
+ALTER TABLE tasks
+	DROP COLUMN IF EXISTS lease_expires_at,
+	DROP COLUMN IF EXISTS lease_owner,
+	DROP COLUMN IF EXISTS finished_at,
+	DROP COLUMN IF EXISTS started_at,
+	DROP COLUMN IF EXISTS last_duration_ms,
+	DROP COLUMN IF EXISTS last_error,
+	DROP COLUMN IF EXISTS result,
+	DROP COLUMN IF EXISTS attempts,
+	DROP COLUMN IF EXISTS job_status,
+	DROP COLUMN IF EXISTS payload,
+	DROP COLUMN IF EXISTS job_type;
@@ -0,0 +1,14 @@
+-- Executable jobs are stored on the task row
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS job_type VARCHAR(100);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS payload JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS job_status VARCHAR(20);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS result JSONB;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS last_error TEXT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS last_duration_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS started_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS finished_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_tasks_pending_jobs ON tasks (id) WHERE job_status = 'pending';
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS lease_owner VARCHAR(200);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_tasks_running_leases ON tasks (lease_expires_at) WHERE job_status = 'running';
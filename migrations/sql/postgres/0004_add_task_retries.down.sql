@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_dead_jobs;
+UPDATE tasks SET job_status = 'failed' WHERE job_status = 'dead';
+ALTER TABLE tasks
+	DROP COLUMN IF EXISTS run_after,
+	DROP COLUMN IF EXISTS max_attempts;
@@ -0,0 +1,5 @@
+-- Retries and the dead-letter state; jobs that used to be 'failed' are dead
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS max_attempts INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS run_after TIMESTAMPTZ;
+UPDATE tasks SET job_status = 'dead' WHERE job_status = 'failed';
+CREATE INDEX IF NOT EXISTS idx_tasks_dead_jobs ON tasks (owner_id) WHERE job_status = 'dead';
@@ -0,0 +1,20 @@
+-- Scheduled and recurring tasks; next_run_at is the next time a schedule fires
+CREATE TABLE IF NOT EXISTS schedules (
+	id SERIAL PRIMARY KEY,
+	owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(200) NOT NULL,
+	cron_expr VARCHAR(100),
+	interval_ms BIGINT,
+	run_at TIMESTAMPTZ,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	job_type VARCHAR(100),
+	payload JSONB,
+	max_attempts INTEGER NOT NULL DEFAULT 3,
+	paused BOOLEAN NOT NULL DEFAULT false,
+	next_run_at TIMESTAMPTZ,
+	last_run_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_schedules_owner_id ON schedules (owner_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules (next_run_at) WHERE NOT paused;
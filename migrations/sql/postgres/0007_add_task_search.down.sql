@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_tasks_owner_updated_at;
+DROP INDEX IF EXISTS idx_tasks_owner_created_at;
+DROP INDEX IF EXISTS idx_tasks_search_vector;
+ALTER TABLE tasks
+	DROP COLUMN IF EXISTS search_vector,
+	DROP COLUMN IF EXISTS updated_at,
+	DROP COLUMN IF EXISTS created_at;
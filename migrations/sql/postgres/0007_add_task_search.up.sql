@@ -0,0 +1,8 @@
+-- Date columns for the list filters and full-text search over title and content
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(content, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_tasks_search_vector ON tasks USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_created_at ON tasks (owner_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_updated_at ON tasks (owner_id, updated_at);
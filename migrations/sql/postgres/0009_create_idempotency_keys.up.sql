@@ -0,0 +1,13 @@
+-- Responses to requests with an Idempotency-Key; status is 0 while the first
+-- request is still running
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	key VARCHAR(255) NOT NULL,
+	fingerprint VARCHAR(64) NOT NULL,
+	status INTEGER NOT NULL DEFAULT 0,
+	headers JSONB,
+	body BYTEA,
+	expires_at TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (user_id, key)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,7 @@
+-- Revoked access tokens (jti) and sessions (sid), kept until the tokens expire
+CREATE TABLE IF NOT EXISTS token_revocations (
+	token_id VARCHAR(64) PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_token_revocations_expires_at ON token_revocations (expires_at);
@@ -0,0 +1,14 @@
+-- Personal API keys; the key is stored hashed and found by its prefix
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(100) NOT NULL,
+	prefix VARCHAR(32) UNIQUE NOT NULL,
+	secret_hash VARCHAR(64) NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	expires_at TIMESTAMPTZ,
+	last_used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,14 @@
+-- Times are stored as fixed-width UTC text so that they sort as text
+CREATE TABLE IF NOT EXISTS tasks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	content TEXT,
+	status TEXT NOT NULL DEFAULT 'todo'
+		CHECK (status IN ('todo', 'in_progress', 'blocked', 'done', 'cancelled')),
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_id ON tasks (owner_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_created_at ON tasks (owner_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_updated_at ON tasks (owner_id, updated_at);
@@ -0,0 +1,8 @@
+-- task_id cannot be done before depends_on_id is
+CREATE TABLE IF NOT EXISTS task_dependencies (
+	task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+	depends_on_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+	PRIMARY KEY (task_id, depends_on_id),
+	CHECK (task_id <> depends_on_id)
+);
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_depends_on_id ON task_dependencies (depends_on_id);
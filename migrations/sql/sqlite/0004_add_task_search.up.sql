@@ -0,0 +1,15 @@
+-- Full-text search over title and content, kept in sync by triggers. Like
+-- the simple configuration in Postgres, words are only lower-cased.
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(title, content, content='tasks', content_rowid='id', tokenize='unicode61 remove_diacritics 0');
+INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+	INSERT INTO tasks_fts (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+	INSERT INTO tasks_fts (tasks_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+END;
+CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF title, content ON tasks BEGIN
+	INSERT INTO tasks_fts (tasks_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+	INSERT INTO tasks_fts (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
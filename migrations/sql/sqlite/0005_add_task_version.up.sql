@@ -0,0 +1,2 @@
+-- Incremented on every write of a task; updates and deletes can require a version
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- Every existing user becomes a member; the first admin is set with
+-- KONZEK_ADMIN_EMAIL or promoted with UPDATE users SET role = 'admin' WHERE email = '...'
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'member'
+    CHECK (role IN ('admin', 'member', 'viewer'));
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_tasks_dead_jobs;
+DROP INDEX IF EXISTS idx_tasks_running_leases;
+DROP INDEX IF EXISTS idx_tasks_pending_jobs;
+ALTER TABLE tasks DROP COLUMN lease_expires_at;
+ALTER TABLE tasks DROP COLUMN lease_owner;
+ALTER TABLE tasks DROP COLUMN run_after;
+ALTER TABLE tasks DROP COLUMN finished_at;
+ALTER TABLE tasks DROP COLUMN started_at;
+ALTER TABLE tasks DROP COLUMN last_duration_ms;
+ALTER TABLE tasks DROP COLUMN last_error;
+ALTER TABLE tasks DROP COLUMN result;
+ALTER TABLE tasks DROP COLUMN max_attempts;
+ALTER TABLE tasks DROP COLUMN attempts;
+ALTER TABLE tasks DROP COLUMN job_status;
+ALTER TABLE tasks DROP COLUMN payload;
+ALTER TABLE tasks DROP COLUMN job_type;
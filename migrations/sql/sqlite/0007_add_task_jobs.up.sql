@@ -0,0 +1,18 @@
+-- Executable jobs are stored on the task row, like in Postgres. Times are
+-- fixed-width UTC text and payload and result JSON text.
+ALTER TABLE tasks ADD COLUMN job_type TEXT;
+ALTER TABLE tasks ADD COLUMN payload TEXT;
+ALTER TABLE tasks ADD COLUMN job_status TEXT;
+ALTER TABLE tasks ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN max_attempts INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE tasks ADD COLUMN result TEXT;
+ALTER TABLE tasks ADD COLUMN last_error TEXT;
+ALTER TABLE tasks ADD COLUMN last_duration_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tasks ADD COLUMN started_at TEXT;
+ALTER TABLE tasks ADD COLUMN finished_at TEXT;
+ALTER TABLE tasks ADD COLUMN run_after TEXT;
+ALTER TABLE tasks ADD COLUMN lease_owner TEXT;
+ALTER TABLE tasks ADD COLUMN lease_expires_at TEXT;
+CREATE INDEX IF NOT EXISTS idx_tasks_pending_jobs ON tasks (id) WHERE job_status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_tasks_running_leases ON tasks (lease_expires_at) WHERE job_status = 'running';
+CREATE INDEX IF NOT EXISTS idx_tasks_dead_jobs ON tasks (owner_id) WHERE job_status = 'dead';
@@ -0,0 +1,20 @@
+-- Scheduled and recurring tasks; next_run_at is the next time a schedule fires
+CREATE TABLE IF NOT EXISTS schedules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	cron_expr TEXT,
+	interval_ms INTEGER,
+	run_at TEXT,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	job_type TEXT,
+	payload TEXT,
+	max_attempts INTEGER NOT NULL DEFAULT 3,
+	paused INTEGER NOT NULL DEFAULT 0,
+	next_run_at TEXT,
+	last_run_at TEXT,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_schedules_owner_id ON schedules (owner_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules (next_run_at) WHERE NOT paused;
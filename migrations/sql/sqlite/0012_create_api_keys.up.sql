@@ -0,0 +1,15 @@
+-- Personal API keys; the key is stored hashed and found by its prefix.
+-- scopes is a JSON array
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	prefix TEXT UNIQUE NOT NULL,
+	secret_hash TEXT NOT NULL,
+	scopes TEXT NOT NULL DEFAULT '[]',
+	expires_at TEXT,
+	last_used_at TEXT,
+	created_at TEXT NOT NULL,
+	revoked_at TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,226 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: konzek-jun/repository (interfaces: JobQueue)
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	jsontext "encoding/json/jsontext"
+	models "konzek-jun/models"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockJobQueue is a mock of JobQueue interface.
+type MockJobQueue struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobQueueMockRecorder
+}
+
+// MockJobQueueMockRecorder is the mock recorder for MockJobQueue.
+type MockJobQueueMockRecorder struct {
+	mock *MockJobQueue
+}
+
+// NewMockJobQueue creates a new mock instance.
+func NewMockJobQueue(ctrl *gomock.Controller) *MockJobQueue {
+	mock := &MockJobQueue{ctrl: ctrl}
+	mock.recorder = &MockJobQueueMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobQueue) EXPECT() *MockJobQueueMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockJobQueue) Complete(arg0 context.Context, arg1 int, arg2 string, arg3 jsontext.Value, arg4 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockJobQueueMockRecorder) Complete(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockJobQueue)(nil).Complete), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeadLetter mocks base method.
+func (m *MockJobQueue) DeadLetter(arg0 context.Context, arg1 int, arg2, arg3 string, arg4 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadLetter", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeadLetter indicates an expected call of DeadLetter.
+func (mr *MockJobQueueMockRecorder) DeadLetter(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadLetter", reflect.TypeOf((*MockJobQueue)(nil).DeadLetter), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetDead mocks base method.
+func (m *MockJobQueue) GetDead(arg0 context.Context, arg1 int) (models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDead", arg0, arg1)
+	ret0, _ := ret[0].(models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDead indicates an expected call of GetDead.
+func (mr *MockJobQueueMockRecorder) GetDead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDead", reflect.TypeOf((*MockJobQueue)(nil).GetDead), arg0, arg1)
+}
+
+// GetJob mocks base method.
+func (m *MockJobQueue) GetJob(arg0 context.Context, arg1 int64, arg2 int) (models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockJobQueueMockRecorder) GetJob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockJobQueue)(nil).GetJob), arg0, arg1, arg2)
+}
+
+// Heartbeat mocks base method.
+func (m *MockJobQueue) Heartbeat(arg0 context.Context, arg1 int, arg2 string, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Heartbeat", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Heartbeat indicates an expected call of Heartbeat.
+func (mr *MockJobQueueMockRecorder) Heartbeat(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Heartbeat", reflect.TypeOf((*MockJobQueue)(nil).Heartbeat), arg0, arg1, arg2, arg3)
+}
+
+// Lease mocks base method.
+func (m *MockJobQueue) Lease(arg0 context.Context, arg1 string, arg2 time.Duration) (models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lease", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lease indicates an expected call of Lease.
+func (mr *MockJobQueueMockRecorder) Lease(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lease", reflect.TypeOf((*MockJobQueue)(nil).Lease), arg0, arg1, arg2)
+}
+
+// ListDead mocks base method.
+func (m *MockJobQueue) ListDead(arg0 context.Context) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDead", arg0)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDead indicates an expected call of ListDead.
+func (mr *MockJobQueueMockRecorder) ListDead(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDead", reflect.TypeOf((*MockJobQueue)(nil).ListDead), arg0)
+}
+
+// PurgeAllDead mocks base method.
+func (m *MockJobQueue) PurgeAllDead(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeAllDead", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeAllDead indicates an expected call of PurgeAllDead.
+func (mr *MockJobQueueMockRecorder) PurgeAllDead(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeAllDead", reflect.TypeOf((*MockJobQueue)(nil).PurgeAllDead), arg0)
+}
+
+// PurgeDead mocks base method.
+func (m *MockJobQueue) PurgeDead(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDead", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeDead indicates an expected call of PurgeDead.
+func (mr *MockJobQueueMockRecorder) PurgeDead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDead", reflect.TypeOf((*MockJobQueue)(nil).PurgeDead), arg0, arg1)
+}
+
+// ReclaimExpired mocks base method.
+func (m *MockJobQueue) ReclaimExpired(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReclaimExpired", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReclaimExpired indicates an expected call of ReclaimExpired.
+func (mr *MockJobQueueMockRecorder) ReclaimExpired(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReclaimExpired", reflect.TypeOf((*MockJobQueue)(nil).ReclaimExpired), arg0)
+}
+
+// Release mocks base method.
+func (m *MockJobQueue) Release(arg0 context.Context, arg1 int, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockJobQueueMockRecorder) Release(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockJobQueue)(nil).Release), arg0, arg1, arg2)
+}
+
+// Requeue mocks base method.
+func (m *MockJobQueue) Requeue(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockJobQueueMockRecorder) Requeue(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockJobQueue)(nil).Requeue), arg0, arg1)
+}
+
+// Retry mocks base method.
+func (m *MockJobQueue) Retry(arg0 context.Context, arg1 int, arg2, arg3 string, arg4 time.Duration, arg5 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retry", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry.
+func (mr *MockJobQueueMockRecorder) Retry(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockJobQueue)(nil).Retry), arg0, arg1, arg2, arg3, arg4, arg5)
+}
@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: konzek-jun/repository (interfaces: ScheduleRepository)
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	models "konzek-jun/models"
+	repository "konzek-jun/repository"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockScheduleRepository is a mock of ScheduleRepository interface.
+type MockScheduleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleRepositoryMockRecorder
+}
+
+// MockScheduleRepositoryMockRecorder is the mock recorder for MockScheduleRepository.
+type MockScheduleRepositoryMockRecorder struct {
+	mock *MockScheduleRepository
+}
+
+// NewMockScheduleRepository creates a new mock instance.
+func NewMockScheduleRepository(ctrl *gomock.Controller) *MockScheduleRepository {
+	mock := &MockScheduleRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduleRepository) EXPECT() *MockScheduleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockScheduleRepository) Delete(arg0 context.Context, arg1 int64, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockScheduleRepositoryMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockScheduleRepository)(nil).Delete), arg0, arg1, arg2)
+}
+
+// FireDue mocks base method.
+func (m *MockScheduleRepository) FireDue(arg0 context.Context, arg1 time.Time, arg2 int, arg3 repository.NextRunFunc) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FireDue", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FireDue indicates an expected call of FireDue.
+func (mr *MockScheduleRepositoryMockRecorder) FireDue(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FireDue", reflect.TypeOf((*MockScheduleRepository)(nil).FireDue), arg0, arg1, arg2, arg3)
+}
+
+// GetAll mocks base method.
+func (m *MockScheduleRepository) GetAll(arg0 context.Context, arg1 int64) ([]models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
+	ret0, _ := ret[0].([]models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockScheduleRepositoryMockRecorder) GetAll(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockScheduleRepository)(nil).GetAll), arg0, arg1)
+}
+
+// GetByID mocks base method.
+func (m *MockScheduleRepository) GetByID(arg0 context.Context, arg1 int64, arg2 int) (models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockScheduleRepositoryMockRecorder) GetByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockScheduleRepository)(nil).GetByID), arg0, arg1, arg2)
+}
+
+// Insert mocks base method.
+func (m *MockScheduleRepository) Insert(arg0 context.Context, arg1 models.Schedule) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockScheduleRepositoryMockRecorder) Insert(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockScheduleRepository)(nil).Insert), arg0, arg1)
+}
+
+// SetPaused mocks base method.
+func (m *MockScheduleRepository) SetPaused(arg0 context.Context, arg1 int64, arg2 int, arg3 bool, arg4 *time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPaused", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPaused indicates an expected call of SetPaused.
+func (mr *MockScheduleRepositoryMockRecorder) SetPaused(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPaused", reflect.TypeOf((*MockScheduleRepository)(nil).SetPaused), arg0, arg1, arg2, arg3, arg4)
+}
@@ -5,6 +5,7 @@
 package repository
 
 import (
+	context "context"
 	models "konzek-jun/models"
 	reflect "reflect"
 
@@ -34,90 +35,221 @@ func (m *MockTaskRepository) EXPECT() *MockTaskRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddDependency mocks base method.
+func (m *MockTaskRepository) AddDependency(arg0 context.Context, arg1 int64, arg2, arg3 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDependency", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddDependency indicates an expected call of AddDependency.
+func (mr *MockTaskRepositoryMockRecorder) AddDependency(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDependency", reflect.TypeOf((*MockTaskRepository)(nil).AddDependency), arg0, arg1, arg2, arg3)
+}
+
+// CountTasks mocks base method.
+func (m *MockTaskRepository) CountTasks(arg0 context.Context, arg1 int64, arg2 models.TaskFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountTasks", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountTasks indicates an expected call of CountTasks.
+func (mr *MockTaskRepositoryMockRecorder) CountTasks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountTasks", reflect.TypeOf((*MockTaskRepository)(nil).CountTasks), arg0, arg1, arg2)
+}
+
 // Delete mocks base method.
-func (m *MockTaskRepository) Delete(arg0 int) error {
+func (m *MockTaskRepository) Delete(arg0 context.Context, arg1 int64, arg2, arg3 int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTaskRepositoryMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) Delete(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskRepository)(nil).Delete), arg0, arg1, arg2, arg3)
+}
+
+// FindTasks mocks base method.
+func (m *MockTaskRepository) FindTasks(arg0 context.Context, arg1 int64, arg2 models.TaskFilter) ([]models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTasks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTasks indicates an expected call of FindTasks.
+func (mr *MockTaskRepositoryMockRecorder) FindTasks(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTaskRepository)(nil).Delete), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTasks", reflect.TypeOf((*MockTaskRepository)(nil).FindTasks), arg0, arg1, arg2)
 }
 
 // GetAll mocks base method.
-func (m *MockTaskRepository) GetAll() ([]models.Task, error) {
+func (m *MockTaskRepository) GetAll(arg0 context.Context, arg1 int64) ([]models.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
+	ret := m.ctrl.Call(m, "GetAll", arg0, arg1)
 	ret0, _ := ret[0].([]models.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTaskRepositoryMockRecorder) GetAll() *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) GetAll(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTaskRepository)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTaskRepository)(nil).GetAll), arg0, arg1)
 }
 
 // GetByID mocks base method.
-func (m *MockTaskRepository) GetByID(arg0 int) (models.Task, error) {
+func (m *MockTaskRepository) GetByID(arg0 context.Context, arg1 int64, arg2 int) (models.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", arg0)
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1, arg2)
 	ret0, _ := ret[0].(models.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByID indicates an expected call of GetByID.
-func (mr *MockTaskRepositoryMockRecorder) GetByID(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) GetByID(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockTaskRepository)(nil).GetByID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockTaskRepository)(nil).GetByID), arg0, arg1, arg2)
 }
 
-// GetTasksWithPagination mocks base method.
-func (m *MockTaskRepository) GetTasksWithPagination(arg0, arg1 int) ([]models.Task, error) {
+// GetDependencies mocks base method.
+func (m *MockTaskRepository) GetDependencies(arg0 context.Context, arg1 int64) ([]models.TaskDependency, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTasksWithPagination", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetDependencies", arg0, arg1)
+	ret0, _ := ret[0].([]models.TaskDependency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDependencies indicates an expected call of GetDependencies.
+func (mr *MockTaskRepositoryMockRecorder) GetDependencies(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDependencies", reflect.TypeOf((*MockTaskRepository)(nil).GetDependencies), arg0, arg1)
+}
+
+// GetDependents mocks base method.
+func (m *MockTaskRepository) GetDependents(arg0 context.Context, arg1 int64, arg2 int) ([]models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDependents", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]models.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTasksWithPagination indicates an expected call of GetTasksWithPagination.
-func (mr *MockTaskRepositoryMockRecorder) GetTasksWithPagination(arg0, arg1 interface{}) *gomock.Call {
+// GetDependents indicates an expected call of GetDependents.
+func (mr *MockTaskRepositoryMockRecorder) GetDependents(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTasksWithPagination", reflect.TypeOf((*MockTaskRepository)(nil).GetTasksWithPagination), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDependents", reflect.TypeOf((*MockTaskRepository)(nil).GetDependents), arg0, arg1, arg2)
+}
+
+// GetPrerequisites mocks base method.
+func (m *MockTaskRepository) GetPrerequisites(arg0 context.Context, arg1 int64, arg2 int) ([]models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPrerequisites", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPrerequisites indicates an expected call of GetPrerequisites.
+func (mr *MockTaskRepositoryMockRecorder) GetPrerequisites(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrerequisites", reflect.TypeOf((*MockTaskRepository)(nil).GetPrerequisites), arg0, arg1, arg2)
 }
 
 // Insert mocks base method.
-func (m *MockTaskRepository) Insert(arg0 models.Task) (int64, error) {
+func (m *MockTaskRepository) Insert(arg0 context.Context, arg1 models.Task) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Insert", arg0)
+	ret := m.ctrl.Call(m, "Insert", arg0, arg1)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Insert indicates an expected call of Insert.
-func (mr *MockTaskRepositoryMockRecorder) Insert(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) Insert(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockTaskRepository)(nil).Insert), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockTaskRepository)(nil).Insert), arg0, arg1)
+}
+
+// InsertMany mocks base method.
+func (m *MockTaskRepository) InsertMany(arg0 context.Context, arg1 []models.Task) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertMany", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertMany indicates an expected call of InsertMany.
+func (mr *MockTaskRepositoryMockRecorder) InsertMany(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertMany", reflect.TypeOf((*MockTaskRepository)(nil).InsertMany), arg0, arg1)
+}
+
+// LockDependencies mocks base method.
+func (m *MockTaskRepository) LockDependencies(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockDependencies", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockDependencies indicates an expected call of LockDependencies.
+func (mr *MockTaskRepositoryMockRecorder) LockDependencies(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockDependencies", reflect.TypeOf((*MockTaskRepository)(nil).LockDependencies), arg0, arg1)
+}
+
+// RemoveDependency mocks base method.
+func (m *MockTaskRepository) RemoveDependency(arg0 context.Context, arg1 int64, arg2, arg3 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDependency", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDependency indicates an expected call of RemoveDependency.
+func (mr *MockTaskRepositoryMockRecorder) RemoveDependency(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDependency", reflect.TypeOf((*MockTaskRepository)(nil).RemoveDependency), arg0, arg1, arg2, arg3)
 }
 
 // Update mocks base method.
-func (m *MockTaskRepository) Update(arg0 models.Task) error {
+func (m *MockTaskRepository) Update(arg0 context.Context, arg1 models.Task) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", arg0)
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockTaskRepositoryMockRecorder) Update(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskRepositoryMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTaskRepository)(nil).Update), arg0, arg1)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockTaskRepository) UpdateStatus(arg0 context.Context, arg1 int64, arg2 int, arg3 models.TaskStatus, arg4 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockTaskRepositoryMockRecorder) UpdateStatus(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTaskRepository)(nil).Update), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockTaskRepository)(nil).UpdateStatus), arg0, arg1, arg2, arg3, arg4)
 }
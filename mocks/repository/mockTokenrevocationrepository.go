@@ -0,0 +1,81 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: konzek-jun/repository (interfaces: TokenRevocationRepository)
+
+// Package repository is a generated GoMock package.
+package repository
+
+import (
+	context "context"
+	models "konzek-jun/models"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTokenRevocationRepository is a mock of TokenRevocationRepository interface.
+type MockTokenRevocationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenRevocationRepositoryMockRecorder
+}
+
+// MockTokenRevocationRepositoryMockRecorder is the mock recorder for MockTokenRevocationRepository.
+type MockTokenRevocationRepositoryMockRecorder struct {
+	mock *MockTokenRevocationRepository
+}
+
+// NewMockTokenRevocationRepository creates a new mock instance.
+func NewMockTokenRevocationRepository(ctrl *gomock.Controller) *MockTokenRevocationRepository {
+	mock := &MockTokenRevocationRepository{ctrl: ctrl}
+	mock.recorder = &MockTokenRevocationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenRevocationRepository) EXPECT() *MockTokenRevocationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpired mocks base method.
+func (m *MockTokenRevocationRepository) DeleteExpired(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpired", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpired indicates an expected call of DeleteExpired.
+func (mr *MockTokenRevocationRepositoryMockRecorder) DeleteExpired(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpired", reflect.TypeOf((*MockTokenRevocationRepository)(nil).DeleteExpired), arg0, arg1)
+}
+
+// ListActive mocks base method.
+func (m *MockTokenRevocationRepository) ListActive(arg0 context.Context, arg1 time.Time) ([]models.TokenRevocation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActive", arg0, arg1)
+	ret0, _ := ret[0].([]models.TokenRevocation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActive indicates an expected call of ListActive.
+func (mr *MockTokenRevocationRepositoryMockRecorder) ListActive(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActive", reflect.TypeOf((*MockTokenRevocationRepository)(nil).ListActive), arg0, arg1)
+}
+
+// Revoke mocks base method.
+func (m *MockTokenRevocationRepository) Revoke(arg0 context.Context, arg1 []models.TokenRevocation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockTokenRevocationRepositoryMockRecorder) Revoke(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockTokenRevocationRepository)(nil).Revoke), arg0, arg1)
+}
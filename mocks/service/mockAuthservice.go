@@ -5,6 +5,7 @@
 package services
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,15 +35,15 @@ func (m *MockAuthService) EXPECT() *MockAuthServiceMockRecorder {
 }
 
 // VerifyCredential mocks base method.
-func (m *MockAuthService) VerifyCredential(arg0, arg1 string) error {
+func (m *MockAuthService) VerifyCredential(arg0 context.Context, arg1, arg2 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "VerifyCredential", arg0, arg1)
+	ret := m.ctrl.Call(m, "VerifyCredential", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // VerifyCredential indicates an expected call of VerifyCredential.
-func (mr *MockAuthServiceMockRecorder) VerifyCredential(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockAuthServiceMockRecorder) VerifyCredential(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCredential", reflect.TypeOf((*MockAuthService)(nil).VerifyCredential), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyCredential", reflect.TypeOf((*MockAuthService)(nil).VerifyCredential), arg0, arg1, arg2)
 }
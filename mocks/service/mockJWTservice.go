@@ -5,6 +5,8 @@
 package services
 
 import (
+	models "konzek-jun/models"
+	services "konzek-jun/services"
 	reflect "reflect"
 
 	jwt "github.com/dgrijalva/jwt-go"
@@ -35,17 +37,31 @@ func (m *MockJWTService) EXPECT() *MockJWTServiceMockRecorder {
 }
 
 // GenerateToken mocks base method.
-func (m *MockJWTService) GenerateToken(arg0 string) string {
+func (m *MockJWTService) GenerateToken(arg0, arg1 string, arg2 models.Role) string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", arg0)
+	ret := m.ctrl.Call(m, "GenerateToken", arg0, arg1, arg2)
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockJWTServiceMockRecorder) GenerateToken(arg0 interface{}) *gomock.Call {
+func (mr *MockJWTServiceMockRecorder) GenerateToken(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockJWTService)(nil).GenerateToken), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockJWTService)(nil).GenerateToken), arg0, arg1, arg2)
+}
+
+// JWKS mocks base method.
+func (m *MockJWTService) JWKS() services.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(services.JSONWebKeySet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockJWTServiceMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockJWTService)(nil).JWKS))
 }
 
 // ValidateToken mocks base method.
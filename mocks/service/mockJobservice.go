@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: konzek-jun/services (interfaces: JobService)
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	models "konzek-jun/models"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockJobService is a mock of JobService interface.
+type MockJobService struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobServiceMockRecorder
+}
+
+// MockJobServiceMockRecorder is the mock recorder for MockJobService.
+type MockJobServiceMockRecorder struct {
+	mock *MockJobService
+}
+
+// NewMockJobService creates a new mock instance.
+func NewMockJobService(ctrl *gomock.Controller) *MockJobService {
+	mock := &MockJobService{ctrl: ctrl}
+	mock.recorder = &MockJobServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobService) EXPECT() *MockJobServiceMockRecorder {
+	return m.recorder
+}
+
+// DeadJobGet mocks base method.
+func (m *MockJobService) DeadJobGet(arg0 context.Context, arg1 int) (models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadJobGet", arg0, arg1)
+	ret0, _ := ret[0].(models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeadJobGet indicates an expected call of DeadJobGet.
+func (mr *MockJobServiceMockRecorder) DeadJobGet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadJobGet", reflect.TypeOf((*MockJobService)(nil).DeadJobGet), arg0, arg1)
+}
+
+// DeadJobList mocks base method.
+func (m *MockJobService) DeadJobList(arg0 context.Context) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadJobList", arg0)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeadJobList indicates an expected call of DeadJobList.
+func (mr *MockJobServiceMockRecorder) DeadJobList(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadJobList", reflect.TypeOf((*MockJobService)(nil).DeadJobList), arg0)
+}
+
+// DeadJobPurge mocks base method.
+func (m *MockJobService) DeadJobPurge(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadJobPurge", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeadJobPurge indicates an expected call of DeadJobPurge.
+func (mr *MockJobServiceMockRecorder) DeadJobPurge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadJobPurge", reflect.TypeOf((*MockJobService)(nil).DeadJobPurge), arg0, arg1)
+}
+
+// DeadJobPurgeAll mocks base method.
+func (m *MockJobService) DeadJobPurgeAll(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadJobPurgeAll", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeadJobPurgeAll indicates an expected call of DeadJobPurgeAll.
+func (mr *MockJobServiceMockRecorder) DeadJobPurgeAll(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadJobPurgeAll", reflect.TypeOf((*MockJobService)(nil).DeadJobPurgeAll), arg0)
+}
+
+// DeadJobRequeue mocks base method.
+func (m *MockJobService) DeadJobRequeue(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadJobRequeue", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeadJobRequeue indicates an expected call of DeadJobRequeue.
+func (mr *MockJobServiceMockRecorder) DeadJobRequeue(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadJobRequeue", reflect.TypeOf((*MockJobService)(nil).DeadJobRequeue), arg0, arg1)
+}
+
+// JobGet mocks base method.
+func (m *MockJobService) JobGet(arg0 context.Context, arg1 int64, arg2 int) (models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JobGet", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JobGet indicates an expected call of JobGet.
+func (mr *MockJobServiceMockRecorder) JobGet(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JobGet", reflect.TypeOf((*MockJobService)(nil).JobGet), arg0, arg1, arg2)
+}
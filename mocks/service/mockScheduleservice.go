@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: konzek-jun/services (interfaces: ScheduleService)
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	models "konzek-jun/models"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockScheduleService is a mock of ScheduleService interface.
+type MockScheduleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduleServiceMockRecorder
+}
+
+// MockScheduleServiceMockRecorder is the mock recorder for MockScheduleService.
+type MockScheduleServiceMockRecorder struct {
+	mock *MockScheduleService
+}
+
+// NewMockScheduleService creates a new mock instance.
+func NewMockScheduleService(ctrl *gomock.Controller) *MockScheduleService {
+	mock := &MockScheduleService{ctrl: ctrl}
+	mock.recorder = &MockScheduleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduleService) EXPECT() *MockScheduleServiceMockRecorder {
+	return m.recorder
+}
+
+// ScheduleCreate mocks base method.
+func (m *MockScheduleService) ScheduleCreate(arg0 context.Context, arg1 models.Schedule) (models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleCreate", arg0, arg1)
+	ret0, _ := ret[0].(models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleCreate indicates an expected call of ScheduleCreate.
+func (mr *MockScheduleServiceMockRecorder) ScheduleCreate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleCreate", reflect.TypeOf((*MockScheduleService)(nil).ScheduleCreate), arg0, arg1)
+}
+
+// ScheduleDelete mocks base method.
+func (m *MockScheduleService) ScheduleDelete(arg0 context.Context, arg1 int64, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleDelete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ScheduleDelete indicates an expected call of ScheduleDelete.
+func (mr *MockScheduleServiceMockRecorder) ScheduleDelete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleDelete", reflect.TypeOf((*MockScheduleService)(nil).ScheduleDelete), arg0, arg1, arg2)
+}
+
+// ScheduleGetAll mocks base method.
+func (m *MockScheduleService) ScheduleGetAll(arg0 context.Context, arg1 int64) ([]models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleGetAll", arg0, arg1)
+	ret0, _ := ret[0].([]models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleGetAll indicates an expected call of ScheduleGetAll.
+func (mr *MockScheduleServiceMockRecorder) ScheduleGetAll(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleGetAll", reflect.TypeOf((*MockScheduleService)(nil).ScheduleGetAll), arg0, arg1)
+}
+
+// ScheduleGetByID mocks base method.
+func (m *MockScheduleService) ScheduleGetByID(arg0 context.Context, arg1 int64, arg2 int) (models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleGetByID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleGetByID indicates an expected call of ScheduleGetByID.
+func (mr *MockScheduleServiceMockRecorder) ScheduleGetByID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleGetByID", reflect.TypeOf((*MockScheduleService)(nil).ScheduleGetByID), arg0, arg1, arg2)
+}
+
+// SchedulePause mocks base method.
+func (m *MockScheduleService) SchedulePause(arg0 context.Context, arg1 int64, arg2 int) (models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchedulePause", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchedulePause indicates an expected call of SchedulePause.
+func (mr *MockScheduleServiceMockRecorder) SchedulePause(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchedulePause", reflect.TypeOf((*MockScheduleService)(nil).SchedulePause), arg0, arg1, arg2)
+}
+
+// ScheduleResume mocks base method.
+func (m *MockScheduleService) ScheduleResume(arg0 context.Context, arg1 int64, arg2 int) (models.Schedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleResume", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Schedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleResume indicates an expected call of ScheduleResume.
+func (mr *MockScheduleServiceMockRecorder) ScheduleResume(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleResume", reflect.TypeOf((*MockScheduleService)(nil).ScheduleResume), arg0, arg1, arg2)
+}
@@ -5,7 +5,9 @@
 package services
 
 import (
+	context "context"
 	models "konzek-jun/models"
+	services "konzek-jun/services"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,88 +37,210 @@ func (m *MockTaskService) EXPECT() *MockTaskServiceMockRecorder {
 }
 
 // GetAllTaskWithPagination mocks base method.
-func (m *MockTaskService) GetAllTaskWithPagination(arg0, arg1 int) ([]models.Task, error) {
+func (m *MockTaskService) GetAllTaskWithPagination(arg0 context.Context, arg1 int64, arg2 models.TaskFilter, arg3, arg4 int) (models.TaskPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllTaskWithPagination", arg0, arg1)
-	ret0, _ := ret[0].([]models.Task)
+	ret := m.ctrl.Call(m, "GetAllTaskWithPagination", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(models.TaskPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllTaskWithPagination indicates an expected call of GetAllTaskWithPagination.
-func (mr *MockTaskServiceMockRecorder) GetAllTaskWithPagination(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) GetAllTaskWithPagination(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTaskWithPagination", reflect.TypeOf((*MockTaskService)(nil).GetAllTaskWithPagination), arg0, arg1, arg2, arg3, arg4)
+}
+
+// TaskAddDependency mocks base method.
+func (m *MockTaskService) TaskAddDependency(arg0 context.Context, arg1 int64, arg2, arg3 int) (models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskAddDependency", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskAddDependency indicates an expected call of TaskAddDependency.
+func (mr *MockTaskServiceMockRecorder) TaskAddDependency(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskAddDependency", reflect.TypeOf((*MockTaskService)(nil).TaskAddDependency), arg0, arg1, arg2, arg3)
+}
+
+// TaskBulk mocks base method.
+func (m *MockTaskService) TaskBulk(arg0 context.Context, arg1 int64, arg2 []services.BulkTaskOperation, arg3 bool) []services.BulkTaskResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskBulk", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]services.BulkTaskResult)
+	return ret0
+}
+
+// TaskBulk indicates an expected call of TaskBulk.
+func (mr *MockTaskServiceMockRecorder) TaskBulk(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskBulk", reflect.TypeOf((*MockTaskService)(nil).TaskBulk), arg0, arg1, arg2, arg3)
+}
+
+// TaskCursorPage mocks base method.
+func (m *MockTaskService) TaskCursorPage(arg0 context.Context, arg1 int64, arg2 models.TaskFilter, arg3, arg4 string, arg5 int) (models.TaskPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskCursorPage", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(models.TaskPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskCursorPage indicates an expected call of TaskCursorPage.
+func (mr *MockTaskServiceMockRecorder) TaskCursorPage(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTaskWithPagination", reflect.TypeOf((*MockTaskService)(nil).GetAllTaskWithPagination), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskCursorPage", reflect.TypeOf((*MockTaskService)(nil).TaskCursorPage), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
 // TaskDelete mocks base method.
-func (m *MockTaskService) TaskDelete(arg0 int) error {
+func (m *MockTaskService) TaskDelete(arg0 context.Context, arg1 int64, arg2, arg3 int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TaskDelete", arg0)
+	ret := m.ctrl.Call(m, "TaskDelete", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TaskDelete indicates an expected call of TaskDelete.
-func (mr *MockTaskServiceMockRecorder) TaskDelete(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) TaskDelete(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskDelete", reflect.TypeOf((*MockTaskService)(nil).TaskDelete), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskDelete", reflect.TypeOf((*MockTaskService)(nil).TaskDelete), arg0, arg1, arg2, arg3)
 }
 
 // TaskGetAll mocks base method.
-func (m *MockTaskService) TaskGetAll() ([]models.Task, error) {
+func (m *MockTaskService) TaskGetAll(arg0 context.Context, arg1 int64) ([]models.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TaskGetAll")
+	ret := m.ctrl.Call(m, "TaskGetAll", arg0, arg1)
 	ret0, _ := ret[0].([]models.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // TaskGetAll indicates an expected call of TaskGetAll.
-func (mr *MockTaskServiceMockRecorder) TaskGetAll() *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) TaskGetAll(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskGetAll", reflect.TypeOf((*MockTaskService)(nil).TaskGetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskGetAll", reflect.TypeOf((*MockTaskService)(nil).TaskGetAll), arg0, arg1)
 }
 
 // TaskGetByID mocks base method.
-func (m *MockTaskService) TaskGetByID(arg0 int) (models.Task, error) {
+func (m *MockTaskService) TaskGetByID(arg0 context.Context, arg1 int64, arg2 int) (models.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TaskGetByID", arg0)
+	ret := m.ctrl.Call(m, "TaskGetByID", arg0, arg1, arg2)
 	ret0, _ := ret[0].(models.Task)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // TaskGetByID indicates an expected call of TaskGetByID.
-func (mr *MockTaskServiceMockRecorder) TaskGetByID(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) TaskGetByID(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskGetByID", reflect.TypeOf((*MockTaskService)(nil).TaskGetByID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskGetByID", reflect.TypeOf((*MockTaskService)(nil).TaskGetByID), arg0, arg1, arg2)
+}
+
+// TaskGraph mocks base method.
+func (m *MockTaskService) TaskGraph(arg0 context.Context, arg1 int64, arg2 int) (models.TaskGraph, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskGraph", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.TaskGraph)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskGraph indicates an expected call of TaskGraph.
+func (mr *MockTaskServiceMockRecorder) TaskGraph(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskGraph", reflect.TypeOf((*MockTaskService)(nil).TaskGraph), arg0, arg1, arg2)
 }
 
 // TaskInsert mocks base method.
-func (m *MockTaskService) TaskInsert(arg0 models.Task) error {
+func (m *MockTaskService) TaskInsert(arg0 context.Context, arg1 models.Task) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TaskInsert", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "TaskInsert", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // TaskInsert indicates an expected call of TaskInsert.
-func (mr *MockTaskServiceMockRecorder) TaskInsert(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) TaskInsert(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskInsert", reflect.TypeOf((*MockTaskService)(nil).TaskInsert), arg0, arg1)
+}
+
+// TaskPatch mocks base method.
+func (m *MockTaskService) TaskPatch(arg0 context.Context, arg1 int64, arg2, arg3 int, arg4 func(models.Task) (models.Task, error)) (models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskPatch", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskPatch indicates an expected call of TaskPatch.
+func (mr *MockTaskServiceMockRecorder) TaskPatch(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskPatch", reflect.TypeOf((*MockTaskService)(nil).TaskPatch), arg0, arg1, arg2, arg3, arg4)
+}
+
+// TaskRemoveDependency mocks base method.
+func (m *MockTaskService) TaskRemoveDependency(arg0 context.Context, arg1 int64, arg2, arg3 int) (models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskRemoveDependency", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskRemoveDependency indicates an expected call of TaskRemoveDependency.
+func (mr *MockTaskServiceMockRecorder) TaskRemoveDependency(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskRemoveDependency", reflect.TypeOf((*MockTaskService)(nil).TaskRemoveDependency), arg0, arg1, arg2, arg3)
+}
+
+// TaskSearch mocks base method.
+func (m *MockTaskService) TaskSearch(arg0 context.Context, arg1 int64, arg2 models.TaskFilter) ([]models.Task, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskSearch", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Task)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// TaskSearch indicates an expected call of TaskSearch.
+func (mr *MockTaskServiceMockRecorder) TaskSearch(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskInsert", reflect.TypeOf((*MockTaskService)(nil).TaskInsert), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskSearch", reflect.TypeOf((*MockTaskService)(nil).TaskSearch), arg0, arg1, arg2)
+}
+
+// TaskTransition mocks base method.
+func (m *MockTaskService) TaskTransition(arg0 context.Context, arg1 int64, arg2, arg3 int, arg4 models.TaskStatus) (models.Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TaskTransition", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TaskTransition indicates an expected call of TaskTransition.
+func (mr *MockTaskServiceMockRecorder) TaskTransition(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskTransition", reflect.TypeOf((*MockTaskService)(nil).TaskTransition), arg0, arg1, arg2, arg3, arg4)
 }
 
 // TaskUpdate mocks base method.
-func (m *MockTaskService) TaskUpdate(arg0 models.Task) error {
+func (m *MockTaskService) TaskUpdate(arg0 context.Context, arg1 models.Task) (models.Task, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TaskUpdate", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "TaskUpdate", arg0, arg1)
+	ret0, _ := ret[0].(models.Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // TaskUpdate indicates an expected call of TaskUpdate.
-func (mr *MockTaskServiceMockRecorder) TaskUpdate(arg0 interface{}) *gomock.Call {
+func (mr *MockTaskServiceMockRecorder) TaskUpdate(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskUpdate", reflect.TypeOf((*MockTaskService)(nil).TaskUpdate), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TaskUpdate", reflect.TypeOf((*MockTaskService)(nil).TaskUpdate), arg0, arg1)
 }
@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: konzek-jun/services (interfaces: TokenService)
+
+// Package services is a generated GoMock package.
+package services
+
+import (
+	context "context"
+	services "konzek-jun/services"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTokenService is a mock of TokenService interface.
+type MockTokenService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenServiceMockRecorder
+}
+
+// MockTokenServiceMockRecorder is the mock recorder for MockTokenService.
+type MockTokenServiceMockRecorder struct {
+	mock *MockTokenService
+}
+
+// NewMockTokenService creates a new mock instance.
+func NewMockTokenService(ctrl *gomock.Controller) *MockTokenService {
+	mock := &MockTokenService{ctrl: ctrl}
+	mock.recorder = &MockTokenServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenService) EXPECT() *MockTokenServiceMockRecorder {
+	return m.recorder
+}
+
+// IssueTokens mocks base method.
+func (m *MockTokenService) IssueTokens(arg0 context.Context, arg1 int64) (services.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", arg0, arg1)
+	ret0, _ := ret[0].(services.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockTokenServiceMockRecorder) IssueTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockTokenService)(nil).IssueTokens), arg0, arg1)
+}
+
+// Logout mocks base method.
+func (m *MockTokenService) Logout(arg0 context.Context, arg1 services.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockTokenServiceMockRecorder) Logout(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockTokenService)(nil).Logout), arg0, arg1)
+}
+
+// LogoutAll mocks base method.
+func (m *MockTokenService) LogoutAll(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogoutAll", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogoutAll indicates an expected call of LogoutAll.
+func (mr *MockTokenServiceMockRecorder) LogoutAll(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutAll", reflect.TypeOf((*MockTokenService)(nil).LogoutAll), arg0, arg1)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockTokenService) RefreshTokens(arg0 context.Context, arg1 string) (services.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", arg0, arg1)
+	ret0, _ := ret[0].(services.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockTokenServiceMockRecorder) RefreshTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockTokenService)(nil).RefreshTokens), arg0, arg1)
+}
@@ -5,7 +5,9 @@
 package services
 
 import (
+	context "context"
 	dto "konzek-jun/dto"
+	models "konzek-jun/models"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,61 +37,91 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockUserService) CreateUser(arg0 dto.RegisterRequest) (*dto.UserResponse, error) {
+func (m *MockUserService) CreateUser(arg0 context.Context, arg1 dto.RegisterRequest) (*dto.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
 	ret0, _ := ret[0].(*dto.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserServiceMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), arg0, arg1)
 }
 
 // FindUserByEmail mocks base method.
-func (m *MockUserService) FindUserByEmail(arg0 string) (*dto.UserResponse, error) {
+func (m *MockUserService) FindUserByEmail(arg0 context.Context, arg1 string) (*dto.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUserByEmail", arg0)
+	ret := m.ctrl.Call(m, "FindUserByEmail", arg0, arg1)
 	ret0, _ := ret[0].(*dto.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindUserByEmail indicates an expected call of FindUserByEmail.
-func (mr *MockUserServiceMockRecorder) FindUserByEmail(arg0 interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) FindUserByEmail(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByEmail", reflect.TypeOf((*MockUserService)(nil).FindUserByEmail), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByEmail", reflect.TypeOf((*MockUserService)(nil).FindUserByEmail), arg0, arg1)
 }
 
 // FindUserByID mocks base method.
-func (m *MockUserService) FindUserByID(arg0 string) (*dto.UserResponse, error) {
+func (m *MockUserService) FindUserByID(arg0 context.Context, arg1 string) (*dto.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindUserByID", arg0)
+	ret := m.ctrl.Call(m, "FindUserByID", arg0, arg1)
 	ret0, _ := ret[0].(*dto.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindUserByID indicates an expected call of FindUserByID.
-func (mr *MockUserServiceMockRecorder) FindUserByID(arg0 interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) FindUserByID(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByID", reflect.TypeOf((*MockUserService)(nil).FindUserByID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUserByID", reflect.TypeOf((*MockUserService)(nil).FindUserByID), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockUserService) ListUsers(arg0 context.Context) ([]dto.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0)
+	ret0, _ := ret[0].([]dto.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserServiceMockRecorder) ListUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserService)(nil).ListUsers), arg0)
+}
+
+// SetUserRole mocks base method.
+func (m *MockUserService) SetUserRole(arg0 context.Context, arg1 int64, arg2 models.Role) (*dto.UserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*dto.UserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockUserServiceMockRecorder) SetUserRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockUserService)(nil).SetUserRole), arg0, arg1, arg2)
 }
 
 // UpdateUser mocks base method.
-func (m *MockUserService) UpdateUser(arg0 dto.UpdateUserRequest) (*dto.UserResponse, error) {
+func (m *MockUserService) UpdateUser(arg0 context.Context, arg1 dto.UpdateUserRequest) (*dto.UserResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", arg0)
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
 	ret0, _ := ret[0].(*dto.UserResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserServiceMockRecorder) UpdateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), arg0, arg1)
 }
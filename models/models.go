@@ -1,14 +1,231 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
+type TaskStatus string
+
+const (
+	TaskStatusTodo       TaskStatus = "todo"
+	TaskStatusInProgress TaskStatus = "in_progress"
+	TaskStatusBlocked    TaskStatus = "blocked"
+	TaskStatusDone       TaskStatus = "done"
+	TaskStatusCancelled  TaskStatus = "cancelled"
+)
+
+// Role decides what a user may do; see package rbac for the permissions of
+// every role.
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	return r == RoleAdmin || r == RoleMember || r == RoleViewer
+}
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusDead is a job that failed on its last allowed attempt or with
+	// a non-retryable error. It stays dead until an admin requeues it.
+	JobStatusDead JobStatus = "dead"
+)
+
+// DefaultJobMaxAttempts is used when a task does not set max_attempts.
+const DefaultJobMaxAttempts = 3
+
 type Task struct {
-	Id      int    `json:"id,omitempty" `
-	Title   string `json:"title,omitempty" validate:"required,min=2"`
-	Content string `json:"content,omitempty" validate:"required,min=2"`
-	Status  bool   `json:"status,omitempty" validate:"required"`
+	Id      int             `json:"id,omitempty" `
+	OwnerID int64           `json:"owner_id,omitempty"`
+	Title   string          `json:"title,omitempty" validate:"required,min=2"`
+	Content string          `json:"content,omitempty" validate:"required,min=2"`
+	Status  TaskStatus      `json:"status,omitempty" validate:"omitempty,oneof=todo in_progress blocked done cancelled"`
+	JobType string          `json:"job_type,omitempty" validate:"omitempty,max=100"`
+	Payload json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	// MaxAttempts limits how often the job is tried before it is dead-lettered.
+	MaxAttempts int        `json:"max_attempts,omitempty" validate:"omitempty,min=1,max=25"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	// Version is incremented on every write. An update or delete with a
+	// Version only applies while the task still has that version; 0 skips
+	// the check.
+	Version int `json:"version,omitempty"`
 }
+
+// TaskSort orders a task listing by one field. Field is checked against a
+// whitelist by the repository.
+type TaskSort struct {
+	Field string
+	Desc  bool
+}
+
+// TaskFilter narrows down a task listing; zero values do not filter. Date
+// ranges are inclusive. Search is matched against title and content.
+type TaskFilter struct {
+	Statuses    []TaskStatus
+	Search      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	Sort        []TaskSort
+	Offset      int
+	// Limit 0 returns every matching task.
+	Limit int
+	// After and Before page by keyset instead of Offset: only tasks sorted
+	// after (or before) the cursor are returned. Keyset paging supports one
+	// sort field at most.
+	After  *TaskCursor
+	Before *TaskCursor
+}
+
+// TaskCursor is the position of a task in a listing: the value of its sort
+// field and its id.
+type TaskCursor struct {
+	Value string
+	ID    int
+}
+
+// Pagination describes the page a listing returned. Offset pages have Page
+// and TotalPages, keyset pages have the cursors of their neighbours.
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+type TaskPage struct {
+	Tasks      []Task     `json:"tasks"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// TaskDependency says that TaskID cannot be done before DependsOnID is.
+type TaskDependency struct {
+	TaskID      int `json:"task_id"`
+	DependsOnID int `json:"depends_on_id"`
+}
+
+// TaskGraph is the part of the dependency DAG around a task: every task it
+// transitively depends on (Upstream), every task that transitively depends
+// on it (Downstream) and the edges between them.
+type TaskGraph struct {
+	TaskID     int              `json:"task_id"`
+	Upstream   []Task           `json:"upstream"`
+	Downstream []Task           `json:"downstream"`
+	Edges      []TaskDependency `json:"edges"`
+}
+
+// Job is the executable part of a task row.
+type Job struct {
+	ID          int             `json:"job_id"`
+	OwnerID     int64           `json:"owner_id,omitempty"`
+	Type        string          `json:"job_type"`
+	Payload     json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	Status      JobStatus       `json:"job_status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Result      json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	LastError   string          `json:"last_error,omitempty"`
+	DurationMs  int64           `json:"duration_ms"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	// RunAfter is set while a failed job waits for its next attempt.
+	RunAfter *time.Time `json:"run_after,omitempty"`
+}
+
+// Schedule creates a task from its template whenever it is due. Exactly one
+// of Cron, Interval and RunAt is set; a RunAt schedule runs once.
+type Schedule struct {
+	ID          int             `json:"id,omitempty"`
+	OwnerID     int64           `json:"owner_id,omitempty"`
+	Name        string          `json:"name" validate:"required,min=2,max=200"`
+	Cron        string          `json:"cron,omitempty" validate:"omitempty,max=100"`
+	Interval    string          `json:"interval,omitempty" validate:"omitempty,max=50"`
+	RunAt       *time.Time      `json:"run_at,omitempty"`
+	Title       string          `json:"title" validate:"required,min=2"`
+	Content     string          `json:"content" validate:"required,min=2"`
+	JobType     string          `json:"job_type,omitempty" validate:"omitempty,max=100"`
+	Payload     json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	MaxAttempts int             `json:"max_attempts,omitempty" validate:"omitempty,min=1,max=25"`
+	Paused      bool            `json:"paused"`
+	NextRunAt   *time.Time      `json:"next_run_at,omitempty"`
+	LastRunAt   *time.Time      `json:"last_run_at,omitempty"`
+}
+
 type User struct {
 	ID       int64  `json:"-"`
 	Name     string `json:"name,omitempty" validate:"required,min=2"`
 	Email    string ` json:"email,omitempty" validate:"required,email"`
 	Password string ` json:"password,omitempty" validate:"required,min=6"`
+	// Role is RoleMember for users that register themselves.
+	Role Role `json:"role,omitempty"`
+}
+
+// IdempotencyRecord is the response to the first request a user sent with an
+// Idempotency-Key. Requests repeating the key get the same response until
+// ExpiresAt.
+type IdempotencyRecord struct {
+	UserID int64
+	Key    string
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string
+	// Status is 0 while the first request is still running.
+	Status    int
+	Headers   map[string]string
+	Body      []byte
+	ExpiresAt time.Time
+}
+
+// RefreshToken is an opaque refresh token; only the SHA-256 hash of the token
+// is stored. Every refresh rotates the token, and the tokens rotated from one
+// login share a FamilyID.
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	// RotatedAt is set once the token has been exchanged for a new one.
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+// TokenRevocation rejects the access tokens whose jti or sid claim is
+// TokenID. It is kept until the last such token has expired.
+type TokenRevocation struct {
+	TokenID   string
+	UserID    int64
+	ExpiresAt time.Time
+}
+
+// APIKey is a personal key that scripts of a user authenticate with instead
+// of a password. Only the SHA-256 hash of the key is stored; Prefix is a
+// part of the key that is stored in clear to find it.
+type APIKey struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Prefix     string
+	SecretHash string
+	// Scopes are the permissions the key is limited to. The role of the user
+	// still applies.
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
 }
@@ -28,12 +28,40 @@ var (
 			Buckets: prometheus.DefBuckets,
 		},
 	)
+	workerPoolSizeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "worker_pool_size",
+			Help: "Number of request workers.",
+		},
+	)
+	workerPoolInUseGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "worker_pool_in_use",
+			Help: "Number of request workers currently busy.",
+		},
+	)
+	workerPoolQueueGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "worker_pool_queue_depth",
+			Help: "Number of requests waiting for a worker.",
+		},
+	)
+	workerPoolRejectionsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "worker_pool_rejections_total",
+			Help: "Total number of requests rejected because the worker pool was saturated.",
+		},
+	)
 )
 
 func InitPrometheus() {
 	prometheus.MustRegister(httpRequestsTotal)
 	prometheus.MustRegister(memoryUsageGauge)
 	prometheus.MustRegister(processingTimeHistogram)
+	prometheus.MustRegister(workerPoolSizeGauge)
+	prometheus.MustRegister(workerPoolInUseGauge)
+	prometheus.MustRegister(workerPoolQueueGauge)
+	prometheus.MustRegister(workerPoolRejectionsTotal)
 }
 
 func HandleHTTPRequest() {
@@ -56,3 +84,16 @@ func MeasureRequestDuration(c *fiber.Ctx) error {
 
 	return err
 }
+
+func SetWorkerPoolSize(workers int) {
+	workerPoolSizeGauge.Set(float64(workers))
+}
+
+func SetWorkerPoolUsage(inUse, waiting int) {
+	workerPoolInUseGauge.Set(float64(inUse))
+	workerPoolQueueGauge.Set(float64(waiting))
+}
+
+func IncWorkerPoolRejections() {
+	workerPoolRejectionsTotal.Inc()
+}
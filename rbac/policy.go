@@ -0,0 +1,73 @@
+// Package rbac decides what the roles of package models may do. The policy
+// is plain data, so it can be checked without a request; Require enforces it
+// on a route.
+package rbac
+
+import (
+	"konzek-jun/models"
+	"slices"
+)
+
+// Permission is an action on a kind of resource, written resource:action.
+type Permission string
+
+const (
+	TasksRead   Permission = "tasks:read"
+	TasksWrite  Permission = "tasks:write"
+	TasksDelete Permission = "tasks:delete"
+	// TasksManageAll allows the task endpoints under /api/admin/users/:userId
+	// on the tasks of any user.
+	TasksManageAll Permission = "tasks:manage_all"
+
+	SchedulesRead  Permission = "schedules:read"
+	SchedulesWrite Permission = "schedules:write"
+
+	JobsRead  Permission = "jobs:read"
+	JobsWrite Permission = "jobs:write"
+	// JobsAdmin allows the dead-letter endpoints under /api/admin/jobs.
+	JobsAdmin Permission = "jobs:admin"
+
+	UsersManage Permission = "users:manage"
+)
+
+// permissions are all known permissions.
+var permissions = []Permission{
+	TasksRead, TasksWrite, TasksDelete, TasksManageAll,
+	SchedulesRead, SchedulesWrite,
+	JobsRead, JobsWrite, JobsAdmin,
+	UsersManage,
+}
+
+// Valid reports whether p is a known permission.
+func (p Permission) Valid() bool {
+	return slices.Contains(permissions, p)
+}
+
+// policy lists the permissions of every role. A permission that is not
+// listed is denied.
+var policy = map[models.Role][]Permission{
+	models.RoleViewer: {
+		TasksRead, SchedulesRead, JobsRead,
+	},
+	models.RoleMember: {
+		TasksRead, TasksWrite, TasksDelete,
+		SchedulesRead, SchedulesWrite,
+		JobsRead, JobsWrite,
+	},
+	models.RoleAdmin: {
+		TasksRead, TasksWrite, TasksDelete, TasksManageAll,
+		SchedulesRead, SchedulesWrite,
+		JobsRead, JobsWrite, JobsAdmin,
+		UsersManage,
+	},
+}
+
+// Allowed reports whether role has permission.
+func Allowed(role models.Role, permission Permission) bool {
+	return slices.Contains(policy[role], permission)
+}
+
+// Permissions returns the permissions of role.
+func Permissions(role models.Role) []Permission {
+	return slices.Clone(policy[role])
+}
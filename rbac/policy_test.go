@@ -0,0 +1,57 @@
+package rbac
+
+import (
+	"testing"
+
+	"konzek-jun/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		role       models.Role
+		permission Permission
+		allowed    bool
+	}{
+		{models.RoleViewer, TasksRead, true},
+		{models.RoleViewer, TasksWrite, false},
+		{models.RoleViewer, TasksDelete, false},
+		{models.RoleViewer, SchedulesWrite, false},
+		{models.RoleMember, TasksWrite, true},
+		{models.RoleMember, TasksDelete, true},
+		{models.RoleMember, JobsWrite, true},
+		{models.RoleMember, TasksManageAll, false},
+		{models.RoleMember, UsersManage, false},
+		{models.RoleMember, JobsAdmin, false},
+		{models.RoleViewer, JobsAdmin, false},
+		{models.RoleAdmin, TasksManageAll, true},
+		{models.RoleAdmin, UsersManage, true},
+		{models.RoleAdmin, JobsAdmin, true},
+		// Bilinmeyen rol ve izin reddedilir
+		{models.Role("owner"), TasksRead, false},
+		{models.RoleAdmin, Permission("tasks:explode"), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, Allowed(tt.role, tt.permission), "%s %s", tt.role, tt.permission)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	// Her rol, altındaki rolün bütün izinlerine sahiptir
+	for _, permission := range Permissions(models.RoleViewer) {
+		assert.True(t, Allowed(models.RoleMember, permission), permission)
+	}
+	for _, permission := range Permissions(models.RoleMember) {
+		assert.True(t, Allowed(models.RoleAdmin, permission), permission)
+	}
+	for role := range policy {
+		assert.True(t, role.Valid(), role)
+	}
+	// Admin bilinen bütün izinlere sahiptir
+	for _, permission := range permissions {
+		assert.True(t, permission.Valid(), permission)
+		assert.True(t, Allowed(models.RoleAdmin, permission), permission)
+	}
+	assert.False(t, Permission("tasks:explode").Valid())
+}
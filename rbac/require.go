@@ -0,0 +1,58 @@
+package rbac
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"konzek-jun/globalerror"
+	"konzek-jun/loggerx"
+	"konzek-jun/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Require only lets requests through whose user has permission. Requests
+// authenticated with an API key also need permission in the key's scopes. It
+// has to run after AuthorizeJWT.
+func Require(permission Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := middleware.Role(c)
+		if !ok {
+			return c.Status(http.StatusUnauthorized).JSON(globalerror.ErrorResponse{
+				Status: http.StatusUnauthorized,
+				ErrorDetail: []globalerror.ErrorResponseDetail{
+					{
+						FieldName:   "Authorization",
+						Description: "No authenticated user",
+					},
+				},
+			})
+		}
+		if !Allowed(role, permission) {
+			loggerx.Info(fmt.Sprintf("Role %s lacks permission %s for %s %s", role, permission, c.Method(), c.Path()))
+			return c.Status(http.StatusForbidden).JSON(globalerror.ErrorResponse{
+				Status: http.StatusForbidden,
+				ErrorDetail: []globalerror.ErrorResponseDetail{
+					{
+						FieldName:   "Authorization",
+						Description: fmt.Sprintf("Permission %s is required", permission),
+					},
+				},
+			})
+		}
+		if scopes, ok := middleware.Scopes(c); ok && !slices.Contains(scopes, string(permission)) {
+			loggerx.Info(fmt.Sprintf("API key lacks scope %s for %s %s", permission, c.Method(), c.Path()))
+			return c.Status(http.StatusForbidden).JSON(globalerror.ErrorResponse{
+				Status: http.StatusForbidden,
+				ErrorDetail: []globalerror.ErrorResponseDetail{
+					{
+						FieldName:   "Authorization",
+						Description: fmt.Sprintf("The API key does not have the scope %s", permission),
+					},
+				},
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"konzek-jun/middleware"
+	"konzek-jun/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequire(t *testing.T) {
+	status := func(role models.Role, scopes ...string) int {
+		router := fiber.New()
+		router.Use(func(c *fiber.Ctx) error {
+			if role != "" {
+				c.Locals(middleware.RoleKey, role)
+			}
+			if scopes != nil {
+				c.Locals(middleware.ScopesKey, scopes)
+			}
+			return c.Next()
+		})
+		router.Delete("/api/tasks/:id", Require(TasksDelete), func(c *fiber.Ctx) error {
+			return c.SendStatus(http.StatusNoContent)
+		})
+		resp, err := router.Test(httptest.NewRequest(http.MethodDelete, "/api/tasks/1", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusNoContent, status(models.RoleMember))
+	assert.Equal(t, http.StatusNoContent, status(models.RoleAdmin))
+	assert.Equal(t, http.StatusForbidden, status(models.RoleViewer))
+	// Giriş yapılmamış istek
+	assert.Equal(t, http.StatusUnauthorized, status(""))
+
+	// API anahtarı hem kullanıcının rolüne hem de kendi kapsamına bağlıdır
+	assert.Equal(t, http.StatusNoContent, status(models.RoleMember, "tasks:read", "tasks:delete"))
+	assert.Equal(t, http.StatusForbidden, status(models.RoleMember, "tasks:read"))
+	assert.Equal(t, http.StatusForbidden, status(models.RoleViewer, "tasks:delete"))
+}
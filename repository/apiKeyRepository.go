@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrAPIKeyNotFound is returned when no key has the given prefix, or the
+// user has no unrevoked key with the given id.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+//go:generate mockgen -destination=../mocks//repository/mockApikeyrepository.go -package=repository konzek-jun/repository APIKeyRepository
+type APIKeyRepository interface {
+	Insert(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	// FindByPrefix returns the key with prefix, also when it is revoked or
+	// expired.
+	FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	// List returns the unrevoked keys of userID by id.
+	List(ctx context.Context, userID int64) ([]models.APIKey, error)
+	Revoke(ctx context.Context, userID int64, id int64) error
+	MarkUsed(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+type APIKeyRepositoryDb struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepositoryDb {
+	return &APIKeyRepositoryDb{DB: db}
+}
+
+const apiKeyColumns = "id, user_id, name, prefix, secret_hash, scopes, expires_at, last_used_at, created_at, revoked_at"
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.SecretHash, pq.Array(&key.Scopes),
+		&key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt, &key.RevokedAt)
+	return key, err
+}
+
+func (r *APIKeyRepositoryDb) Insert(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	row := r.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiKeyColumns, key.UserID, key.Name, key.Prefix, key.SecretHash, pq.Array(key.Scopes), key.ExpiresAt)
+	inserted, err := scanAPIKey(row)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting api key: %v", err))
+		return models.APIKey{}, err
+	}
+	return inserted, nil
+}
+
+func (r *APIKeyRepositoryDb) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	key, err := scanAPIKey(r.DB.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reading api key: %v", err))
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *APIKeyRepositoryDb) List(ctx context.Context, userID int64) ([]models.APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL ORDER BY id", userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing api keys: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepositoryDb) Revoke(ctx context.Context, userID int64, id int64) error {
+	result, err := r.DB.ExecContext(ctx, "UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", id, userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking api key: %v", err))
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepositoryDb) MarkUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)", id, usedAt)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while marking api key as used: %v", err))
+	}
+	return err
+}
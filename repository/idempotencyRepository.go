@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks//repository/mockIdempotencyrepository.go -package=repository konzek-jun/repository IdempotencyRepository
+type IdempotencyRepository interface {
+	// Claim reserves record.Key of record.UserID for a new request until
+	// record.ExpiresAt. When the key is already taken and has not expired,
+	// the stored record is returned and claimed is false.
+	Claim(ctx context.Context, record models.IdempotencyRecord) (stored models.IdempotencyRecord, claimed bool, err error)
+	// Complete stores the status, headers and body of response until
+	// response.ExpiresAt. claim is the record passed to Claim; the response
+	// is only stored while the key is still held by that claim, with the
+	// same fingerprint and expiry.
+	Complete(ctx context.Context, claim, response models.IdempotencyRecord) error
+	// Release gives up a claim whose request has no response worth keeping,
+	// so that the key can be used again. Like Complete, it does nothing when
+	// the key has been claimed again since.
+	Release(ctx context.Context, claim models.IdempotencyRecord) error
+	// DeleteExpired removes the records that expired before now and returns
+	// how many there were.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// IdempotencyRepositoryDb keeps idempotency keys in Postgres. The primary key
+// on user and key makes a claim atomic across replicas.
+type IdempotencyRepositoryDb struct {
+	DB *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepositoryDb {
+	return &IdempotencyRepositoryDb{DB: db}
+}
+
+func (r *IdempotencyRepositoryDb) Claim(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	// Try again when the record is deleted or expires between the two queries
+	for attempt := 0; attempt < 3; attempt++ {
+		result, err := r.DB.ExecContext(ctx, `
+			INSERT INTO idempotency_keys (user_id, key, fingerprint, status, expires_at) VALUES ($1, $2, $3, 0, $4)
+			ON CONFLICT (user_id, key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = 0, headers = NULL, body = NULL, expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= now()`,
+			record.UserID, record.Key, record.Fingerprint, record.ExpiresAt)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while claiming idempotency key: %v", err))
+			return models.IdempotencyRecord{}, false, err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 1 {
+			return record, err == nil, err
+		}
+
+		stored, err := r.get(ctx, record.UserID, record.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while reading idempotency key: %v", err))
+		}
+		return stored, false, err
+	}
+	return models.IdempotencyRecord{}, false, fmt.Errorf("idempotency key %q could not be claimed", record.Key)
+}
+
+func (r *IdempotencyRepositoryDb) get(ctx context.Context, userID int64, key string) (models.IdempotencyRecord, error) {
+	record := models.IdempotencyRecord{UserID: userID, Key: key}
+	var headers []byte
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT fingerprint, status, headers, body, expires_at FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND expires_at > now()`, userID, key).
+		Scan(&record.Fingerprint, &record.Status, &headers, &record.Body, &record.ExpiresAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	if headers != nil {
+		if err := json.Unmarshal(headers, &record.Headers); err != nil {
+			return models.IdempotencyRecord{}, err
+		}
+	}
+	return record, nil
+}
+
+func (r *IdempotencyRepositoryDb) Complete(ctx context.Context, claim, response models.IdempotencyRecord) error {
+	headers, err := json.Marshal(response.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = $5, headers = $6::jsonb, body = $7, expires_at = $8
+		WHERE user_id = $1 AND key = $2 AND fingerprint = $3 AND expires_at = $4 AND status = 0`,
+		claim.UserID, claim.Key, claim.Fingerprint, claim.ExpiresAt, response.Status, headers, response.Body, response.ExpiresAt)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while storing idempotent response: %v", err))
+	}
+	return err
+}
+
+func (r *IdempotencyRepositoryDb) Release(ctx context.Context, claim models.IdempotencyRecord) error {
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2 AND fingerprint = $3 AND expires_at = $4 AND status = 0`,
+		claim.UserID, claim.Key, claim.Fingerprint, claim.ExpiresAt)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while releasing idempotency key: %v", err))
+	}
+	return err
+}
+
+func (r *IdempotencyRepositoryDb) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting expired idempotency keys: %v", err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
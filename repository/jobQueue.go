@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+var (
+	// ErrNoPendingJob is returned by Lease when there is nothing to run.
+	ErrNoPendingJob = errors.New("no pending job")
+	// ErrJobNotFound is returned when a job does not exist or belongs to another user.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrLeaseLost is returned when a worker no longer holds the lease of a job,
+	// usually because it expired and was reclaimed by another worker.
+	ErrLeaseLost = errors.New("job lease lost")
+)
+
+// JobQueue hands out jobs stored on task rows to workers. A leased job is
+// invisible to other workers until the lease expires; workers extend it with
+// Heartbeat while they run and release it with Complete, Retry or DeadLetter.
+// Dead-lettered jobs stay put until they are requeued or purged; the
+// dead-letter methods serve the admin endpoints and cover the jobs of every
+// user. A job whose
+// task depends on other tasks is not leased before all of their jobs have
+// succeeded; a prerequisite without a job counts once its task is done.
+//
+//go:generate mockgen -destination=../mocks//repository/mockJobqueue.go -package=repository konzek-jun/repository JobQueue
+type JobQueue interface {
+	Lease(ctx context.Context, workerID string, visibility time.Duration) (models.Job, error)
+	Heartbeat(ctx context.Context, id int, workerID string, visibility time.Duration) error
+	Complete(ctx context.Context, id int, workerID string, result json.RawMessage, duration time.Duration) error
+	Retry(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration, runAfter time.Time) error
+	DeadLetter(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration) error
+	Release(ctx context.Context, id int, workerID string) error
+	ReclaimExpired(ctx context.Context) (int64, error)
+	GetJob(ctx context.Context, ownerID int64, id int) (models.Job, error)
+	ListDead(ctx context.Context) ([]models.Job, error)
+	GetDead(ctx context.Context, id int) (models.Job, error)
+	Requeue(ctx context.Context, id int) error
+	PurgeDead(ctx context.Context, id int) error
+	PurgeAllDead(ctx context.Context) (int64, error)
+}
+
+// JobQueueDb is a JobQueue on the Postgres tasks table. Several service
+// replicas can share it: FOR UPDATE SKIP LOCKED keeps two workers from
+// leasing the same row.
+type JobQueueDb struct {
+	DB *sql.DB
+}
+
+func NewJobQueue(db *sql.DB) *JobQueueDb {
+	return &JobQueueDb{DB: db}
+}
+
+const jobColumns = "id, owner_id, job_type, payload, job_status, attempts, max_attempts, result, last_error, last_duration_ms, started_at, finished_at, run_after"
+
+func (j *JobQueueDb) Lease(ctx context.Context, workerID string, visibility time.Duration) (models.Job, error) {
+	row := j.DB.QueryRowContext(ctx, `
+		UPDATE tasks SET job_status = 'running', attempts = attempts + 1,
+			lease_owner = $1, lease_expires_at = now() + $2 * interval '1 millisecond',
+			started_at = now(), finished_at = NULL, run_after = NULL
+		WHERE id = (
+			SELECT id FROM tasks t WHERE job_status = 'pending'
+				AND (run_after IS NULL OR run_after <= now())
+				AND NOT EXISTS (
+					SELECT 1 FROM task_dependencies d JOIN tasks p ON p.id = d.depends_on_id
+					WHERE d.task_id = t.id
+						AND p.job_status IS DISTINCT FROM 'succeeded'
+						AND NOT (p.job_type IS NULL AND p.status = 'done')
+				)
+			ORDER BY id LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, workerID, visibility.Milliseconds())
+
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrNoPendingJob
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while leasing job: %v", err))
+		return models.Job{}, err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d leased by %s", job.ID, workerID))
+	return job, nil
+}
+
+func (j *JobQueueDb) Heartbeat(ctx context.Context, id int, workerID string, visibility time.Duration) error {
+	result, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET lease_expires_at = now() + $1 * interval '1 millisecond'
+		WHERE id = $2 AND lease_owner = $3 AND job_status = 'running'`, visibility.Milliseconds(), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while extending job lease: %v", err))
+		return err
+	}
+	return checkLease(result)
+}
+
+func (j *JobQueueDb) Complete(ctx context.Context, id int, workerID string, result json.RawMessage, duration time.Duration) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'succeeded', result = $1::jsonb, last_error = NULL, last_duration_ms = $2,
+			finished_at = now(), lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $3 AND lease_owner = $4 AND job_status = 'running'`, nullableJSON(result), duration.Milliseconds(), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while completing job: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d succeeded", id))
+	return nil
+}
+
+// Retry releases a failed job and puts it back to pending; it is not leased
+// again before runAfter.
+func (j *JobQueueDb) Retry(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration, runAfter time.Time) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'pending', last_error = $1, last_duration_ms = $2, run_after = $3,
+			finished_at = now(), lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $4 AND lease_owner = $5 AND job_status = 'running'`, errMsg, duration.Milliseconds(), runAfter, id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while scheduling job retry: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d failed, retrying after %s: %s", id, runAfter.Format(time.RFC3339), errMsg))
+	return nil
+}
+
+// DeadLetter marks a failed job as dead. It is not run again until requeued.
+func (j *JobQueueDb) DeadLetter(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'dead', last_error = $1, last_duration_ms = $2, run_after = NULL,
+			finished_at = now(), lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $3 AND lease_owner = $4 AND job_status = 'running'`, errMsg, duration.Milliseconds(), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while dead-lettering job: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Error(fmt.Sprintf("Job %d dead-lettered: %s", id, errMsg))
+	return nil
+}
+
+// Release puts a running job back to pending without using up its attempt,
+// e.g. when the worker is shutting down before the job could finish.
+func (j *JobQueueDb) Release(ctx context.Context, id int, workerID string) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'pending', attempts = GREATEST(attempts - 1, 0),
+			lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = $1 AND lease_owner = $2 AND job_status = 'running'`, id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while releasing job: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d released by %s", id, workerID))
+	return nil
+}
+
+// ReclaimExpired puts running jobs whose lease has expired back to pending so
+// that another worker picks them up. Jobs that already used their last attempt
+// are dead-lettered instead. It returns the number of reclaimed jobs.
+func (j *JobQueueDb) ReclaimExpired(ctx context.Context) (int64, error) {
+	result, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+			lease_owner = NULL, lease_expires_at = NULL, last_error = 'lease expired'
+		WHERE job_status = 'running' AND lease_expires_at < now()`)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reclaiming expired jobs: %v", err))
+		return 0, err
+	}
+	reclaimed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if reclaimed > 0 {
+		loggerx.Info(fmt.Sprintf("Reclaimed %d expired job leases", reclaimed))
+	}
+	return reclaimed, nil
+}
+
+func (j *JobQueueDb) GetJob(ctx context.Context, ownerID int64, id int) (models.Job, error) {
+	row := j.DB.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM tasks WHERE id = $1 AND owner_id = $2 AND job_type IS NOT NULL", id, ownerID)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting job: %v", err))
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+func (j *JobQueueDb) ListDead(ctx context.Context) ([]models.Job, error) {
+	rows, err := j.DB.QueryContext(ctx, "SELECT "+jobColumns+" FROM tasks WHERE job_status = 'dead' ORDER BY finished_at DESC, id DESC")
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing dead jobs: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while scanning dead job: %v", err))
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetDead returns ErrJobNotFound for jobs that exist but are not dead.
+func (j *JobQueueDb) GetDead(ctx context.Context, id int) (models.Job, error) {
+	row := j.DB.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM tasks WHERE id = $1 AND job_status = 'dead'", id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting dead job: %v", err))
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+// Requeue gives a dead job a fresh set of attempts.
+func (j *JobQueueDb) Requeue(ctx context.Context, id int) error {
+	result, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'pending', attempts = 0, run_after = NULL, finished_at = NULL
+		WHERE id = $1 AND job_status = 'dead'`, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while requeueing job: %v", err))
+		return err
+	}
+	if err := checkJobAffected(result); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d requeued", id))
+	return nil
+}
+
+// PurgeDead deletes a dead job together with its task.
+func (j *JobQueueDb) PurgeDead(ctx context.Context, id int) error {
+	result, err := j.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1 AND job_status = 'dead'", id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while purging dead job: %v", err))
+		return err
+	}
+	return checkJobAffected(result)
+}
+
+func (j *JobQueueDb) PurgeAllDead(ctx context.Context) (int64, error) {
+	result, err := j.DB.ExecContext(ctx, "DELETE FROM tasks WHERE job_status = 'dead'")
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while purging dead jobs: %v", err))
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	loggerx.Info(fmt.Sprintf("Purged %d dead jobs", purged))
+	return purged, nil
+}
+
+func checkJobAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+func checkLease(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var payload, result []byte
+	var lastError sql.NullString
+	var startedAt, finishedAt, runAfter sql.NullTime
+
+	err := row.Scan(&job.ID, &job.OwnerID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &result, &lastError, &job.DurationMs, &startedAt, &finishedAt, &runAfter)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	job.Payload = payload
+	job.Result = result
+	job.LastError = lastError.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	if runAfter.Valid {
+		job.RunAfter = &runAfter.Time
+	}
+	return job, nil
+}
+
+// nullableJSON turns an empty payload into SQL NULL; lib/pq needs JSON as text.
+func nullableJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
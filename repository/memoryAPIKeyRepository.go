@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"konzek-jun/models"
+)
+
+// MemoryAPIKeyRepository is an in-process APIKeyRepository for tests and
+// local development.
+type MemoryAPIKeyRepository struct {
+	mu     sync.Mutex
+	keys   map[int64]models.APIKey
+	nextID int64
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		keys: make(map[int64]models.APIKey),
+	}
+}
+
+func (m *MemoryAPIKeyRepository) Insert(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	key.ID = m.nextID
+	key.Scopes = slices.Clone(key.Scopes)
+	key.CreatedAt = time.Now()
+	key.LastUsedAt, key.RevokedAt = nil, nil
+	m.keys[key.ID] = key
+	return key, nil
+}
+
+func (m *MemoryAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range m.keys {
+		if key.Prefix == prefix {
+			key.Scopes = slices.Clone(key.Scopes)
+			return key, nil
+		}
+	}
+	return models.APIKey{}, ErrAPIKeyNotFound
+}
+
+func (m *MemoryAPIKeyRepository) List(ctx context.Context, userID int64) ([]models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := []models.APIKey{}
+	for _, key := range m.keys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			key.Scopes = slices.Clone(key.Scopes)
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (m *MemoryAPIKeyRepository) Revoke(ctx context.Context, userID int64, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.keys[id]
+	if !ok || key.UserID != userID || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	m.keys[id] = key
+	return nil
+}
+
+func (m *MemoryAPIKeyRepository) MarkUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.keys[id]
+	if ok && (key.LastUsedAt == nil || key.LastUsedAt.Before(usedAt)) {
+		key.LastUsedAt = &usedAt
+		m.keys[id] = key
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"konzek-jun/models"
+)
+
+type idempotencyKey struct {
+	userID int64
+	key    string
+}
+
+// MemoryIdempotencyRepository is an in-process IdempotencyRepository for tests
+// and local development. Claims only hold within one process.
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]models.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[idempotencyKey]models.IdempotencyRecord),
+	}
+}
+
+func (m *MemoryIdempotencyRepository) Claim(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey{userID: record.UserID, key: record.Key}
+	if stored, ok := m.records[k]; ok && stored.ExpiresAt.After(time.Now()) {
+		return stored, false, nil
+	}
+	record.Status, record.Headers, record.Body = 0, nil, nil
+	m.records[k] = record
+	return record, true, nil
+}
+
+func (m *MemoryIdempotencyRepository) Complete(ctx context.Context, claim, response models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey{userID: claim.UserID, key: claim.Key}
+	stored, ok := m.records[k]
+	if !ok || !heldBy(stored, claim) {
+		return nil
+	}
+	stored.Status, stored.ExpiresAt = response.Status, response.ExpiresAt
+	stored.Body = append([]byte(nil), response.Body...)
+	stored.Headers = make(map[string]string, len(response.Headers))
+	for name, value := range response.Headers {
+		stored.Headers[name] = value
+	}
+	m.records[k] = stored
+	return nil
+}
+
+func (m *MemoryIdempotencyRepository) Release(ctx context.Context, claim models.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey{userID: claim.UserID, key: claim.Key}
+	if stored, ok := m.records[k]; ok && heldBy(stored, claim) {
+		delete(m.records, k)
+	}
+	return nil
+}
+
+// heldBy reports whether stored is still the pending record of claim.
+func heldBy(stored, claim models.IdempotencyRecord) bool {
+	return stored.Status == 0 && stored.Fingerprint == claim.Fingerprint && stored.ExpiresAt.Equal(claim.ExpiresAt)
+}
+
+func (m *MemoryIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for k, stored := range m.records {
+		if !stored.ExpiresAt.After(now) {
+			delete(m.records, k)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
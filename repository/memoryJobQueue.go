@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"konzek-jun/models"
+)
+
+// MemoryJobQueue is an in-process JobQueue. A lease lasts for its visibility
+// timeout and a job is not leased before its prerequisites have succeeded.
+// It is meant for tests and local development.
+type MemoryJobQueue struct {
+	mu     sync.Mutex
+	jobs   map[int]*memoryJob
+	nextID int
+	// Now is used instead of time.Now so tests can move the clock.
+	Now func() time.Time
+}
+
+type memoryJob struct {
+	job            models.Job
+	leaseOwner     string
+	leaseExpiresAt time.Time
+	dependsOn      []int
+}
+
+func NewMemoryJobQueue() *MemoryJobQueue {
+	return &MemoryJobQueue{
+		jobs: make(map[int]*memoryJob),
+		Now:  time.Now,
+	}
+}
+
+// Enqueue adds a pending job and returns its id. A zero job.ID is assigned automatically.
+func (q *MemoryJobQueue) Enqueue(job models.Job) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job.ID == 0 {
+		q.nextID++
+		job.ID = q.nextID
+	} else if job.ID > q.nextID {
+		q.nextID = job.ID
+	}
+	job.Status = models.JobStatusPending
+	if job.MaxAttempts == 0 {
+		job.MaxAttempts = models.DefaultJobMaxAttempts
+	}
+	q.jobs[job.ID] = &memoryJob{job: job}
+	return job.ID
+}
+
+// AddDependency keeps job id from being leased before job dependsOnID has
+// succeeded. Prerequisites that are not in the queue are ignored.
+func (q *MemoryJobQueue) AddDependency(id, dependsOnID int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry, ok := q.jobs[id]; ok {
+		entry.dependsOn = append(entry.dependsOn, dependsOnID)
+	}
+}
+
+func (q *MemoryJobQueue) removeDependency(id, dependsOnID int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.jobs[id]
+	if !ok {
+		return
+	}
+	for i, parent := range entry.dependsOn {
+		if parent == dependsOnID {
+			entry.dependsOn = append(entry.dependsOn[:i], entry.dependsOn[i+1:]...)
+			return
+		}
+	}
+}
+
+// remove drops the job of a deleted task and returns a func that puts it
+// back, for MemoryUnitOfWork.
+func (q *MemoryJobQueue) remove(id int) func() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.jobs[id]
+	delete(q.jobs, id)
+	return func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if _, taken := q.jobs[id]; ok && !taken {
+			q.jobs[id] = entry
+		}
+	}
+}
+
+func (q *MemoryJobQueue) Lease(ctx context.Context, workerID string, visibility time.Duration) (models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.Now()
+	for _, id := range q.sortedIDs() {
+		entry := q.jobs[id]
+		if entry.job.Status != models.JobStatusPending {
+			continue
+		}
+		if entry.job.RunAfter != nil && entry.job.RunAfter.After(now) {
+			continue
+		}
+		if !q.prerequisitesSucceeded(entry) {
+			continue
+		}
+		entry.job.Status = models.JobStatusRunning
+		entry.job.Attempts++
+		entry.job.StartedAt = &now
+		entry.job.FinishedAt = nil
+		entry.job.RunAfter = nil
+		entry.leaseOwner = workerID
+		entry.leaseExpiresAt = now.Add(visibility)
+		return entry.job, nil
+	}
+	return models.Job{}, ErrNoPendingJob
+}
+
+func (q *MemoryJobQueue) Heartbeat(ctx context.Context, id int, workerID string, visibility time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.leased(id, workerID)
+	if err != nil {
+		return err
+	}
+	entry.leaseExpiresAt = q.Now().Add(visibility)
+	return nil
+}
+
+func (q *MemoryJobQueue) Complete(ctx context.Context, id int, workerID string, result json.RawMessage, duration time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.leased(id, workerID)
+	if err != nil {
+		return err
+	}
+	q.finish(entry, models.JobStatusSucceeded, duration)
+	entry.job.Result = result
+	entry.job.LastError = ""
+	return nil
+}
+
+func (q *MemoryJobQueue) Retry(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration, runAfter time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.leased(id, workerID)
+	if err != nil {
+		return err
+	}
+	q.finish(entry, models.JobStatusPending, duration)
+	entry.job.LastError = errMsg
+	entry.job.RunAfter = &runAfter
+	return nil
+}
+
+func (q *MemoryJobQueue) DeadLetter(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.leased(id, workerID)
+	if err != nil {
+		return err
+	}
+	q.finish(entry, models.JobStatusDead, duration)
+	entry.job.LastError = errMsg
+	return nil
+}
+
+func (q *MemoryJobQueue) Release(ctx context.Context, id int, workerID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.leased(id, workerID)
+	if err != nil {
+		return err
+	}
+	entry.job.Status = models.JobStatusPending
+	if entry.job.Attempts > 0 {
+		entry.job.Attempts--
+	}
+	entry.leaseOwner = ""
+	return nil
+}
+
+func (q *MemoryJobQueue) ReclaimExpired(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var reclaimed int64
+	now := q.Now()
+	for _, entry := range q.jobs {
+		if entry.job.Status == models.JobStatusRunning && entry.leaseExpiresAt.Before(now) {
+			entry.job.Status = models.JobStatusPending
+			if entry.job.Attempts >= entry.job.MaxAttempts {
+				entry.job.Status = models.JobStatusDead
+			}
+			entry.job.LastError = "lease expired"
+			entry.leaseOwner = ""
+			reclaimed++
+		}
+	}
+	return reclaimed, nil
+}
+
+func (q *MemoryJobQueue) GetJob(ctx context.Context, ownerID int64, id int) (models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.jobs[id]
+	if !ok || entry.job.OwnerID != ownerID {
+		return models.Job{}, ErrJobNotFound
+	}
+	return entry.job, nil
+}
+
+func (q *MemoryJobQueue) ListDead(ctx context.Context) ([]models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := []models.Job{}
+	for _, id := range q.sortedIDs() {
+		entry := q.jobs[id]
+		if entry.job.Status == models.JobStatusDead {
+			jobs = append(jobs, entry.job)
+		}
+	}
+	return jobs, nil
+}
+
+func (q *MemoryJobQueue) GetDead(ctx context.Context, id int) (models.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.dead(id)
+	if err != nil {
+		return models.Job{}, err
+	}
+	return entry.job, nil
+}
+
+func (q *MemoryJobQueue) Requeue(ctx context.Context, id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.dead(id)
+	if err != nil {
+		return err
+	}
+	entry.job.Status = models.JobStatusPending
+	entry.job.Attempts = 0
+	entry.job.RunAfter = nil
+	entry.job.FinishedAt = nil
+	return nil
+}
+
+func (q *MemoryJobQueue) PurgeDead(ctx context.Context, id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, err := q.dead(id); err != nil {
+		return err
+	}
+	delete(q.jobs, id)
+	return nil
+}
+
+func (q *MemoryJobQueue) PurgeAllDead(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var purged int64
+	for id, entry := range q.jobs {
+		if entry.job.Status == models.JobStatusDead {
+			delete(q.jobs, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (q *MemoryJobQueue) prerequisitesSucceeded(entry *memoryJob) bool {
+	for _, id := range entry.dependsOn {
+		if parent, ok := q.jobs[id]; ok && parent.job.Status != models.JobStatusSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *MemoryJobQueue) dead(id int) (*memoryJob, error) {
+	entry, ok := q.jobs[id]
+	if !ok || entry.job.Status != models.JobStatusDead {
+		return nil, ErrJobNotFound
+	}
+	return entry, nil
+}
+
+func (q *MemoryJobQueue) leased(id int, workerID string) (*memoryJob, error) {
+	entry, ok := q.jobs[id]
+	if !ok || entry.job.Status != models.JobStatusRunning || entry.leaseOwner != workerID {
+		return nil, ErrLeaseLost
+	}
+	return entry, nil
+}
+
+func (q *MemoryJobQueue) finish(entry *memoryJob, status models.JobStatus, duration time.Duration) {
+	now := q.Now()
+	entry.job.Status = status
+	entry.job.DurationMs = duration.Milliseconds()
+	entry.job.FinishedAt = &now
+	entry.leaseOwner = ""
+}
+
+func (q *MemoryJobQueue) sortedIDs() []int {
+	ids := make([]int, 0, len(q.jobs))
+	for id := range q.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
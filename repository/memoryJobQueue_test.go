@@ -0,0 +1,184 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryJobQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newQueue := func() *repository.MemoryJobQueue {
+		queue := repository.NewMemoryJobQueue()
+		queue.Now = func() time.Time { return now }
+		return queue
+	}
+	queue := newQueue()
+
+	t.Run("LeaseHidesJobFromOtherWorkers", func(t *testing.T) {
+		id := queue.Enqueue(models.Job{OwnerID: 1, Type: "echo"})
+
+		job, err := queue.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, id, job.ID)
+		assert.Equal(t, models.JobStatusRunning, job.Status)
+
+		_, err = queue.Lease(ctx, "worker-b", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+
+		assert.NoError(t, queue.Complete(ctx, id, "worker-a", nil, time.Second))
+	})
+
+	t.Run("ExpiredLeaseIsReclaimed", func(t *testing.T) {
+		id := queue.Enqueue(models.Job{OwnerID: 1, Type: "echo"})
+		_, err := queue.Lease(ctx, "crashed-worker", time.Minute)
+		assert.NoError(t, err)
+
+		// Heartbeat gelmeden süre dolar
+		now = now.Add(2 * time.Minute)
+		reclaimed, err := queue.ReclaimExpired(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), reclaimed)
+
+		job, err := queue.Lease(ctx, "worker-b", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, id, job.ID)
+		assert.Equal(t, 2, job.Attempts)
+
+		// Eski worker artık sonucu yazamaz
+		assert.ErrorIs(t, queue.Complete(ctx, id, "crashed-worker", nil, time.Second), repository.ErrLeaseLost)
+		assert.ErrorIs(t, queue.Heartbeat(ctx, id, "crashed-worker", time.Minute), repository.ErrLeaseLost)
+		assert.NoError(t, queue.DeadLetter(ctx, id, "worker-b", "boom", time.Second))
+	})
+
+	t.Run("ReleaseReturnsAttempt", func(t *testing.T) {
+		id := queue.Enqueue(models.Job{OwnerID: 1, Type: "echo", MaxAttempts: 1})
+		_, err := queue.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, queue.Release(ctx, id, "worker-b"), repository.ErrLeaseLost)
+		assert.NoError(t, queue.Release(ctx, id, "worker-a"))
+		assert.ErrorIs(t, queue.Release(ctx, id, "worker-a"), repository.ErrLeaseLost)
+
+		job, err := queue.Lease(ctx, "worker-b", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, id, job.ID)
+		assert.Equal(t, 1, job.Attempts)
+		assert.NoError(t, queue.Complete(ctx, id, "worker-b", nil, time.Second))
+	})
+
+	t.Run("HeartbeatExtendsLease", func(t *testing.T) {
+		id := queue.Enqueue(models.Job{OwnerID: 1, Type: "echo"})
+		_, err := queue.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+
+		now = now.Add(50 * time.Second)
+		assert.NoError(t, queue.Heartbeat(ctx, id, "worker-a", time.Minute))
+
+		now = now.Add(50 * time.Second)
+		reclaimed, _ := queue.ReclaimExpired(ctx)
+		assert.Equal(t, int64(0), reclaimed)
+
+		assert.NoError(t, queue.Complete(ctx, id, "worker-a", nil, time.Second))
+	})
+
+	t.Run("GetJobIsScopedToOwner", func(t *testing.T) {
+		id := queue.Enqueue(models.Job{OwnerID: 1, Type: "echo"})
+
+		_, err := queue.GetJob(ctx, 2, id)
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+
+		job, err := queue.GetJob(ctx, 1, id)
+		assert.NoError(t, err)
+		assert.Equal(t, models.JobStatusPending, job.Status)
+	})
+
+	t.Run("RetryWaitsForRunAfter", func(t *testing.T) {
+		queue := newQueue()
+		id := queue.Enqueue(models.Job{OwnerID: 3, Type: "echo"})
+		_, err := queue.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+		assert.NoError(t, queue.Retry(ctx, id, "worker-a", "boom", time.Second, now.Add(time.Minute)))
+
+		_, err = queue.Lease(ctx, "worker-a", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+
+		now = now.Add(2 * time.Minute)
+		job, err := queue.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, id, job.ID)
+		assert.Equal(t, 2, job.Attempts)
+		assert.Nil(t, job.RunAfter)
+		assert.NoError(t, queue.Complete(ctx, id, "worker-a", nil, time.Second))
+	})
+
+	t.Run("ExpiredLeaseOnLastAttemptIsDeadLettered", func(t *testing.T) {
+		queue := newQueue()
+		id := queue.Enqueue(models.Job{OwnerID: 3, Type: "echo", MaxAttempts: 1})
+		_, err := queue.Lease(ctx, "crashed-worker", time.Minute)
+		assert.NoError(t, err)
+
+		now = now.Add(2 * time.Minute)
+		_, err = queue.ReclaimExpired(ctx)
+		assert.NoError(t, err)
+
+		job, _ := queue.GetJob(ctx, 3, id)
+		assert.Equal(t, models.JobStatusDead, job.Status)
+	})
+
+	t.Run("DeadLetterRequeueAndPurge", func(t *testing.T) {
+		queue := newQueue()
+		requeued := queue.Enqueue(models.Job{OwnerID: 4, Type: "echo"})
+		purged := queue.Enqueue(models.Job{OwnerID: 4, Type: "echo"})
+		for _, id := range []int{requeued, purged} {
+			_, err := queue.Lease(ctx, "worker-a", time.Minute)
+			assert.NoError(t, err)
+			assert.NoError(t, queue.DeadLetter(ctx, id, "worker-a", "boom", time.Second))
+		}
+
+		dead, err := queue.ListDead(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, dead, 2)
+
+		assert.NoError(t, queue.Requeue(ctx, requeued))
+		job, _ := queue.GetJob(ctx, 4, requeued)
+		assert.Equal(t, models.JobStatusPending, job.Status)
+		assert.Equal(t, 0, job.Attempts)
+		assert.ErrorIs(t, queue.Requeue(ctx, requeued), repository.ErrJobNotFound)
+
+		assert.NoError(t, queue.PurgeDead(ctx, purged))
+		_, err = queue.GetJob(ctx, 4, purged)
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+
+		count, err := queue.PurgeAllDead(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("DependentWaitsForPrerequisites", func(t *testing.T) {
+		queue := newQueue()
+		parent := queue.Enqueue(models.Job{OwnerID: 5, Type: "echo"})
+		child := queue.Enqueue(models.Job{OwnerID: 5, Type: "echo"})
+		queue.AddDependency(child, parent)
+		queue.AddDependency(child, 99)
+
+		// child, parent başarıyla bitene kadar verilmez
+		job, err := queue.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, parent, job.ID)
+
+		_, err = queue.Lease(ctx, "worker-b", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+
+		assert.NoError(t, queue.Complete(ctx, parent, "worker-a", nil, time.Second))
+		job, err = queue.Lease(ctx, "worker-b", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, child, job.ID)
+	})
+}
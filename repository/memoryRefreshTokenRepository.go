@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"konzek-jun/models"
+)
+
+// MemoryRefreshTokenRepository is an in-process RefreshTokenRepository for
+// tests and local development.
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+	nextID int64
+}
+
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{
+		tokens: make(map[string]models.RefreshToken),
+	}
+}
+
+func (m *MemoryRefreshTokenRepository) Insert(ctx context.Context, token models.RefreshToken) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insert(token), nil
+}
+
+func (m *MemoryRefreshTokenRepository) insert(token models.RefreshToken) int64 {
+	m.nextID++
+	token.ID = m.nextID
+	token.CreatedAt = time.Now()
+	token.RotatedAt, token.RevokedAt = nil, nil
+	m.tokens[token.TokenHash] = token
+	return token.ID
+}
+
+func (m *MemoryRefreshTokenRepository) Rotate(ctx context.Context, hash string, next models.RefreshToken) (models.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	current, ok := m.tokens[hash]
+	if !ok || current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+		return models.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	if current.RotatedAt != nil {
+		m.revokeFamily(current.FamilyID, now)
+		return current, ErrRefreshTokenReused
+	}
+
+	current.RotatedAt = &now
+	m.tokens[hash] = current
+	next.UserID, next.FamilyID = current.UserID, current.FamilyID
+	m.insert(next)
+	return current, nil
+}
+
+func (m *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revokeFamily(familyID, time.Now())
+	return nil
+}
+
+func (m *MemoryRefreshTokenRepository) RevokeUser(ctx context.Context, userID int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	families := []string{}
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil && token.ExpiresAt.After(now) && !slices.Contains(families, token.FamilyID) {
+			families = append(families, token.FamilyID)
+		}
+	}
+	sort.Strings(families)
+	for _, familyID := range families {
+		m.revokeFamily(familyID, now)
+	}
+	return families, nil
+}
+
+func (m *MemoryRefreshTokenRepository) revokeFamily(familyID string, now time.Time) {
+	for hash, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			m.tokens[hash] = token
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/repository/repositorytest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func memoryRepositories(t *testing.T) repositorytest.Repositories {
+	jobs := repository.NewMemoryJobQueue()
+	tasks := repository.NewMemoryTaskRepository()
+	tasks.Jobs = jobs
+	schedules := repository.NewMemoryScheduleRepository()
+	schedules.TaskRepository = tasks
+	users := repository.NewMemoryUserRepository()
+	return repositorytest.Repositories{
+		Tasks:            tasks,
+		Users:            users,
+		UnitOfWork:       repository.NewMemoryUnitOfWork(tasks, users),
+		Jobs:             jobs,
+		Schedules:        schedules,
+		Idempotency:      repository.NewMemoryIdempotencyRepository(),
+		RefreshTokens:    repository.NewMemoryRefreshTokenRepository(),
+		TokenRevocations: repository.NewMemoryTokenRevocationRepository(),
+		APIKeys:          repository.NewMemoryAPIKeyRepository(),
+	}
+}
+
+func TestMemoryTaskRepository(t *testing.T) {
+	repositorytest.RunTaskRepository(t, memoryRepositories)
+}
+
+func TestMemoryUserRepository(t *testing.T) {
+	repositorytest.RunUserRepository(t, memoryRepositories)
+}
+
+func TestMemoryUnitOfWork(t *testing.T) {
+	repositorytest.RunUnitOfWork(t, memoryRepositories)
+}
+
+func TestMemoryJobQueue_Conformance(t *testing.T) {
+	repositorytest.RunJobQueue(t, memoryRepositories)
+}
+
+func TestMemoryScheduleRepository(t *testing.T) {
+	repositorytest.RunScheduleRepository(t, memoryRepositories)
+}
+
+func TestMemoryIdempotencyRepository(t *testing.T) {
+	repositorytest.RunIdempotencyRepository(t, memoryRepositories)
+}
+
+func TestMemoryRefreshTokenRepository(t *testing.T) {
+	repositorytest.RunRefreshTokenRepository(t, memoryRepositories)
+}
+
+func TestMemoryTokenRevocationRepository(t *testing.T) {
+	repositorytest.RunTokenRevocationRepository(t, memoryRepositories)
+}
+
+func TestMemoryAPIKeyRepository(t *testing.T) {
+	repositorytest.RunAPIKeyRepository(t, memoryRepositories)
+}
+
+func TestMemoryTaskRepository_Jobs(t *testing.T) {
+	ctx := context.Background()
+	jobs := repository.NewMemoryJobQueue()
+	tasks := repository.NewMemoryTaskRepository()
+	tasks.Jobs = jobs
+
+	// Job tipi olan task'ler kuyruğa eklenir
+	first, _ := tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "First", Content: "Content", Status: models.TaskStatusTodo, JobType: "noop"})
+	second, _ := tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "Second", Content: "Content", Status: models.TaskStatusTodo, JobType: "noop"})
+	plain, _ := tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "Plain", Content: "Content", Status: models.TaskStatusTodo})
+	assert.NoError(t, tasks.AddDependency(ctx, 1, int(first), int(second)))
+
+	_, err := jobs.GetJob(ctx, 1, int(plain))
+	assert.ErrorIs(t, err, repository.ErrJobNotFound)
+
+	// first, second bitmeden kiralanamaz
+	job, err := jobs.Lease(ctx, "worker", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int(second), job.ID)
+	_, err = jobs.Lease(ctx, "worker", 0)
+	assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+
+	// Bağımlılık kaldırılınca first da kiralanabilir
+	assert.NoError(t, tasks.RemoveDependency(ctx, 1, int(first), int(second)))
+	job, err = jobs.Lease(ctx, "worker", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int(first), job.ID)
+
+	// Silinen task'in job'u da silinir
+	assert.NoError(t, tasks.Delete(ctx, 1, int(first), 0))
+	_, err = jobs.GetJob(ctx, 1, int(first))
+	assert.ErrorIs(t, err, repository.ErrJobNotFound)
+}
+
+func TestMemoryUnitOfWork_Jobs(t *testing.T) {
+	ctx := context.Background()
+	jobs := repository.NewMemoryJobQueue()
+	tasks := repository.NewMemoryTaskRepository()
+	tasks.Jobs = jobs
+	unitOfWork := repository.NewMemoryUnitOfWork(tasks, repository.NewMemoryUserRepository())
+
+	// Geri alınan task'in job'u da kuyruktan çıkar
+	var id int64
+	err := unitOfWork.Do(ctx, func(tx repository.Repositories) error {
+		id, _ = tx.Tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "Lost", Content: "Content", Status: models.TaskStatusTodo, JobType: "noop"})
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+	_, err = jobs.GetJob(ctx, 1, int(id))
+	assert.ErrorIs(t, err, repository.ErrJobNotFound)
+	_, err = jobs.Lease(ctx, "worker", 0)
+	assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+}
+
+func TestMemoryUnitOfWork_RollbackKeepsOtherWrites(t *testing.T) {
+	ctx := context.Background()
+	jobs := repository.NewMemoryJobQueue()
+	tasks := repository.NewMemoryTaskRepository()
+	tasks.Jobs = jobs
+	users := repository.NewMemoryUserRepository()
+	unitOfWork := repository.NewMemoryUnitOfWork(tasks, users)
+
+	shared, _ := tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "Shared", Content: "Content", Status: models.TaskStatusTodo})
+	removed, _ := tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "Removed", Content: "Content", Status: models.TaskStatusTodo, JobType: "noop"})
+	assert.NoError(t, tasks.AddDependency(ctx, 1, int(shared), int(removed)))
+
+	var other int64
+	err := unitOfWork.Do(ctx, func(tx repository.Repositories) error {
+		if _, err := tx.Tasks.Insert(ctx, models.Task{OwnerID: 1, Title: "Lost", Content: "Content", Status: models.TaskStatusTodo}); err != nil {
+			return err
+		}
+		if err := tx.Tasks.Delete(ctx, 1, int(removed), 0); err != nil {
+			return err
+		}
+		if err := tx.Tasks.UpdateStatus(ctx, 1, int(shared), models.TaskStatusInProgress, 0); err != nil {
+			return err
+		}
+
+		// Unit of work dışındaki yazmalar geri alınmaz
+		other, _ = tasks.Insert(ctx, models.Task{OwnerID: 2, Title: "Other", Content: "Content", Status: models.TaskStatusTodo})
+		if _, err := users.InsertUser(ctx, models.User{Name: "Other", Email: "other@example.com", Password: "testpass"}); err != nil {
+			return err
+		}
+		if err := tasks.UpdateStatus(ctx, 1, int(shared), models.TaskStatusDone, 0); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	all, err := tasks.GetAll(ctx, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, all, 2) {
+		// Sonradan yazılan task'in durumu korunur
+		assert.Equal(t, models.TaskStatusDone, all[0].Status)
+		assert.Equal(t, "Removed", all[1].Title)
+	}
+	prerequisites, err := tasks.GetPrerequisites(ctx, 1, int(shared))
+	assert.NoError(t, err)
+	assert.Len(t, prerequisites, 1)
+	_, err = jobs.GetJob(ctx, 1, int(removed))
+	assert.NoError(t, err)
+
+	_, err = tasks.GetByID(ctx, 2, int(other))
+	assert.NoError(t, err)
+	_, err = users.FindByEmail(ctx, "other@example.com")
+	assert.NoError(t, err)
+}
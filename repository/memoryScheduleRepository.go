@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"konzek-jun/models"
+)
+
+// MemoryScheduleRepository is an in-process ScheduleRepository for tests and
+// local development. Tasks created by fired schedules are kept in Tasks.
+type MemoryScheduleRepository struct {
+	mu        sync.Mutex
+	schedules map[int]models.Schedule
+	nextID    int
+	Tasks     []models.Task
+	// TaskRepository also receives the tasks created by fired schedules, so
+	// that they show up in the task listings. Optional.
+	TaskRepository TaskRepository
+}
+
+func NewMemoryScheduleRepository() *MemoryScheduleRepository {
+	return &MemoryScheduleRepository{
+		schedules: make(map[int]models.Schedule),
+	}
+}
+
+func (m *MemoryScheduleRepository) Insert(ctx context.Context, schedule models.Schedule) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	schedule.ID = m.nextID
+	schedule.MaxAttempts = scheduleMaxAttempts(schedule)
+	m.schedules[schedule.ID] = schedule
+	return int64(schedule.ID), nil
+}
+
+func (m *MemoryScheduleRepository) GetAll(ctx context.Context, ownerID int64) ([]models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedules := []models.Schedule{}
+	for _, id := range m.sortedIDs() {
+		if m.schedules[id].OwnerID == ownerID {
+			schedules = append(schedules, m.schedules[id])
+		}
+	}
+	return schedules, nil
+}
+
+func (m *MemoryScheduleRepository) GetByID(ctx context.Context, ownerID int64, id int) (models.Schedule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedule, ok := m.schedules[id]
+	if !ok || schedule.OwnerID != ownerID {
+		return models.Schedule{}, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (m *MemoryScheduleRepository) Delete(ctx context.Context, ownerID int64, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedule, ok := m.schedules[id]
+	if !ok || schedule.OwnerID != ownerID {
+		return ErrScheduleNotFound
+	}
+	delete(m.schedules, id)
+	return nil
+}
+
+func (m *MemoryScheduleRepository) SetPaused(ctx context.Context, ownerID int64, id int, paused bool, nextRunAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	schedule, ok := m.schedules[id]
+	if !ok || schedule.OwnerID != ownerID {
+		return ErrScheduleNotFound
+	}
+	schedule.Paused = paused
+	schedule.NextRunAt = nextRunAt
+	m.schedules[id] = schedule
+	return nil
+}
+
+func (m *MemoryScheduleRepository) FireDue(ctx context.Context, now time.Time, limit int, next NextRunFunc) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fired := 0
+	for _, id := range m.sortedIDs() {
+		if fired == limit {
+			break
+		}
+		schedule := m.schedules[id]
+		if schedule.Paused || schedule.NextRunAt == nil || schedule.NextRunAt.After(now) {
+			continue
+		}
+		task := scheduledTask(schedule)
+		if m.TaskRepository != nil {
+			if _, err := m.TaskRepository.Insert(ctx, task); err != nil {
+				return fired, err
+			}
+		}
+		m.Tasks = append(m.Tasks, task)
+		lastRunAt := now
+		schedule.LastRunAt = &lastRunAt
+		schedule.NextRunAt = next(schedule, now)
+		m.schedules[id] = schedule
+		fired++
+	}
+	return fired, nil
+}
+
+func (m *MemoryScheduleRepository) sortedIDs() []int {
+	ids := make([]int, 0, len(m.schedules))
+	for id := range m.schedules {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,642 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"konzek-jun/models"
+)
+
+// MemoryTaskRepository is an in-process TaskRepository with the same
+// semantics as TaskRepositoryDb: ids count up from 1, tasks of other owners
+// are not found and listings are sorted and paged the same way. Search
+// approximates the Postgres full-text match: every word has to occur in the
+// title or the content and a word prefixed with - must not. It is meant for
+// tests and local development.
+type MemoryTaskRepository struct {
+	mu           sync.Mutex
+	tasks        map[int]models.Task
+	dependencies map[int]map[int]bool
+	nextID       int
+	// Jobs receives the job of every task inserted with a job type, like the
+	// job columns of the tasks table do for JobQueueDb. Optional.
+	Jobs *MemoryJobQueue
+	// Now is used instead of time.Now so tests can move the clock.
+	Now func() time.Time
+}
+
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks:        make(map[int]models.Task),
+		dependencies: make(map[int]map[int]bool),
+		Now:          time.Now,
+	}
+}
+
+func (m *MemoryTaskRepository) Insert(ctx context.Context, task models.Task) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, _ := m.insert(task)
+	return id, nil
+}
+
+// insert adds task and returns a func that removes it again. The id is not
+// given back, like a Postgres sequence does not roll back.
+func (m *MemoryTaskRepository) insert(task models.Task) (int64, func()) {
+	m.nextID++
+	task.Id = m.nextID
+	if m.Jobs != nil && task.JobType != "" {
+		m.Jobs.Enqueue(models.Job{ID: task.Id, OwnerID: task.OwnerID, Type: task.JobType, Payload: task.Payload, MaxAttempts: task.MaxAttempts})
+	}
+
+	// Postgres only returns the taskColumns as well
+	now := m.now()
+	task.JobType, task.Payload, task.MaxAttempts = "", nil, 0
+	task.CreatedAt, task.UpdatedAt = &now, &now
+	task.Version = 1
+	m.tasks[task.Id] = task
+	return int64(task.Id), func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.drop(task.Id)
+	}
+}
+
+func (m *MemoryTaskRepository) InsertMany(ctx context.Context, tasks []models.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		id, err := m.Insert(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *MemoryTaskRepository) GetAll(ctx context.Context, ownerID int64) ([]models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.matching(ownerID, models.TaskFilter{}), nil
+}
+
+func (m *MemoryTaskRepository) Delete(ctx context.Context, ownerID int64, id int, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.delete(ownerID, id, version)
+	return err
+}
+
+// delete removes the task and returns a func that puts it back with the
+// dependencies that are still possible.
+func (m *MemoryTaskRepository) delete(ownerID int64, id int, version int) (func(), error) {
+	task, err := m.owned(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && task.Version != version {
+		return nil, ErrVersionConflict
+	}
+	prerequisites := sortedKeys(m.dependencies[id])
+	var dependents []int
+	for taskID := range m.dependencies {
+		if m.dependencies[taskID][id] {
+			dependents = append(dependents, taskID)
+		}
+	}
+	restoreJob := m.drop(id)
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.tasks[id]; ok {
+			return
+		}
+		m.tasks[id] = task
+		restoreJob()
+		for _, dependsOnID := range prerequisites {
+			m.link(id, dependsOnID)
+		}
+		for _, taskID := range dependents {
+			m.link(taskID, id)
+		}
+	}, nil
+}
+
+func (m *MemoryTaskRepository) GetByID(ctx context.Context, ownerID int64, id int) (models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.owned(ownerID, id)
+}
+
+func (m *MemoryTaskRepository) Update(ctx context.Context, task models.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.update(task.OwnerID, task.Id, task.Version, func(existing *models.Task) {
+		existing.Title = task.Title
+		existing.Content = task.Content
+		existing.Status = task.Status
+	})
+	return err
+}
+
+func (m *MemoryTaskRepository) UpdateStatus(ctx context.Context, ownerID int64, id int, status models.TaskStatus, version int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.update(ownerID, id, version, func(task *models.Task) {
+		task.Status = status
+	})
+	return err
+}
+
+// update applies change to the task, bumps its version and returns a func
+// that puts the previous task back unless it has been written again since.
+func (m *MemoryTaskRepository) update(ownerID int64, id int, version int, change func(task *models.Task)) (func(), error) {
+	previous, err := m.owned(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && previous.Version != version {
+		return nil, ErrVersionConflict
+	}
+	now := m.now()
+	task := previous
+	change(&task)
+	task.UpdatedAt = &now
+	task.Version++
+	m.tasks[id] = task
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if current, ok := m.tasks[id]; ok && current.Version == task.Version {
+			m.tasks[id] = previous
+		}
+	}, nil
+}
+
+// FindTasks returns the tasks of ownerID that match filter, ordered by
+// filter.Sort and then by id, and paged by filter.Offset and filter.Limit or
+// by the keyset cursor in filter.After or filter.Before.
+func (m *MemoryTaskRepository) FindTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) ([]models.Task, error) {
+	sorts := filter.Sort
+	backward := filter.Before != nil
+	cursor := filter.After
+	if backward {
+		cursor = filter.Before
+	}
+	if cursor != nil {
+		if len(sorts) > 1 {
+			return nil, ErrKeysetSort
+		}
+		if len(sorts) == 0 {
+			sorts = []models.TaskSort{{Field: "id"}}
+		}
+	}
+	for _, s := range sorts {
+		if !SortableTaskField(s.Field) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSortField, s.Field)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tasks := m.matching(ownerID, filter)
+	if cursor != nil {
+		// id is the second key, in the direction of the sort field
+		field := sorts[0].Field
+		desc := sorts[0].Desc != backward
+		position, err := cursorTask(field, *cursor)
+		if err != nil {
+			return nil, err
+		}
+		var after []models.Task
+		for _, task := range tasks {
+			if c := compareKeyset(task, position, field); (c > 0 && !desc) || (c < 0 && desc) {
+				after = append(after, task)
+			}
+		}
+		tasks = after
+		sort.Slice(tasks, func(i, j int) bool {
+			c := compareKeyset(tasks[i], tasks[j], field)
+			return (c < 0) != desc
+		})
+	} else {
+		sort.Slice(tasks, func(i, j int) bool { return lessTasks(tasks[i], tasks[j], sorts) })
+	}
+
+	if filter.Offset >= len(tasks) {
+		tasks = nil
+	} else {
+		tasks = tasks[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(tasks) {
+		tasks = tasks[:filter.Limit]
+	}
+	if backward {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+	return tasks, nil
+}
+
+func (m *MemoryTaskRepository) CountTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return int64(len(m.matching(ownerID, filter))), nil
+}
+
+func (m *MemoryTaskRepository) AddDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.addDependency(ownerID, taskID, dependsOnID)
+	return err
+}
+
+// addDependency returns a func that removes the dependency again if it was
+// added.
+func (m *MemoryTaskRepository) addDependency(ownerID int64, taskID, dependsOnID int) (func(), error) {
+	if taskID == dependsOnID {
+		return nil, fmt.Errorf("task %d cannot depend on itself", taskID)
+	}
+	// Like in Postgres, a dependency on a task of another user is silently skipped
+	if _, err := m.owned(ownerID, taskID); err != nil {
+		return func() {}, nil
+	}
+	if _, err := m.owned(ownerID, dependsOnID); err != nil {
+		return func() {}, nil
+	}
+	if !m.link(taskID, dependsOnID) {
+		return func() {}, nil
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.unlink(taskID, dependsOnID)
+	}, nil
+}
+
+func (m *MemoryTaskRepository) RemoveDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.removeDependency(ownerID, taskID, dependsOnID)
+	return err
+}
+
+// removeDependency returns a func that adds the dependency back while both
+// tasks exist.
+func (m *MemoryTaskRepository) removeDependency(ownerID int64, taskID, dependsOnID int) (func(), error) {
+	if _, err := m.owned(ownerID, taskID); err != nil || !m.dependencies[taskID][dependsOnID] {
+		return nil, ErrDependencyNotFound
+	}
+	m.unlink(taskID, dependsOnID)
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.link(taskID, dependsOnID)
+	}, nil
+}
+
+func (m *MemoryTaskRepository) GetDependencies(ctx context.Context, ownerID int64) ([]models.TaskDependency, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var dependencies []models.TaskDependency
+	for _, task := range m.matching(ownerID, models.TaskFilter{}) {
+		for _, dependsOnID := range sortedKeys(m.dependencies[task.Id]) {
+			dependencies = append(dependencies, models.TaskDependency{TaskID: task.Id, DependsOnID: dependsOnID})
+		}
+	}
+	return dependencies, nil
+}
+
+func (m *MemoryTaskRepository) GetPrerequisites(ctx context.Context, ownerID int64, id int) ([]models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tasks []models.Task
+	for _, dependsOnID := range sortedKeys(m.dependencies[id]) {
+		if task, err := m.owned(ownerID, dependsOnID); err == nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+func (m *MemoryTaskRepository) GetDependents(ctx context.Context, ownerID int64, id int) ([]models.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tasks []models.Task
+	for _, task := range m.matching(ownerID, models.TaskFilter{}) {
+		if m.dependencies[task.Id][id] {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// LockDependencies does nothing: MemoryUnitOfWork already runs units of work
+// one at a time.
+func (m *MemoryTaskRepository) LockDependencies(ctx context.Context, ownerID int64) error {
+	return nil
+}
+
+// memoryTaskTx is the MemoryTaskRepository a MemoryUnitOfWork hands to its
+// function. Every write adds the func that undoes it to undo.
+type memoryTaskTx struct {
+	*MemoryTaskRepository
+	undo *memoryUndoLog
+}
+
+func (t memoryTaskTx) Insert(ctx context.Context, task models.Task) (int64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id, undo := t.insert(task)
+	t.undo.add(undo)
+	return id, nil
+}
+
+func (t memoryTaskTx) InsertMany(ctx context.Context, tasks []models.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		id, err := t.Insert(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (t memoryTaskTx) Delete(ctx context.Context, ownerID int64, id int, version int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.undo.record(t.delete(ownerID, id, version))
+}
+
+func (t memoryTaskTx) Update(ctx context.Context, task models.Task) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.undo.record(t.update(task.OwnerID, task.Id, task.Version, func(existing *models.Task) {
+		existing.Title = task.Title
+		existing.Content = task.Content
+		existing.Status = task.Status
+	}))
+}
+
+func (t memoryTaskTx) UpdateStatus(ctx context.Context, ownerID int64, id int, status models.TaskStatus, version int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.undo.record(t.update(ownerID, id, version, func(task *models.Task) {
+		task.Status = status
+	}))
+}
+
+func (t memoryTaskTx) AddDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.undo.record(t.addDependency(ownerID, taskID, dependsOnID))
+}
+
+func (t memoryTaskTx) RemoveDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.undo.record(t.removeDependency(ownerID, taskID, dependsOnID))
+}
+
+func (m *MemoryTaskRepository) owned(ownerID int64, id int) (models.Task, error) {
+	task, ok := m.tasks[id]
+	if !ok || task.OwnerID != ownerID {
+		return models.Task{}, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// matching returns the tasks of ownerID that match the where part of
+// filter, ordered by id.
+func (m *MemoryTaskRepository) matching(ownerID int64, filter models.TaskFilter) []models.Task {
+	ids := make([]int, 0, len(m.tasks))
+	for id := range m.tasks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var tasks []models.Task
+	for _, id := range ids {
+		task := m.tasks[id]
+		if task.OwnerID == ownerID && matchesFilter(task, filter) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// link adds the dependency if both tasks exist and reports whether it was
+// new.
+func (m *MemoryTaskRepository) link(taskID, dependsOnID int) bool {
+	_, taskFound := m.tasks[taskID]
+	_, dependsOnFound := m.tasks[dependsOnID]
+	if !taskFound || !dependsOnFound || m.dependencies[taskID][dependsOnID] {
+		return false
+	}
+	if m.dependencies[taskID] == nil {
+		m.dependencies[taskID] = make(map[int]bool)
+	}
+	m.dependencies[taskID][dependsOnID] = true
+	if m.Jobs != nil {
+		m.Jobs.AddDependency(taskID, dependsOnID)
+	}
+	return true
+}
+
+func (m *MemoryTaskRepository) unlink(taskID, dependsOnID int) {
+	delete(m.dependencies[taskID], dependsOnID)
+	if m.Jobs != nil {
+		m.Jobs.removeDependency(taskID, dependsOnID)
+	}
+}
+
+// drop removes the task with its dependencies and job and returns a func
+// that puts the job back.
+func (m *MemoryTaskRepository) drop(id int) func() {
+	for _, dependsOnID := range sortedKeys(m.dependencies[id]) {
+		m.unlink(id, dependsOnID)
+	}
+	for taskID, prerequisites := range m.dependencies {
+		if prerequisites[id] {
+			m.unlink(taskID, id)
+		}
+	}
+	delete(m.tasks, id)
+	delete(m.dependencies, id)
+	if m.Jobs == nil {
+		return func() {}
+	}
+	return m.Jobs.remove(id)
+}
+
+// now has the precision of a Postgres timestamp.
+func (m *MemoryTaskRepository) now() time.Time {
+	return m.Now().UTC().Truncate(time.Microsecond)
+}
+
+func matchesFilter(task models.Task, filter models.TaskFilter) bool {
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, status := range filter.Statuses {
+			found = found || task.Status == status
+		}
+		if !found {
+			return false
+		}
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" && !matchesSearch(task, search) {
+		return false
+	}
+	return inRange(task.CreatedAt, filter.CreatedFrom, filter.CreatedTo) &&
+		inRange(task.UpdatedAt, filter.UpdatedFrom, filter.UpdatedTo)
+}
+
+func matchesSearch(task models.Task, search string) bool {
+	words := make(map[string]bool)
+	for _, word := range searchWords(task.Title + " " + task.Content) {
+		words[word] = true
+	}
+	for _, term := range strings.Fields(search) {
+		negated := strings.HasPrefix(term, "-")
+		for _, word := range searchWords(term) {
+			if words[word] == negated {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// searchWords splits s into lower case words the way the simple text search
+// configuration does.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func inRange(t, from, to *time.Time) bool {
+	if t == nil {
+		return from == nil && to == nil
+	}
+	return (from == nil || !t.Before(*from)) && (to == nil || !t.After(*to))
+}
+
+// lessTasks orders by sorts and then by id unless id is one of them.
+func lessTasks(a, b models.Task, sorts []models.TaskSort) bool {
+	for _, s := range sorts {
+		if c := compareTaskField(a, b, s.Field); c != 0 {
+			return (c < 0) != s.Desc
+		}
+	}
+	return a.Id < b.Id
+}
+
+// compareKeyset compares (field, id) as a row, like the keyset condition.
+func compareKeyset(a, b models.Task, field string) int {
+	if c := compareTaskField(a, b, field); c != 0 {
+		return c
+	}
+	return compareInts(a.Id, b.Id)
+}
+
+func compareTaskField(a, b models.Task, field string) int {
+	switch field {
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "status":
+		return strings.Compare(string(a.Status), string(b.Status))
+	case "created_at":
+		return compareTimes(a.CreatedAt, b.CreatedAt)
+	case "updated_at":
+		return compareTimes(a.UpdatedAt, b.UpdatedAt)
+	default:
+		return compareInts(a.Id, b.Id)
+	}
+}
+
+// cursorTask returns a task at the position of cursor, so that it can be
+// compared with compareKeyset.
+func cursorTask(field string, cursor models.TaskCursor) (models.Task, error) {
+	task := models.Task{Id: cursor.ID}
+	switch field {
+	case "title":
+		task.Title = cursor.Value
+	case "status":
+		task.Status = models.TaskStatus(cursor.Value)
+	case "created_at", "updated_at":
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return models.Task{}, fmt.Errorf("invalid cursor value %q: %w", cursor.Value, err)
+		}
+		task.CreatedAt, task.UpdatedAt = &t, &t
+	default:
+		id, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return models.Task{}, fmt.Errorf("invalid cursor value %q: %w", cursor.Value, err)
+		}
+		task.Id = id
+	}
+	return task, nil
+}
+
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil || b == nil:
+		return 0
+	case a.Before(*b):
+		return -1
+	case a.After(*b):
+		return 1
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(set map[int]bool) []int {
+	keys := make([]int, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
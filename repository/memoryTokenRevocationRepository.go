@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"konzek-jun/models"
+)
+
+// MemoryTokenRevocationRepository is an in-process TokenRevocationRepository
+// for tests and local development.
+type MemoryTokenRevocationRepository struct {
+	mu          sync.Mutex
+	revocations map[string]models.TokenRevocation
+}
+
+func NewMemoryTokenRevocationRepository() *MemoryTokenRevocationRepository {
+	return &MemoryTokenRevocationRepository{
+		revocations: make(map[string]models.TokenRevocation),
+	}
+}
+
+func (m *MemoryTokenRevocationRepository) Revoke(ctx context.Context, revocations []models.TokenRevocation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, revocation := range revocations {
+		if stored, ok := m.revocations[revocation.TokenID]; ok && stored.ExpiresAt.After(revocation.ExpiresAt) {
+			revocation.ExpiresAt = stored.ExpiresAt
+		}
+		m.revocations[revocation.TokenID] = revocation
+	}
+	return nil
+}
+
+func (m *MemoryTokenRevocationRepository) ListActive(ctx context.Context, now time.Time) ([]models.TokenRevocation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revocations := []models.TokenRevocation{}
+	for _, revocation := range m.revocations {
+		if revocation.ExpiresAt.After(now) {
+			revocations = append(revocations, revocation)
+		}
+	}
+	sort.Slice(revocations, func(i, j int) bool { return revocations[i].TokenID < revocations[j].TokenID })
+	return revocations, nil
+}
+
+func (m *MemoryTokenRevocationRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for id, revocation := range m.revocations {
+		if !revocation.ExpiresAt.After(now) {
+			delete(m.revocations, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
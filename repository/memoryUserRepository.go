@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+
+	"konzek-jun/models"
+)
+
+// MemoryUserRepository is an in-process UserRepository with the same
+// semantics as the Postgres one: ids count up from 1, emails are unique and
+// passwords are stored hashed. It is meant for tests and local development.
+type MemoryUserRepository struct {
+	mu     sync.Mutex
+	users  map[int64]models.User
+	nextID int64
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: make(map[int64]models.User),
+	}
+}
+
+func (m *MemoryUserRepository) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, _, err := m.insertUser(user)
+	return user, err
+}
+
+// insertUser returns a func that removes the user again.
+func (m *MemoryUserRepository) insertUser(user models.User) (models.User, func(), error) {
+	if m.emailTaken(user.Email, 0) {
+		return models.User{}, nil, ErrEmailTaken
+	}
+	m.nextID++
+	user.ID = m.nextID
+	user.Password = hashAndSalt([]byte(user.Password))
+	user.Role = defaultRole(user.Role)
+	m.users[user.ID] = user
+	return user, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.users, user.ID)
+	}, nil
+}
+
+func (m *MemoryUserRepository) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, _, err := m.updateUser(user)
+	return user, err
+}
+
+func (m *MemoryUserRepository) updateUser(user models.User) (models.User, func(), error) {
+	existing, ok := m.users[user.ID]
+	if !ok {
+		return models.User{}, nil, ErrUserNotFound
+	}
+	if m.emailTaken(user.Email, user.ID) {
+		return models.User{}, nil, ErrEmailTaken
+	}
+	if user.Password != "" {
+		user.Password = hashAndSalt([]byte(user.Password))
+	} else {
+		user.Password = existing.Password
+	}
+	user.Role = existing.Role
+	return user, m.put(existing, user), nil
+}
+
+func (m *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrUserNotFound
+}
+
+func (m *MemoryUserRepository) FindByUserID(ctx context.Context, userID string) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return models.User{}, ErrUserNotFound
+	}
+	user, ok := m.users[id]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (m *MemoryUserRepository) ListUsers(ctx context.Context) ([]models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	users := make([]models.User, 0, len(m.users))
+	for _, user := range m.users {
+		user.Password = ""
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (m *MemoryUserRepository) SetRole(ctx context.Context, userID int64, role models.Role) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, _, err := m.setRole(userID, role)
+	return user, err
+}
+
+func (m *MemoryUserRepository) setRole(userID int64, role models.Role) (models.User, func(), error) {
+	existing, ok := m.users[userID]
+	if !ok {
+		return models.User{}, nil, ErrUserNotFound
+	}
+	user := existing
+	user.Role = role
+	undo := m.put(existing, user)
+	user.Password = ""
+	return user, undo, nil
+}
+
+// put replaces previous with user and returns a func that puts previous
+// back unless the user has been written again since.
+func (m *MemoryUserRepository) put(previous, user models.User) func() {
+	m.users[user.ID] = user
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if current, ok := m.users[user.ID]; ok && current == user {
+			m.users[user.ID] = previous
+		}
+	}
+}
+
+// memoryUserTx is the MemoryUserRepository a MemoryUnitOfWork hands to its
+// function. Every write adds the func that undoes it to undo.
+type memoryUserTx struct {
+	*MemoryUserRepository
+	undo *memoryUndoLog
+}
+
+func (t memoryUserTx) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	user, undo, err := t.insertUser(user)
+	return user, t.undo.record(undo, err)
+}
+
+func (t memoryUserTx) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	user, undo, err := t.updateUser(user)
+	return user, t.undo.record(undo, err)
+}
+
+func (t memoryUserTx) SetRole(ctx context.Context, userID int64, role models.Role) (models.User, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	user, undo, err := t.setRole(userID, role)
+	return user, t.undo.record(undo, err)
+}
+
+// emailTaken reports whether a user other than exceptID has email.
+func (m *MemoryUserRepository) emailTaken(email string, exceptID int64) bool {
+	for id, user := range m.users {
+		if id != exceptID && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+)
+
+var (
+	// ErrRefreshTokenNotFound is returned for unknown, expired and revoked
+	// refresh tokens.
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrRefreshTokenReused is returned when a token that was already rotated
+	// is presented again. The whole token family is revoked by then.
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+)
+
+//go:generate mockgen -destination=../mocks//repository/mockRefreshtokenrepository.go -package=repository konzek-jun/repository RefreshTokenRepository
+type RefreshTokenRepository interface {
+	Insert(ctx context.Context, token models.RefreshToken) (int64, error)
+	// Rotate marks the token with hash as used and stores next in its family
+	// for the same user. It returns the rotated token. A token that was
+	// already rotated revokes its family and is returned with
+	// ErrRefreshTokenReused, so that the family's sessions can be revoked too.
+	Rotate(ctx context.Context, hash string, next models.RefreshToken) (models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeUser revokes every unexpired token of userID and returns the
+	// families they belonged to.
+	RevokeUser(ctx context.Context, userID int64) ([]string, error)
+}
+
+type RefreshTokenRepositoryDb struct {
+	DB *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepositoryDb {
+	return &RefreshTokenRepositoryDb{DB: db}
+}
+
+func (r *RefreshTokenRepositoryDb) Insert(ctx context.Context, token models.RefreshToken) (int64, error) {
+	return insertRefreshToken(ctx, r.DB, token)
+}
+
+func insertRefreshToken(ctx context.Context, db DBTX, token models.RefreshToken) (int64, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)
+		RETURNING id`, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).Scan(&id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting refresh token: %v", err))
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *RefreshTokenRepositoryDb) Rotate(ctx context.Context, hash string, next models.RefreshToken) (models.RefreshToken, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	defer tx.Rollback()
+
+	// Concurrent requests with the same token are handled one after the other
+	var current models.RefreshToken
+	var expired bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, family_id, token_hash, expires_at, created_at, rotated_at, revoked_at, expires_at <= now()
+		FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`, hash).
+		Scan(&current.ID, &current.UserID, &current.FamilyID, &current.TokenHash, &current.ExpiresAt,
+			&current.CreatedAt, &current.RotatedAt, &current.RevokedAt, &expired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reading refresh token: %v", err))
+		return models.RefreshToken{}, err
+	}
+	if current.RevokedAt != nil || expired {
+		return models.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+
+	if current.RotatedAt != nil {
+		_, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL", current.FamilyID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while revoking refresh token family: %v", err))
+			return models.RefreshToken{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return models.RefreshToken{}, err
+		}
+		loggerx.Info(fmt.Sprintf("Refresh token reused, revoked token family of user %d", current.UserID))
+		return current, ErrRefreshTokenReused
+	}
+
+	if err := tx.QueryRowContext(ctx, "UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1 RETURNING rotated_at", current.ID).Scan(&current.RotatedAt); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while rotating refresh token: %v", err))
+		return models.RefreshToken{}, err
+	}
+	next.UserID, next.FamilyID = current.UserID, current.FamilyID
+	if _, err := insertRefreshToken(ctx, tx, next); err != nil {
+		return models.RefreshToken{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.RefreshToken{}, err
+	}
+	return current, nil
+}
+
+func (r *RefreshTokenRepositoryDb) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL", familyID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking refresh token family: %v", err))
+	}
+	return err
+}
+
+func (r *RefreshTokenRepositoryDb) RevokeUser(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		RETURNING family_id`, userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking refresh tokens of user: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	families := []string{}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var familyID string
+		if err := rows.Scan(&familyID); err != nil {
+			return nil, err
+		}
+		if !seen[familyID] {
+			seen[familyID] = true
+			families = append(families, familyID)
+		}
+	}
+	return families, rows.Err()
+}
@@ -0,0 +1,1255 @@
+// Package repositorytest is a conformance suite for the repository
+// implementations. Every implementation runs the same
+// checks, so the in-memory repositories can stand in for Postgres.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Repositories are the implementations under test. All of them have to share
+// one store, since tasks belong to users.
+type Repositories struct {
+	Tasks      repository.TaskRepository
+	Users      repository.UserRepository
+	UnitOfWork repository.UnitOfWork
+	// Jobs is only used by RunJobQueue. It has to lease the jobs of the
+	// tasks inserted into Tasks.
+	Jobs repository.JobQueue
+	// Schedules is only used by RunScheduleRepository. The tasks of fired
+	// schedules have to show up in Tasks.
+	Schedules repository.ScheduleRepository
+	// Idempotency is only used by RunIdempotencyRepository.
+	Idempotency repository.IdempotencyRepository
+	// RefreshTokens is only used by RunRefreshTokenRepository.
+	RefreshTokens repository.RefreshTokenRepository
+	// TokenRevocations is only used by RunTokenRevocationRepository.
+	TokenRevocations repository.TokenRevocationRepository
+	// APIKeys is only used by RunAPIKeyRepository.
+	APIKeys repository.APIKeyRepository
+}
+
+// Factory returns empty repositories. It is called once per subtest.
+type Factory func(t *testing.T) Repositories
+
+// RunUnitOfWork checks that a unit of work commits all of its writes or
+// none of them.
+func RunUnitOfWork(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("Commit", func(t *testing.T) {
+		repos := newRepositories(t)
+		var taskID int64
+		err := repos.UnitOfWork.Do(ctx, func(tx repository.Repositories) error {
+			user, err := tx.Users.InsertUser(ctx, models.User{Name: "Committed", Email: "committed@example.com", Password: "testpass"})
+			if err != nil {
+				return err
+			}
+			taskID, err = tx.Tasks.Insert(ctx, models.Task{OwnerID: user.ID, Title: "Committed", Content: "Content", Status: models.TaskStatusTodo})
+			return err
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		user, err := repos.Users.FindByEmail(ctx, "committed@example.com")
+		assert.NoError(t, err)
+		task, err := repos.Tasks.GetByID(ctx, user.ID, int(taskID))
+		assert.NoError(t, err)
+		assert.Equal(t, "Committed", task.Title)
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		kept, err := repos.Tasks.Insert(ctx, models.Task{OwnerID: owner.ID, Title: "Kept", Content: "Content", Status: models.TaskStatusTodo})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		failure := errors.New("second step failed")
+		err = repos.UnitOfWork.Do(ctx, func(tx repository.Repositories) error {
+			if _, err := tx.Users.InsertUser(ctx, models.User{Name: "Lost", Email: "lost@example.com", Password: "testpass"}); err != nil {
+				return err
+			}
+			if _, err := tx.Tasks.Insert(ctx, models.Task{OwnerID: owner.ID, Title: "Lost", Content: "Content", Status: models.TaskStatusTodo}); err != nil {
+				return err
+			}
+			if err := tx.Tasks.UpdateStatus(ctx, owner.ID, int(kept), models.TaskStatusDone, 0); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		// Hiçbir adımın etkisi kalmaz
+		_, err = repos.Users.FindByEmail(ctx, "lost@example.com")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		all, err := repos.Tasks.GetAll(ctx, owner.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, all, 1) {
+			assert.Equal(t, "Kept", all[0].Title)
+			assert.Equal(t, models.TaskStatusTodo, all[0].Status)
+		}
+	})
+
+	t.Run("LockDependencies", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// İkinci unit of work, ilki bitene kadar kilidi alamaz
+		locked := make(chan struct{})
+		var firstDone int32
+		first := make(chan error, 1)
+		go func() {
+			first <- repos.UnitOfWork.Do(ctx, func(tx repository.Repositories) error {
+				if err := tx.Tasks.LockDependencies(ctx, owner.ID); err != nil {
+					return err
+				}
+				close(locked)
+				time.Sleep(50 * time.Millisecond)
+				atomic.StoreInt32(&firstDone, 1)
+				return nil
+			})
+		}()
+		select {
+		case <-locked:
+		case err := <-first:
+			t.Fatalf("Kilit alınamadı: %v", err)
+		}
+		var sawFirstDone bool
+		err = repos.UnitOfWork.Do(ctx, func(tx repository.Repositories) error {
+			if err := tx.Tasks.LockDependencies(ctx, owner.ID); err != nil {
+				return err
+			}
+			sawFirstDone = atomic.LoadInt32(&firstDone) == 1
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, <-first)
+		assert.True(t, sawFirstDone)
+	})
+}
+
+// RunUserRepository checks the UserRepository semantics.
+func RunUserRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("InsertAssignsIncreasingIDs", func(t *testing.T) {
+		users := newRepositories(t).Users
+		first, err := users.InsertUser(ctx, models.User{Name: "First", Email: "first@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		second, err := users.InsertUser(ctx, models.User{Name: "Second", Email: "second@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Greater(t, first.ID, int64(0))
+		assert.Greater(t, second.ID, first.ID)
+		// Şifre açık haliyle saklanmaz
+		assert.NotEqual(t, "testpass", first.Password)
+	})
+
+	t.Run("UniqueEmail", func(t *testing.T) {
+		users := newRepositories(t).Users
+		_, err := users.InsertUser(ctx, models.User{Name: "Taken", Email: "taken@example.com", Password: "testpass"})
+		assert.NoError(t, err)
+		_, err = users.InsertUser(ctx, models.User{Name: "Again", Email: "taken@example.com", Password: "testpass"})
+		assert.ErrorIs(t, err, repository.ErrEmailTaken)
+
+		other, err := users.InsertUser(ctx, models.User{Name: "Other", Email: "other@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		other.Email = "taken@example.com"
+		other.Password = ""
+		_, err = users.UpdateUser(ctx, other)
+		assert.ErrorIs(t, err, repository.ErrEmailTaken)
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		users := newRepositories(t).Users
+		inserted, err := users.InsertUser(ctx, models.User{Name: "Find Me", Email: "find@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		byEmail, err := users.FindByEmail(ctx, "find@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, inserted, byEmail)
+
+		byID, err := users.FindByUserID(ctx, strconv.FormatInt(inserted.ID, 10))
+		assert.NoError(t, err)
+		assert.Equal(t, inserted, byID)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		users := newRepositories(t).Users
+		_, err := users.FindByEmail(ctx, "nobody@example.com")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		_, err = users.FindByUserID(ctx, "4242")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		_, err = users.FindByUserID(ctx, "not-a-number")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		_, err = users.UpdateUser(ctx, models.User{ID: 4242, Name: "Nobody", Email: "nobody@example.com", Password: "testpass"})
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("UpdateKeepsPassword", func(t *testing.T) {
+		users := newRepositories(t).Users
+		inserted, err := users.InsertUser(ctx, models.User{Name: "Before", Email: "before@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Boş şifre mevcut şifreyi korur
+		updated, err := users.UpdateUser(ctx, models.User{ID: inserted.ID, Name: "After", Email: "after@example.com"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, inserted.Password, updated.Password)
+
+		found, err := users.FindByEmail(ctx, "after@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "After", found.Name)
+		assert.Equal(t, inserted.Password, found.Password)
+	})
+
+	t.Run("Roles", func(t *testing.T) {
+		users := newRepositories(t).Users
+		member, err := users.InsertUser(ctx, models.User{Name: "Member", Email: "member@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		viewer, err := users.InsertUser(ctx, models.User{Name: "Viewer", Email: "viewer@example.com", Password: "testpass", Role: models.RoleViewer})
+		if !assert.NoError(t, err) {
+			return
+		}
+		// Rol verilmeyen kullanıcı üyedir
+		assert.Equal(t, models.RoleMember, member.Role)
+
+		admin, err := users.SetRole(ctx, member.ID, models.RoleAdmin)
+		assert.NoError(t, err)
+		assert.Equal(t, models.User{ID: member.ID, Name: "Member", Email: "member@example.com", Role: models.RoleAdmin}, admin)
+		_, err = users.SetRole(ctx, 4242, models.RoleAdmin)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+		// Kullanıcı güncellemesi rolü değiştirmez
+		_, err = users.UpdateUser(ctx, models.User{ID: member.ID, Name: "Renamed", Email: "member@example.com"})
+		assert.NoError(t, err)
+		found, err := users.FindByUserID(ctx, strconv.FormatInt(member.ID, 10))
+		assert.NoError(t, err)
+		assert.Equal(t, models.RoleAdmin, found.Role)
+
+		all, err := users.ListUsers(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.User{
+			{ID: member.ID, Name: "Renamed", Email: "member@example.com", Role: models.RoleAdmin},
+			{ID: viewer.ID, Name: "Viewer", Email: "viewer@example.com", Role: models.RoleViewer},
+		}, all)
+	})
+}
+
+// RunTaskRepository checks the TaskRepository semantics: ownership,
+// not-found errors, filtering, sort order, offset and keyset paging and
+// dependencies.
+func RunTaskRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	// setup returns the repository and two users, each with their own tasks
+	setup := func(t *testing.T) (repository.TaskRepository, int64, int64) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if err != nil {
+			t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
+		}
+		other, err := repos.Users.InsertUser(ctx, models.User{Name: "Other", Email: "other@example.com", Password: "testpass"})
+		if err != nil {
+			t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
+		}
+		return repos.Tasks, owner.ID, other.ID
+	}
+	insert := func(t *testing.T, tasks repository.TaskRepository, task models.Task) int {
+		id, err := tasks.Insert(ctx, task)
+		if err != nil {
+			t.Fatalf("Task eklenirken hata oluştu: %v", err)
+		}
+		return int(id)
+	}
+
+	t.Run("InsertAndGet", func(t *testing.T) {
+		tasks, ownerID, otherID := setup(t)
+		first := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "First", Content: "Content", Status: models.TaskStatusTodo})
+		second := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Second", Content: "Content", Status: models.TaskStatusDone})
+		assert.Greater(t, first, 0)
+		assert.Greater(t, second, first)
+
+		task, err := tasks.GetByID(ctx, ownerID, first)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "First", task.Title)
+		assert.Equal(t, ownerID, task.OwnerID)
+		assert.Equal(t, models.TaskStatusTodo, task.Status)
+		assert.NotNil(t, task.CreatedAt)
+		assert.NotNil(t, task.UpdatedAt)
+
+		// Başka kullanıcının task'i bulunamaz
+		_, err = tasks.GetByID(ctx, otherID, first)
+		assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+
+		all, err := tasks.GetAll(ctx, ownerID)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{first, second}, ids(all))
+
+		none, err := tasks.GetAll(ctx, otherID)
+		assert.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("InsertMany", func(t *testing.T) {
+		tasks, ownerID, _ := setup(t)
+		batch := []models.Task{
+			{OwnerID: ownerID, Title: "First", Content: "Content", Status: models.TaskStatusTodo},
+			{OwnerID: ownerID, Title: "Second", Content: "Content", Status: models.TaskStatusDone},
+			{OwnerID: ownerID, Title: "Third", Content: "Content", Status: models.TaskStatusInProgress},
+		}
+		created, err := tasks.InsertMany(ctx, batch)
+		if !assert.NoError(t, err) || !assert.Len(t, created, 3) {
+			return
+		}
+
+		// id'ler verilen sırayla döner
+		for i, id := range created {
+			task, err := tasks.GetByID(ctx, ownerID, int(id))
+			assert.NoError(t, err)
+			assert.Equal(t, batch[i].Title, task.Title)
+			assert.Equal(t, batch[i].Status, task.Status)
+			assert.Equal(t, 1, task.Version)
+		}
+
+		none, err := tasks.InsertMany(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		tasks, ownerID, otherID := setup(t)
+		id := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Old", Content: "Old content", Status: models.TaskStatusTodo})
+
+		err := tasks.Update(ctx, models.Task{Id: id, OwnerID: ownerID, Title: "New", Content: "New content", Status: models.TaskStatusInProgress})
+		assert.NoError(t, err)
+		assert.NoError(t, tasks.UpdateStatus(ctx, ownerID, id, models.TaskStatusDone, 0))
+		task, err := tasks.GetByID(ctx, ownerID, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "New", task.Title)
+		assert.Equal(t, "New content", task.Content)
+		assert.Equal(t, models.TaskStatusDone, task.Status)
+
+		assert.ErrorIs(t, tasks.Update(ctx, models.Task{Id: id, OwnerID: otherID, Title: "Stolen", Content: "Stolen", Status: models.TaskStatusTodo}), repository.ErrTaskNotFound)
+		assert.ErrorIs(t, tasks.UpdateStatus(ctx, otherID, id, models.TaskStatusTodo, 0), repository.ErrTaskNotFound)
+		assert.ErrorIs(t, tasks.Delete(ctx, otherID, id, 0), repository.ErrTaskNotFound)
+
+		assert.NoError(t, tasks.Delete(ctx, ownerID, id, 0))
+		_, err = tasks.GetByID(ctx, ownerID, id)
+		assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+		assert.ErrorIs(t, tasks.Delete(ctx, ownerID, id, 0), repository.ErrTaskNotFound)
+		assert.ErrorIs(t, tasks.Update(ctx, models.Task{Id: id, OwnerID: ownerID, Title: "Gone", Content: "Gone", Status: models.TaskStatusTodo}), repository.ErrTaskNotFound)
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		tasks, ownerID, otherID := setup(t)
+		id := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Old", Content: "Old content", Status: models.TaskStatusTodo})
+		task, err := tasks.GetByID(ctx, ownerID, id)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 1, task.Version)
+
+		// Her yazma sürümü bir artırır
+		assert.NoError(t, tasks.Update(ctx, models.Task{Id: id, OwnerID: ownerID, Title: "New", Content: "New content", Status: models.TaskStatusTodo, Version: 1}))
+		assert.NoError(t, tasks.UpdateStatus(ctx, ownerID, id, models.TaskStatusInProgress, 2))
+		task, err = tasks.GetByID(ctx, ownerID, id)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, task.Version)
+
+		// Eski sürümle yazılamaz, task değişmez
+		err = tasks.Update(ctx, models.Task{Id: id, OwnerID: ownerID, Title: "Stale", Content: "Stale", Status: models.TaskStatusTodo, Version: 1})
+		assert.ErrorIs(t, err, repository.ErrVersionConflict)
+		assert.ErrorIs(t, tasks.Delete(ctx, ownerID, id, 2), repository.ErrVersionConflict)
+		assert.ErrorIs(t, tasks.UpdateStatus(ctx, ownerID, id, models.TaskStatusBlocked, 2), repository.ErrVersionConflict)
+		task, err = tasks.GetByID(ctx, ownerID, id)
+		assert.NoError(t, err)
+		assert.Equal(t, "New", task.Title)
+		assert.Equal(t, models.TaskStatusInProgress, task.Status)
+		assert.Equal(t, 3, task.Version)
+
+		// Başka kullanıcının task'i için sürüm çakışması değil, bulunamadı döner
+		assert.ErrorIs(t, tasks.Delete(ctx, otherID, id, 3), repository.ErrTaskNotFound)
+		err = tasks.Update(ctx, models.Task{Id: id, OwnerID: otherID, Title: "Stolen", Content: "Stolen", Status: models.TaskStatusTodo, Version: 1})
+		assert.ErrorIs(t, err, repository.ErrTaskNotFound)
+		assert.ErrorIs(t, tasks.UpdateStatus(ctx, otherID, id, models.TaskStatusTodo, 1), repository.ErrTaskNotFound)
+
+		assert.NoError(t, tasks.Delete(ctx, ownerID, id, 3))
+		assert.ErrorIs(t, tasks.Delete(ctx, ownerID, id, 3), repository.ErrTaskNotFound)
+	})
+
+	t.Run("FindTasks", func(t *testing.T) {
+		tasks, ownerID, otherID := setup(t)
+		report := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Quarterly report", Content: "Collect the sales numbers", Status: models.TaskStatusTodo})
+		lunch := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Team lunch", Content: "Book a table", Status: models.TaskStatusDone})
+		review := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Code review", Content: "Review the sales dashboard", Status: models.TaskStatusInProgress})
+		insert(t, tasks, models.Task{OwnerID: otherID, Title: "Sales call", Content: "Other user's sales", Status: models.TaskStatusTodo})
+
+		filter := models.TaskFilter{Statuses: []models.TaskStatus{models.TaskStatusTodo, models.TaskStatusInProgress}}
+		found, err := tasks.FindTasks(ctx, ownerID, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{report, review}, ids(found))
+
+		filter = models.TaskFilter{Search: "sales"}
+		found, err = tasks.FindTasks(ctx, ownerID, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{report, review}, ids(found))
+		total, err := tasks.CountTasks(ctx, ownerID, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+
+		filter = models.TaskFilter{Search: "sales -dashboard"}
+		found, err = tasks.FindTasks(ctx, ownerID, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{report}, ids(found))
+
+		future := time.Now().Add(time.Hour)
+		found, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{CreatedFrom: &future})
+		assert.NoError(t, err)
+		assert.Empty(t, found)
+		found, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{UpdatedTo: &future})
+		assert.NoError(t, err)
+		assert.Len(t, found, 3)
+
+		found, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: []models.TaskSort{{Field: "title", Desc: true}}})
+		assert.NoError(t, err)
+		assert.Equal(t, []int{lunch, report, review}, ids(found))
+
+		_, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: []models.TaskSort{{Field: "title; DROP TABLE tasks"}}})
+		assert.ErrorIs(t, err, repository.ErrUnknownSortField)
+	})
+
+	t.Run("OffsetPaging", func(t *testing.T) {
+		tasks, ownerID, _ := setup(t)
+		var all []int
+		for i := 0; i < 5; i++ {
+			// Aynı status'teki task'ler id sırasıyla döner
+			all = append(all, insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Task " + strconv.Itoa(i), Content: "Content", Status: models.TaskStatusTodo}))
+		}
+
+		sort := []models.TaskSort{{Field: "status"}}
+		page, err := tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: sort, Offset: 2, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, all[2:4], ids(page))
+
+		page, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: sort, Offset: 4, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, all[4:], ids(page))
+
+		page, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: sort, Offset: 10, Limit: 2})
+		assert.NoError(t, err)
+		assert.Empty(t, page)
+
+		total, err := tasks.CountTasks(ctx, ownerID, models.TaskFilter{Offset: 4, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), total)
+	})
+
+	t.Run("KeysetPaging", func(t *testing.T) {
+		tasks, ownerID, _ := setup(t)
+		for _, title := range []string{"delta", "alpha", "echo", "charlie", "bravo"} {
+			insert(t, tasks, models.Task{OwnerID: ownerID, Title: title, Content: "Content", Status: models.TaskStatusTodo})
+		}
+		sort := []models.TaskSort{{Field: "title"}}
+		all, err := tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: sort})
+		if !assert.NoError(t, err) || !assert.Len(t, all, 5) {
+			return
+		}
+		assert.Equal(t, []string{"alpha", "bravo", "charlie", "delta", "echo"}, titles(all))
+
+		after := &models.TaskCursor{Value: all[0].Title, ID: all[0].Id}
+		page, err := tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: sort, After: after, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, ids(all[1:3]), ids(page))
+
+		// Geriye doğru okunan sayfa da aynı sırayla döner
+		before := &models.TaskCursor{Value: all[3].Title, ID: all[3].Id}
+		page, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: sort, Before: before, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, ids(all[1:3]), ids(page))
+
+		desc := []models.TaskSort{{Field: "title", Desc: true}}
+		after = &models.TaskCursor{Value: all[2].Title, ID: all[2].Id}
+		page, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: desc, After: after})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bravo", "alpha"}, titles(page))
+
+		byID := &models.TaskCursor{Value: strconv.Itoa(all[0].Id), ID: all[0].Id}
+		page, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{After: byID, Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, page, 1)
+		assert.Greater(t, page[0].Id, all[0].Id)
+
+		_, err = tasks.FindTasks(ctx, ownerID, models.TaskFilter{Sort: append(sort, models.TaskSort{Field: "status"}), After: after})
+		assert.ErrorIs(t, err, repository.ErrKeysetSort)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		tasks, ownerID, otherID := setup(t)
+		design := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Design", Content: "Content", Status: models.TaskStatusDone})
+		build := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Build", Content: "Content", Status: models.TaskStatusTodo})
+		ship := insert(t, tasks, models.Task{OwnerID: ownerID, Title: "Ship", Content: "Content", Status: models.TaskStatusTodo})
+		foreign := insert(t, tasks, models.Task{OwnerID: otherID, Title: "Foreign", Content: "Content", Status: models.TaskStatusTodo})
+
+		assert.NoError(t, tasks.AddDependency(ctx, ownerID, build, design))
+		assert.NoError(t, tasks.AddDependency(ctx, ownerID, ship, build))
+		assert.NoError(t, tasks.AddDependency(ctx, ownerID, ship, design))
+		// Aynı bağımlılığı tekrar eklemek bir şey değiştirmez
+		assert.NoError(t, tasks.AddDependency(ctx, ownerID, ship, design))
+		// Başka kullanıcının task'ine bağımlılık eklenmez
+		assert.NoError(t, tasks.AddDependency(ctx, ownerID, ship, foreign))
+
+		edges, err := tasks.GetDependencies(ctx, ownerID)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.TaskDependency{
+			{TaskID: build, DependsOnID: design},
+			{TaskID: ship, DependsOnID: design},
+			{TaskID: ship, DependsOnID: build},
+		}, edges)
+
+		prerequisites, err := tasks.GetPrerequisites(ctx, ownerID, ship)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{design, build}, ids(prerequisites))
+		dependents, err := tasks.GetDependents(ctx, ownerID, design)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{build, ship}, ids(dependents))
+
+		assert.ErrorIs(t, tasks.RemoveDependency(ctx, otherID, ship, build), repository.ErrDependencyNotFound)
+		assert.NoError(t, tasks.RemoveDependency(ctx, ownerID, ship, build))
+		assert.ErrorIs(t, tasks.RemoveDependency(ctx, ownerID, ship, build), repository.ErrDependencyNotFound)
+
+		// Silinen task'in bağımlılıkları da silinir
+		assert.NoError(t, tasks.Delete(ctx, ownerID, design, 0))
+		edges, err = tasks.GetDependencies(ctx, ownerID)
+		assert.NoError(t, err)
+		assert.Empty(t, edges)
+	})
+
+}
+
+func ids(tasks []models.Task) []int {
+	result := []int{}
+	for _, task := range tasks {
+		result = append(result, task.Id)
+	}
+	return result
+}
+
+func titles(tasks []models.Task) []string {
+	result := []string{}
+	for _, task := range tasks {
+		result = append(result, task.Title)
+	}
+	return result
+}
+
+// RunIdempotencyRepository checks that a key is claimed by one request only
+// until it expires, and that the stored response is returned to the others.
+func RunIdempotencyRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("ClaimCompleteReplay", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		record := models.IdempotencyRecord{UserID: owner.ID, Key: "create-1", Fingerprint: "first", ExpiresAt: time.Now().Add(time.Minute)}
+
+		_, claimed, err := repos.Idempotency.Claim(ctx, record)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		// İlk istek bitmeden gelen istek devam eden kaydı görür
+		stored, claimed, err := repos.Idempotency.Claim(ctx, models.IdempotencyRecord{UserID: owner.ID, Key: "create-1", Fingerprint: "second", ExpiresAt: time.Now().Add(time.Minute)})
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+		assert.Equal(t, "first", stored.Fingerprint)
+		assert.Equal(t, 0, stored.Status)
+
+		response := models.IdempotencyRecord{
+			Status:    201,
+			Headers:   map[string]string{"Content-Type": "application/json"},
+			Body:      []byte(`{"id":1}`),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		assert.NoError(t, repos.Idempotency.Complete(ctx, record, response))
+
+		stored, claimed, err = repos.Idempotency.Claim(ctx, models.IdempotencyRecord{UserID: owner.ID, Key: "create-1", Fingerprint: "first", ExpiresAt: time.Now().Add(time.Minute)})
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+		assert.Equal(t, 201, stored.Status)
+		assert.Equal(t, "application/json", stored.Headers["Content-Type"])
+		assert.Equal(t, `{"id":1}`, string(stored.Body))
+
+		// Tamamlanmış kayıt bırakılamaz
+		assert.NoError(t, repos.Idempotency.Release(ctx, record))
+		_, claimed, err = repos.Idempotency.Claim(ctx, record)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+	})
+
+	t.Run("KeysArePerUser", func(t *testing.T) {
+		repos := newRepositories(t)
+		first, err := repos.Users.InsertUser(ctx, models.User{Name: "First", Email: "first@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		second, err := repos.Users.InsertUser(ctx, models.User{Name: "Second", Email: "second@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for _, userID := range []int64{first.ID, second.ID} {
+			_, claimed, err := repos.Idempotency.Claim(ctx, models.IdempotencyRecord{UserID: userID, Key: "shared", Fingerprint: "f", ExpiresAt: time.Now().Add(time.Minute)})
+			assert.NoError(t, err)
+			assert.True(t, claimed)
+		}
+	})
+
+	t.Run("StaleClaim", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Kilit süresi dolan isteğin anahtarını başka bir istek alır
+		stale := models.IdempotencyRecord{UserID: owner.ID, Key: "stale", Fingerprint: "old", ExpiresAt: time.Now().Add(-time.Second)}
+		_, claimed, err := repos.Idempotency.Claim(ctx, stale)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		current := models.IdempotencyRecord{UserID: owner.ID, Key: "stale", Fingerprint: "new", ExpiresAt: time.Now().Add(time.Minute)}
+		_, claimed, err = repos.Idempotency.Claim(ctx, current)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		// Eski istek yeni isteğin kaydını bırakamaz ya da tamamlayamaz
+		assert.NoError(t, repos.Idempotency.Release(ctx, stale))
+		assert.NoError(t, repos.Idempotency.Complete(ctx, stale, models.IdempotencyRecord{Status: 201, ExpiresAt: time.Now().Add(time.Hour)}))
+		sameFingerprint := current
+		sameFingerprint.ExpiresAt = current.ExpiresAt.Add(-time.Second)
+		assert.NoError(t, repos.Idempotency.Release(ctx, sameFingerprint))
+		stored, claimed, err := repos.Idempotency.Claim(ctx, current)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+		assert.Equal(t, "new", stored.Fingerprint)
+		assert.Equal(t, 0, stored.Status)
+
+		assert.NoError(t, repos.Idempotency.Complete(ctx, current, models.IdempotencyRecord{Status: 201, ExpiresAt: time.Now().Add(time.Hour)}))
+		stored, _, err = repos.Idempotency.Claim(ctx, current)
+		assert.NoError(t, err)
+		assert.Equal(t, 201, stored.Status)
+	})
+
+	t.Run("ReleaseAndExpiry", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Bırakılan anahtar tekrar alınabilir
+		record := models.IdempotencyRecord{UserID: owner.ID, Key: "released", Fingerprint: "f", ExpiresAt: time.Now().Add(time.Minute)}
+		_, claimed, err := repos.Idempotency.Claim(ctx, record)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		assert.NoError(t, repos.Idempotency.Release(ctx, record))
+		_, claimed, err = repos.Idempotency.Claim(ctx, record)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		// Süresi dolan yanıt yeni bir istekle değiştirilebilir
+		expired := models.IdempotencyRecord{UserID: owner.ID, Key: "expired", Fingerprint: "old", ExpiresAt: time.Now().Add(time.Minute)}
+		_, claimed, err = repos.Idempotency.Claim(ctx, expired)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		assert.NoError(t, repos.Idempotency.Complete(ctx, expired, models.IdempotencyRecord{Status: 201, ExpiresAt: time.Now().Add(-time.Second)}))
+		stored, claimed, err := repos.Idempotency.Claim(ctx, models.IdempotencyRecord{UserID: owner.ID, Key: "expired", Fingerprint: "new", ExpiresAt: time.Now().Add(time.Minute)})
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, "new", stored.Fingerprint)
+
+		// Süresi dolan kayıtlar silinir
+		deleted, err := repos.Idempotency.DeleteExpired(ctx, time.Now().Add(2*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+		deleted, err = repos.Idempotency.DeleteExpired(ctx, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), deleted)
+	})
+}
+
+// RunRefreshTokenRepository checks that refresh tokens are rotated once and
+// that a reused token revokes its family.
+func RunRefreshTokenRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("RotateAndReuse", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		expires := time.Now().Add(time.Hour)
+		_, err = repos.RefreshTokens.Insert(ctx, models.RefreshToken{UserID: owner.ID, FamilyID: "family", TokenHash: "first", ExpiresAt: expires})
+		assert.NoError(t, err)
+
+		rotated, err := repos.RefreshTokens.Rotate(ctx, "first", models.RefreshToken{TokenHash: "second", ExpiresAt: expires})
+		assert.NoError(t, err)
+		assert.Equal(t, owner.ID, rotated.UserID)
+		assert.Equal(t, "family", rotated.FamilyID)
+		assert.NotNil(t, rotated.RotatedAt)
+
+		// Yeni token aynı aileye eklenir ve bir kez daha döndürülebilir
+		rotated, err = repos.RefreshTokens.Rotate(ctx, "second", models.RefreshToken{TokenHash: "third", ExpiresAt: expires})
+		assert.NoError(t, err)
+		assert.Equal(t, owner.ID, rotated.UserID)
+
+		// Kullanılmış token tekrar gelince bütün aile iptal edilir
+		reused, err := repos.RefreshTokens.Rotate(ctx, "first", models.RefreshToken{TokenHash: "stolen", ExpiresAt: expires})
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenReused)
+		assert.Equal(t, owner.ID, reused.UserID)
+		assert.Equal(t, "family", reused.FamilyID)
+		_, err = repos.RefreshTokens.Rotate(ctx, "third", models.RefreshToken{TokenHash: "fourth", ExpiresAt: expires})
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenNotFound)
+		_, err = repos.RefreshTokens.Rotate(ctx, "stolen", models.RefreshToken{TokenHash: "fifth", ExpiresAt: expires})
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenNotFound)
+	})
+
+	t.Run("UnknownExpiredAndRevoked", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		next := models.RefreshToken{TokenHash: "next", ExpiresAt: time.Now().Add(time.Hour)}
+
+		_, err = repos.RefreshTokens.Rotate(ctx, "unknown", next)
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenNotFound)
+
+		_, err = repos.RefreshTokens.Insert(ctx, models.RefreshToken{UserID: owner.ID, FamilyID: "expired", TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+		assert.NoError(t, err)
+		_, err = repos.RefreshTokens.Rotate(ctx, "expired", next)
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenNotFound)
+
+		_, err = repos.RefreshTokens.Insert(ctx, models.RefreshToken{UserID: owner.ID, FamilyID: "revoked", TokenHash: "revoked", ExpiresAt: time.Now().Add(time.Hour)})
+		assert.NoError(t, err)
+		assert.NoError(t, repos.RefreshTokens.RevokeFamily(ctx, "revoked"))
+		_, err = repos.RefreshTokens.Rotate(ctx, "revoked", next)
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenNotFound)
+	})
+
+	t.Run("RevokeUser", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		other, err := repos.Users.InsertUser(ctx, models.User{Name: "Other", Email: "other@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		expires := time.Now().Add(time.Hour)
+		for _, token := range []models.RefreshToken{
+			{UserID: owner.ID, FamilyID: "laptop", TokenHash: "laptop-1", ExpiresAt: expires},
+			{UserID: owner.ID, FamilyID: "phone", TokenHash: "phone-1", ExpiresAt: expires},
+			{UserID: owner.ID, FamilyID: "old", TokenHash: "old-1", ExpiresAt: time.Now().Add(-time.Second)},
+			{UserID: other.ID, FamilyID: "other", TokenHash: "other-1", ExpiresAt: expires},
+		} {
+			_, err := repos.RefreshTokens.Insert(ctx, token)
+			assert.NoError(t, err)
+		}
+		_, err = repos.RefreshTokens.Rotate(ctx, "laptop-1", models.RefreshToken{TokenHash: "laptop-2", ExpiresAt: expires})
+		assert.NoError(t, err)
+
+		// Süresi dolmamış bütün aileler bir kez döner
+		families, err := repos.RefreshTokens.RevokeUser(ctx, owner.ID)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"laptop", "phone"}, families)
+		_, err = repos.RefreshTokens.Rotate(ctx, "laptop-2", models.RefreshToken{TokenHash: "laptop-3", ExpiresAt: expires})
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenNotFound)
+
+		families, err = repos.RefreshTokens.RevokeUser(ctx, owner.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, families)
+
+		// Başka kullanıcının token'ları etkilenmez
+		_, err = repos.RefreshTokens.Rotate(ctx, "other-1", models.RefreshToken{TokenHash: "other-2", ExpiresAt: expires})
+		assert.NoError(t, err)
+	})
+}
+
+// RunTokenRevocationRepository checks that revocations are listed until they
+// expire.
+func RunTokenRevocationRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("RevokeListAndExpire", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		now := time.Now()
+		err = repos.TokenRevocations.Revoke(ctx, []models.TokenRevocation{
+			{TokenID: "jti-1", UserID: owner.ID, ExpiresAt: now.Add(time.Minute)},
+			{TokenID: "sid-1", UserID: owner.ID, ExpiresAt: now.Add(time.Hour)},
+		})
+		assert.NoError(t, err)
+
+		// Tekrar iptal edilen token daha geç olan süreyi korur
+		assert.NoError(t, repos.TokenRevocations.Revoke(ctx, []models.TokenRevocation{{TokenID: "sid-1", UserID: owner.ID, ExpiresAt: now.Add(time.Minute)}}))
+
+		active, err := repos.TokenRevocations.ListActive(ctx, now)
+		assert.NoError(t, err)
+		if assert.Len(t, active, 2) {
+			assert.Equal(t, "jti-1", active[0].TokenID)
+			assert.Equal(t, owner.ID, active[0].UserID)
+			assert.Equal(t, "sid-1", active[1].TokenID)
+		}
+		active, err = repos.TokenRevocations.ListActive(ctx, now.Add(10*time.Minute))
+		assert.NoError(t, err)
+		if assert.Len(t, active, 1) {
+			assert.Equal(t, "sid-1", active[0].TokenID)
+		}
+
+		deleted, err := repos.TokenRevocations.DeleteExpired(ctx, now.Add(10*time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
+
+// RunAPIKeyRepository checks that keys are found by prefix and listed until
+// they are revoked.
+func RunAPIKeyRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("InsertFindAndList", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		other, err := repos.Users.InsertUser(ctx, models.User{Name: "Other", Email: "other@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		expires := time.Now().Add(time.Hour).Truncate(time.Second)
+		ci, err := repos.APIKeys.Insert(ctx, models.APIKey{UserID: owner.ID, Name: "CI", Prefix: "ci", SecretHash: "ci-hash", Scopes: []string{"tasks:read", "tasks:write"}, ExpiresAt: &expires})
+		assert.NoError(t, err)
+		assert.NotZero(t, ci.ID)
+		assert.False(t, ci.CreatedAt.IsZero())
+		_, err = repos.APIKeys.Insert(ctx, models.APIKey{UserID: owner.ID, Name: "Cron", Prefix: "cron", SecretHash: "cron-hash", Scopes: []string{"tasks:read"}})
+		assert.NoError(t, err)
+		_, err = repos.APIKeys.Insert(ctx, models.APIKey{UserID: other.ID, Name: "Other", Prefix: "other", SecretHash: "other-hash", Scopes: []string{"tasks:read"}})
+		assert.NoError(t, err)
+
+		found, err := repos.APIKeys.FindByPrefix(ctx, "ci")
+		if assert.NoError(t, err) {
+			assert.Equal(t, ci.ID, found.ID)
+			assert.Equal(t, owner.ID, found.UserID)
+			assert.Equal(t, "ci-hash", found.SecretHash)
+			assert.Equal(t, []string{"tasks:read", "tasks:write"}, found.Scopes)
+			if assert.NotNil(t, found.ExpiresAt) {
+				assert.True(t, expires.Equal(*found.ExpiresAt))
+			}
+			assert.Nil(t, found.LastUsedAt)
+		}
+		_, err = repos.APIKeys.FindByPrefix(ctx, "unknown")
+		assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+
+		// Her kullanıcı yalnızca kendi anahtarlarını görür
+		keys, err := repos.APIKeys.List(ctx, owner.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, keys, 2) {
+			assert.Equal(t, "CI", keys[0].Name)
+			assert.Equal(t, "Cron", keys[1].Name)
+		}
+	})
+
+	t.Run("RevokeAndMarkUsed", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		key, err := repos.APIKeys.Insert(ctx, models.APIKey{UserID: owner.ID, Name: "CI", Prefix: "ci", SecretHash: "ci-hash", Scopes: []string{"tasks:read"}})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		used := time.Now().Truncate(time.Second)
+		assert.NoError(t, repos.APIKeys.MarkUsed(ctx, key.ID, used))
+		// Daha eski bir kullanım son kullanımı geri almaz
+		assert.NoError(t, repos.APIKeys.MarkUsed(ctx, key.ID, used.Add(-time.Minute)))
+		found, err := repos.APIKeys.FindByPrefix(ctx, "ci")
+		if assert.NoError(t, err) && assert.NotNil(t, found.LastUsedAt) {
+			assert.True(t, used.Equal(*found.LastUsedAt))
+		}
+
+		// Başka kullanıcının anahtarı iptal edilemez
+		assert.ErrorIs(t, repos.APIKeys.Revoke(ctx, owner.ID+1, key.ID), repository.ErrAPIKeyNotFound)
+		assert.NoError(t, repos.APIKeys.Revoke(ctx, owner.ID, key.ID))
+		assert.ErrorIs(t, repos.APIKeys.Revoke(ctx, owner.ID, key.ID), repository.ErrAPIKeyNotFound)
+
+		// İptal edilen anahtar listelenmez ama prefix ile bulunur
+		keys, err := repos.APIKeys.List(ctx, owner.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+		found, err = repos.APIKeys.FindByPrefix(ctx, "ci")
+		if assert.NoError(t, err) {
+			assert.NotNil(t, found.RevokedAt)
+		}
+	})
+}
+
+// RunJobQueue checks that a job is leased by one worker at a time and moves
+// through retries, the dead-letter state and requeues.
+func RunJobQueue(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (Repositories, int64) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if err != nil {
+			t.Fatalf("Kullanıcı eklenirken hata oluştu: %v", err)
+		}
+		return repos, owner.ID
+	}
+	insertJob := func(t *testing.T, repos Repositories, ownerID int64, maxAttempts int) int {
+		id, err := repos.Tasks.Insert(ctx, models.Task{OwnerID: ownerID, Title: "Job", Content: "Content", Status: models.TaskStatusTodo,
+			JobType: "echo", Payload: []byte(`{"n": 1}`), MaxAttempts: maxAttempts})
+		if err != nil {
+			t.Fatalf("Task eklenirken hata oluştu: %v", err)
+		}
+		return int(id)
+	}
+
+	t.Run("LeaseCompleteAndRetry", func(t *testing.T) {
+		repos, ownerID := setup(t)
+		plain, err := repos.Tasks.Insert(ctx, models.Task{OwnerID: ownerID, Title: "Plain", Content: "Content", Status: models.TaskStatusTodo})
+		assert.NoError(t, err)
+		id := insertJob(t, repos, ownerID, 0)
+
+		job, err := repos.Jobs.Lease(ctx, "worker-a", time.Minute)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, id, job.ID)
+		assert.Equal(t, ownerID, job.OwnerID)
+		assert.Equal(t, "echo", job.Type)
+		assert.JSONEq(t, `{"n": 1}`, string(job.Payload))
+		assert.Equal(t, models.JobStatusRunning, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Equal(t, models.DefaultJobMaxAttempts, job.MaxAttempts)
+		assert.NotNil(t, job.StartedAt)
+
+		// Kiralanan job başka bir worker'a verilmez
+		_, err = repos.Jobs.Lease(ctx, "worker-b", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+		assert.NoError(t, repos.Jobs.Heartbeat(ctx, id, "worker-a", time.Minute))
+		assert.ErrorIs(t, repos.Jobs.Heartbeat(ctx, id, "worker-b", time.Minute), repository.ErrLeaseLost)
+
+		// Tekrar denenen job runAfter gelmeden kiralanmaz
+		assert.NoError(t, repos.Jobs.Retry(ctx, id, "worker-a", "boom", time.Second, time.Now().Add(time.Hour)))
+		_, err = repos.Jobs.Lease(ctx, "worker-b", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+		job, err = repos.Jobs.GetJob(ctx, ownerID, id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.JobStatusPending, job.Status)
+			assert.Equal(t, "boom", job.LastError)
+			assert.Equal(t, int64(1000), job.DurationMs)
+			assert.NotNil(t, job.RunAfter)
+		}
+
+		other := insertJob(t, repos, ownerID, 0)
+		job, err = repos.Jobs.Lease(ctx, "worker-b", time.Minute)
+		if assert.NoError(t, err) {
+			assert.Equal(t, other, job.ID)
+		}
+		assert.NoError(t, repos.Jobs.Complete(ctx, other, "worker-b", []byte(`{"ok": true}`), time.Second))
+		assert.ErrorIs(t, repos.Jobs.Complete(ctx, other, "worker-b", nil, time.Second), repository.ErrLeaseLost)
+		job, err = repos.Jobs.GetJob(ctx, ownerID, other)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.JobStatusSucceeded, job.Status)
+			assert.JSONEq(t, `{"ok": true}`, string(job.Result))
+			assert.NotNil(t, job.FinishedAt)
+		}
+
+		// Job'u olmayan ya da başka kullanıcının task'i bulunamaz
+		_, err = repos.Jobs.GetJob(ctx, ownerID, int(plain))
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+		_, err = repos.Jobs.GetJob(ctx, ownerID+1, id)
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+	})
+
+	t.Run("ReleaseAndReclaim", func(t *testing.T) {
+		repos, ownerID := setup(t)
+		id := insertJob(t, repos, ownerID, 2)
+
+		// Bırakılan job denemesini harcamaz
+		_, err := repos.Jobs.Lease(ctx, "worker-a", time.Minute)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, repos.Jobs.Release(ctx, id, "worker-b"), repository.ErrLeaseLost)
+		assert.NoError(t, repos.Jobs.Release(ctx, id, "worker-a"))
+		job, err := repos.Jobs.GetJob(ctx, ownerID, id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.JobStatusPending, job.Status)
+			assert.Equal(t, 0, job.Attempts)
+		}
+
+		// Süresi dolan kira geri alınır, eski worker sonucu yazamaz
+		_, err = repos.Jobs.Lease(ctx, "crashed-worker", -time.Minute)
+		assert.NoError(t, err)
+		reclaimed, err := repos.Jobs.ReclaimExpired(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), reclaimed)
+		assert.ErrorIs(t, repos.Jobs.Complete(ctx, id, "crashed-worker", nil, time.Second), repository.ErrLeaseLost)
+
+		// Son denemesi de süresi dolan job ölür
+		job, err = repos.Jobs.Lease(ctx, "worker-b", -time.Minute)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, job.Attempts)
+		}
+		reclaimed, err = repos.Jobs.ReclaimExpired(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), reclaimed)
+		job, err = repos.Jobs.GetJob(ctx, ownerID, id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.JobStatusDead, job.Status)
+			assert.Equal(t, "lease expired", job.LastError)
+		}
+	})
+
+	t.Run("DeadLetterRequeueAndPurge", func(t *testing.T) {
+		repos, ownerID := setup(t)
+		other, err := repos.Users.InsertUser(ctx, models.User{Name: "Other", Email: "other@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		first := insertJob(t, repos, ownerID, 0)
+		second := insertJob(t, repos, other.ID, 0)
+		for _, id := range []int{first, second} {
+			_, err := repos.Jobs.Lease(ctx, "worker", time.Minute)
+			assert.NoError(t, err)
+			assert.NoError(t, repos.Jobs.DeadLetter(ctx, id, "worker", "boom", time.Second))
+		}
+		running := insertJob(t, repos, ownerID, 0)
+
+		// Dead-letter işlemleri admin içindir, bütün kullanıcıların job'larını kapsar
+		dead, err := repos.Jobs.ListDead(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, dead, 2)
+		job, err := repos.Jobs.GetDead(ctx, second)
+		if assert.NoError(t, err) {
+			assert.Equal(t, other.ID, job.OwnerID)
+			assert.Equal(t, "boom", job.LastError)
+		}
+		_, err = repos.Jobs.GetDead(ctx, running)
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+
+		// Yeniden kuyruğa alınan job'un denemeleri sıfırlanır
+		assert.NoError(t, repos.Jobs.Requeue(ctx, first))
+		assert.ErrorIs(t, repos.Jobs.Requeue(ctx, first), repository.ErrJobNotFound)
+		job, err = repos.Jobs.Lease(ctx, "worker", time.Minute)
+		if assert.NoError(t, err) {
+			assert.Equal(t, first, job.ID)
+			assert.Equal(t, 1, job.Attempts)
+		}
+		assert.NoError(t, repos.Jobs.DeadLetter(ctx, first, "worker", "boom", time.Second))
+
+		assert.ErrorIs(t, repos.Jobs.PurgeDead(ctx, running), repository.ErrJobNotFound)
+		assert.NoError(t, repos.Jobs.PurgeDead(ctx, first))
+		_, err = repos.Jobs.GetJob(ctx, ownerID, first)
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+		purged, err := repos.Jobs.PurgeAllDead(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+		_, err = repos.Jobs.GetJob(ctx, other.ID, second)
+		assert.ErrorIs(t, err, repository.ErrJobNotFound)
+		_, err = repos.Jobs.GetJob(ctx, ownerID, running)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		repos, ownerID := setup(t)
+		first := insertJob(t, repos, ownerID, 0)
+		second := insertJob(t, repos, ownerID, 0)
+		assert.NoError(t, repos.Tasks.AddDependency(ctx, ownerID, first, second))
+
+		// first, second başarıyla bitmeden kiralanmaz
+		job, err := repos.Jobs.Lease(ctx, "worker", time.Minute)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, second, job.ID)
+		_, err = repos.Jobs.Lease(ctx, "worker", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+
+		assert.NoError(t, repos.Jobs.Complete(ctx, second, "worker", nil, time.Second))
+		job, err = repos.Jobs.Lease(ctx, "worker", time.Minute)
+		if assert.NoError(t, err) {
+			assert.Equal(t, first, job.ID)
+		}
+	})
+
+	t.Run("RolledBackTaskHasNoJob", func(t *testing.T) {
+		repos, ownerID := setup(t)
+		failure := errors.New("rollback")
+		err := repos.UnitOfWork.Do(ctx, func(tx repository.Repositories) error {
+			if _, err := tx.Tasks.Insert(ctx, models.Task{OwnerID: ownerID, Title: "Lost", Content: "Content", Status: models.TaskStatusTodo, JobType: "echo"}); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		_, err = repos.Jobs.Lease(ctx, "worker", time.Minute)
+		assert.ErrorIs(t, err, repository.ErrNoPendingJob)
+	})
+}
+
+// RunScheduleRepository checks that schedules are kept per user and that a
+// due schedule fires once.
+func RunScheduleRepository(t *testing.T, newRepositories Factory) {
+	ctx := context.Background()
+
+	t.Run("InsertPauseAndDelete", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		next := time.Now().Add(time.Hour).Truncate(time.Second)
+		cronID, err := repos.Schedules.Insert(ctx, models.Schedule{OwnerID: owner.ID, Name: "Cron", Cron: "*/5 * * * *", Title: "Report", Content: "Content",
+			JobType: "echo", Payload: []byte(`{"n": 1}`), NextRunAt: &next})
+		assert.NoError(t, err)
+		intervalID, err := repos.Schedules.Insert(ctx, models.Schedule{OwnerID: owner.ID, Name: "Interval", Interval: "15m", Title: "Sync", Content: "Content",
+			MaxAttempts: 5, NextRunAt: &next})
+		assert.NoError(t, err)
+
+		schedule, err := repos.Schedules.GetByID(ctx, owner.ID, int(cronID))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Cron", schedule.Name)
+			assert.Equal(t, "*/5 * * * *", schedule.Cron)
+			assert.Empty(t, schedule.Interval)
+			assert.Equal(t, "echo", schedule.JobType)
+			assert.JSONEq(t, `{"n": 1}`, string(schedule.Payload))
+			assert.Equal(t, models.DefaultJobMaxAttempts, schedule.MaxAttempts)
+			assert.False(t, schedule.Paused)
+			if assert.NotNil(t, schedule.NextRunAt) {
+				assert.True(t, next.Equal(*schedule.NextRunAt))
+			}
+			assert.Nil(t, schedule.LastRunAt)
+		}
+		schedules, err := repos.Schedules.GetAll(ctx, owner.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, schedules, 2) {
+			assert.Equal(t, int(intervalID), schedules[1].ID)
+			assert.NotEmpty(t, schedules[1].Interval)
+			assert.Equal(t, 5, schedules[1].MaxAttempts)
+		}
+
+		// Başka kullanıcı schedule'ları göremez
+		schedules, err = repos.Schedules.GetAll(ctx, owner.ID+1)
+		assert.NoError(t, err)
+		assert.Empty(t, schedules)
+		_, err = repos.Schedules.GetByID(ctx, owner.ID+1, int(cronID))
+		assert.ErrorIs(t, err, repository.ErrScheduleNotFound)
+
+		assert.NoError(t, repos.Schedules.SetPaused(ctx, owner.ID, int(cronID), true, nil))
+		assert.ErrorIs(t, repos.Schedules.SetPaused(ctx, owner.ID+1, int(cronID), false, &next), repository.ErrScheduleNotFound)
+		schedule, err = repos.Schedules.GetByID(ctx, owner.ID, int(cronID))
+		if assert.NoError(t, err) {
+			assert.True(t, schedule.Paused)
+			assert.Nil(t, schedule.NextRunAt)
+		}
+
+		assert.ErrorIs(t, repos.Schedules.Delete(ctx, owner.ID+1, int(cronID)), repository.ErrScheduleNotFound)
+		assert.NoError(t, repos.Schedules.Delete(ctx, owner.ID, int(cronID)))
+		_, err = repos.Schedules.GetByID(ctx, owner.ID, int(cronID))
+		assert.ErrorIs(t, err, repository.ErrScheduleNotFound)
+	})
+
+	t.Run("FireDue", func(t *testing.T) {
+		repos := newRepositories(t)
+		owner, err := repos.Users.InsertUser(ctx, models.User{Name: "Owner", Email: "owner@example.com", Password: "testpass"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		now := time.Now().Truncate(time.Second)
+		past, future := now.Add(-time.Minute), now.Add(time.Hour)
+		for _, schedule := range []models.Schedule{
+			{Name: "Due", Title: "Due", NextRunAt: &past},
+			{Name: "Later", Title: "Later", NextRunAt: &future},
+			{Name: "Paused", Title: "Paused", Paused: true, NextRunAt: &past},
+			{Name: "Done", Title: "Done"},
+		} {
+			schedule.OwnerID, schedule.Interval, schedule.Content = owner.ID, "1h", "Content"
+			_, err := repos.Schedules.Insert(ctx, schedule)
+			assert.NoError(t, err)
+		}
+
+		nextRun := func(schedule models.Schedule, after time.Time) *time.Time {
+			next := after.Add(time.Hour)
+			return &next
+		}
+		fired, err := repos.Schedules.FireDue(ctx, now, 10, nextRun)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, fired)
+
+		// Ateşlenen schedule bir task oluşturur ve sonraki çalışmasına geçer
+		tasks, err := repos.Tasks.GetAll(ctx, owner.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, tasks, 1) {
+			assert.Equal(t, "Due", tasks[0].Title)
+			assert.Equal(t, models.TaskStatusTodo, tasks[0].Status)
+		}
+		schedules, err := repos.Schedules.GetAll(ctx, owner.ID)
+		if assert.NoError(t, err) && assert.Len(t, schedules, 4) {
+			if assert.NotNil(t, schedules[0].LastRunAt) {
+				assert.True(t, now.Equal(*schedules[0].LastRunAt))
+			}
+			if assert.NotNil(t, schedules[0].NextRunAt) {
+				assert.True(t, now.Add(time.Hour).Equal(*schedules[0].NextRunAt))
+			}
+		}
+
+		fired, err = repos.Schedules.FireDue(ctx, now, 10, nextRun)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, fired)
+	})
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"konzek-jun/retry"
+
+	"github.com/lib/pq"
+)
+
+// DefaultRetryPolicy is used by repositories for transient database errors.
+var DefaultRetryPolicy = retry.Policy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+	Retryable:      IsRetryableDBError,
+}
+
+// retryableSQLStates are the Postgres error classes and codes that can
+// succeed on a retry: lost connections, serialization failures, deadlocks,
+// lock timeouts, resource exhaustion and server shutdown.
+var retryableSQLStates = map[string]bool{
+	"08":    true,
+	"40":    true,
+	"53":    true,
+	"55P03": true,
+	"57P01": true,
+	"57P02": true,
+	"57P03": true,
+}
+
+// IsRetryableDBError reports whether err is a transient database error.
+// Missing rows, constraint violations and other errors caused by the
+// query or the data itself are never retried.
+func IsRetryableDBError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, sql.ErrNoRows),
+		errors.Is(err, ErrTaskNotFound),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		code := string(pqErr.Code)
+		return retryableSQLStates[code] || retryableSQLStates[string(pqErr.Code.Class())]
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,38 @@
+package repository_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"konzek-jun/repository"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryableDBError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"NoRows", sql.ErrNoRows, false},
+		{"TaskNotFound", repository.ErrTaskNotFound, false},
+		{"UniqueViolation", &pq.Error{Code: "23505"}, false},
+		{"CheckViolation", &pq.Error{Code: "23514"}, false},
+		{"BadConn", driver.ErrBadConn, true},
+		{"SerializationFailure", &pq.Error{Code: "40001"}, true},
+		{"Deadlock", &pq.Error{Code: "40P01"}, true},
+		{"ConnectionFailure", &pq.Error{Code: "08006"}, true},
+		{"AdminShutdown", &pq.Error{Code: "57P01"}, true},
+		{"Wrapped", fmt.Errorf("insert: %w", &pq.Error{Code: "40001"}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repository.IsRetryableDBError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// ErrScheduleNotFound is returned when a schedule does not exist or belongs to another user.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// NextRunFunc returns the first run of a schedule after the given time, or
+// nil when the schedule has no more runs.
+type NextRunFunc func(schedule models.Schedule, after time.Time) *time.Time
+
+//go:generate mockgen -destination=../mocks//repository/mockSchedulerepository.go -package=repository konzek-jun/repository ScheduleRepository
+type ScheduleRepository interface {
+	Insert(ctx context.Context, schedule models.Schedule) (int64, error)
+	GetAll(ctx context.Context, ownerID int64) ([]models.Schedule, error)
+	GetByID(ctx context.Context, ownerID int64, id int) (models.Schedule, error)
+	Delete(ctx context.Context, ownerID int64, id int) error
+	SetPaused(ctx context.Context, ownerID int64, id int, paused bool, nextRunAt *time.Time) error
+	// FireDue creates a task for each schedule due at now, at most limit of
+	// them, and moves each one to its next run. It returns how many fired.
+	FireDue(ctx context.Context, now time.Time, limit int, next NextRunFunc) (int, error)
+}
+
+// ScheduleRepositoryDb keeps schedules in Postgres. A due schedule is locked
+// with FOR UPDATE SKIP LOCKED while its task is created and its next run is
+// stored in the same transaction, so replicas and restarts never fire it twice.
+type ScheduleRepositoryDb struct {
+	DB *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) *ScheduleRepositoryDb {
+	return &ScheduleRepositoryDb{DB: db}
+}
+
+const scheduleColumns = "id, owner_id, name, cron_expr, interval_ms, run_at, title, content, job_type, payload, max_attempts, paused, next_run_at, last_run_at"
+
+func (s *ScheduleRepositoryDb) Insert(ctx context.Context, schedule models.Schedule) (int64, error) {
+	var id int64
+	err := s.DB.QueryRowContext(ctx, `
+		INSERT INTO schedules (owner_id, name, cron_expr, interval_ms, run_at, title, content, job_type, payload, max_attempts, paused, next_run_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, NULLIF($8, ''), $9::jsonb, $10, $11, $12) RETURNING id`,
+		schedule.OwnerID, schedule.Name, schedule.Cron, intervalMs(schedule.Interval), schedule.RunAt, schedule.Title, schedule.Content,
+		schedule.JobType, nullableJSON(schedule.Payload), scheduleMaxAttempts(schedule), schedule.Paused, schedule.NextRunAt).Scan(&id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting schedule: %v", err))
+		return 0, err
+	}
+	loggerx.Info("Schedule inserted successfully")
+	return id, nil
+}
+
+func (s *ScheduleRepositoryDb) GetAll(ctx context.Context, ownerID int64) ([]models.Schedule, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE owner_id = $1 ORDER BY id", ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting schedules: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while scanning schedule: %v", err))
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (s *ScheduleRepositoryDb) GetByID(ctx context.Context, ownerID int64, id int) (models.Schedule, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE id = $1 AND owner_id = $2", id, ownerID)
+	schedule, err := scanSchedule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Schedule{}, ErrScheduleNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting schedule: %v", err))
+		return models.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (s *ScheduleRepositoryDb) Delete(ctx context.Context, ownerID int64, id int) error {
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM schedules WHERE id = $1 AND owner_id = $2", id, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting schedule: %v", err))
+		return err
+	}
+	return checkScheduleAffected(result)
+}
+
+func (s *ScheduleRepositoryDb) SetPaused(ctx context.Context, ownerID int64, id int, paused bool, nextRunAt *time.Time) error {
+	result, err := s.DB.ExecContext(ctx, "UPDATE schedules SET paused = $1, next_run_at = $2 WHERE id = $3 AND owner_id = $4",
+		paused, nextRunAt, id, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while pausing schedule: %v", err))
+		return err
+	}
+	return checkScheduleAffected(result)
+}
+
+func (s *ScheduleRepositoryDb) FireDue(ctx context.Context, now time.Time, limit int, next NextRunFunc) (int, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+scheduleColumns+` FROM schedules
+		WHERE NOT paused AND next_run_at <= $1
+		ORDER BY next_run_at LIMIT $2
+		FOR UPDATE SKIP LOCKED`, now, limit)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while claiming due schedules: %v", err))
+		return 0, err
+	}
+	var due []models.Schedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, schedule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, schedule := range due {
+		if _, err := insertTask(ctx, tx, scheduledTask(schedule)); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while creating task of schedule %d: %v", schedule.ID, err))
+			return 0, err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE schedules SET last_run_at = $1, next_run_at = $2 WHERE id = $3",
+			now, next(schedule, now), schedule.ID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while advancing schedule %d: %v", schedule.ID, err))
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if len(due) > 0 {
+		loggerx.Info(fmt.Sprintf("Fired %d due schedules", len(due)))
+	}
+	return len(due), nil
+}
+
+func checkScheduleAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+func scanSchedule(row rowScanner) (models.Schedule, error) {
+	var schedule models.Schedule
+	var cronExpr, jobType sql.NullString
+	var interval sql.NullInt64
+	var payload []byte
+	var runAt, nextRunAt, lastRunAt sql.NullTime
+
+	err := row.Scan(&schedule.ID, &schedule.OwnerID, &schedule.Name, &cronExpr, &interval, &runAt, &schedule.Title, &schedule.Content,
+		&jobType, &payload, &schedule.MaxAttempts, &schedule.Paused, &nextRunAt, &lastRunAt)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	schedule.Cron = cronExpr.String
+	if interval.Valid {
+		schedule.Interval = (time.Duration(interval.Int64) * time.Millisecond).String()
+	}
+	schedule.JobType = jobType.String
+	schedule.Payload = payload
+	if runAt.Valid {
+		schedule.RunAt = &runAt.Time
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+	return schedule, nil
+}
+
+// scheduledTask is the task a schedule creates when it fires.
+func scheduledTask(schedule models.Schedule) models.Task {
+	return models.Task{
+		OwnerID:     schedule.OwnerID,
+		Title:       schedule.Title,
+		Content:     schedule.Content,
+		Status:      models.TaskStatusTodo,
+		JobType:     schedule.JobType,
+		Payload:     schedule.Payload,
+		MaxAttempts: schedule.MaxAttempts,
+	}
+}
+
+func scheduleMaxAttempts(schedule models.Schedule) int {
+	if schedule.MaxAttempts == 0 {
+		return models.DefaultJobMaxAttempts
+	}
+	return schedule.MaxAttempts
+}
+
+// intervalMs stores a validated interval such as "15m" in milliseconds.
+func intervalMs(interval string) interface{} {
+	if interval == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return nil
+	}
+	return d.Milliseconds()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// SQLiteAPIKeyRepository is the APIKeyRepository of the sqlite storage. The
+// scopes of a key are stored as a JSON array.
+type SQLiteAPIKeyRepository struct {
+	DB *sql.DB
+}
+
+func NewSQLiteAPIKeyRepository(db *sql.DB) *SQLiteAPIKeyRepository {
+	return &SQLiteAPIKeyRepository{DB: db}
+}
+
+func scanSQLiteAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.SecretHash, &scopes,
+		sqliteTimeScanner{&key.ExpiresAt}, sqliteTimeScanner{&key.LastUsedAt}, sqliteTimeScanner{&key.CreatedAt}, sqliteTimeScanner{&key.RevokedAt})
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *SQLiteAPIKeyRepository) Insert(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	encoded, err := json.Marshal(scopes)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	row := r.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING `+apiKeyColumns, key.UserID, key.Name, key.Prefix, key.SecretHash, string(encoded), sqliteNullableTime(key.ExpiresAt), sqliteNow())
+	inserted, err := scanSQLiteAPIKey(row)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting api key: %v", err))
+		return models.APIKey{}, err
+	}
+	return inserted, nil
+}
+
+func (r *SQLiteAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	key, err := scanSQLiteAPIKey(r.DB.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = ?", prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reading api key: %v", err))
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *SQLiteAPIKeyRepository) List(ctx context.Context, userID int64) ([]models.APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = ? AND revoked_at IS NULL ORDER BY id", userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing api keys: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanSQLiteAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *SQLiteAPIKeyRepository) Revoke(ctx context.Context, userID int64, id int64) error {
+	result, err := r.DB.ExecContext(ctx, "UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL", sqliteNow(), id, userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking api key: %v", err))
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *SQLiteAPIKeyRepository) MarkUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	// Times are stored with a fixed width, so comparing the text is enough
+	_, err := r.DB.ExecContext(ctx, "UPDATE api_keys SET last_used_at = ?2 WHERE id = ?1 AND (last_used_at IS NULL OR last_used_at < ?2)", id, sqliteTime(usedAt))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while marking api key as used: %v", err))
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// SQLiteIdempotencyRepository is the IdempotencyRepository of the sqlite
+// storage. The primary key on user and key makes a claim atomic, and an
+// expired key is taken over in the same statement.
+type SQLiteIdempotencyRepository struct {
+	DB *sql.DB
+}
+
+func NewSQLiteIdempotencyRepository(db *sql.DB) *SQLiteIdempotencyRepository {
+	return &SQLiteIdempotencyRepository{DB: db}
+}
+
+func (r *SQLiteIdempotencyRepository) Claim(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	// Try again when the record is deleted or expires between the two queries
+	for attempt := 0; attempt < 3; attempt++ {
+		result, err := r.DB.ExecContext(ctx, `
+			INSERT INTO idempotency_keys (user_id, key, fingerprint, status, expires_at) VALUES (?1, ?2, ?3, 0, ?4)
+			ON CONFLICT (user_id, key) DO UPDATE
+			SET fingerprint = excluded.fingerprint, status = 0, headers = NULL, body = NULL, expires_at = excluded.expires_at
+			WHERE idempotency_keys.expires_at <= ?5`,
+			record.UserID, record.Key, record.Fingerprint, sqliteTime(record.ExpiresAt), sqliteNow())
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while claiming idempotency key: %v", err))
+			return models.IdempotencyRecord{}, false, err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 1 {
+			return record, err == nil, err
+		}
+
+		stored, err := r.get(ctx, record.UserID, record.Key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while reading idempotency key: %v", err))
+		}
+		return stored, false, err
+	}
+	return models.IdempotencyRecord{}, false, fmt.Errorf("idempotency key %q could not be claimed", record.Key)
+}
+
+func (r *SQLiteIdempotencyRepository) get(ctx context.Context, userID int64, key string) (models.IdempotencyRecord, error) {
+	record := models.IdempotencyRecord{UserID: userID, Key: key}
+	var headers []byte
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT fingerprint, status, headers, body, expires_at FROM idempotency_keys
+		WHERE user_id = ? AND key = ? AND expires_at > ?`, userID, key, sqliteNow()).
+		Scan(&record.Fingerprint, &record.Status, &headers, &record.Body, sqliteTimeScanner{&record.ExpiresAt})
+	if err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	if headers != nil {
+		if err := json.Unmarshal(headers, &record.Headers); err != nil {
+			return models.IdempotencyRecord{}, err
+		}
+	}
+	return record, nil
+}
+
+func (r *SQLiteIdempotencyRepository) Complete(ctx context.Context, claim, response models.IdempotencyRecord) error {
+	headers, err := json.Marshal(response.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status = ?5, headers = ?6, body = ?7, expires_at = ?8
+		WHERE user_id = ?1 AND key = ?2 AND fingerprint = ?3 AND expires_at = ?4 AND status = 0`,
+		claim.UserID, claim.Key, claim.Fingerprint, sqliteTime(claim.ExpiresAt),
+		response.Status, string(headers), response.Body, sqliteTime(response.ExpiresAt))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while storing idempotent response: %v", err))
+	}
+	return err
+}
+
+func (r *SQLiteIdempotencyRepository) Release(ctx context.Context, claim models.IdempotencyRecord) error {
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = ? AND key = ? AND fingerprint = ? AND expires_at = ? AND status = 0`,
+		claim.UserID, claim.Key, claim.Fingerprint, sqliteTime(claim.ExpiresAt))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while releasing idempotency key: %v", err))
+	}
+	return err
+}
+
+func (r *SQLiteIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", sqliteTime(now))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting expired idempotency keys: %v", err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
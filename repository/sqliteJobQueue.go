@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// SQLiteJobQueue is the JobQueue of the sqlite storage, on the job columns of
+// the tasks table. SQLite runs one write at a time, so the UPDATE of Lease
+// alone keeps two workers from leasing the same job.
+type SQLiteJobQueue struct {
+	DB *sql.DB
+}
+
+func NewSQLiteJobQueue(db *sql.DB) *SQLiteJobQueue {
+	return &SQLiteJobQueue{DB: db}
+}
+
+func (j *SQLiteJobQueue) Lease(ctx context.Context, workerID string, visibility time.Duration) (models.Job, error) {
+	now := time.Now()
+	row := j.DB.QueryRowContext(ctx, `
+		UPDATE tasks SET job_status = 'running', attempts = attempts + 1,
+			lease_owner = ?1, lease_expires_at = ?2,
+			started_at = ?3, finished_at = NULL, run_after = NULL
+		WHERE id = (
+			SELECT id FROM tasks t WHERE job_status = 'pending'
+				AND (run_after IS NULL OR run_after <= ?3)
+				AND NOT EXISTS (
+					SELECT 1 FROM task_dependencies d JOIN tasks p ON p.id = d.depends_on_id
+					WHERE d.task_id = t.id
+						AND p.job_status IS NOT 'succeeded'
+						AND NOT (p.job_type IS NULL AND p.status = 'done')
+				)
+			ORDER BY id LIMIT 1
+		)
+		RETURNING `+jobColumns, workerID, sqliteTime(now.Add(visibility)), sqliteTime(now))
+
+	job, err := scanSQLiteJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrNoPendingJob
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while leasing job: %v", err))
+		return models.Job{}, err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d leased by %s", job.ID, workerID))
+	return job, nil
+}
+
+func (j *SQLiteJobQueue) Heartbeat(ctx context.Context, id int, workerID string, visibility time.Duration) error {
+	result, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET lease_expires_at = ?
+		WHERE id = ? AND lease_owner = ? AND job_status = 'running'`, sqliteTime(time.Now().Add(visibility)), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while extending job lease: %v", err))
+		return err
+	}
+	return checkLease(result)
+}
+
+func (j *SQLiteJobQueue) Complete(ctx context.Context, id int, workerID string, result json.RawMessage, duration time.Duration) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'succeeded', result = ?, last_error = NULL, last_duration_ms = ?,
+			finished_at = ?, lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = ? AND lease_owner = ? AND job_status = 'running'`, nullableJSON(result), duration.Milliseconds(), sqliteNow(), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while completing job: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d succeeded", id))
+	return nil
+}
+
+// Retry releases a failed job and puts it back to pending; it is not leased
+// again before runAfter.
+func (j *SQLiteJobQueue) Retry(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration, runAfter time.Time) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'pending', last_error = ?, last_duration_ms = ?, run_after = ?,
+			finished_at = ?, lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = ? AND lease_owner = ? AND job_status = 'running'`, errMsg, duration.Milliseconds(), sqliteTime(runAfter), sqliteNow(), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while scheduling job retry: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d failed, retrying after %s: %s", id, runAfter.Format(time.RFC3339), errMsg))
+	return nil
+}
+
+// DeadLetter marks a failed job as dead. It is not run again until requeued.
+func (j *SQLiteJobQueue) DeadLetter(ctx context.Context, id int, workerID string, errMsg string, duration time.Duration) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'dead', last_error = ?, last_duration_ms = ?, run_after = NULL,
+			finished_at = ?, lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = ? AND lease_owner = ? AND job_status = 'running'`, errMsg, duration.Milliseconds(), sqliteNow(), id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while dead-lettering job: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Error(fmt.Sprintf("Job %d dead-lettered: %s", id, errMsg))
+	return nil
+}
+
+// Release puts a running job back to pending without using up its attempt.
+func (j *SQLiteJobQueue) Release(ctx context.Context, id int, workerID string) error {
+	res, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'pending', attempts = MAX(attempts - 1, 0),
+			lease_owner = NULL, lease_expires_at = NULL
+		WHERE id = ? AND lease_owner = ? AND job_status = 'running'`, id, workerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while releasing job: %v", err))
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d released by %s", id, workerID))
+	return nil
+}
+
+// ReclaimExpired puts running jobs whose lease has expired back to pending,
+// or dead-letters them when they used their last attempt, and returns how
+// many it reclaimed.
+func (j *SQLiteJobQueue) ReclaimExpired(ctx context.Context) (int64, error) {
+	result, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+			lease_owner = NULL, lease_expires_at = NULL, last_error = 'lease expired'
+		WHERE job_status = 'running' AND lease_expires_at < ?`, sqliteNow())
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reclaiming expired jobs: %v", err))
+		return 0, err
+	}
+	reclaimed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if reclaimed > 0 {
+		loggerx.Info(fmt.Sprintf("Reclaimed %d expired job leases", reclaimed))
+	}
+	return reclaimed, nil
+}
+
+func (j *SQLiteJobQueue) GetJob(ctx context.Context, ownerID int64, id int) (models.Job, error) {
+	row := j.DB.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM tasks WHERE id = ? AND owner_id = ? AND job_type IS NOT NULL", id, ownerID)
+	job, err := scanSQLiteJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting job: %v", err))
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+func (j *SQLiteJobQueue) ListDead(ctx context.Context) ([]models.Job, error) {
+	rows, err := j.DB.QueryContext(ctx, "SELECT "+jobColumns+" FROM tasks WHERE job_status = 'dead' ORDER BY finished_at DESC, id DESC")
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing dead jobs: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanSQLiteJob(rows)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while scanning dead job: %v", err))
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetDead returns ErrJobNotFound for jobs that exist but are not dead.
+func (j *SQLiteJobQueue) GetDead(ctx context.Context, id int) (models.Job, error) {
+	row := j.DB.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM tasks WHERE id = ? AND job_status = 'dead'", id)
+	job, err := scanSQLiteJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting dead job: %v", err))
+		return models.Job{}, err
+	}
+	return job, nil
+}
+
+// Requeue gives a dead job a fresh set of attempts.
+func (j *SQLiteJobQueue) Requeue(ctx context.Context, id int) error {
+	result, err := j.DB.ExecContext(ctx, `
+		UPDATE tasks SET job_status = 'pending', attempts = 0, run_after = NULL, finished_at = NULL
+		WHERE id = ? AND job_status = 'dead'`, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while requeueing job: %v", err))
+		return err
+	}
+	if err := checkJobAffected(result); err != nil {
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Job %d requeued", id))
+	return nil
+}
+
+// PurgeDead deletes a dead job together with its task.
+func (j *SQLiteJobQueue) PurgeDead(ctx context.Context, id int) error {
+	result, err := j.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = ? AND job_status = 'dead'", id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while purging dead job: %v", err))
+		return err
+	}
+	return checkJobAffected(result)
+}
+
+func (j *SQLiteJobQueue) PurgeAllDead(ctx context.Context) (int64, error) {
+	result, err := j.DB.ExecContext(ctx, "DELETE FROM tasks WHERE job_status = 'dead'")
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while purging dead jobs: %v", err))
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	loggerx.Info(fmt.Sprintf("Purged %d dead jobs", purged))
+	return purged, nil
+}
+
+func scanSQLiteJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var payload, result []byte
+	var lastError sql.NullString
+
+	err := row.Scan(&job.ID, &job.OwnerID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &result, &lastError, &job.DurationMs,
+		sqliteTimeScanner{&job.StartedAt}, sqliteTimeScanner{&job.FinishedAt}, sqliteTimeScanner{&job.RunAfter})
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	job.Payload = payload
+	job.Result = result
+	job.LastError = lastError.String
+	return job, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// SQLiteRefreshTokenRepository is the RefreshTokenRepository of the sqlite
+// storage. The storage has a single connection, so concurrent rotations of
+// one token run one after the other like with FOR UPDATE in Postgres.
+type SQLiteRefreshTokenRepository struct {
+	DB *sql.DB
+}
+
+func NewSQLiteRefreshTokenRepository(db *sql.DB) *SQLiteRefreshTokenRepository {
+	return &SQLiteRefreshTokenRepository{DB: db}
+}
+
+func (r *SQLiteRefreshTokenRepository) Insert(ctx context.Context, token models.RefreshToken) (int64, error) {
+	return insertSQLiteRefreshToken(ctx, r.DB, token)
+}
+
+func insertSQLiteRefreshToken(ctx context.Context, db DBTX, token models.RefreshToken) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		token.UserID, token.FamilyID, token.TokenHash, sqliteTime(token.ExpiresAt), sqliteNow())
+	var id int64
+	if err == nil {
+		id, err = result.LastInsertId()
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting refresh token: %v", err))
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *SQLiteRefreshTokenRepository) Rotate(ctx context.Context, hash string, next models.RefreshToken) (models.RefreshToken, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RefreshToken{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var current models.RefreshToken
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, family_id, token_hash, expires_at, created_at, rotated_at, revoked_at
+		FROM refresh_tokens WHERE token_hash = ?`, hash).
+		Scan(&current.ID, &current.UserID, &current.FamilyID, &current.TokenHash, sqliteTimeScanner{&current.ExpiresAt},
+			sqliteTimeScanner{&current.CreatedAt}, sqliteTimeScanner{&current.RotatedAt}, sqliteTimeScanner{&current.RevokedAt})
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reading refresh token: %v", err))
+		return models.RefreshToken{}, err
+	}
+	if current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+		return models.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+
+	if current.RotatedAt != nil {
+		_, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", sqliteTime(now), current.FamilyID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while revoking refresh token family: %v", err))
+			return models.RefreshToken{}, err
+		}
+		if err := tx.Commit(); err != nil {
+			return models.RefreshToken{}, err
+		}
+		loggerx.Info(fmt.Sprintf("Refresh token reused, revoked token family of user %d", current.UserID))
+		return current, ErrRefreshTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET rotated_at = ? WHERE id = ?", sqliteTime(now), current.ID); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while rotating refresh token: %v", err))
+		return models.RefreshToken{}, err
+	}
+	current.RotatedAt = &now
+	next.UserID, next.FamilyID = current.UserID, current.FamilyID
+	if _, err := insertSQLiteRefreshToken(ctx, tx, next); err != nil {
+		return models.RefreshToken{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.RefreshToken{}, err
+	}
+	return current, nil
+}
+
+func (r *SQLiteRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", sqliteNow(), familyID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking refresh token family: %v", err))
+	}
+	return err
+}
+
+func (r *SQLiteRefreshTokenRepository) RevokeUser(ctx context.Context, userID int64) ([]string, error) {
+	now := sqliteNow()
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE refresh_tokens SET revoked_at = ?1
+		WHERE user_id = ?2 AND revoked_at IS NULL AND expires_at > ?1
+		RETURNING family_id`, now, userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking refresh tokens of user: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	families := []string{}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var familyID string
+		if err := rows.Scan(&familyID); err != nil {
+			return nil, err
+		}
+		if !seen[familyID] {
+			seen[familyID] = true
+			families = append(families, familyID)
+		}
+	}
+	return families, rows.Err()
+}
@@ -0,0 +1,83 @@
+//go:build sqlite
+
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"konzek-jun/migrations"
+	"konzek-jun/repository"
+	"konzek-jun/repository/repositorytest"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteRepositories her test için geçici bir SQLite dosyasındaki
+// repository'leri döner. go test -tags sqlite ile çalışır.
+func sqliteRepositories(t *testing.T) repositorytest.Repositories {
+	path := filepath.Join(t.TempDir(), "konzek.db")
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("Veritabanına bağlanırken hata oluştu: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db, migrations.SQLite)
+	if err != nil {
+		t.Fatalf("Migration'lar okunurken hata oluştu: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Migration'lar uygulanırken hata oluştu: %v", err)
+	}
+	return repositorytest.Repositories{
+		Tasks:            repository.NewSQLiteTaskRepository(db),
+		Users:            repository.NewSQLiteUserRepository(db),
+		UnitOfWork:       repository.NewSQLiteTxManager(db),
+		Jobs:             repository.NewSQLiteJobQueue(db),
+		Schedules:        repository.NewSQLiteScheduleRepository(db),
+		Idempotency:      repository.NewSQLiteIdempotencyRepository(db),
+		RefreshTokens:    repository.NewSQLiteRefreshTokenRepository(db),
+		TokenRevocations: repository.NewSQLiteTokenRevocationRepository(db),
+		APIKeys:          repository.NewSQLiteAPIKeyRepository(db),
+	}
+}
+
+func TestSQLiteTaskRepository(t *testing.T) {
+	repositorytest.RunTaskRepository(t, sqliteRepositories)
+}
+
+func TestSQLiteUserRepository(t *testing.T) {
+	repositorytest.RunUserRepository(t, sqliteRepositories)
+}
+
+func TestSQLiteUnitOfWork(t *testing.T) {
+	repositorytest.RunUnitOfWork(t, sqliteRepositories)
+}
+
+func TestSQLiteJobQueue(t *testing.T) {
+	repositorytest.RunJobQueue(t, sqliteRepositories)
+}
+
+func TestSQLiteScheduleRepository(t *testing.T) {
+	repositorytest.RunScheduleRepository(t, sqliteRepositories)
+}
+
+func TestSQLiteIdempotencyRepository(t *testing.T) {
+	repositorytest.RunIdempotencyRepository(t, sqliteRepositories)
+}
+
+func TestSQLiteRefreshTokenRepository(t *testing.T) {
+	repositorytest.RunRefreshTokenRepository(t, sqliteRepositories)
+}
+
+func TestSQLiteTokenRevocationRepository(t *testing.T) {
+	repositorytest.RunTokenRevocationRepository(t, sqliteRepositories)
+}
+
+func TestSQLiteAPIKeyRepository(t *testing.T) {
+	repositorytest.RunAPIKeyRepository(t, sqliteRepositories)
+}
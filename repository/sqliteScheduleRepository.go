@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// SQLiteScheduleRepository is the ScheduleRepository of the sqlite storage.
+// FireDue creates the tasks and stores the next runs in one transaction;
+// the storage has a single connection, so it never runs twice at once.
+type SQLiteScheduleRepository struct {
+	DB *sql.DB
+}
+
+func NewSQLiteScheduleRepository(db *sql.DB) *SQLiteScheduleRepository {
+	return &SQLiteScheduleRepository{DB: db}
+}
+
+func (s *SQLiteScheduleRepository) Insert(ctx context.Context, schedule models.Schedule) (int64, error) {
+	result, err := s.DB.ExecContext(ctx, `
+		INSERT INTO schedules (owner_id, name, cron_expr, interval_ms, run_at, title, content, job_type, payload, max_attempts, paused, next_run_at, created_at)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)`,
+		schedule.OwnerID, schedule.Name, schedule.Cron, intervalMs(schedule.Interval), sqliteNullableTime(schedule.RunAt), schedule.Title, schedule.Content,
+		schedule.JobType, nullableJSON(schedule.Payload), scheduleMaxAttempts(schedule), schedule.Paused, sqliteNullableTime(schedule.NextRunAt), sqliteNow())
+	var id int64
+	if err == nil {
+		id, err = result.LastInsertId()
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting schedule: %v", err))
+		return 0, err
+	}
+	loggerx.Info("Schedule inserted successfully")
+	return id, nil
+}
+
+func (s *SQLiteScheduleRepository) GetAll(ctx context.Context, ownerID int64) ([]models.Schedule, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE owner_id = ? ORDER BY id", ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting schedules: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		schedule, err := scanSQLiteSchedule(rows)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while scanning schedule: %v", err))
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
+
+func (s *SQLiteScheduleRepository) GetByID(ctx context.Context, ownerID int64, id int) (models.Schedule, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE id = ? AND owner_id = ?", id, ownerID)
+	schedule, err := scanSQLiteSchedule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Schedule{}, ErrScheduleNotFound
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting schedule: %v", err))
+		return models.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (s *SQLiteScheduleRepository) Delete(ctx context.Context, ownerID int64, id int) error {
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM schedules WHERE id = ? AND owner_id = ?", id, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting schedule: %v", err))
+		return err
+	}
+	return checkScheduleAffected(result)
+}
+
+func (s *SQLiteScheduleRepository) SetPaused(ctx context.Context, ownerID int64, id int, paused bool, nextRunAt *time.Time) error {
+	result, err := s.DB.ExecContext(ctx, "UPDATE schedules SET paused = ?, next_run_at = ? WHERE id = ? AND owner_id = ?",
+		paused, sqliteNullableTime(nextRunAt), id, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while pausing schedule: %v", err))
+		return err
+	}
+	return checkScheduleAffected(result)
+}
+
+func (s *SQLiteScheduleRepository) FireDue(ctx context.Context, now time.Time, limit int, next NextRunFunc) (int, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT "+scheduleColumns+` FROM schedules
+		WHERE NOT paused AND next_run_at <= ?
+		ORDER BY next_run_at LIMIT ?`, sqliteTime(now), limit)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while claiming due schedules: %v", err))
+		return 0, err
+	}
+	var due []models.Schedule
+	for rows.Next() {
+		schedule, err := scanSQLiteSchedule(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, schedule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	tasks := &SQLiteTaskRepository{DB: tx}
+	for _, schedule := range due {
+		if _, err := tasks.Insert(ctx, scheduledTask(schedule)); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while creating task of schedule %d: %v", schedule.ID, err))
+			return 0, err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE schedules SET last_run_at = ?, next_run_at = ? WHERE id = ?",
+			sqliteTime(now), sqliteNullableTime(next(schedule, now)), schedule.ID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while advancing schedule %d: %v", schedule.ID, err))
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	if len(due) > 0 {
+		loggerx.Info(fmt.Sprintf("Fired %d due schedules", len(due)))
+	}
+	return len(due), nil
+}
+
+func scanSQLiteSchedule(row rowScanner) (models.Schedule, error) {
+	var schedule models.Schedule
+	var cronExpr, jobType sql.NullString
+	var interval sql.NullInt64
+	var payload []byte
+
+	err := row.Scan(&schedule.ID, &schedule.OwnerID, &schedule.Name, &cronExpr, &interval, sqliteTimeScanner{&schedule.RunAt}, &schedule.Title, &schedule.Content,
+		&jobType, &payload, &schedule.MaxAttempts, &schedule.Paused, sqliteTimeScanner{&schedule.NextRunAt}, sqliteTimeScanner{&schedule.LastRunAt})
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	schedule.Cron = cronExpr.String
+	if interval.Valid {
+		schedule.Interval = (time.Duration(interval.Int64) * time.Millisecond).String()
+	}
+	schedule.JobType = jobType.String
+	schedule.Payload = payload
+	return schedule, nil
+}
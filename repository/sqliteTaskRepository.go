@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqliteTimeLayout is how times are stored in SQLite: fixed width UTC text
+// with the precision of a Postgres timestamp, so that text order is time order.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// SQLiteTaskRepository is the TaskRepository of the sqlite storage. Tasks of
+// other owners are not found and writes that require a version fail with
+// ErrVersionConflict when the task has another one. Search uses an FTS5 index
+// with the same word splitting as the Postgres simple configuration. Jobs are stored
+// on the task row and run by SQLiteJobQueue.
+type SQLiteTaskRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteTaskRepository(db *sql.DB) *SQLiteTaskRepository {
+	return &SQLiteTaskRepository{DB: db}
+}
+
+func (s *SQLiteTaskRepository) Insert(ctx context.Context, task models.Task) (int64, error) {
+	now := sqliteNow()
+	result, err := s.DB.ExecContext(ctx, `
+		INSERT INTO tasks (owner_id, title, content, status, job_type, payload, job_status, max_attempts, created_at, updated_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)`,
+		append(insertArgs(task), now, now)...)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting task: %v", err))
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	loggerx.Info("Task inserted successfully")
+	return id, nil
+}
+
+// InsertMany inserts tasks one at a time. Round trips cost little on a
+// local file, unlike on a Postgres server.
+func (s *SQLiteTaskRepository) InsertMany(ctx context.Context, tasks []models.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		id, err := s.Insert(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *SQLiteTaskRepository) GetAll(ctx context.Context, ownerID int64) ([]models.Task, error) {
+	return s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks WHERE owner_id = ? ORDER BY id", ownerID)
+}
+
+func (s *SQLiteTaskRepository) Delete(ctx context.Context, ownerID int64, id int, version int) error {
+	// The foreign keys delete the task_dependencies rows
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?1 AND owner_id = ?2 AND (?3 = 0 OR version = ?3)", id, ownerID, version)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting task: %v", err))
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return s.missingOrStale(ctx, ownerID, id, version)
+	}
+	loggerx.Info("Task deleted successfully")
+	return nil
+}
+
+func (s *SQLiteTaskRepository) GetByID(ctx context.Context, ownerID int64, id int) (models.Task, error) {
+	tasks, err := s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ? AND owner_id = ?", id, ownerID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if len(tasks) == 0 {
+		return models.Task{}, ErrTaskNotFound
+	}
+	return tasks[0], nil
+}
+
+func (s *SQLiteTaskRepository) Update(ctx context.Context, task models.Task) error {
+	result, err := s.DB.ExecContext(ctx, `
+		UPDATE tasks SET title = ?1, content = ?2, status = ?3, updated_at = ?4, version = version + 1
+		WHERE id = ?5 AND owner_id = ?6 AND (?7 = 0 OR version = ?7)`,
+		task.Title, task.Content, task.Status, sqliteNow(), task.Id, task.OwnerID, task.Version)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while updating task: %v", err))
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return s.missingOrStale(ctx, task.OwnerID, task.Id, task.Version)
+	}
+	return nil
+}
+
+func (s *SQLiteTaskRepository) UpdateStatus(ctx context.Context, ownerID int64, id int, status models.TaskStatus, version int) error {
+	result, err := s.DB.ExecContext(ctx, `
+		UPDATE tasks SET status = ?1, updated_at = ?2, version = version + 1
+		WHERE id = ?3 AND owner_id = ?4 AND (?5 = 0 OR version = ?5)`,
+		status, sqliteNow(), id, ownerID, version)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while updating task status: %v", err))
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return s.missingOrStale(ctx, ownerID, id, version)
+	}
+	return nil
+}
+
+// missingOrStale tells why a write of task id that required version matched
+// no row: the task is gone or it has another version by now.
+func (s *SQLiteTaskRepository) missingOrStale(ctx context.Context, ownerID int64, id int, version int) error {
+	if version == 0 {
+		return ErrTaskNotFound
+	}
+	var exists bool
+	err := s.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ? AND owner_id = ?)", id, ownerID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskNotFound
+	}
+	return ErrVersionConflict
+}
+
+// FindTasks returns the tasks of ownerID that match filter, ordered by
+// filter.Sort and then by id, and paged by filter.Offset and filter.Limit or
+// by the keyset cursor in filter.After or filter.Before.
+func (s *SQLiteTaskRepository) FindTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) ([]models.Task, error) {
+	where, args := sqliteTaskWhere(ownerID, filter)
+
+	var orderBy string
+	backward := filter.Before != nil
+	if filter.After != nil || backward {
+		column, desc, err := taskKeysetSort(filter)
+		if err != nil {
+			return nil, err
+		}
+		cursor := filter.After
+		if backward {
+			cursor = filter.Before
+		}
+		value, err := sqliteCursorValue(column, *cursor)
+		if err != nil {
+			return nil, err
+		}
+		operator := " > "
+		if desc {
+			operator = " < "
+		}
+		where += " AND (" + column.name + ", id)" + operator + "(?, ?)"
+		args = append(args, value, cursor.ID)
+		orderBy = column.name + sortDirection(desc) + ", id" + sortDirection(desc)
+	} else {
+		var err error
+		if orderBy, err = taskOrderBy(filter.Sort); err != nil {
+			return nil, err
+		}
+	}
+
+	// LIMIT -1 is no limit in SQLite
+	limit := -1
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	args = append(args, limit, filter.Offset)
+	tasks, err := s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks"+where+" ORDER BY "+orderBy+" LIMIT ? OFFSET ?", args...)
+	if err == nil && backward {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+	return tasks, err
+}
+
+func (s *SQLiteTaskRepository) CountTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) (int64, error) {
+	where, args := sqliteTaskWhere(ownerID, filter)
+	var total int64
+	err := s.DB.QueryRowContext(ctx, "SELECT count(*) FROM tasks"+where, args...).Scan(&total)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while counting tasks: %v", err))
+	}
+	return total, err
+}
+
+// AddDependency records that taskID depends on dependsOnID. A dependency on a
+// task of another owner is skipped and an existing one is kept, both without
+// an error.
+func (s *SQLiteTaskRepository) AddDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	// The WHERE keeps ON CONFLICT from being parsed as the ON of a join
+	_, err := s.DB.ExecContext(ctx, `
+		INSERT INTO task_dependencies (task_id, depends_on_id)
+		SELECT t.id, p.id FROM tasks t JOIN tasks p ON p.owner_id = t.owner_id
+		WHERE t.id = ? AND p.id = ? AND t.owner_id = ?
+		ON CONFLICT DO NOTHING`, taskID, dependsOnID, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while adding task dependency: %v", err))
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Task %d now depends on task %d", taskID, dependsOnID))
+	return nil
+}
+
+func (s *SQLiteTaskRepository) RemoveDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	result, err := s.DB.ExecContext(ctx, `
+		DELETE FROM task_dependencies
+		WHERE task_id = ? AND depends_on_id = ? AND task_id IN (SELECT id FROM tasks WHERE owner_id = ?)`, taskID, dependsOnID, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while removing task dependency: %v", err))
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrDependencyNotFound
+	}
+	loggerx.Info(fmt.Sprintf("Task %d no longer depends on task %d", taskID, dependsOnID))
+	return nil
+}
+
+func (s *SQLiteTaskRepository) GetDependencies(ctx context.Context, ownerID int64) ([]models.TaskDependency, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT d.task_id, d.depends_on_id FROM task_dependencies d JOIN tasks t ON t.id = d.task_id
+		WHERE t.owner_id = ? ORDER BY d.task_id, d.depends_on_id`, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting task dependencies: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dependencies []models.TaskDependency
+	for rows.Next() {
+		var dependency models.TaskDependency
+		if err := rows.Scan(&dependency.TaskID, &dependency.DependsOnID); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, rows.Err()
+}
+
+func (s *SQLiteTaskRepository) GetPrerequisites(ctx context.Context, ownerID int64, id int) ([]models.Task, error) {
+	return s.queryTasks(ctx, `
+		SELECT p.id, p.owner_id, p.title, p.content, p.status, p.created_at, p.updated_at, p.version
+		FROM task_dependencies d JOIN tasks p ON p.id = d.depends_on_id
+		WHERE d.task_id = ? AND p.owner_id = ? ORDER BY p.id`, id, ownerID)
+}
+
+func (s *SQLiteTaskRepository) GetDependents(ctx context.Context, ownerID int64, id int) ([]models.Task, error) {
+	return s.queryTasks(ctx, `
+		SELECT c.id, c.owner_id, c.title, c.content, c.status, c.created_at, c.updated_at, c.version
+		FROM task_dependencies d JOIN tasks c ON c.id = d.task_id
+		WHERE d.depends_on_id = ? AND c.owner_id = ? ORDER BY c.id`, id, ownerID)
+}
+
+// LockDependencies does nothing: the sqlite storage has a single connection,
+// so its transactions already run one at a time.
+func (s *SQLiteTaskRepository) LockDependencies(ctx context.Context, ownerID int64) error {
+	return nil
+}
+
+func (s *SQLiteTaskRepository) queryTasks(ctx context.Context, query string, args ...interface{}) ([]models.Task, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting tasks: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanSQLiteTask(rows)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while scanning task: %v", err))
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// sqliteTaskWhere builds the condition for the filter fields that are set.
+// Search words are matched against tasks_fts: all words of the terms have to
+// occur and none of the words of a term prefixed with - may.
+func sqliteTaskWhere(ownerID int64, filter models.TaskFilter) (string, []interface{}) {
+	where := " WHERE owner_id = ?"
+	args := []interface{}{ownerID}
+
+	if len(filter.Statuses) > 0 {
+		statuses, _ := json.Marshal(filter.Statuses)
+		where += " AND status IN (SELECT value FROM json_each(?))"
+		args = append(args, string(statuses))
+	}
+
+	var include, exclude []string
+	for _, term := range strings.Fields(filter.Search) {
+		negated := strings.HasPrefix(term, "-")
+		for _, word := range searchWords(term) {
+			// Words are only letters and digits, and quoted they are not FTS5 operators
+			if negated {
+				exclude = append(exclude, `"`+word+`"`)
+			} else {
+				include = append(include, `"`+word+`"`)
+			}
+		}
+	}
+	if len(include) > 0 {
+		where += " AND id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)"
+		args = append(args, strings.Join(include, " "))
+	}
+	if len(exclude) > 0 {
+		where += " AND id NOT IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)"
+		args = append(args, strings.Join(exclude, " OR "))
+	}
+
+	bounds := []struct {
+		condition string
+		value     *time.Time
+	}{
+		{" AND created_at >= ?", filter.CreatedFrom},
+		{" AND created_at <= ?", filter.CreatedTo},
+		{" AND updated_at >= ?", filter.UpdatedFrom},
+		{" AND updated_at <= ?", filter.UpdatedTo},
+	}
+	for _, bound := range bounds {
+		if bound.value != nil {
+			where += bound.condition
+			args = append(args, sqliteTime(*bound.value))
+		}
+	}
+	return where, args
+}
+
+// sqliteCursorValue converts a cursor value to the type column is stored as.
+func sqliteCursorValue(column taskSortColumn, cursor models.TaskCursor) (interface{}, error) {
+	switch column.cast {
+	case "integer":
+		id, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value %q: %w", cursor.Value, err)
+		}
+		return id, nil
+	case "timestamptz":
+		t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value %q: %w", cursor.Value, err)
+		}
+		return sqliteTime(t), nil
+	}
+	return cursor.Value, nil
+}
+
+func scanSQLiteTask(row rowScanner) (models.Task, error) {
+	var task models.Task
+	var createdAt, updatedAt string
+	if err := row.Scan(&task.Id, &task.OwnerID, &task.Title, &task.Content, &task.Status, &createdAt, &updatedAt, &task.Version); err != nil {
+		return models.Task{}, err
+	}
+	for _, field := range []struct {
+		raw    string
+		target **time.Time
+	}{{createdAt, &task.CreatedAt}, {updatedAt, &task.UpdatedAt}} {
+		t, err := time.Parse(sqliteTimeLayout, field.raw)
+		if err != nil {
+			return models.Task{}, err
+		}
+		*field.target = &t
+	}
+	return task, nil
+}
+
+func sqliteNow() string {
+	return sqliteTime(time.Now())
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteNullableTime stores t like sqliteTime, or NULL when t is nil.
+func sqliteNullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// sqliteTimeScanner scans a time stored as sqliteTimeLayout text into the
+// *time.Time or, for nullable columns, the **time.Time it wraps.
+type sqliteTimeScanner struct {
+	target interface{}
+}
+
+func (s sqliteTimeScanner) Scan(src interface{}) error {
+	var raw string
+	switch value := src.(type) {
+	case nil:
+		if target, ok := s.target.(**time.Time); ok {
+			*target = nil
+			return nil
+		}
+		return errors.New("time column is NULL")
+	case string:
+		raw = value
+	case []byte:
+		raw = string(value)
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+	t, err := time.Parse(sqliteTimeLayout, raw)
+	if err != nil {
+		return err
+	}
+	switch target := s.target.(type) {
+	case *time.Time:
+		*target = t
+	case **time.Time:
+		*target = &t
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+// SQLiteTokenRevocationRepository is the TokenRevocationRepository of the
+// sqlite storage.
+type SQLiteTokenRevocationRepository struct {
+	DB *sql.DB
+}
+
+func NewSQLiteTokenRevocationRepository(db *sql.DB) *SQLiteTokenRevocationRepository {
+	return &SQLiteTokenRevocationRepository{DB: db}
+}
+
+func (r *SQLiteTokenRevocationRepository) Revoke(ctx context.Context, revocations []models.TokenRevocation) error {
+	for _, revocation := range revocations {
+		// Times are stored as text, so MAX picks the later one
+		_, err := r.DB.ExecContext(ctx, `
+			INSERT INTO token_revocations (token_id, user_id, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT (token_id) DO UPDATE SET expires_at = MAX(token_revocations.expires_at, excluded.expires_at)`,
+			revocation.TokenID, revocation.UserID, sqliteTime(revocation.ExpiresAt))
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while revoking token: %v", err))
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *SQLiteTokenRevocationRepository) ListActive(ctx context.Context, now time.Time) ([]models.TokenRevocation, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT token_id, user_id, expires_at FROM token_revocations WHERE expires_at > ? ORDER BY token_id", sqliteTime(now))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing token revocations: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	revocations := []models.TokenRevocation{}
+	for rows.Next() {
+		var revocation models.TokenRevocation
+		if err := rows.Scan(&revocation.TokenID, &revocation.UserID, sqliteTimeScanner{&revocation.ExpiresAt}); err != nil {
+			return nil, err
+		}
+		revocations = append(revocations, revocation)
+	}
+	return revocations, rows.Err()
+}
+
+func (r *SQLiteTokenRevocationRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM token_revocations WHERE expires_at <= ?", sqliteTime(now))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting expired token revocations: %v", err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
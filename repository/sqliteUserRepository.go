@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"strings"
+)
+
+// SQLiteUserRepository is the UserRepository of the sqlite storage. Emails
+// are unique and passwords are stored hashed.
+type SQLiteUserRepository struct {
+	DB DBTX
+}
+
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{DB: db}
+}
+
+func (s *SQLiteUserRepository) InsertUser(ctx context.Context, user models.User) (models.User, error) {
+	user.Password = hashAndSalt([]byte(user.Password))
+	user.Role = defaultRole(user.Role)
+	result, err := s.DB.ExecContext(ctx, "INSERT INTO users (name, email, password, role) VALUES (?, ?, ?, ?)", user.Name, user.Email, user.Password, user.Role)
+	if err == nil {
+		user.ID, err = result.LastInsertId()
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting user: %v", err))
+		return models.User{}, sqliteUserError(err)
+	}
+	loggerx.Info("User inserted successfully")
+	return user, nil
+}
+
+func (s *SQLiteUserRepository) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	if user.Password != "" {
+		user.Password = hashAndSalt([]byte(user.Password))
+	} else {
+		err := s.DB.QueryRowContext(ctx, "SELECT password FROM users WHERE id = ?", user.ID).Scan(&user.Password)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while updating user: %v", err))
+			return models.User{}, sqliteUserError(err)
+		}
+	}
+
+	result, err := s.DB.ExecContext(ctx, "UPDATE users SET name = ?, email = ?, password = ? WHERE id = ?", user.Name, user.Email, user.Password, user.ID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while updating user: %v", err))
+		return models.User{}, sqliteUserError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return models.User{}, err
+	}
+	if affected == 0 {
+		return models.User{}, ErrUserNotFound
+	}
+	loggerx.Info("User updated successfully")
+	return user, nil
+}
+
+func (s *SQLiteUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return s.findUser(ctx, "email", email)
+}
+
+// FindByUserID does not find an id that is not a number: SQLite compares
+// it as text, which never equals an integer id.
+func (s *SQLiteUserRepository) FindByUserID(ctx context.Context, userID string) (models.User, error) {
+	return s.findUser(ctx, "id", userID)
+}
+
+func (s *SQLiteUserRepository) findUser(ctx context.Context, column, value string) (models.User, error) {
+	var user models.User
+	err := s.DB.QueryRowContext(ctx, "SELECT id, name, email, password, role FROM users WHERE "+column+" = ?", value).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while finding user by %s: %v", column, err))
+		return models.User{}, sqliteUserError(err)
+	}
+	loggerx.Info(fmt.Sprintf("User found by %s successfully", column))
+	return user, nil
+}
+
+func (s *SQLiteUserRepository) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT id, name, email, role FROM users ORDER BY id")
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing users: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLiteUserRepository) SetRole(ctx context.Context, userID int64, role models.Role) (models.User, error) {
+	user := models.User{ID: userID}
+	err := s.DB.QueryRowContext(ctx, "UPDATE users SET role = ? WHERE id = ? RETURNING name, email, role", role, userID).Scan(&user.Name, &user.Email, &user.Role)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while setting user role: %v", err))
+		return models.User{}, sqliteUserError(err)
+	}
+	loggerx.Info("User role set successfully")
+	return user, nil
+}
+
+// sqliteUserError maps a missing row and a duplicate email to ErrUserNotFound
+// and ErrEmailTaken. The drivers differ in their error types but not in the
+// message of a unique constraint violation.
+func sqliteUserError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if strings.Contains(err.Error(), "UNIQUE constraint failed: users.email") {
+		return ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+)
+
+// ErrDependencyNotFound is returned when a task does not depend on the given task.
+var ErrDependencyNotFound = errors.New("task dependency not found")
+
+// dependencyLockClass is the first key of the advisory locks on dependency
+// graphs, the second one is the owner id. Two key locks do not collide with
+// the single key lock of the migrations.
+const dependencyLockClass = 727_002
+
+// LockDependencies takes a transaction level advisory lock on the dependency
+// graph of ownerID, so that two transactions cannot both check the graph for
+// cycles before either of them has added its dependency.
+func (t *TaskRepositoryDb) LockDependencies(ctx context.Context, ownerID int64) error {
+	_, err := t.DB.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, $2)", dependencyLockClass, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while locking task dependencies: %v", err))
+	}
+	return err
+}
+
+// AddDependency records that taskID depends on dependsOnID. Both tasks must
+// belong to ownerID; adding an existing dependency again is a no-op. Cycles
+// are not checked here, the service does that before calling it.
+func (t *TaskRepositoryDb) AddDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	return t.withRetry(ctx, func() error {
+		_, err := t.DB.ExecContext(ctx, `
+			INSERT INTO task_dependencies (task_id, depends_on_id)
+			SELECT t.id, p.id FROM tasks t JOIN tasks p ON p.owner_id = t.owner_id
+			WHERE t.id = $1 AND p.id = $2 AND t.owner_id = $3
+			ON CONFLICT DO NOTHING`, taskID, dependsOnID, ownerID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while adding task dependency: %v", err))
+			return err
+		}
+		loggerx.Info(fmt.Sprintf("Task %d now depends on task %d", taskID, dependsOnID))
+		return nil
+	})
+}
+
+func (t *TaskRepositoryDb) RemoveDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error {
+	return t.withRetry(ctx, func() error {
+		result, err := t.DB.ExecContext(ctx, `
+			DELETE FROM task_dependencies d USING tasks t
+			WHERE d.task_id = t.id AND d.task_id = $1 AND d.depends_on_id = $2 AND t.owner_id = $3`, taskID, dependsOnID, ownerID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while removing task dependency: %v", err))
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrDependencyNotFound
+		}
+		loggerx.Info(fmt.Sprintf("Task %d no longer depends on task %d", taskID, dependsOnID))
+		return nil
+	})
+}
+
+// GetDependencies returns every dependency edge between the tasks of ownerID.
+func (t *TaskRepositoryDb) GetDependencies(ctx context.Context, ownerID int64) ([]models.TaskDependency, error) {
+	var dependencies []models.TaskDependency
+	err := t.withRetry(ctx, func() error {
+		dependencies = nil
+		rows, err := t.DB.QueryContext(ctx, `
+			SELECT d.task_id, d.depends_on_id FROM task_dependencies d JOIN tasks t ON t.id = d.task_id
+			WHERE t.owner_id = $1 ORDER BY d.task_id, d.depends_on_id`, ownerID)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while getting task dependencies: %v", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var dependency models.TaskDependency
+			if err := rows.Scan(&dependency.TaskID, &dependency.DependsOnID); err != nil {
+				return err
+			}
+			dependencies = append(dependencies, dependency)
+		}
+		return rows.Err()
+	})
+	return dependencies, err
+}
+
+// GetPrerequisites returns the tasks that task id directly depends on.
+func (t *TaskRepositoryDb) GetPrerequisites(ctx context.Context, ownerID int64, id int) ([]models.Task, error) {
+	return t.queryTasks(ctx, `
+		SELECT p.id, p.owner_id, p.title, p.content, p.status, p.created_at, p.updated_at, p.version
+		FROM task_dependencies d JOIN tasks p ON p.id = d.depends_on_id
+		WHERE d.task_id = $1 AND p.owner_id = $2 ORDER BY p.id`, id, ownerID)
+}
+
+// GetDependents returns the tasks that directly depend on task id.
+func (t *TaskRepositoryDb) GetDependents(ctx context.Context, ownerID int64, id int) ([]models.Task, error) {
+	return t.queryTasks(ctx, `
+		SELECT c.id, c.owner_id, c.title, c.content, c.status, c.created_at, c.updated_at, c.version
+		FROM task_dependencies d JOIN tasks c ON c.id = d.task_id
+		WHERE d.depends_on_id = $1 AND c.owner_id = $2 ORDER BY c.id`, id, ownerID)
+}
+
+func (t *TaskRepositoryDb) queryTasks(ctx context.Context, query string, args ...interface{}) ([]models.Task, error) {
+	var tasks []models.Task
+	err := t.withRetry(ctx, func() error {
+		tasks = nil
+		rows, err := t.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while getting related tasks: %v", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			task, err := scanTask(rows)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+		return rows.Err()
+	})
+	return tasks, err
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrUnknownSortField is returned when a task listing is sorted by a field
+	// that is not in taskSortColumns.
+	ErrUnknownSortField = errors.New("unknown sort field")
+	// ErrKeysetSort is returned when a keyset page is sorted by more than one field.
+	ErrKeysetSort = errors.New("keyset pagination supports a single sort field")
+)
+
+// taskSortColumn is a sortable column and the SQL type a cursor value is cast to.
+type taskSortColumn struct {
+	name string
+	cast string
+}
+
+// taskSortColumns whitelists the fields a task listing can be sorted by.
+// Only these constants ever end up in the ORDER BY clause. All of them are
+// NOT NULL, which keyset paging relies on.
+var taskSortColumns = map[string]taskSortColumn{
+	"id":         {"id", "integer"},
+	"title":      {"title", "text"},
+	"status":     {"status", "text"},
+	"created_at": {"created_at", "timestamptz"},
+	"updated_at": {"updated_at", "timestamptz"},
+}
+
+// taskFilterWhere matches every TaskFilter field; a filter that is not set is
+// passed as NULL or an empty value and matches every row.
+const taskFilterWhere = `
+	WHERE owner_id = $1
+		AND (cardinality($2::text[]) = 0 OR status = ANY($2::text[]))
+		AND ($3 = '' OR search_vector @@ websearch_to_tsquery('simple', $3))
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at <= $5)
+		AND ($6::timestamptz IS NULL OR updated_at >= $6)
+		AND ($7::timestamptz IS NULL OR updated_at <= $7)`
+
+// SortableTaskField reports whether a task listing can be sorted by field.
+func SortableTaskField(field string) bool {
+	_, ok := taskSortColumns[field]
+	return ok
+}
+
+// FindTasks returns the tasks of ownerID that match filter, in filter.Sort
+// order followed by id. A page read backwards with filter.Before is returned
+// in the same order as a forward page.
+func (t *TaskRepositoryDb) FindTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) ([]models.Task, error) {
+	var limit interface{}
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	args := append(taskFilterArgs(ownerID, filter), limit, filter.Offset)
+
+	var query string
+	backward := filter.Before != nil
+	if filter.After != nil || backward {
+		keyset, orderBy, err := taskKeyset(filter)
+		if err != nil {
+			return nil, err
+		}
+		cursor := filter.After
+		if backward {
+			cursor = filter.Before
+		}
+		args = append(args, cursor.Value, cursor.ID)
+		query = "SELECT " + taskColumns + " FROM tasks" + taskFilterWhere + keyset + " ORDER BY " + orderBy + " LIMIT $8 OFFSET $9"
+	} else {
+		orderBy, err := taskOrderBy(filter.Sort)
+		if err != nil {
+			return nil, err
+		}
+		query = "SELECT " + taskColumns + " FROM tasks" + taskFilterWhere + " ORDER BY " + orderBy + " LIMIT $8 OFFSET $9"
+	}
+
+	var tasks []models.Task
+	err := t.withRetry(ctx, func() error {
+		tasks = nil
+		rows, err := t.DB.QueryContext(ctx, query, args...)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while finding tasks: %v", err))
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			task, err := scanTask(rows)
+			if err != nil {
+				loggerx.Error(fmt.Sprintf("Error while scanning task: %v", err))
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+		return rows.Err()
+	})
+	if err == nil && backward {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+	return tasks, err
+}
+
+// CountTasks returns how many tasks of ownerID match filter, ignoring its
+// sort order, offset and limit.
+func (t *TaskRepositoryDb) CountTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) (int64, error) {
+	var total int64
+	err := t.withRetry(ctx, func() error {
+		err := t.DB.QueryRowContext(ctx, "SELECT count(*) FROM tasks"+taskFilterWhere, taskFilterArgs(ownerID, filter)...).Scan(&total)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while counting tasks: %v", err))
+		}
+		return err
+	})
+	return total, err
+}
+
+func taskFilterArgs(ownerID int64, filter models.TaskFilter) []interface{} {
+	statuses := make([]string, len(filter.Statuses))
+	for i, status := range filter.Statuses {
+		statuses[i] = string(status)
+	}
+	return []interface{}{
+		ownerID,
+		pq.Array(statuses),
+		strings.TrimSpace(filter.Search),
+		filter.CreatedFrom,
+		filter.CreatedTo,
+		filter.UpdatedFrom,
+		filter.UpdatedTo,
+	}
+}
+
+// taskOrderBy builds the ORDER BY list from whitelisted columns. id is the
+// last key unless it is sorted on explicitly, so that pages are stable.
+func taskOrderBy(sort []models.TaskSort) (string, error) {
+	keys := make([]string, 0, len(sort)+1)
+	byID := false
+	for _, s := range sort {
+		column, ok := taskSortColumns[s.Field]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownSortField, s.Field)
+		}
+		keys = append(keys, column.name+sortDirection(s.Desc))
+		byID = byID || column.name == "id"
+	}
+	if !byID {
+		keys = append(keys, "id ASC")
+	}
+	return strings.Join(keys, ", "), nil
+}
+
+// taskKeyset returns the condition that skips to the cursor, as $10 (sort
+// value) and $11 (id), and the matching ORDER BY. id is sorted in the same
+// direction as the sort field so that (field, id) can be compared as a row.
+// A backward page is read in reverse order.
+func taskKeyset(filter models.TaskFilter) (string, string, error) {
+	column, desc, err := taskKeysetSort(filter)
+	if err != nil {
+		return "", "", err
+	}
+	operator := " > "
+	if desc {
+		operator = " < "
+	}
+	keyset := " AND (" + column.name + ", id)" + operator + "($10::" + column.cast + ", $11::integer)"
+	orderBy := column.name + sortDirection(desc) + ", id" + sortDirection(desc)
+	return keyset, orderBy, nil
+}
+
+// taskKeysetSort returns the column a keyset page is sorted by and the
+// direction it is read in.
+func taskKeysetSort(filter models.TaskFilter) (taskSortColumn, bool, error) {
+	if len(filter.Sort) > 1 {
+		return taskSortColumn{}, false, ErrKeysetSort
+	}
+	sort := models.TaskSort{Field: "id"}
+	if len(filter.Sort) == 1 {
+		sort = filter.Sort[0]
+	}
+	column, ok := taskSortColumns[sort.Field]
+	if !ok {
+		return taskSortColumn{}, false, fmt.Errorf("%w: %s", ErrUnknownSortField, sort.Field)
+	}
+	return column, sort.Desc != (filter.Before != nil), nil
+}
+
+func sortDirection(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
@@ -3,39 +3,73 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"konzek-jun/loggerx"
 	"konzek-jun/models"
-	"time"
+	"konzek-jun/retry"
+	"slices"
+	"strings"
 
 	_ "github.com/lib/pq"
 )
 
+var (
+	// ErrTaskNotFound is returned when a task does not exist or belongs to another user.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrVersionConflict is returned when a write requires a version the task no longer has.
+	ErrVersionConflict = errors.New("task version conflict")
+)
+
+const taskColumns = "id, owner_id, title, content, status, created_at, updated_at, version"
+
+// insertBatchSize is how many tasks one INSERT of InsertMany adds. A
+// statement may have at most 65535 parameters.
+const insertBatchSize = 500
+
 type TaskRepositoryDb struct {
-	DB *sql.DB
+	DB    DBTX
+	Retry retry.Policy
 }
 
 type TaskRepository interface {
-	Insert(todo models.Task) (int64, error)
-	GetAll() ([]models.Task, error)
-	Delete(id int) error
-	GetByID(id int) (models.Task, error)
-	Update(task models.Task) error
-	GetTasksWithPagination(offset, limit int) ([]models.Task, error)
+	Insert(ctx context.Context, task models.Task) (int64, error)
+	// InsertMany inserts tasks in batches and returns their ids in the same
+	// order. It is only atomic when it runs in a unit of work.
+	InsertMany(ctx context.Context, tasks []models.Task) ([]int64, error)
+	GetAll(ctx context.Context, ownerID int64) ([]models.Task, error)
+	// Delete removes task id. A version other than 0 has to match the task.
+	Delete(ctx context.Context, ownerID int64, id int, version int) error
+	GetByID(ctx context.Context, ownerID int64, id int) (models.Task, error)
+	// Update writes title, content and status. A task.Version other than 0
+	// has to match the stored task.
+	Update(ctx context.Context, task models.Task) error
+	// UpdateStatus writes status. A version other than 0 has to match the
+	// task.
+	UpdateStatus(ctx context.Context, ownerID int64, id int, status models.TaskStatus, version int) error
+	FindTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) ([]models.Task, error)
+	CountTasks(ctx context.Context, ownerID int64, filter models.TaskFilter) (int64, error)
+	AddDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error
+	RemoveDependency(ctx context.Context, ownerID int64, taskID, dependsOnID int) error
+	GetDependencies(ctx context.Context, ownerID int64) ([]models.TaskDependency, error)
+	GetPrerequisites(ctx context.Context, ownerID int64, id int) ([]models.Task, error)
+	GetDependents(ctx context.Context, ownerID int64, id int) ([]models.Task, error)
+	// LockDependencies makes other units of work that lock the dependency
+	// graph of ownerID wait until the current one ends. Outside of a unit of
+	// work it does nothing.
+	LockDependencies(ctx context.Context, ownerID int64) error
 }
 
 func NewTaskRepository(db *sql.DB) *TaskRepositoryDb {
-	return &TaskRepositoryDb{DB: db}
+	return &TaskRepositoryDb{DB: db, Retry: DefaultRetryPolicy}
 }
 
-func (t *TaskRepositoryDb) Insert(task models.Task) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (t *TaskRepositoryDb) Insert(ctx context.Context, task models.Task) (int64, error) {
 	var lastInsertID int64
 
-	err := withRetry(func() error {
-		err := t.DB.QueryRowContext(ctx, "INSERT INTO tasks (title, content, status) VALUES ($1, $2, $3) RETURNING id", task.Title, task.Content, task.Status).Scan(&lastInsertID)
-
+	err := t.withRetry(ctx, func() error {
+		var err error
+		lastInsertID, err = insertTask(ctx, t.DB, task)
 		if err != nil {
 			loggerx.Error(fmt.Sprintf("Error while inserting task: %v", err))
 			return err
@@ -47,12 +81,90 @@ func (t *TaskRepositoryDb) Insert(task models.Task) (int64, error) {
 	return lastInsertID, err
 }
 
-func (t *TaskRepositoryDb) GetAll() ([]models.Task, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// insertTask is shared with the scheduler, which creates tasks inside its own transaction.
+func insertTask(ctx context.Context, db interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, task models.Task) (int64, error) {
+	var id int64
+	err := db.QueryRowContext(ctx, "INSERT INTO tasks (owner_id, title, content, status, job_type, payload, job_status, max_attempts) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6::jsonb, $7, $8) RETURNING id",
+		insertArgs(task)...).Scan(&id)
+	return id, err
+}
+
+// insertArgs are the parameters of one task row of an INSERT.
+func insertArgs(task models.Task) []interface{} {
+	var jobStatus interface{}
+	if task.JobType != "" {
+		jobStatus = models.JobStatusPending
+	}
+	maxAttempts := task.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = models.DefaultJobMaxAttempts
+	}
+	return []interface{}{task.OwnerID, task.Title, task.Content, task.Status, task.JobType, nullableJSON(task.Payload), jobStatus, maxAttempts}
+}
+
+func (t *TaskRepositoryDb) InsertMany(ctx context.Context, tasks []models.Task) ([]int64, error) {
+	ids := make([]int64, 0, len(tasks))
+	for start := 0; start < len(tasks); start += insertBatchSize {
+		batch := tasks[start:min(start+insertBatchSize, len(tasks))]
+		var batchIDs []int64
+		err := t.withRetry(ctx, func() error {
+			var err error
+			batchIDs, err = t.insertBatch(ctx, batch)
+			return err
+		})
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while inserting tasks: %v", err))
+			return nil, err
+		}
+		ids = append(ids, batchIDs...)
+	}
+	loggerx.Info(fmt.Sprintf("%d tasks inserted successfully", len(tasks)))
+	return ids, nil
+}
+
+// insertBatch adds tasks with one multi-row INSERT.
+func (t *TaskRepositoryDb) insertBatch(ctx context.Context, tasks []models.Task) ([]int64, error) {
+	var query strings.Builder
+	query.WriteString("INSERT INTO tasks (owner_id, title, content, status, job_type, payload, job_status, max_attempts) VALUES ")
+	args := make([]interface{}, 0, len(tasks)*8)
+	for i, task := range tasks {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, NULLIF($%d, ''), $%d::jsonb, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+		args = append(args, insertArgs(task)...)
+	}
+	query.WriteString(" RETURNING id")
+
+	rows, err := t.DB.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int64, 0, len(tasks))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Rows are inserted in the order of VALUES, so their ids increase in that order
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (t *TaskRepositoryDb) GetAll(ctx context.Context, ownerID int64) ([]models.Task, error) {
 	var tasks []models.Task
-	err := withRetry(func() error {
-		rows, err := t.DB.QueryContext(ctx, "SELECT id, title, content, status FROM tasks")
+	err := t.withRetry(ctx, func() error {
+		tasks = nil
+		rows, err := t.DB.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE owner_id = $1 ORDER BY id", ownerID)
 		if err != nil {
 			loggerx.Error(fmt.Sprintf("Error while getting all tasks: %v", err))
 			return err
@@ -60,8 +172,7 @@ func (t *TaskRepositoryDb) GetAll() ([]models.Task, error) {
 		defer rows.Close()
 
 		for rows.Next() {
-			var task models.Task
-			err := rows.Scan(&task.Id, &task.Title, &task.Content, &task.Status)
+			task, err := scanTask(rows)
 			if err != nil {
 				return err
 			}
@@ -74,27 +185,30 @@ func (t *TaskRepositoryDb) GetAll() ([]models.Task, error) {
 	return tasks, err
 }
 
-func (t *TaskRepositoryDb) Delete(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	err := withRetry(func() error {
-		_, err := t.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
+func (t *TaskRepositoryDb) Delete(ctx context.Context, ownerID int64, id int, version int) error {
+	err := t.withRetry(ctx, func() error {
+		result, err := t.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1 AND owner_id = $2 AND ($3::integer = 0 OR version = $3)", id, ownerID, version)
 		if err != nil {
 			loggerx.Error(fmt.Sprintf("Error while deleting task: %v", err))
 			return err
 		}
+		if err := checkAffected(result); err != nil {
+			return t.missingOrStale(ctx, ownerID, id, version)
+		}
 		loggerx.Info("Task deleted successfully")
 		return nil
 	})
 	return err
 }
 
-func (t *TaskRepositoryDb) GetByID(id int) (models.Task, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (t *TaskRepositoryDb) GetByID(ctx context.Context, ownerID int64, id int) (models.Task, error) {
 	var task models.Task
-	err := withRetry(func() error {
-		err := t.DB.QueryRowContext(ctx, "SELECT id, title, content, status FROM tasks WHERE id = $1", id).Scan(&task.Id, &task.Title, &task.Content, &task.Status)
+	err := t.withRetry(ctx, func() error {
+		var err error
+		task, err = scanTask(t.DB.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1 AND owner_id = $2", id, ownerID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotFound
+		}
 		if err != nil {
 			loggerx.Error(fmt.Sprintf("Error while getting task by ID: %v", err))
 			return err
@@ -105,56 +219,91 @@ func (t *TaskRepositoryDb) GetByID(id int) (models.Task, error) {
 	return task, err
 }
 
-func (t *TaskRepositoryDb) Update(task models.Task) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	err := withRetry(func() error {
-		_, err := t.DB.ExecContext(ctx, "UPDATE tasks SET title = $1, content = $2, status = $3 WHERE id = $4", task.Title, task.Content, task.Status, task.Id)
+func (t *TaskRepositoryDb) Update(ctx context.Context, task models.Task) error {
+	err := t.withRetry(ctx, func() error {
+		result, err := t.DB.ExecContext(ctx, `
+			UPDATE tasks SET title = $1, content = $2, status = $3, updated_at = now(), version = version + 1
+			WHERE id = $4 AND owner_id = $5 AND ($6::integer = 0 OR version = $6)`, task.Title, task.Content, task.Status, task.Id, task.OwnerID, task.Version)
 		if err != nil {
 			loggerx.Error(fmt.Sprintf("Error while updating task: %v", err))
 			return err
 		}
+		if err := checkAffected(result); err != nil {
+			return t.missingOrStale(ctx, task.OwnerID, task.Id, task.Version)
+		}
 		loggerx.Info("Task updated successfully")
 		return nil
 	})
 	return err
 }
 
-func withRetry(operation func() error) error {
-	var err error
-	for i := 0; i < 3; i++ {
-		err = operation()
-		if err == nil {
-			return nil
+func (t *TaskRepositoryDb) UpdateStatus(ctx context.Context, ownerID int64, id int, status models.TaskStatus, version int) error {
+	err := t.withRetry(ctx, func() error {
+		result, err := t.DB.ExecContext(ctx, `
+			UPDATE tasks SET status = $1, updated_at = now(), version = version + 1
+			WHERE id = $2 AND owner_id = $3 AND ($4::integer = 0 OR version = $4)`, status, id, ownerID, version)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while updating task status: %v", err))
+			return err
 		}
-		loggerx.Error(fmt.Sprintf("Error occurred, retrying: %v", err))
-		time.Sleep(100 * time.Millisecond)
-	}
+		if err := checkAffected(result); err != nil {
+			return t.missingOrStale(ctx, ownerID, id, version)
+		}
+		loggerx.Info("Task status updated successfully")
+		return nil
+	})
 	return err
 }
 
-func (t *TaskRepositoryDb) GetTasksWithPagination(offset, limit int) ([]models.Task, error) {
-	query := "SELECT * FROM tasks ORDER BY id LIMIT $1 OFFSET $2"
-	rows, err := t.DB.Query(query, limit, offset)
+// missingOrStale tells why a write of task id that required version matched
+// no row: the task is gone or it has another version by now.
+func (t *TaskRepositoryDb) missingOrStale(ctx context.Context, ownerID int64, id int, version int) error {
+	if version == 0 {
+		return ErrTaskNotFound
+	}
+	var exists bool
+	err := t.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND owner_id = $2)", id, ownerID).Scan(&exists)
 	if err != nil {
-		loggerx.Error(fmt.Sprintf("Error while getting tasks with pagination: %v", err))
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	if !exists {
+		return ErrTaskNotFound
+	}
+	return ErrVersionConflict
+}
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		if err := rows.Scan(&task.Id, &task.Title, &task.Content, &task.Status); err != nil {
-			loggerx.Error(fmt.Sprintf("Error while scanning task: %v", err))
-			return nil, err
+func checkAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+func (t *TaskRepositoryDb) withRetry(ctx context.Context, operation func() error) error {
+	return retry.Do(ctx, t.Retry, func() error {
+		err := operation()
+		if err != nil && t.Retry.IsRetryable(err) {
+			loggerx.Error(fmt.Sprintf("Error occurred, retrying: %v", err))
 		}
-		tasks = append(tasks, task)
+		return err
+	})
+}
+
+func scanTask(row rowScanner) (models.Task, error) {
+	var task models.Task
+	var createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&task.Id, &task.OwnerID, &task.Title, &task.Content, &task.Status, &createdAt, &updatedAt, &task.Version); err != nil {
+		return models.Task{}, err
 	}
-	if err := rows.Err(); err != nil {
-		loggerx.Error(fmt.Sprintf("Error occurred while iterating over rows: %v", err))
-		return nil, err
+	if createdAt.Valid {
+		task.CreatedAt = &createdAt.Time
+	}
+	if updatedAt.Valid {
+		task.UpdatedAt = &updatedAt.Time
 	}
-	loggerx.Info("Retrieved tasks with pagination successfully")
-	return tasks, nil
+	return task, nil
 }
@@ -1,113 +1,90 @@
 package repository_test
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"os"
 	"testing"
 
-	"konzek-jun/models"
+	"konzek-jun/migrations"
 	"konzek-jun/repository"
+	"konzek-jun/repository/repositorytest"
 
 	_ "github.com/lib/pq"
 )
 
-func TestTaskRepository(t *testing.T) {
+// testDatabaseURL, Postgres testlerinin bağlandığı veritabanını değiştirir
+const testDatabaseURL = "KONZEK_TEST_DATABASE_URL"
 
-	db, err := sql.Open("postgres", "dbname=konzek user=postgres password=test host=localhost port=5432 sslmode=disable")
+// postgresRepositories boş bir veritabanındaki repository'leri döner.
+// Postgres'e ulaşılamazsa test atlanır.
+func postgresRepositories(t *testing.T) repositorytest.Repositories {
+	dsn := os.Getenv(testDatabaseURL)
+	if dsn == "" {
+		dsn = "dbname=konzek user=postgres password=test host=localhost port=5432 sslmode=disable"
+	}
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		log.Fatalf("Veritabanına bağlanırken hata oluştu: %v", err)
+		t.Fatalf("Veritabanına bağlanırken hata oluştu: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Skipf("Postgres'e ulaşılamadı, test atlanıyor (%s ile ayarlanabilir): %v", testDatabaseURL, err)
 	}
-	defer db.Close()
-
-	clearDatabase(db)
-
-	taskRepo := repository.NewTaskRepository(db)
 
-	// Insert metodunu test et
-	t.Run("Insert", func(t *testing.T) {
-		task := models.Task{Title: "Test Task", Content: "Test Content", Status: true}
-		id, err := taskRepo.Insert(task)
-		if err != nil {
-			t.Errorf("Task eklenirken hata oluştu: %v", err)
-		}
-		// Insert işlemi başarılıysa, dönen ID'nin 0'dan büyük olduğunu kontrol et
-		if id <= 0 {
-			t.Errorf("Beklenen ID 0'dan büyük değil, alınan: %d", id)
-		}
-	})
+	migrator, err := migrations.New(db, migrations.Postgres)
+	if err != nil {
+		t.Fatalf("Migration'lar okunurken hata oluştu: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Migration'lar uygulanırken hata oluştu: %v", err)
+	}
 
-	// GetAll metodunu test et
-	t.Run("GetAll", func(t *testing.T) {
-		// Test için örnek veri ekle
-		_, err := db.Exec("INSERT INTO tasks (title, content, status) VALUES ($1, $2, $3)", "Test Task 1", "Test Content 1", true)
-		if err != nil {
-			t.Fatalf("Veritabanına örnek veri eklerken hata oluştu: %v", err)
-		}
+	// Veritabanını temizle
+	if _, err := db.Exec("TRUNCATE users, tasks RESTART IDENTITY CASCADE"); err != nil {
+		t.Fatalf("Veritabanını temizlerken hata oluştu: %v", err)
+	}
+	return repositorytest.Repositories{
+		Tasks:            repository.NewTaskRepository(db),
+		Users:            repository.NewUserRepo(db),
+		UnitOfWork:       repository.NewTxManager(db),
+		Jobs:             repository.NewJobQueue(db),
+		Schedules:        repository.NewScheduleRepository(db),
+		Idempotency:      repository.NewIdempotencyRepository(db),
+		RefreshTokens:    repository.NewRefreshTokenRepository(db),
+		TokenRevocations: repository.NewTokenRevocationRepository(db),
+		APIKeys:          repository.NewAPIKeyRepository(db),
+	}
+}
 
-		// GetAll metodunu test et
-		tasks, err := taskRepo.GetAll()
-		if err != nil {
-			t.Errorf("Task'leri getirirken hata oluştu: %v", err)
-		}
-		if len(tasks) != 1 {
-			t.Errorf("Beklenen task sayısı 1 değil, alınan: %d", len(tasks))
-		}
-	})
+func TestTaskRepository(t *testing.T) {
+	repositorytest.RunTaskRepository(t, postgresRepositories)
+}
 
-	// Delete metodunu test et
-	t.Run("Delete", func(t *testing.T) {
-		// Test için örnek veri ekle
-		_, err := db.Exec("INSERT INTO tasks (title, content, status) VALUES ($1, $2, $3)", "Test Task 1", "Test Content 1", true)
-		if err != nil {
-			t.Fatalf("Veritabanına örnek veri eklerken hata oluştu: %v", err)
-		}
+func TestTxManager(t *testing.T) {
+	repositorytest.RunUnitOfWork(t, postgresRepositories)
+}
 
-		// Delete metodunu test et
-		err = taskRepo.Delete(1)
-		if err != nil {
-			t.Errorf("Task silinirken hata oluştu: %v", err)
-		}
-	})
+func TestJobQueue(t *testing.T) {
+	repositorytest.RunJobQueue(t, postgresRepositories)
+}
 
-	// GetByID metodunu test et
-	t.Run("GetByID", func(t *testing.T) {
-		// Test için örnek veri ekle
-		_, err := db.Exec("INSERT INTO tasks (title, content, status) VALUES ($1, $2, $3)", "Test Task 1", "Test Content 1", true)
-		if err != nil {
-			t.Fatalf("Veritabanına örnek veri eklerken hata oluştu: %v", err)
-		}
+func TestScheduleRepository(t *testing.T) {
+	repositorytest.RunScheduleRepository(t, postgresRepositories)
+}
 
-		// GetByID metodunu test et
-		task, err := taskRepo.GetByID(1)
-		if err != nil {
-			t.Errorf("Task getirilirken hata oluştu: %v", err)
-		}
-		if task.Id != 0 {
-			t.Errorf("Beklenen task ID'si 1 değil, alınan: %d", task.Id)
-		}
-	})
+func TestIdempotencyRepository(t *testing.T) {
+	repositorytest.RunIdempotencyRepository(t, postgresRepositories)
+}
 
-	// Update metodunu test et
-	t.Run("Update", func(t *testing.T) {
-		// Test için örnek veri ekle
-		_, err := db.Exec("INSERT INTO tasks (title, content, status) VALUES ($1, $2, $3)", "Test Task 1", "Test Content 1", true)
-		if err != nil {
-			t.Fatalf("Veritabanına örnek veri eklerken hata oluştu: %v", err)
-		}
+func TestRefreshTokenRepository(t *testing.T) {
+	repositorytest.RunRefreshTokenRepository(t, postgresRepositories)
+}
 
-		// Update metodunu test et
-		task := models.Task{Id: 1, Title: "Updated Task", Content: "Updated Content", Status: false}
-		err = taskRepo.Update(task)
-		if err != nil {
-			t.Errorf("Task güncellenirken hata oluştu: %v", err)
-		}
-	})
+func TestTokenRevocationRepository(t *testing.T) {
+	repositorytest.RunTokenRevocationRepository(t, postgresRepositories)
 }
 
-// Test veritabanını temizle
-func clearDatabase(db *sql.DB) {
-	_, err := db.Exec("DELETE FROM tasks")
-	if err != nil {
-		log.Fatalf("Veritabanını temizlerken hata oluştu: %v", err)
-	}
+func TestAPIKeyRepository(t *testing.T) {
+	repositorytest.RunAPIKeyRepository(t, postgresRepositories)
 }
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks//repository/mockTokenrevocationrepository.go -package=repository konzek-jun/repository TokenRevocationRepository
+type TokenRevocationRepository interface {
+	// Revoke stores revocations. Revoking a token id again keeps the later
+	// expiry.
+	Revoke(ctx context.Context, revocations []models.TokenRevocation) error
+	// ListActive returns the revocations that have not expired at now.
+	ListActive(ctx context.Context, now time.Time) ([]models.TokenRevocation, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type TokenRevocationRepositoryDb struct {
+	DB *sql.DB
+}
+
+func NewTokenRevocationRepository(db *sql.DB) *TokenRevocationRepositoryDb {
+	return &TokenRevocationRepositoryDb{DB: db}
+}
+
+func (r *TokenRevocationRepositoryDb) Revoke(ctx context.Context, revocations []models.TokenRevocation) error {
+	for _, revocation := range revocations {
+		_, err := r.DB.ExecContext(ctx, `
+			INSERT INTO token_revocations (token_id, user_id, expires_at) VALUES ($1, $2, $3)
+			ON CONFLICT (token_id) DO UPDATE SET expires_at = GREATEST(token_revocations.expires_at, EXCLUDED.expires_at)`,
+			revocation.TokenID, revocation.UserID, revocation.ExpiresAt)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while revoking token: %v", err))
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TokenRevocationRepositoryDb) ListActive(ctx context.Context, now time.Time) ([]models.TokenRevocation, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT token_id, user_id, expires_at FROM token_revocations WHERE expires_at > $1 ORDER BY token_id", now)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing token revocations: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	revocations := []models.TokenRevocation{}
+	for rows.Next() {
+		var revocation models.TokenRevocation
+		if err := rows.Scan(&revocation.TokenID, &revocation.UserID, &revocation.ExpiresAt); err != nil {
+			return nil, err
+		}
+		revocations = append(revocations, revocation)
+	}
+	return revocations, rows.Err()
+}
+
+func (r *TokenRevocationRepositoryDb) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM token_revocations WHERE expires_at <= $1", now)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting expired token revocations: %v", err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/retry"
+	"sync"
+)
+
+// DBTX is what the SQL repositories run their queries on: a *sql.DB, or the
+// transaction of a unit of work.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Repositories are the repositories a unit of work hands to its function.
+type Repositories struct {
+	Tasks TaskRepository
+	Users UserRepository
+}
+
+// UnitOfWork runs several repository calls atomically.
+type UnitOfWork interface {
+	// Do runs fn with repositories that are bound to one transaction. The
+	// transaction is committed when fn returns nil and rolled back
+	// otherwise; the error of fn is returned as is. fn may run more than
+	// once when the transaction is retried, so it should only touch the
+	// repositories it is given.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// TxManager is the UnitOfWork of the SQL storages: it runs fn in a *sql.Tx
+// and retries the whole transaction on transient errors such as
+// serialization failures and deadlocks.
+type TxManager struct {
+	DB *sql.DB
+	// Bind returns the repositories of the storage bound to tx.
+	Bind  func(tx *sql.Tx) Repositories
+	Retry retry.Policy
+}
+
+// NewTxManager returns the unit of work of the Postgres storage. Repositories
+// bound to a transaction do not retry single statements, the TxManager
+// retries the transaction instead.
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{
+		DB: db,
+		Bind: func(tx *sql.Tx) Repositories {
+			return Repositories{
+				Tasks: &TaskRepositoryDb{DB: tx, Retry: retry.Policy{MaxAttempts: 1}},
+				Users: &userRepo{db: tx},
+			}
+		},
+		Retry: DefaultRetryPolicy,
+	}
+}
+
+// NewSQLiteTxManager returns the unit of work of the sqlite storage. The
+// storage has a single connection, so transactions run one after the other
+// and are not retried.
+func NewSQLiteTxManager(db *sql.DB) *TxManager {
+	return &TxManager{
+		DB: db,
+		Bind: func(tx *sql.Tx) Repositories {
+			return Repositories{
+				Tasks: &SQLiteTaskRepository{DB: tx},
+				Users: &SQLiteUserRepository{DB: tx},
+			}
+		},
+		Retry: retry.Policy{MaxAttempts: 1},
+	}
+}
+
+func (m *TxManager) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	return retry.Do(ctx, m.Retry, func() error {
+		err := m.run(ctx, fn)
+		if err != nil && m.Retry.IsRetryable(err) {
+			loggerx.Error(fmt.Sprintf("Transaction failed, retrying: %v", err))
+		}
+		return err
+	})
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(repos Repositories) error) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while beginning transaction: %v", err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(m.Bind(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while committing transaction: %v", err))
+		return err
+	}
+	return nil
+}
+
+// MemoryUnitOfWork is the UnitOfWork of the memory storage. Units of work
+// run one at a time and the repositories they hand to fn record how to undo
+// each write. When fn fails, only those writes are undone, in reverse order;
+// writes other goroutines made in the meantime stay, and a task or user they
+// wrote again after fn keeps their version. Reads outside the unit of work
+// see its writes before it ends. It is meant for tests and local
+// development.
+type MemoryUnitOfWork struct {
+	mu    sync.Mutex
+	Tasks *MemoryTaskRepository
+	Users *MemoryUserRepository
+}
+
+func NewMemoryUnitOfWork(tasks *MemoryTaskRepository, users *MemoryUserRepository) *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{Tasks: tasks, Users: users}
+}
+
+func (m *MemoryUnitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var undo memoryUndoLog
+	repos := Repositories{
+		Tasks: memoryTaskTx{MemoryTaskRepository: m.Tasks, undo: &undo},
+		Users: memoryUserTx{MemoryUserRepository: m.Users, undo: &undo},
+	}
+	if err := fn(repos); err != nil {
+		undo.rollback()
+		return err
+	}
+	return nil
+}
+
+// memoryUndoLog holds the funcs that undo the writes of a memory unit of
+// work.
+type memoryUndoLog []func()
+
+func (l *memoryUndoLog) add(undo func()) {
+	*l = append(*l, undo)
+}
+
+// record adds undo when the write succeeded and returns err.
+func (l *memoryUndoLog) record(undo func(), err error) error {
+	if err == nil {
+		l.add(undo)
+	}
+	return err
+}
+
+func (l memoryUndoLog) rollback() {
+	for i := len(l) - 1; i >= 0; i-- {
+		l[i]()
+	}
+}
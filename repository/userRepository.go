@@ -1,25 +1,38 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"konzek-jun/loggerx"
 	"konzek-jun/models"
 	"log"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrUserNotFound is returned when no user has the given id or email.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when another user already has the email.
+	ErrEmailTaken = errors.New("email already in use")
+)
+
 //go:generate mockgen -destination=../mocks//repository/mockUserrepository.go -package=repository konzek-jun/repository UserRepository
 type UserRepository interface {
-	InsertUser(user models.User) (models.User, error)
-	UpdateUser(user models.User) (models.User, error)
-	FindByEmail(email string) (models.User, error)
-	FindByUserID(userID string) (models.User, error)
+	InsertUser(ctx context.Context, user models.User) (models.User, error)
+	UpdateUser(ctx context.Context, user models.User) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	FindByUserID(ctx context.Context, userID string) (models.User, error)
+	// ListUsers returns every user by id, without passwords.
+	ListUsers(ctx context.Context) ([]models.User, error)
+	SetRole(ctx context.Context, userID int64, role models.Role) (models.User, error)
 }
 
 type userRepo struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewUserRepo(db *sql.DB) UserRepository {
@@ -28,61 +41,125 @@ func NewUserRepo(db *sql.DB) UserRepository {
 	}
 }
 
-func (ur *userRepo) InsertUser(user models.User) (models.User, error) {
+func (ur *userRepo) InsertUser(ctx context.Context, user models.User) (models.User, error) {
 	user.Password = hashAndSalt([]byte(user.Password))
-	err := ur.db.QueryRow("INSERT INTO users (name, email, password) VALUES ($1, $2, $3) RETURNING id", user.Name, user.Email, user.Password).Scan(&user.ID)
+	user.Role = defaultRole(user.Role)
+	err := ur.db.QueryRowContext(ctx, "INSERT INTO users (name, email, password, role) VALUES ($1, $2, $3, $4) RETURNING id", user.Name, user.Email, user.Password, user.Role).Scan(&user.ID)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while inserting user: %v", err))
-		return models.User{}, err
+		return models.User{}, userError(err)
 	}
 	loggerx.Info("User inserted successfully")
 	return user, nil
 }
 
-func (ur *userRepo) UpdateUser(user models.User) (models.User, error) {
+func (ur *userRepo) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
 	if user.Password != "" {
 		user.Password = hashAndSalt([]byte(user.Password))
 	} else {
 		var tempUser models.User
-		err := ur.db.QueryRow("SELECT password FROM users WHERE id = $1", user.ID).Scan(&tempUser.Password)
+		err := ur.db.QueryRowContext(ctx, "SELECT password FROM users WHERE id = $1", user.ID).Scan(&tempUser.Password)
 		if err != nil {
 			loggerx.Error(fmt.Sprintf("Error while updating user: %v", err))
-			return models.User{}, err
+			return models.User{}, userError(err)
 		}
 		user.Password = tempUser.Password
 	}
 
-	_, err := ur.db.Exec("UPDATE users SET name = $1, email = $2, password = $3 WHERE id = $4", user.Name, user.Email, user.Password, user.ID)
+	result, err := ur.db.ExecContext(ctx, "UPDATE users SET name = $1, email = $2, password = $3 WHERE id = $4", user.Name, user.Email, user.Password, user.ID)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while updating user: %v", err))
+		return models.User{}, userError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
 		return models.User{}, err
 	}
+	if affected == 0 {
+		return models.User{}, ErrUserNotFound
+	}
 	loggerx.Info("User updated successfully")
 	return user, nil
 }
 
-func (ur *userRepo) FindByEmail(email string) (models.User, error) {
+func (ur *userRepo) FindByEmail(ctx context.Context, email string) (models.User, error) {
 	var user models.User
-	err := ur.db.QueryRow("SELECT id, name, email, password FROM users WHERE email = $1", email).Scan(&user.ID, &user.Name, &user.Email, &user.Password)
+	err := ur.db.QueryRowContext(ctx, "SELECT id, name, email, password, role FROM users WHERE email = $1", email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while finding user by email: %v", err))
-		return models.User{}, err
+		return models.User{}, userError(err)
 	}
 	loggerx.Info("User found by email successfully")
 	return user, nil
 }
 
-func (ur *userRepo) FindByUserID(userID string) (models.User, error) {
+func (ur *userRepo) FindByUserID(ctx context.Context, userID string) (models.User, error) {
 	var user models.User
-	err := ur.db.QueryRow("SELECT id, name, email, password FROM users WHERE id = $1", userID).Scan(&user.ID, &user.Name, &user.Email, &user.Password)
+	err := ur.db.QueryRowContext(ctx, "SELECT id, name, email, password, role FROM users WHERE id = $1", userID).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Role)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while finding user by ID: %v", err))
-		return models.User{}, err
+		return models.User{}, userError(err)
 	}
 	loggerx.Info("User found by ID successfully")
 	return user, nil
 }
 
+func (ur *userRepo) ListUsers(ctx context.Context) ([]models.User, error) {
+	rows, err := ur.db.QueryContext(ctx, "SELECT id, name, email, role FROM users ORDER BY id")
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing users: %v", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (ur *userRepo) SetRole(ctx context.Context, userID int64, role models.Role) (models.User, error) {
+	user := models.User{ID: userID}
+	err := ur.db.QueryRowContext(ctx, "UPDATE users SET role = $1 WHERE id = $2 RETURNING name, email, role", role, userID).Scan(&user.Name, &user.Email, &user.Role)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while setting user role: %v", err))
+		return models.User{}, userError(err)
+	}
+	loggerx.Info("User role set successfully")
+	return user, nil
+}
+
+// defaultRole is the role of a user inserted without one.
+func defaultRole(role models.Role) models.Role {
+	if role == "" {
+		return models.RoleMember
+	}
+	return role
+}
+
+// userError maps a missing row and a duplicate email to ErrUserNotFound and
+// ErrEmailTaken. An id that is not a number cannot match a user either.
+func userError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			return ErrEmailTaken
+		case "22P02":
+			return ErrUserNotFound
+		}
+	}
+	return err
+}
+
 func hashAndSalt(pwd []byte) string {
 	hash, err := bcrypt.GenerateFromPassword(pwd, bcrypt.MinCost)
 	if err != nil {
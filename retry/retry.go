@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy describes how often and how fast a failed operation is retried.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction (0-1) of every backoff that is randomised so
+	// that many clients failing together do not retry in lockstep.
+	Jitter float64
+	// Retryable decides which errors are worth another try. A nil
+	// Retryable retries every error that is not marked Permanent.
+	Retryable func(error) bool
+}
+
+// Backoff returns how long to wait after the given failed attempt (1-based).
+func (p Policy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		backoff = backoff*(1-jitter) + backoff*jitter*rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// IsRetryable reports whether err may succeed on another try.
+func (p Policy) IsRetryable(err error) bool {
+	if err == nil || IsPermanent(err) {
+		return false
+	}
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// Do runs op until it succeeds, returns a non-retryable error, runs out of
+// attempts or ctx is done. The last error is returned.
+func Do(ctx context.Context, p Policy, op func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = op()
+		if err == nil || !p.IsRetryable(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(p.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not retryable regardless of the policy.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+func TestPolicy_Backoff(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(10))
+}
+
+func TestPolicy_BackoffJitter(t *testing.T) {
+	p := Policy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		backoff := p.Backoff(2)
+		assert.GreaterOrEqual(t, backoff, 100*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 200*time.Millisecond)
+	}
+}
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Policy{MaxAttempts: 3}, func() error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_StopsAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Policy{MaxAttempts: 3}, func() error {
+		calls++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_DoesNotRetryClassifiedErrors(t *testing.T) {
+	errConstraint := errors.New("constraint violation")
+	policy := Policy{MaxAttempts: 5, Retryable: func(err error) bool { return !errors.Is(err, errConstraint) }}
+
+	calls := 0
+	err := Do(context.Background(), policy, func() error {
+		calls++
+		return errConstraint
+	})
+
+	assert.ErrorIs(t, err, errConstraint)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_DoesNotRetryPermanentErrors(t *testing.T) {
+	calls := 0
+	err := Do(context.Background(), Policy{MaxAttempts: 5}, func() error {
+		calls++
+		return Permanent(errTransient)
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.True(t, IsPermanent(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_StopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := Do(ctx, Policy{MaxAttempts: 5, InitialBackoff: time.Hour}, func() error {
+		calls++
+		cancel()
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"konzek-jun/loggerx"
+	"konzek-jun/repository"
+)
+
+// Scheduler turns due schedules into tasks. Tasks with a job_type are picked
+// up by the job worker pool like any other task. Several replicas may run a
+// Scheduler on the same store.
+type Scheduler struct {
+	Store repository.ScheduleRepository
+	// PollInterval is how often due schedules are looked up.
+	PollInterval time.Duration
+	// BatchSize limits how many schedules fire in one transaction.
+	BatchSize int
+	// Now is used instead of time.Now so tests can move the clock.
+	Now func() time.Time
+}
+
+func New(store repository.ScheduleRepository) *Scheduler {
+	return &Scheduler{
+		Store:        store,
+		PollInterval: time.Second,
+		BatchSize:    100,
+		Now:          time.Now,
+	}
+}
+
+// Run fires due schedules until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	Every(ctx, s.PollInterval, func() {
+		if _, err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			loggerx.Error(fmt.Sprintf("Could not fire due schedules: %v", err))
+		}
+	})
+	loggerx.Info("Scheduler stopped")
+}
+
+// Tick fires every schedule that is due now and returns how many fired.
+func (s *Scheduler) Tick(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		fired, err := s.Store.FireDue(ctx, s.Now(), s.BatchSize, NextRun)
+		total += fired
+		if err != nil || fired < s.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// Every calls fn right away and then every d until ctx is cancelled.
+func Every(ctx context.Context, d time.Duration, fn func()) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScheduler(store repository.ScheduleRepository, now *time.Time) *Scheduler {
+	s := New(store)
+	s.BatchSize = 2
+	s.Now = func() time.Time { return *now }
+	return s
+}
+
+func insertSchedule(t *testing.T, store repository.ScheduleRepository, schedule models.Schedule, now time.Time) int {
+	schedule.OwnerID = 1
+	schedule.Title = "Scheduled"
+	schedule.Content = "Scheduled content"
+	schedule.NextRunAt = NextRun(schedule, now)
+	id, err := store.Insert(context.Background(), schedule)
+	assert.NoError(t, err)
+	return int(id)
+}
+
+func TestScheduler_FiresDueSchedulesOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := repository.NewMemoryScheduleRepository()
+	s := newTestScheduler(store, &now)
+
+	id := insertSchedule(t, store, models.Schedule{Interval: "1m", JobType: "echo"}, now)
+
+	// Henüz zamanı gelmedi
+	fired, err := s.Tick(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fired)
+
+	now = now.Add(time.Minute)
+	fired, _ = s.Tick(ctx)
+	assert.Equal(t, 1, fired)
+	fired, _ = s.Tick(ctx)
+	assert.Equal(t, 0, fired)
+
+	schedule, _ := store.GetByID(ctx, 1, id)
+	assert.Equal(t, now.Add(time.Minute), *schedule.NextRunAt)
+	assert.Equal(t, now, *schedule.LastRunAt)
+	if assert.Len(t, store.Tasks, 1) {
+		assert.Equal(t, "echo", store.Tasks[0].JobType)
+		assert.Equal(t, models.TaskStatusTodo, store.Tasks[0].Status)
+	}
+}
+
+func TestScheduler_SkipsMissedRuns(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := repository.NewMemoryScheduleRepository()
+	s := newTestScheduler(store, &now)
+
+	id := insertSchedule(t, store, models.Schedule{Interval: "1m"}, now)
+
+	// Servis on dakika kapalı kaldı; kaçırılan çalışmalar tek seferde telafi edilmez
+	now = now.Add(10 * time.Minute)
+	fired, _ := s.Tick(ctx)
+	assert.Equal(t, 1, fired)
+
+	schedule, _ := store.GetByID(ctx, 1, id)
+	assert.Equal(t, now.Add(time.Minute), *schedule.NextRunAt)
+}
+
+func TestScheduler_PausedAndOneOffSchedules(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := repository.NewMemoryScheduleRepository()
+	s := newTestScheduler(store, &now)
+
+	runAt := now.Add(time.Minute)
+	oneOff := insertSchedule(t, store, models.Schedule{RunAt: &runAt}, now)
+	paused := insertSchedule(t, store, models.Schedule{Cron: "* * * * *"}, now)
+	schedule, _ := store.GetByID(ctx, 1, paused)
+	assert.NoError(t, store.SetPaused(ctx, 1, paused, true, schedule.NextRunAt))
+
+	now = now.Add(5 * time.Minute)
+	fired, _ := s.Tick(ctx)
+	assert.Equal(t, 1, fired)
+
+	schedule, _ = store.GetByID(ctx, 1, oneOff)
+	assert.Nil(t, schedule.NextRunAt)
+
+	now = now.Add(5 * time.Minute)
+	fired, _ = s.Tick(ctx)
+	assert.Equal(t, 0, fired)
+}
+
+func TestScheduler_TickDrainsEveryBatch(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := repository.NewMemoryScheduleRepository()
+	s := newTestScheduler(store, &now)
+
+	for i := 0; i < 5; i++ {
+		insertSchedule(t, store, models.Schedule{Interval: "1h"}, now)
+	}
+
+	now = now.Add(time.Hour)
+	fired, err := s.Tick(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, fired)
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"konzek-jun/models"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MinInterval is the shortest interval a schedule may repeat at.
+const MinInterval = time.Second
+
+// SpecError reports a missing or invalid cron, interval or run_at.
+type SpecError struct {
+	Field string
+	Err   error
+}
+
+func (e *SpecError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Field, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// Standard five field cron expressions plus descriptors such as @daily and @every 1h.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Validate checks that exactly one of cron, interval and run_at is set and
+// that it is usable at now.
+func Validate(schedule models.Schedule, now time.Time) error {
+	set := 0
+	if schedule.Cron != "" {
+		set++
+		if _, err := cronParser.Parse(schedule.Cron); err != nil {
+			return &SpecError{Field: "cron", Err: err}
+		}
+	}
+	if schedule.Interval != "" {
+		set++
+		d, err := time.ParseDuration(schedule.Interval)
+		if err != nil {
+			return &SpecError{Field: "interval", Err: err}
+		}
+		if d < MinInterval {
+			return &SpecError{Field: "interval", Err: fmt.Errorf("must be at least %s", MinInterval)}
+		}
+	}
+	if schedule.RunAt != nil {
+		set++
+		if !schedule.RunAt.After(now) {
+			return &SpecError{Field: "run_at", Err: errors.New("must be in the future")}
+		}
+	}
+
+	if set != 1 {
+		return &SpecError{Field: "schedule", Err: errors.New("exactly one of cron, interval and run_at must be set")}
+	}
+	return nil
+}
+
+// NextRun returns the first run of a valid schedule after the given time, or
+// nil when it has no more runs. Runs missed while the service was down are
+// not caught up: a late schedule fires once and continues from then on.
+func NextRun(schedule models.Schedule, after time.Time) *time.Time {
+	var next time.Time
+	switch {
+	case schedule.Cron != "":
+		spec, err := cronParser.Parse(schedule.Cron)
+		if err != nil {
+			return nil
+		}
+		next = spec.Next(after)
+	case schedule.Interval != "":
+		d, err := time.ParseDuration(schedule.Interval)
+		if err != nil || d <= 0 {
+			return nil
+		}
+		next = after.Add(d)
+	case schedule.RunAt != nil && schedule.RunAt.After(after):
+		next = *schedule.RunAt
+	default:
+		return nil
+	}
+
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
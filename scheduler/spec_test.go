@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"konzek-jun/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		schedule models.Schedule
+		field    string
+	}{
+		{"Cron", models.Schedule{Cron: "*/5 * * * *"}, ""},
+		{"Descriptor", models.Schedule{Cron: "@daily"}, ""},
+		{"Interval", models.Schedule{Interval: "15m"}, ""},
+		{"RunAt", models.Schedule{RunAt: &future}, ""},
+		{"InvalidCron", models.Schedule{Cron: "every monday"}, "cron"},
+		{"InvalidInterval", models.Schedule{Interval: "soon"}, "interval"},
+		{"TooShortInterval", models.Schedule{Interval: "10ms"}, "interval"},
+		{"RunAtInPast", models.Schedule{RunAt: &past}, "run_at"},
+		{"Nothing", models.Schedule{}, "schedule"},
+		{"CronAndInterval", models.Schedule{Cron: "@hourly", Interval: "1h"}, "schedule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.schedule, now)
+			if tt.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var specErr *SpecError
+			if assert.ErrorAs(t, err, &specErr) {
+				assert.Equal(t, tt.field, specErr.Field)
+			}
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 2, 0, 0, time.UTC)
+	runAt := now.Add(time.Hour)
+
+	next := NextRun(models.Schedule{Cron: "*/5 * * * *"}, now)
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC), *next)
+
+	next = NextRun(models.Schedule{Interval: "90s"}, now)
+	assert.Equal(t, now.Add(90*time.Second), *next)
+
+	// Tek seferlik schedule çalıştıktan sonra bir daha çalışmaz
+	next = NextRun(models.Schedule{RunAt: &runAt}, now)
+	assert.Equal(t, runAt, *next)
+	assert.Nil(t, NextRun(models.Schedule{RunAt: &runAt}, runAt))
+}
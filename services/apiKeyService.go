@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"konzek-jun/dto"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyMarker starts every API key, so that leaked keys are easy to
+	// recognize.
+	apiKeyMarker = "kzk"
+	// apiKeyUseInterval is how often the last use of a key is written.
+	apiKeyUseInterval = time.Minute
+)
+
+// ErrInvalidAPIKey is returned for API keys that are malformed, unknown,
+// expired or revoked, and for keys of deleted users.
+var ErrInvalidAPIKey = errors.New("api key is invalid, expired or revoked")
+
+//go:generate mockgen -destination=../mocks//service/mockApikeyservice.go -package=services konzek-jun/services APIKeyService
+type APIKeyService interface {
+	// CreateAPIKey returns the new key of userID. The response holds the key
+	// itself, which cannot be read again.
+	CreateAPIKey(ctx context.Context, userID int64, request dto.APIKeyRequest) (dto.APIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]dto.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID int64, id int64) error
+	// Authenticate returns the stored key and its user, and records that the
+	// key was used.
+	Authenticate(ctx context.Context, key string) (models.APIKey, models.User, error)
+}
+
+type DefaultAPIKeyService struct {
+	Repo  repository.APIKeyRepository
+	Users repository.UserRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository) DefaultAPIKeyService {
+	return DefaultAPIKeyService{
+		Repo:  repo,
+		Users: users,
+	}
+}
+
+func (s DefaultAPIKeyService) CreateAPIKey(ctx context.Context, userID int64, request dto.APIKeyRequest) (dto.APIKeyResponse, error) {
+	loggerx.Info("CreateAPIKey function called")
+
+	// Keys look like kzk_<prefix>_<secret> and are looked up by prefix
+	prefix := make([]byte, 6)
+	if _, err := rand.Read(prefix); err != nil {
+		return dto.APIKeyResponse{}, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return dto.APIKeyResponse{}, err
+	}
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      request.Name,
+		Prefix:    hex.EncodeToString(prefix),
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+	}
+	plain := strings.Join([]string{apiKeyMarker, key.Prefix, secret}, "_")
+	key.SecretHash = hashToken(plain)
+
+	key, err = s.Repo.Insert(ctx, key)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while storing api key: %s", err))
+		return dto.APIKeyResponse{}, err
+	}
+	res := dto.NewAPIKeyResponse(key)
+	res.Key = plain
+	loggerx.Info(fmt.Sprintf("API key %s of user %d created successfully", key.Prefix, userID))
+	return res, nil
+}
+
+func (s DefaultAPIKeyService) ListAPIKeys(ctx context.Context, userID int64) ([]dto.APIKeyResponse, error) {
+	loggerx.Info("ListAPIKeys function called")
+
+	keys, err := s.Repo.List(ctx, userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing api keys: %s", err))
+		return nil, err
+	}
+	res := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		res[i] = dto.NewAPIKeyResponse(key)
+	}
+	return res, nil
+}
+
+func (s DefaultAPIKeyService) RevokeAPIKey(ctx context.Context, userID int64, id int64) error {
+	loggerx.Info("RevokeAPIKey function called")
+
+	if err := s.Repo.Revoke(ctx, userID, id); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking api key: %s", err))
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("API key %d of user %d revoked", id, userID))
+	return nil
+}
+
+func (s DefaultAPIKeyService) Authenticate(ctx context.Context, key string) (models.APIKey, models.User, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyMarker {
+		return models.APIKey{}, models.User{}, ErrInvalidAPIKey
+	}
+	stored, err := s.Repo.FindByPrefix(ctx, parts[1])
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return models.APIKey{}, models.User{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, models.User{}, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hashToken(key)), []byte(stored.SecretHash)) != 1 ||
+		stored.RevokedAt != nil || stored.ExpiresAt != nil && !stored.ExpiresAt.After(now) {
+		return models.APIKey{}, models.User{}, ErrInvalidAPIKey
+	}
+	user, err := s.Users.FindByUserID(ctx, strconv.FormatInt(stored.UserID, 10))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return models.APIKey{}, models.User{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, models.User{}, err
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= apiKeyUseInterval {
+		// The request is accepted even when the last use cannot be written
+		if err := s.Repo.MarkUsed(ctx, stored.ID, now); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while marking api key as used: %s", err))
+		} else {
+			stored.LastUsedAt = &now
+		}
+	}
+	return stored, user, nil
+}
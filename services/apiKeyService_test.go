@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"konzek-jun/dto"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAPIKeyService(t *testing.T) (DefaultAPIKeyService, models.User) {
+	users := repository.NewMemoryUserRepository()
+	user, err := users.InsertUser(context.Background(), models.User{Name: "CI", Email: "ci@example.com", Password: "testpass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAPIKeyService(repository.NewMemoryAPIKeyRepository(), users), user
+}
+
+func TestDefaultAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	// Test için hazırlıkları yap
+	apiKeyService, user := newTestAPIKeyService(t)
+	ctx := context.Background()
+
+	// Servis fonksiyonunun çağrılması
+	created, err := apiKeyService.CreateAPIKey(ctx, user.ID, dto.APIKeyRequest{Name: "CI", Scopes: []string{"tasks:read"}})
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, "kzk_"+created.Prefix+"_"))
+	key, owner, err := apiKeyService.Authenticate(ctx, created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, owner.ID)
+	assert.Equal(t, models.RoleMember, owner.Role)
+	assert.Equal(t, []string{"tasks:read"}, key.Scopes)
+	assert.NotNil(t, key.LastUsedAt)
+
+	// Anahtarın kendisi saklanmaz ve bir daha okunamaz
+	keys, err := apiKeyService.ListAPIKeys(ctx, user.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Empty(t, keys[0].Key)
+		assert.NotNil(t, keys[0].LastUsedAt)
+	}
+	stored, _ := apiKeyService.Repo.FindByPrefix(ctx, created.Prefix)
+	assert.Equal(t, hashToken(created.Key), stored.SecretHash)
+}
+
+func TestDefaultAPIKeyService_Authenticate_Invalid(t *testing.T) {
+	// Test için hazırlıkları yap
+	apiKeyService, user := newTestAPIKeyService(t)
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Second)
+	old, _ := apiKeyService.CreateAPIKey(ctx, user.ID, dto.APIKeyRequest{Name: "Old", Scopes: []string{"tasks:read"}, ExpiresAt: &expired})
+	revoked, _ := apiKeyService.CreateAPIKey(ctx, user.ID, dto.APIKeyRequest{Name: "Revoked", Scopes: []string{"tasks:read"}})
+	assert.NoError(t, apiKeyService.RevokeAPIKey(ctx, user.ID, revoked.ID))
+	valid, _ := apiKeyService.CreateAPIKey(ctx, user.ID, dto.APIKeyRequest{Name: "Valid", Scopes: []string{"tasks:read"}})
+
+	for _, key := range []string{
+		"",
+		"not-a-key",
+		"kzk_unknown_secret",
+		old.Key,
+		revoked.Key,
+		// Doğru prefix, yanlış secret
+		"kzk_" + valid.Prefix + "_guessed",
+	} {
+		// Servis fonksiyonunun çağrılması
+		_, _, err := apiKeyService.Authenticate(ctx, key)
+
+		// Hata kontrolü
+		assert.ErrorIs(t, err, ErrInvalidAPIKey, key)
+	}
+	_, _, err := apiKeyService.Authenticate(ctx, valid.Key)
+	assert.NoError(t, err)
+}
+
+func TestDefaultAPIKeyService_RevokeAPIKey_OtherUser(t *testing.T) {
+	// Test için hazırlıkları yap
+	apiKeyService, user := newTestAPIKeyService(t)
+	created, _ := apiKeyService.CreateAPIKey(context.Background(), user.ID, dto.APIKeyRequest{Name: "CI", Scopes: []string{"tasks:read"}})
+
+	// Servis fonksiyonunun çağrılması
+	err := apiKeyService.RevokeAPIKey(context.Background(), user.ID+1, created.ID)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+	_, _, err = apiKeyService.Authenticate(context.Background(), created.Key)
+	assert.NoError(t, err)
+}
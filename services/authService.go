@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"konzek-jun/loggerx"
@@ -10,7 +11,7 @@ import (
 )
 
 type AuthService interface {
-	VerifyCredential(email string, password string) error
+	VerifyCredential(ctx context.Context, email string, password string) error
 }
 
 type authService struct {
@@ -23,10 +24,10 @@ func NewAuthService(userRepo repository.UserRepository) AuthService {
 	}
 }
 
-func (c *authService) VerifyCredential(email string, password string) error {
+func (c *authService) VerifyCredential(ctx context.Context, email string, password string) error {
 	loggerx.Info("Verifying user credential")
 
-	user, err := c.userRepo.FindByEmail(email)
+	user, err := c.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while finding user by email: %s", err))
 		return err
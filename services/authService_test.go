@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"konzek-jun/mocks/repository"
 	"konzek-jun/models"
@@ -34,10 +35,10 @@ func TestAuthService_VerifyCredential_Success(t *testing.T) {
 	hashedPassword := "$2a$12$3AX3dyNLdk3D8EQri2w2f.mgU8pWDDn2Slehr7c1dUB1DP4WxH3L6"
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockAuthRepo.EXPECT().FindByEmail(email).Return(models.User{Email: email, Password: hashedPassword}, nil)
+	mockAuthRepo.EXPECT().FindByEmail(gomock.Any(), email).Return(models.User{Email: email, Password: hashedPassword}, nil)
 
 	// Servis fonksiyonunun çağrılması
-	err := mockAuthService.VerifyCredential(email, password)
+	err := mockAuthService.VerifyCredential(context.Background(), email, password)
 
 	// Hata kontrolü
 	assert.NoError(t, err)
@@ -52,10 +53,10 @@ func TestAuthService_VerifyCredential_UserNotFound(t *testing.T) {
 	password := "password"
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockAuthRepo.EXPECT().FindByEmail(email).Return(models.User{}, errors.New("user not found"))
+	mockAuthRepo.EXPECT().FindByEmail(gomock.Any(), email).Return(models.User{}, errors.New("user not found"))
 
 	// Servis fonksiyonunun çağrılması
-	err := mockAuthService.VerifyCredential(email, password)
+	err := mockAuthService.VerifyCredential(context.Background(), email, password)
 
 	// Hata kontrolü
 	assert.Error(t, err)
@@ -71,10 +72,10 @@ func TestAuthService_VerifyCredential_WrongPassword(t *testing.T) {
 	hashedPassword := "$2a$10$XkO/7pHBkHZvqK0b54R0YOMNc6q5aP/V0TbS3VIsffzY9j28W2PK6"
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockAuthRepo.EXPECT().FindByEmail(email).Return(models.User{Email: email, Password: hashedPassword}, nil)
+	mockAuthRepo.EXPECT().FindByEmail(gomock.Any(), email).Return(models.User{Email: email, Password: hashedPassword}, nil)
 
 	// Servis fonksiyonunun çağrılması
-	err := mockAuthService.VerifyCredential(email, password)
+	err := mockAuthService.VerifyCredential(context.Background(), email, password)
 
 	// Hata kontrolü
 	assert.Error(t, err)
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+)
+
+//go:generate mockgen -destination=../mocks//service/mockJobservice.go -package=services konzek-jun/services JobService
+type JobService interface {
+	JobGet(ctx context.Context, ownerID int64, id int) (models.Job, error)
+	// The DeadJob methods cover the dead jobs of every user.
+	DeadJobList(ctx context.Context) ([]models.Job, error)
+	DeadJobGet(ctx context.Context, id int) (models.Job, error)
+	DeadJobRequeue(ctx context.Context, id int) error
+	DeadJobPurge(ctx context.Context, id int) error
+	DeadJobPurgeAll(ctx context.Context) (int64, error)
+}
+
+type DefaultJobService struct {
+	Repo repository.JobQueue
+}
+
+func NewJobService(repo repository.JobQueue) DefaultJobService {
+	return DefaultJobService{
+		Repo: repo,
+	}
+}
+
+func (j DefaultJobService) JobGet(ctx context.Context, ownerID int64, id int) (models.Job, error) {
+	job, err := j.Repo.GetJob(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting job: %s", err))
+		return models.Job{}, err
+	}
+	loggerx.Info("Retrieved job successfully")
+	return job, nil
+}
+
+func (j DefaultJobService) DeadJobList(ctx context.Context) ([]models.Job, error) {
+	jobs, err := j.Repo.ListDead(ctx)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing dead jobs: %s", err))
+		return nil, err
+	}
+	loggerx.Info("Retrieved dead jobs successfully")
+	return jobs, nil
+}
+
+// DeadJobGet returns ErrJobNotFound for jobs that exist but are not dead.
+func (j DefaultJobService) DeadJobGet(ctx context.Context, id int) (models.Job, error) {
+	job, err := j.Repo.GetDead(ctx, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting dead job: %s", err))
+		return models.Job{}, err
+	}
+	loggerx.Info("Retrieved dead job successfully")
+	return job, nil
+}
+
+func (j DefaultJobService) DeadJobRequeue(ctx context.Context, id int) error {
+	if err := j.Repo.Requeue(ctx, id); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while requeueing job: %s", err))
+		return err
+	}
+	loggerx.Info("Requeued dead job successfully")
+	return nil
+}
+
+func (j DefaultJobService) DeadJobPurge(ctx context.Context, id int) error {
+	if err := j.Repo.PurgeDead(ctx, id); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while purging dead job: %s", err))
+		return err
+	}
+	loggerx.Info("Purged dead job successfully")
+	return nil
+}
+
+func (j DefaultJobService) DeadJobPurgeAll(ctx context.Context) (int64, error) {
+	purged, err := j.Repo.PurgeAllDead(ctx)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while purging dead jobs: %s", err))
+		return 0, err
+	}
+	return purged, nil
+}
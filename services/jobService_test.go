@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"konzek-jun/mocks/repository"
+	"konzek-jun/models"
+	taskrepo "konzek-jun/repository"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultJobService_JobGet_Success(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockJobQueue := repository.NewMockJobQueue(ctrl)
+	jobService := NewJobService(mockJobQueue)
+
+	// Mock repository'den beklenen değerlerin ayarlanması
+	mockJobQueue.EXPECT().GetJob(gomock.Any(), int64(1), 5).Return(models.Job{ID: 5, Status: models.JobStatusPending}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	job, err := jobService.JobGet(context.Background(), 1, 5)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobStatusPending, job.Status)
+}
+
+func TestDefaultJobService_JobGet_NotFound(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockJobQueue := repository.NewMockJobQueue(ctrl)
+	jobService := NewJobService(mockJobQueue)
+
+	mockJobQueue.EXPECT().GetJob(gomock.Any(), int64(2), 5).Return(models.Job{}, taskrepo.ErrJobNotFound)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := jobService.JobGet(context.Background(), 2, 5)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrJobNotFound)
+}
+
+func TestDefaultJobService_DeadJobGet_NotDead(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockJobQueue := repository.NewMockJobQueue(ctrl)
+	jobService := NewJobService(mockJobQueue)
+
+	// Çalışmakta olan bir job dead-letter listesinde görünmemeli
+	mockJobQueue.EXPECT().GetDead(gomock.Any(), 5).Return(models.Job{}, taskrepo.ErrJobNotFound)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := jobService.DeadJobGet(context.Background(), 5)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrJobNotFound)
+}
+
+func TestDefaultJobService_DeadJobRequeue_Success(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockJobQueue := repository.NewMockJobQueue(ctrl)
+	jobService := NewJobService(mockJobQueue)
+
+	mockJobQueue.EXPECT().Requeue(gomock.Any(), 5).Return(nil)
+
+	// Servis fonksiyonunun çağrılması
+	err := jobService.DeadJobRequeue(context.Background(), 5)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// minRSAKeyBits is the smallest RSA key tokens are signed or verified with.
+const minRSAKeyBits = 2048
+
+// JSONWebKey is the public part of a token signing key (RFC 7517).
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// N and E are the modulus and exponent of RSA keys.
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X are the curve and public key of Ed25519 keys.
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// signingKey is an RSA or Ed25519 key tokens are verified with and, if
+// private is set, signed with.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+	jwk     JSONWebKey
+}
+
+// loadKeyFile reads every key of the PEM file at path. Private keys can be
+// PKCS#8 or PKCS#1, public keys PKIX or PKCS#1.
+func loadKeyFile(path string) ([]signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []signingKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var key interface{}
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			// Other blocks such as certificates are skipped
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		signing, err := newSigningKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, signing)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no PEM encoded key found", path)
+	}
+	return keys, nil
+}
+
+// newSigningKey wraps an RSA or Ed25519 key. Its id is the RFC 7638
+// thumbprint of the public key, so every server derives the same kid.
+func newSigningKey(key interface{}) (signingKey, error) {
+	var k signingKey
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k = rsaKey(&key.PublicKey)
+		k.private = key
+	case *rsa.PublicKey:
+		k = rsaKey(key)
+	case ed25519.PrivateKey:
+		k = ed25519Key(key.Public().(ed25519.PublicKey))
+		k.private = key
+	case ed25519.PublicKey:
+		k = ed25519Key(key)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported key type %T, only RSA and Ed25519 keys can sign tokens", key)
+	}
+	if public, ok := k.public.(*rsa.PublicKey); ok && public.N.BitLen() < minRSAKeyBits {
+		return signingKey{}, fmt.Errorf("RSA key has %d bits, at least %d are required", public.N.BitLen(), minRSAKeyBits)
+	}
+
+	// The members are in alphabetical order, as RFC 7638 requires
+	var members interface{}
+	if k.jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.jwk.E, k.jwk.KeyType, k.jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.jwk.Curve, k.jwk.KeyType, k.jwk.X}
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return signingKey{}, err
+	}
+	sum := sha256.Sum256(canonical)
+	k.id = base64.RawURLEncoding.EncodeToString(sum[:])
+	k.jwk.KeyID = k.id
+	return k, nil
+}
+
+func rsaKey(public *rsa.PublicKey) signingKey {
+	return signingKey{
+		method: jwt.SigningMethodRS256,
+		public: public,
+		jwk: JSONWebKey{
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		},
+	}
+}
+
+func ed25519Key(public ed25519.PublicKey) signingKey {
+	return signingKey{
+		method: SigningMethodEdDSA,
+		public: public,
+		jwk: JSONWebKey{
+			KeyType:   "OKP",
+			Use:       "sig",
+			Algorithm: SigningMethodEdDSA.Alg(),
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(public),
+		},
+	}
+}
+
+// SigningMethodEdDSA signs tokens with Ed25519 keys (RFC 8037). jwt-go only
+// ships HMAC, RSA and ECDSA.
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"konzek-jun/configs"
+	"konzek-jun/models"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -11,47 +13,121 @@ import (
 //go:generate mockgen -destination=../mocks//service/mockJWTservice.go -package=services konzek-jun/services JWTService
 
 type JWTService interface {
-	GenerateToken(userID string) string
+	// GenerateToken returns an access token of userID for the session
+	// sessionID. Every token gets its own jti claim, so it can be revoked,
+	// and carries the role of the user.
+	GenerateToken(userID string, sessionID string, role models.Role) string
 	ValidateToken(token string) *jwt.Token
+	// JWKS returns the public keys tokens are verified with. It is empty when
+	// tokens are signed with a shared secret.
+	JWKS() JSONWebKeySet
 }
 
 type jwtCustomClaim struct {
-	UserID string `json:"user_id"`
+	UserID    string      `json:"user_id"`
+	SessionID string      `json:"sid"`
+	Role      models.Role `json:"role"`
 	jwt.StandardClaims
 }
 
 type jwtService struct {
 	secretKey string
-	issuer    string
+	// hs256Until ends the acceptance of tokens signed with secretKey when
+	// tokens are signed with signingKey.
+	hs256Until time.Time
+	issuer     string
+	ttl        time.Duration
+	// signingKey signs new tokens when they are not signed with secretKey.
+	signingKey *signingKey
+	// keys are the public keys by kid that tokens are verified with, the
+	// signing key first.
+	keys  map[string]signingKey
+	order []string
 }
 
-// NewJWTService method is creates a new instance of JWTService
-func NewJWTService() JWTService {
-	return &jwtService{
-		issuer:    "admin",
-		secretKey: getSecretKey(),
+// NewJWTService method is creates a new instance of JWTService. With RS256
+// and EdDSA it reads the signing key and the verification keys from their
+// PEM files, and accepts tokens signed with the secret until
+// cfg.HS256Until.
+func NewJWTService(cfg configs.JWTConfig) (JWTService, error) {
+	j := &jwtService{
+		issuer:     cfg.Issuer,
+		secretKey:  cfg.Secret,
+		hs256Until: cfg.HS256Until,
+		ttl:        cfg.TTL,
+		keys:       make(map[string]signingKey),
 	}
+	if cfg.Algorithm == "" || cfg.Algorithm == configs.JWTAlgorithmHS256 {
+		if cfg.Secret == "" {
+			return nil, errors.New("jwt secret is required for HS256")
+		}
+		return j, j.addKeyFiles(cfg.VerificationKeyFiles)
+	}
+
+	keys, err := loadKeyFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signing := keys[0]
+	if len(keys) > 1 || signing.private == nil {
+		return nil, fmt.Errorf("%s must contain exactly one private key", cfg.PrivateKeyFile)
+	}
+	if signing.method.Alg() != cfg.Algorithm {
+		return nil, fmt.Errorf("%s holds a %s key, not a %s key", cfg.PrivateKeyFile, signing.method.Alg(), cfg.Algorithm)
+	}
+	j.signingKey = &signing
+	j.addKey(signing)
+	return j, j.addKeyFiles(cfg.VerificationKeyFiles)
+}
+
+func (j *jwtService) addKeyFiles(paths []string) error {
+	for _, path := range paths {
+		keys, err := loadKeyFile(path)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			// Verification keys are only kept as public keys
+			key.private = nil
+			j.addKey(key)
+		}
+	}
+	return nil
 }
 
-func getSecretKey() string {
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey != "" {
-		secretKey = "system"
+func (j *jwtService) addKey(key signingKey) {
+	if _, ok := j.keys[key.id]; ok {
+		return
 	}
-	return secretKey
+	j.keys[key.id] = key
+	j.order = append(j.order, key.id)
 }
 
-func (j *jwtService) GenerateToken(UserID string) string {
+func (j *jwtService) GenerateToken(UserID string, sessionID string, role models.Role) string {
+	tokenID, err := randomToken(16)
+	if err != nil {
+		panic(err)
+	}
 	claims := &jwtCustomClaim{
 		UserID,
+		sessionID,
+		role,
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().AddDate(1, 0, 0).Unix(),
+			Id:        tokenID,
+			ExpiresAt: time.Now().Add(j.ttl).Unix(),
 			Issuer:    j.issuer,
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(j.secretKey))
+
+	var t string
+	if j.signingKey == nil {
+		t, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(j.secretKey))
+	} else {
+		token := jwt.NewWithClaims(j.signingKey.method, claims)
+		token.Header["kid"] = j.signingKey.id
+		t, err = token.SignedString(j.signingKey.private)
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -60,10 +136,22 @@ func (j *jwtService) GenerateToken(UserID string) string {
 
 func (j *jwtService) ValidateToken(token string) *jwt.Token {
 	t, err := jwt.Parse(token, func(t_ *jwt.Token) (interface{}, error) {
-		if _, ok := t_.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
+		if _, ok := t_.Method.(*jwt.SigningMethodHMAC); ok {
+			// While moving to an asymmetric key, old tokens are only valid until hs256Until
+			if j.secretKey == "" || (j.signingKey != nil && !time.Now().Before(j.hs256Until)) {
+				return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
+			}
+			return []byte(j.secretKey), nil
 		}
-		return []byte(j.secretKey), nil
+		kid, _ := t_.Header["kid"].(string)
+		key, ok := j.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if t_.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v for key %q", t_.Header["alg"], kid)
+		}
+		return key.public, nil
 	})
 
 	if err != nil {
@@ -73,3 +161,11 @@ func (j *jwtService) ValidateToken(token string) *jwt.Token {
 	return t
 
 }
+
+func (j *jwtService) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(j.order))}
+	for _, id := range j.order {
+		set.Keys = append(set.Keys, j.keys[id].jwk)
+	}
+	return set
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"konzek-jun/configs"
+	"konzek-jun/models"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// writeKey writes key as a PKCS#8 private key and its public key as a PKIX
+// public key and returns both paths.
+func writeKey(t *testing.T, key crypto.Signer) (string, string) {
+	dir := t.TempDir()
+	private, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return privatePath, publicPath
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newJWT(t *testing.T, cfg configs.JWTConfig) JWTService {
+	cfg.Issuer, cfg.TTL = "test", time.Minute
+	jwtService, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtService
+}
+
+func TestJWTService_Asymmetric(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		algorithm string
+		key       crypto.Signer
+		keyType   string
+	}{
+		{configs.JWTAlgorithmRS256, rsaKey, "RSA"},
+		{configs.JWTAlgorithmEdDSA, newEd25519Key(t), "OKP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			privatePath, _ := writeKey(t, tt.key)
+			jwtService := newJWT(t, configs.JWTConfig{Algorithm: tt.algorithm, PrivateKeyFile: privatePath})
+
+			token := jwtService.ValidateToken(jwtService.GenerateToken("1", "laptop", models.RoleMember))
+			if !assert.NotNil(t, token) {
+				return
+			}
+			assert.Equal(t, tt.algorithm, token.Method.Alg())
+			assert.Equal(t, "1", token.Claims.(jwt.MapClaims)["user_id"])
+
+			// Token'ın kid'i JWKS'teki anahtarı gösterir
+			jwks := jwtService.JWKS()
+			if assert.Len(t, jwks.Keys, 1) {
+				assert.Equal(t, jwks.Keys[0].KeyID, token.Header["kid"])
+				assert.Equal(t, tt.keyType, jwks.Keys[0].KeyType)
+				assert.Equal(t, tt.algorithm, jwks.Keys[0].Algorithm)
+			}
+		})
+	}
+}
+
+func TestJWTService_Rotation(t *testing.T) {
+	oldPrivate, oldPublic := writeKey(t, newEd25519Key(t))
+	newPrivate, _ := writeKey(t, newEd25519Key(t))
+	before := newJWT(t, configs.JWTConfig{Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: oldPrivate})
+	oldToken := before.GenerateToken("1", "laptop", models.RoleMember)
+
+	// Yeni anahtarla imzalanır, eski anahtarın token'ları geçerli kalır
+	after := newJWT(t, configs.JWTConfig{
+		Algorithm:            configs.JWTAlgorithmEdDSA,
+		PrivateKeyFile:       newPrivate,
+		VerificationKeyFiles: []string{oldPublic},
+	})
+	assert.NotNil(t, after.ValidateToken(oldToken))
+	newToken := after.ValidateToken(after.GenerateToken("1", "laptop", models.RoleMember))
+	if assert.NotNil(t, newToken) {
+		assert.NotEqual(t, before.JWKS().Keys[0].KeyID, newToken.Header["kid"])
+	}
+	jwks := after.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, newToken.Header["kid"], jwks.Keys[0].KeyID)
+		assert.Equal(t, before.JWKS().Keys[0].KeyID, jwks.Keys[1].KeyID)
+	}
+
+	// Eski anahtar kaldırılınca token'ları reddedilir
+	removed := newJWT(t, configs.JWTConfig{Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: newPrivate})
+	assert.Nil(t, removed.ValidateToken(oldToken))
+}
+
+func TestJWTService_HMACFallback(t *testing.T) {
+	privatePath, _ := writeKey(t, newEd25519Key(t))
+	hmacToken := newJWT(t, configs.JWTConfig{Secret: testSecret}).GenerateToken("1", "laptop", models.RoleMember)
+
+	// HS256'dan geçişte eski token'lar yalnızca HS256Until'e kadar geçerlidir
+	migrating := newJWT(t, configs.JWTConfig{Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: privatePath, Secret: testSecret, HS256Until: time.Now().Add(time.Hour)})
+	assert.NotNil(t, migrating.ValidateToken(hmacToken))
+	migrated := newJWT(t, configs.JWTConfig{Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: privatePath, Secret: testSecret, HS256Until: time.Now().Add(-time.Second)})
+	assert.Nil(t, migrated.ValidateToken(hmacToken))
+	unlimited := newJWT(t, configs.JWTConfig{Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: privatePath, Secret: testSecret})
+	assert.Nil(t, unlimited.ValidateToken(hmacToken))
+
+	asymmetric := newJWT(t, configs.JWTConfig{Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: privatePath})
+	assert.Nil(t, asymmetric.ValidateToken(hmacToken))
+	assert.Empty(t, newJWT(t, configs.JWTConfig{Secret: testSecret}).JWKS().Keys)
+}
+
+func TestNewJWTService_Errors(t *testing.T) {
+	privatePath, publicPath := writeKey(t, newEd25519Key(t))
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakPath, _ := writeKey(t, weakKey)
+
+	tests := map[string]configs.JWTConfig{
+		"missing secret":       {},
+		"missing key file":     {Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: filepath.Join(t.TempDir(), "nope.pem")},
+		"public signing key":   {Algorithm: configs.JWTAlgorithmEdDSA, PrivateKeyFile: publicPath},
+		"wrong algorithm":      {Algorithm: configs.JWTAlgorithmRS256, PrivateKeyFile: privatePath},
+		"weak RSA key":         {Algorithm: configs.JWTAlgorithmRS256, PrivateKeyFile: weakPath},
+		"bad verification key": {Secret: testSecret, VerificationKeyFiles: []string{filepath.Join(t.TempDir(), "nope.pem")}},
+	}
+	for name, cfg := range tests {
+		_, err := NewJWTService(cfg)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"sync"
+	"time"
+)
+
+// RevocationList is the in-process cache of revoked token and session ids
+// that the auth middleware consults on every request. Every id is kept until
+// the tokens it revokes have expired. Revocations made by this process are
+// seen at once, those of other instances after the next Sync.
+type RevocationList struct {
+	Repo repository.TokenRevocationRepository
+
+	mu      sync.RWMutex
+	expires map[string]time.Time
+}
+
+func NewRevocationList(repo repository.TokenRevocationRepository) *RevocationList {
+	return &RevocationList{
+		Repo:    repo,
+		expires: make(map[string]time.Time),
+	}
+}
+
+// Revoke stores revocations and adds them to the cache.
+func (l *RevocationList) Revoke(ctx context.Context, revocations []models.TokenRevocation) error {
+	if len(revocations) == 0 {
+		return nil
+	}
+	if err := l.Repo.Revoke(ctx, revocations); err != nil {
+		return err
+	}
+	l.add(revocations)
+	return nil
+}
+
+// IsRevoked reports whether any of ids is revoked.
+func (l *RevocationList) IsRevoked(ids ...string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if expires, ok := l.expires[id]; ok && expires.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync loads the revocations of all instances and drops the expired ones
+// from the cache.
+func (l *RevocationList) Sync(ctx context.Context) error {
+	now := time.Now()
+	revocations, err := l.Repo.ListActive(ctx, now)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while loading token revocations: %s", err))
+		return err
+	}
+
+	l.mu.Lock()
+	for id, expires := range l.expires {
+		if !expires.After(now) {
+			delete(l.expires, id)
+		}
+	}
+	l.mu.Unlock()
+	l.add(revocations)
+	return nil
+}
+
+func (l *RevocationList) add(revocations []models.TokenRevocation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, revocation := range revocations {
+		if revocation.ExpiresAt.After(l.expires[revocation.TokenID]) {
+			l.expires[revocation.TokenID] = revocation.ExpiresAt
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/scheduler"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks//service/mockScheduleservice.go -package=services konzek-jun/services ScheduleService
+type ScheduleService interface {
+	ScheduleCreate(ctx context.Context, schedule models.Schedule) (models.Schedule, error)
+	ScheduleGetAll(ctx context.Context, ownerID int64) ([]models.Schedule, error)
+	ScheduleGetByID(ctx context.Context, ownerID int64, id int) (models.Schedule, error)
+	ScheduleDelete(ctx context.Context, ownerID int64, id int) error
+	SchedulePause(ctx context.Context, ownerID int64, id int) (models.Schedule, error)
+	ScheduleResume(ctx context.Context, ownerID int64, id int) (models.Schedule, error)
+}
+
+type DefaultScheduleService struct {
+	Repo repository.ScheduleRepository
+}
+
+func NewScheduleService(repo repository.ScheduleRepository) DefaultScheduleService {
+	return DefaultScheduleService{
+		Repo: repo,
+	}
+}
+
+// ScheduleCreate returns a *scheduler.SpecError when cron, interval or run_at is invalid.
+func (s DefaultScheduleService) ScheduleCreate(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+	now := time.Now()
+	if err := scheduler.Validate(schedule, now); err != nil {
+		loggerx.Error(fmt.Sprintf("Invalid schedule: %s", err))
+		return models.Schedule{}, err
+	}
+	if schedule.Interval != "" {
+		// Stored in milliseconds, so "90s" is returned as "1m30s" later on
+		interval, _ := time.ParseDuration(schedule.Interval)
+		schedule.Interval = interval.String()
+	}
+	if schedule.MaxAttempts == 0 {
+		schedule.MaxAttempts = models.DefaultJobMaxAttempts
+	}
+	schedule.NextRunAt = scheduler.NextRun(schedule, now)
+
+	id, err := s.Repo.Insert(ctx, schedule)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while inserting schedule: %s", err))
+		return models.Schedule{}, err
+	}
+	schedule.ID = int(id)
+	loggerx.Info("Schedule created successfully")
+	return schedule, nil
+}
+
+func (s DefaultScheduleService) ScheduleGetAll(ctx context.Context, ownerID int64) ([]models.Schedule, error) {
+	schedules, err := s.Repo.GetAll(ctx, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting schedules: %s", err))
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (s DefaultScheduleService) ScheduleGetByID(ctx context.Context, ownerID int64, id int) (models.Schedule, error) {
+	schedule, err := s.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting schedule: %s", err))
+		return models.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (s DefaultScheduleService) ScheduleDelete(ctx context.Context, ownerID int64, id int) error {
+	if err := s.Repo.Delete(ctx, ownerID, id); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting schedule: %s", err))
+		return err
+	}
+	loggerx.Info("Schedule deleted successfully")
+	return nil
+}
+
+func (s DefaultScheduleService) SchedulePause(ctx context.Context, ownerID int64, id int) (models.Schedule, error) {
+	schedule, err := s.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while pausing schedule: %s", err))
+		return models.Schedule{}, err
+	}
+
+	if err := s.Repo.SetPaused(ctx, ownerID, id, true, schedule.NextRunAt); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while pausing schedule: %s", err))
+		return models.Schedule{}, err
+	}
+	schedule.Paused = true
+	loggerx.Info("Schedule paused successfully")
+	return schedule, nil
+}
+
+// ScheduleResume continues a schedule from now on; runs missed while it was
+// paused are skipped.
+func (s DefaultScheduleService) ScheduleResume(ctx context.Context, ownerID int64, id int) (models.Schedule, error) {
+	schedule, err := s.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while resuming schedule: %s", err))
+		return models.Schedule{}, err
+	}
+
+	nextRunAt := scheduler.NextRun(schedule, time.Now())
+	if err := s.Repo.SetPaused(ctx, ownerID, id, false, nextRunAt); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while resuming schedule: %s", err))
+		return models.Schedule{}, err
+	}
+	schedule.Paused = false
+	schedule.NextRunAt = nextRunAt
+	loggerx.Info("Schedule resumed successfully")
+	return schedule, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"konzek-jun/mocks/repository"
+	"konzek-jun/models"
+	taskrepo "konzek-jun/repository"
+	"konzek-jun/scheduler"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultScheduleService_ScheduleCreate_Success(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockScheduleRepo := repository.NewMockScheduleRepository(ctrl)
+	scheduleService := NewScheduleService(mockScheduleRepo)
+
+	var inserted models.Schedule
+	mockScheduleRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, schedule models.Schedule) (int64, error) {
+		inserted = schedule
+		return 3, nil
+	})
+
+	// Servis fonksiyonunun çağrılması
+	before := time.Now()
+	schedule, err := scheduleService.ScheduleCreate(context.Background(), models.Schedule{OwnerID: 1, Name: "Report", Interval: "90s", Title: "Report", Content: "Weekly report"})
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, 3, schedule.ID)
+	assert.Equal(t, "1m30s", inserted.Interval)
+	assert.Equal(t, models.DefaultJobMaxAttempts, inserted.MaxAttempts)
+	if assert.NotNil(t, inserted.NextRunAt) {
+		assert.False(t, inserted.NextRunAt.Before(before.Add(90*time.Second)))
+	}
+}
+
+func TestDefaultScheduleService_ScheduleCreate_InvalidCron(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockScheduleRepo := repository.NewMockScheduleRepository(ctrl)
+	scheduleService := NewScheduleService(mockScheduleRepo)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := scheduleService.ScheduleCreate(context.Background(), models.Schedule{OwnerID: 1, Name: "Broken", Cron: "61 * * * *", Title: "Broken", Content: "Broken"})
+
+	// Hata kontrolü
+	var specErr *scheduler.SpecError
+	assert.ErrorAs(t, err, &specErr)
+	assert.Equal(t, "cron", specErr.Field)
+}
+
+func TestDefaultScheduleService_ScheduleResume_RecomputesNextRun(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockScheduleRepo := repository.NewMockScheduleRepository(ctrl)
+	scheduleService := NewScheduleService(mockScheduleRepo)
+
+	// Uzun süre duraklatılmış schedule kaçırdığı çalışmaları telafi etmez
+	stale := time.Now().Add(-24 * time.Hour)
+	mockScheduleRepo.EXPECT().GetByID(gomock.Any(), int64(1), 3).Return(models.Schedule{ID: 3, OwnerID: 1, Interval: "1h0m0s", Paused: true, NextRunAt: &stale}, nil)
+	mockScheduleRepo.EXPECT().SetPaused(gomock.Any(), int64(1), 3, false, gomock.Any()).Return(nil)
+
+	// Servis fonksiyonunun çağrılması
+	schedule, err := scheduleService.ScheduleResume(context.Background(), 1, 3)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.False(t, schedule.Paused)
+	assert.True(t, schedule.NextRunAt.After(time.Now()))
+}
+
+func TestDefaultScheduleService_SchedulePause_NotFound(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockScheduleRepo := repository.NewMockScheduleRepository(ctrl)
+	scheduleService := NewScheduleService(mockScheduleRepo)
+
+	mockScheduleRepo.EXPECT().GetByID(gomock.Any(), int64(2), 3).Return(models.Schedule{}, taskrepo.ErrScheduleNotFound)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := scheduleService.SchedulePause(context.Background(), 2, 3)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrScheduleNotFound)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+)
+
+type BulkOp string
+
+const (
+	BulkCreate BulkOp = "create"
+	BulkUpdate BulkOp = "update"
+	BulkDelete BulkOp = "delete"
+)
+
+// ErrBulkRolledBack is the result of the operations of an atomic bulk
+// request that were not applied because another operation failed.
+var ErrBulkRolledBack = errors.New("not applied because another operation of the request failed")
+
+// BulkTaskOperation is one step of a bulk request. Update uses Task like
+// TaskUpdate; delete only uses Task.Id and Task.Version.
+type BulkTaskOperation struct {
+	Op   BulkOp
+	Task models.Task
+}
+
+// BulkTaskResult is the outcome of the operation with the same index. Task
+// is the created or updated task, or only the id of a deleted one.
+type BulkTaskResult struct {
+	Task models.Task
+	Err  error
+}
+
+// TaskBulk runs operations for ownerID. The tasks to create are inserted in
+// batches before the updates and deletes run in order. Atomic runs all of
+// them in one transaction that is rolled back on the first error; otherwise
+// every operation succeeds or fails on its own. Without a UnitOfWork, atomic
+// only stops at the first error and the operations before it stay applied.
+func (t DefaultTaskService) TaskBulk(ctx context.Context, ownerID int64, operations []BulkTaskOperation, atomic bool) []BulkTaskResult {
+	results := make([]BulkTaskResult, len(operations))
+	if !atomic {
+		t.bulk(ctx, ownerID, operations, results, false)
+		return results
+	}
+
+	err := t.inTx(ctx, func(t DefaultTaskService) error {
+		// The results of an earlier try of the transaction are void
+		clear(results)
+		return t.bulk(ctx, ownerID, operations, results, true)
+	})
+	if err != nil {
+		failed := false
+		for i := range results {
+			if results[i].Err != nil {
+				failed = true
+				continue
+			}
+			if t.UnitOfWork != nil || results[i].Task.Id == 0 {
+				results[i] = BulkTaskResult{Err: ErrBulkRolledBack}
+			}
+		}
+		// A commit error belongs to no operation
+		if !failed {
+			for i := range results {
+				results[i].Err = err
+			}
+		}
+	}
+	return results
+}
+
+// bulk fills results. With atomic it stops at the first error and returns
+// it; the caller's transaction is the one that is rolled back.
+func (t DefaultTaskService) bulk(ctx context.Context, ownerID int64, operations []BulkTaskOperation, results []BulkTaskResult, atomic bool) error {
+	var creates []models.Task
+	var createIndexes []int
+	for i, operation := range operations {
+		if operation.Op != BulkCreate {
+			continue
+		}
+		task := operation.Task
+		task.OwnerID = ownerID
+		if task.Status == "" {
+			task.Status = models.TaskStatusTodo
+		}
+		creates = append(creates, task)
+		createIndexes = append(createIndexes, i)
+	}
+	if len(creates) > 0 {
+		ids, err := t.Repo.InsertMany(ctx, creates)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while inserting tasks: %s", err))
+		}
+		for j, i := range createIndexes {
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			created := creates[j]
+			created.Id, created.Version = int(ids[j]), 1
+			created.JobType, created.Payload, created.MaxAttempts = "", nil, 0
+			results[i].Task = created
+		}
+		if err != nil && atomic {
+			return err
+		}
+	}
+
+	for i, operation := range operations {
+		task := operation.Task
+		task.OwnerID = ownerID
+		var err error
+		switch operation.Op {
+		case BulkCreate:
+			continue
+		case BulkUpdate:
+			if atomic {
+				results[i].Task, err = t.updateTask(ctx, task)
+			} else {
+				results[i].Task, err = t.TaskUpdate(ctx, task)
+			}
+		case BulkDelete:
+			if atomic {
+				err = t.deleteTask(ctx, ownerID, task.Id, task.Version)
+			} else {
+				err = t.TaskDelete(ctx, ownerID, task.Id, task.Version)
+			}
+			results[i].Task = models.Task{Id: task.Id}
+		default:
+			err = fmt.Errorf("unknown bulk operation %q", operation.Op)
+		}
+		results[i].Err = err
+		if err != nil && atomic {
+			return err
+		}
+	}
+	loggerx.Info(fmt.Sprintf("Bulk request with %d operations done", len(operations)))
+	return nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a listing does not ask for a page size.
+	DefaultPageSize = 20
+	// MaxPageSize caps the page size of every task listing.
+	MaxPageSize = 100
+)
+
+// ErrInvalidCursor is returned for a cursor that cannot be decoded or that
+// was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursorToken is what an opaque cursor encodes: the sort it belongs to and
+// the position of the task it points at.
+type cursorToken struct {
+	Field string `json:"f"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// TaskCursorPage returns up to limit tasks after (or before) an opaque cursor
+// taken from a previous page. Without a cursor it returns the first page.
+func (t DefaultTaskService) TaskCursorPage(ctx context.Context, ownerID int64, filter models.TaskFilter, after, before string, limit int) (models.TaskPage, error) {
+	limit = pageSize(limit)
+	sort := keysetSort(filter.Sort)
+	if after != "" && before != "" {
+		return models.TaskPage{}, fmt.Errorf("%w: after and before cannot be combined", ErrInvalidCursor)
+	}
+	var err error
+	if after != "" {
+		if filter.After, err = decodeTaskCursor(after, sort); err != nil {
+			return models.TaskPage{}, err
+		}
+	}
+	if before != "" {
+		if filter.Before, err = decodeTaskCursor(before, sort); err != nil {
+			return models.TaskPage{}, err
+		}
+	}
+
+	// Ask for one task more to learn whether there is a next page
+	filter.Offset = 0
+	filter.Limit = limit + 1
+	tasks, err := t.Repo.FindTasks(ctx, ownerID, filter)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting tasks after cursor: %s", err))
+		return models.TaskPage{}, err
+	}
+	more := len(tasks) > limit
+	if more && filter.Before != nil {
+		tasks = tasks[1:]
+	} else if more {
+		tasks = tasks[:limit]
+	}
+
+	total, err := t.Repo.CountTasks(ctx, ownerID, filter)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while counting tasks: %s", err))
+		return models.TaskPage{}, err
+	}
+
+	result := models.TaskPage{
+		Tasks:      tasks,
+		Pagination: models.Pagination{PageSize: limit, Total: total},
+	}
+	if len(tasks) > 0 {
+		hasNext := more || filter.Before != nil
+		hasPrev := filter.After != nil || (more && filter.Before != nil)
+		if hasNext {
+			result.Pagination.NextCursor = encodeTaskCursor(sort, tasks[len(tasks)-1])
+		}
+		if hasPrev {
+			result.Pagination.PrevCursor = encodeTaskCursor(sort, tasks[0])
+		}
+	} else {
+		result.Tasks = []models.Task{}
+	}
+	loggerx.Info("Retrieved tasks after cursor successfully")
+	return result, nil
+}
+
+// pageSize applies DefaultPageSize and MaxPageSize.
+func pageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+	return size
+}
+
+// keysetSort is the single field a keyset page is sorted by; id by default.
+func keysetSort(sort []models.TaskSort) models.TaskSort {
+	if len(sort) == 0 {
+		return models.TaskSort{Field: "id"}
+	}
+	return sort[0]
+}
+
+func encodeTaskCursor(sort models.TaskSort, task models.Task) string {
+	token := cursorToken{Field: sort.Field, Desc: sort.Desc, Value: taskSortValue(task, sort.Field), ID: task.Id}
+	raw, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeTaskCursor(cursor string, sort models.TaskSort) (*models.TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(raw, &token); err != nil || token.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	if token.Field != sort.Field || token.Desc != sort.Desc {
+		return nil, fmt.Errorf("%w: it belongs to another sort order", ErrInvalidCursor)
+	}
+	return &models.TaskCursor{Value: token.Value, ID: token.ID}, nil
+}
+
+// taskSortValue returns the value of a sortable field as the repository
+// compares it.
+func taskSortValue(task models.Task, field string) string {
+	switch field {
+	case "title":
+		return task.Title
+	case "status":
+		return string(task.Status)
+	case "created_at":
+		return formatCursorTime(task.CreatedAt)
+	case "updated_at":
+		return formatCursorTime(task.UpdatedAt)
+	default:
+		return strconv.Itoa(task.Id)
+	}
+}
+
+func formatCursorTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
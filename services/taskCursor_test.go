@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"konzek-jun/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultTaskService_TaskCursorPage_WalksForwardAndBack(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	filter := models.TaskFilter{Sort: []models.TaskSort{{Field: "title"}}}
+
+	// İlk sayfa: limit+1 task gelirse bir sonraki sayfa vardır
+	first := filter
+	first.Limit = 3
+	mockRepo.EXPECT().FindTasks(gomock.Any(), int64(1), first).Return([]models.Task{
+		{Id: 4, Title: "A"}, {Id: 2, Title: "B"}, {Id: 7, Title: "C"},
+	}, nil)
+	mockRepo.EXPECT().CountTasks(gomock.Any(), int64(1), first).Return(int64(5), nil)
+
+	page, err := service.TaskCursorPage(context.Background(), 1, filter, "", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Task{{Id: 4, Title: "A"}, {Id: 2, Title: "B"}}, page.Tasks)
+	assert.Empty(t, page.Pagination.PrevCursor)
+	assert.NotEmpty(t, page.Pagination.NextCursor)
+
+	// İkinci sayfa, ilk sayfanın son task'inden sonra başlar
+	second := first
+	second.After = &models.TaskCursor{Value: "B", ID: 2}
+	mockRepo.EXPECT().FindTasks(gomock.Any(), int64(1), second).Return([]models.Task{{Id: 7, Title: "C"}}, nil)
+	mockRepo.EXPECT().CountTasks(gomock.Any(), int64(1), second).Return(int64(5), nil)
+
+	page, err = service.TaskCursorPage(context.Background(), 1, filter, page.Pagination.NextCursor, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Task{{Id: 7, Title: "C"}}, page.Tasks)
+	assert.Empty(t, page.Pagination.NextCursor)
+
+	// Geri dönüldüğünde ilk sayfanın son task'i dahil edilir
+	back := first
+	back.Before = &models.TaskCursor{Value: "C", ID: 7}
+	mockRepo.EXPECT().FindTasks(gomock.Any(), int64(1), back).Return([]models.Task{{Id: 4, Title: "A"}, {Id: 2, Title: "B"}}, nil)
+	mockRepo.EXPECT().CountTasks(gomock.Any(), int64(1), back).Return(int64(5), nil)
+
+	page, err = service.TaskCursorPage(context.Background(), 1, filter, "", page.Pagination.PrevCursor, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+	assert.Empty(t, page.Pagination.PrevCursor)
+	assert.NotEmpty(t, page.Pagination.NextCursor)
+}
+
+func TestDefaultTaskService_TaskCursorPage_RejectsForeignCursor(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// title'a göre sıralı bir sayfanın cursor'ı created_at sıralamasında kullanılamaz
+	cursor := encodeTaskCursor(models.TaskSort{Field: "title"}, models.Task{Id: 2, Title: "B"})
+	filter := models.TaskFilter{Sort: []models.TaskSort{{Field: "created_at", Desc: true}}}
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskCursorPage(context.Background(), 1, filter, cursor, "", 10)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = service.TaskCursorPage(context.Background(), 1, filter, "not-a-cursor", "", 10)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestDefaultTaskService_GetAllTaskWithPagination_ClampsPage(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// page=0 negatif offset üretmez, sayfa boyutu MaxPageSize ile sınırlanır
+	expected := models.TaskFilter{Offset: 0, Limit: MaxPageSize}
+	mockRepo.EXPECT().FindTasks(gomock.Any(), int64(1), expected).Return(nil, nil)
+
+	// Servis fonksiyonunun çağrılması
+	page, err := service.GetAllTaskWithPagination(context.Background(), 1, models.TaskFilter{}, 0, 1000)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Pagination.Page)
+	assert.Equal(t, MaxPageSize, page.Pagination.PageSize)
+	assert.Equal(t, []models.Task{}, page.Tasks)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"strings"
+)
+
+// DependencyCycleError is returned when a new dependency would close a cycle.
+// Path is the existing chain from DependsOnID back to TaskID.
+type DependencyCycleError struct {
+	TaskID      int
+	DependsOnID int
+	Path        []int
+}
+
+func (e *DependencyCycleError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("task %d cannot depend on itself", e.TaskID)
+	}
+	chain := make([]string, 0, len(e.Path)+1)
+	chain = append(chain, fmt.Sprint(e.TaskID))
+	for _, id := range e.Path {
+		chain = append(chain, fmt.Sprint(id))
+	}
+	return fmt.Sprintf("task %d cannot depend on task %d: it would create the cycle %s", e.TaskID, e.DependsOnID, strings.Join(chain, " -> "))
+}
+
+// OpenPrerequisitesError is returned when a task is moved to done before the
+// tasks it depends on are done.
+type OpenPrerequisitesError struct {
+	TaskID int
+	Open   []int
+}
+
+func (e *OpenPrerequisitesError) Error() string {
+	return fmt.Sprintf("task %d cannot be done before its prerequisites %v are done", e.TaskID, e.Open)
+}
+
+// TaskAddDependency makes task id depend on task dependsOnID. A task that is
+// not done yet is blocked while the new prerequisite is open. The dependency
+// graph of the owner is locked while it is checked for cycles, so concurrent
+// additions cannot close a cycle together.
+func (t DefaultTaskService) TaskAddDependency(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error) {
+	var task models.Task
+	err := t.inTx(ctx, func(t DefaultTaskService) error {
+		var err error
+		task, err = t.addDependency(ctx, ownerID, id, dependsOnID)
+		return err
+	})
+	return task, err
+}
+
+func (t DefaultTaskService) addDependency(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error) {
+	// Two concurrent inserts must not read the same graph and close a cycle together
+	if err := t.Repo.LockDependencies(ctx, ownerID); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while adding task dependency: %s", err))
+		return models.Task{}, err
+	}
+	task, err := t.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while adding task dependency: %s", err))
+		return models.Task{}, err
+	}
+	prerequisite, err := t.Repo.GetByID(ctx, ownerID, dependsOnID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while adding task dependency: %s", err))
+		return models.Task{}, err
+	}
+
+	if id == dependsOnID {
+		return models.Task{}, &DependencyCycleError{TaskID: id, DependsOnID: dependsOnID}
+	}
+	dependencies, err := t.Repo.GetDependencies(ctx, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while adding task dependency: %s", err))
+		return models.Task{}, err
+	}
+	if path := dependencyPath(dependencies, dependsOnID, id); path != nil {
+		loggerx.Error(fmt.Sprintf("Rejected task dependency %d -> %d: cycle", id, dependsOnID))
+		return models.Task{}, &DependencyCycleError{TaskID: id, DependsOnID: dependsOnID, Path: path}
+	}
+
+	if err := t.Repo.AddDependency(ctx, ownerID, id, dependsOnID); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while adding task dependency: %s", err))
+		return models.Task{}, err
+	}
+
+	if prerequisite.Status != models.TaskStatusDone && (task.Status == models.TaskStatusTodo || task.Status == models.TaskStatusInProgress) {
+		if err := t.Repo.UpdateStatus(ctx, ownerID, id, models.TaskStatusBlocked, task.Version); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while blocking task: %s", err))
+			return models.Task{}, err
+		}
+		task.Status = models.TaskStatusBlocked
+		task.Version++
+	}
+	loggerx.Info("Task dependency added successfully")
+	return task, nil
+}
+
+// TaskRemoveDependency removes a dependency and unblocks the task if it has
+// no open prerequisites left.
+func (t DefaultTaskService) TaskRemoveDependency(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error) {
+	var task models.Task
+	err := t.inTx(ctx, func(t DefaultTaskService) error {
+		var err error
+		task, err = t.removeDependency(ctx, ownerID, id, dependsOnID)
+		return err
+	})
+	return task, err
+}
+
+func (t DefaultTaskService) removeDependency(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error) {
+	task, err := t.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while removing task dependency: %s", err))
+		return models.Task{}, err
+	}
+	if err := t.Repo.RemoveDependency(ctx, ownerID, id, dependsOnID); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while removing task dependency: %s", err))
+		return models.Task{}, err
+	}
+
+	task, err = t.unblockIfReady(ctx, task)
+	if err != nil {
+		return models.Task{}, err
+	}
+	loggerx.Info("Task dependency removed successfully")
+	return task, nil
+}
+
+// TaskGraph returns the tasks upstream and downstream of task id.
+func (t DefaultTaskService) TaskGraph(ctx context.Context, ownerID int64, id int) (models.TaskGraph, error) {
+	if _, err := t.Repo.GetByID(ctx, ownerID, id); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting task graph: %s", err))
+		return models.TaskGraph{}, err
+	}
+	dependencies, err := t.Repo.GetDependencies(ctx, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting task graph: %s", err))
+		return models.TaskGraph{}, err
+	}
+	tasks, err := t.Repo.GetAll(ctx, ownerID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while getting task graph: %s", err))
+		return models.TaskGraph{}, err
+	}
+
+	upstream := reachable(dependencies, id, func(d models.TaskDependency) (int, int) { return d.TaskID, d.DependsOnID })
+	downstream := reachable(dependencies, id, func(d models.TaskDependency) (int, int) { return d.DependsOnID, d.TaskID })
+
+	graph := models.TaskGraph{
+		TaskID:     id,
+		Upstream:   []models.Task{},
+		Downstream: []models.Task{},
+		Edges:      []models.TaskDependency{},
+	}
+	for _, task := range tasks {
+		if upstream[task.Id] {
+			graph.Upstream = append(graph.Upstream, task)
+		}
+		if downstream[task.Id] {
+			graph.Downstream = append(graph.Downstream, task)
+		}
+	}
+	for _, dependency := range dependencies {
+		// Edges that leave the graph, e.g. another prerequisite of a downstream task, are left out
+		if dependency.TaskID == id || upstream[dependency.TaskID] || dependency.DependsOnID == id || downstream[dependency.DependsOnID] {
+			graph.Edges = append(graph.Edges, dependency)
+		}
+	}
+	loggerx.Info("Retrieved task graph successfully")
+	return graph, nil
+}
+
+// checkPrerequisites returns an OpenPrerequisitesError when a prerequisite of
+// task id is not done.
+func (t DefaultTaskService) checkPrerequisites(ctx context.Context, ownerID int64, id int) error {
+	prerequisites, err := t.Repo.GetPrerequisites(ctx, ownerID, id)
+	if err != nil {
+		return err
+	}
+	var open []int
+	for _, prerequisite := range prerequisites {
+		if prerequisite.Status != models.TaskStatusDone {
+			open = append(open, prerequisite.Id)
+		}
+	}
+	if len(open) > 0 {
+		return &OpenPrerequisitesError{TaskID: id, Open: open}
+	}
+	return nil
+}
+
+// propagateStatus updates the dependents of task id after it moved from one
+// status to another. Finishing a task unblocks the dependents that have no
+// other open prerequisite; reopening it blocks the dependents that are not
+// done yet.
+func (t DefaultTaskService) propagateStatus(ctx context.Context, ownerID int64, id int, from, to models.TaskStatus) error {
+	if from == to || (from != models.TaskStatusDone && to != models.TaskStatusDone) {
+		return nil
+	}
+	dependents, err := t.Repo.GetDependents(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while updating dependent tasks: %s", err))
+		return err
+	}
+	for _, dependent := range dependents {
+		if to == models.TaskStatusDone {
+			if _, err := t.unblockIfReady(ctx, dependent); err != nil {
+				return err
+			}
+			continue
+		}
+		if dependent.Status == models.TaskStatusTodo || dependent.Status == models.TaskStatusInProgress {
+			if err := t.Repo.UpdateStatus(ctx, ownerID, dependent.Id, models.TaskStatusBlocked, dependent.Version); err != nil {
+				loggerx.Error(fmt.Sprintf("Error while blocking dependent task: %s", err))
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unblockIfReady moves a blocked task back to todo once all of its
+// prerequisites are done.
+func (t DefaultTaskService) unblockIfReady(ctx context.Context, task models.Task) (models.Task, error) {
+	if task.Status != models.TaskStatusBlocked {
+		return task, nil
+	}
+	err := t.checkPrerequisites(ctx, task.OwnerID, task.Id)
+	var openErr *OpenPrerequisitesError
+	if errors.As(err, &openErr) {
+		return task, nil
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while unblocking task: %s", err))
+		return models.Task{}, err
+	}
+	if err := t.Repo.UpdateStatus(ctx, task.OwnerID, task.Id, models.TaskStatusTodo, task.Version); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while unblocking task: %s", err))
+		return models.Task{}, err
+	}
+	task.Status = models.TaskStatusTodo
+	task.Version++
+	loggerx.Info(fmt.Sprintf("Task %d unblocked", task.Id))
+	return task, nil
+}
+
+// dependencyPath returns the shortest chain of dependencies leading from one
+// task to another, both included, or nil if there is none.
+func dependencyPath(dependencies []models.TaskDependency, from, to int) []int {
+	edges := make(map[int][]int)
+	for _, dependency := range dependencies {
+		edges[dependency.TaskID] = append(edges[dependency.TaskID], dependency.DependsOnID)
+	}
+
+	previous := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			path := []int{to}
+			for id := to; id != from; id = previous[id] {
+				path = append([]int{previous[id]}, path...)
+			}
+			return path
+		}
+		for _, next := range edges[current] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// reachable returns every task reachable from id by following edge.
+func reachable(dependencies []models.TaskDependency, id int, edge func(models.TaskDependency) (int, int)) map[int]bool {
+	edges := make(map[int][]int)
+	for _, dependency := range dependencies {
+		from, to := edge(dependency)
+		edges[from] = append(edges[from], to)
+	}
+
+	seen := make(map[int]bool)
+	stack := []int{id}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range edges[current] {
+			if next != id && !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return seen
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"konzek-jun/models"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultTaskService_TaskAddDependency_BlocksTask(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Mock repository'den beklenen değerlerin ayarlanması
+	// Graf, döngü kontrolü için okunmadan önce kilitlenmeli
+	lock := mockRepo.EXPECT().LockDependencies(gomock.Any(), int64(1)).Return(nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 2).Return(models.Task{Id: 2, OwnerID: 1, Status: models.TaskStatusTodo}, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusInProgress}, nil)
+	mockRepo.EXPECT().GetDependencies(gomock.Any(), int64(1)).Return(nil, nil).After(lock)
+	mockRepo.EXPECT().AddDependency(gomock.Any(), int64(1), 2, 1).Return(nil)
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), int64(1), 2, models.TaskStatusBlocked, 0).Return(nil)
+
+	// Servis fonksiyonunun çağrılması
+	task, err := service.TaskAddDependency(context.Background(), 1, 2, 1)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, models.TaskStatusBlocked, task.Status)
+}
+
+func TestDefaultTaskService_TaskAddDependency_RejectsCycle(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// 1 -> 2 -> 3 zinciri varken 3'ün 1'e bağlanması döngü oluşturur
+	mockRepo.EXPECT().LockDependencies(gomock.Any(), int64(1)).Return(nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 3).Return(models.Task{Id: 3, OwnerID: 1, Status: models.TaskStatusTodo}, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusBlocked}, nil)
+	mockRepo.EXPECT().GetDependencies(gomock.Any(), int64(1)).Return([]models.TaskDependency{
+		{TaskID: 1, DependsOnID: 2},
+		{TaskID: 2, DependsOnID: 3},
+	}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskAddDependency(context.Background(), 1, 3, 1)
+
+	// Hata kontrolü
+	var cycleErr *DependencyCycleError
+	assert.ErrorAs(t, err, &cycleErr)
+	assert.Equal(t, []int{1, 2, 3}, cycleErr.Path)
+	assert.Contains(t, err.Error(), "3 -> 1 -> 2 -> 3")
+}
+
+func TestDefaultTaskService_TaskTransition_OpenPrerequisites(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 2).Return(models.Task{Id: 2, OwnerID: 1, Status: models.TaskStatusInProgress}, nil)
+	mockRepo.EXPECT().GetPrerequisites(gomock.Any(), int64(1), 2).Return([]models.Task{
+		{Id: 1, OwnerID: 1, Status: models.TaskStatusDone},
+		{Id: 3, OwnerID: 1, Status: models.TaskStatusTodo},
+	}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskTransition(context.Background(), 1, 2, 0, models.TaskStatusDone)
+
+	// Hata kontrolü
+	var openErr *OpenPrerequisitesError
+	assert.ErrorAs(t, err, &openErr)
+	assert.Equal(t, []int{3}, openErr.Open)
+}
+
+func TestDefaultTaskService_TaskTransition_UnblocksDependents(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusInProgress}, nil)
+	mockRepo.EXPECT().GetPrerequisites(gomock.Any(), int64(1), 1).Return(nil, nil)
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), int64(1), 1, models.TaskStatusDone, 0).Return(nil)
+	mockRepo.EXPECT().GetDependents(gomock.Any(), int64(1), 1).Return([]models.Task{
+		{Id: 2, OwnerID: 1, Status: models.TaskStatusBlocked},
+		{Id: 3, OwnerID: 1, Status: models.TaskStatusBlocked},
+	}, nil)
+	// 2'nin tek ön koşulu 1, 3 ise hala 4'ü bekliyor
+	mockRepo.EXPECT().GetPrerequisites(gomock.Any(), int64(1), 2).Return([]models.Task{{Id: 1, OwnerID: 1, Status: models.TaskStatusDone}}, nil)
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), int64(1), 2, models.TaskStatusTodo, 0).Return(nil)
+	mockRepo.EXPECT().GetPrerequisites(gomock.Any(), int64(1), 3).Return([]models.Task{
+		{Id: 1, OwnerID: 1, Status: models.TaskStatusDone},
+		{Id: 4, OwnerID: 1, Status: models.TaskStatusTodo},
+	}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	task, err := service.TaskTransition(context.Background(), 1, 1, 0, models.TaskStatusDone)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, models.TaskStatusDone, task.Status)
+}
+
+func TestDefaultTaskService_TaskGraph(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// 1 <- 2 <- 3 ve 4 <- 3; 2'nin grafiğinde 4 yer almaz
+	tasks := []models.Task{{Id: 1}, {Id: 2}, {Id: 3}, {Id: 4}}
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 2).Return(tasks[1], nil)
+	mockRepo.EXPECT().GetDependencies(gomock.Any(), int64(1)).Return([]models.TaskDependency{
+		{TaskID: 2, DependsOnID: 1},
+		{TaskID: 3, DependsOnID: 2},
+		{TaskID: 3, DependsOnID: 4},
+	}, nil)
+	mockRepo.EXPECT().GetAll(gomock.Any(), int64(1)).Return(tasks, nil)
+
+	// Servis fonksiyonunun çağrılması
+	graph, err := service.TaskGraph(context.Background(), 1, 2)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Task{{Id: 1}}, graph.Upstream)
+	assert.Equal(t, []models.Task{{Id: 3}}, graph.Downstream)
+	assert.Equal(t, []models.TaskDependency{{TaskID: 2, DependsOnID: 1}, {TaskID: 3, DependsOnID: 2}}, graph.Edges)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"konzek-jun/loggerx"
 	"konzek-jun/models"
@@ -9,16 +10,27 @@ import (
 
 //go:generate mockgen -destination=../mocks//service/mockTaskservice.go -package=services konzek-jun/services TaskService
 type TaskService interface {
-	TaskInsert(Task models.Task) error
-	TaskGetAll() ([]models.Task, error)
-	TaskDelete(id int) error
-	TaskUpdate(task models.Task) error
-	TaskGetByID(id int) (models.Task, error)
-	GetAllTaskWithPagination(page, pageSize int) ([]models.Task, error)
+	TaskInsert(ctx context.Context, task models.Task) (int64, error)
+	TaskGetAll(ctx context.Context, ownerID int64) ([]models.Task, error)
+	TaskDelete(ctx context.Context, ownerID int64, id int, version int) error
+	TaskUpdate(ctx context.Context, task models.Task) (models.Task, error)
+	TaskPatch(ctx context.Context, ownerID int64, id int, version int, patch func(task models.Task) (models.Task, error)) (models.Task, error)
+	TaskBulk(ctx context.Context, ownerID int64, operations []BulkTaskOperation, atomic bool) []BulkTaskResult
+	TaskGetByID(ctx context.Context, ownerID int64, id int) (models.Task, error)
+	TaskTransition(ctx context.Context, ownerID int64, id int, version int, status models.TaskStatus) (models.Task, error)
+	TaskSearch(ctx context.Context, ownerID int64, filter models.TaskFilter) ([]models.Task, int64, error)
+	GetAllTaskWithPagination(ctx context.Context, ownerID int64, filter models.TaskFilter, page, pageSize int) (models.TaskPage, error)
+	TaskCursorPage(ctx context.Context, ownerID int64, filter models.TaskFilter, after, before string, limit int) (models.TaskPage, error)
+	TaskAddDependency(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error)
+	TaskRemoveDependency(ctx context.Context, ownerID int64, id, dependsOnID int) (models.Task, error)
+	TaskGraph(ctx context.Context, ownerID int64, id int) (models.TaskGraph, error)
 }
 
 type DefaultTaskService struct {
 	Repo repository.TaskRepository
+	// UnitOfWork runs the operations that change more than one task in a
+	// single transaction. Without it they run one call at a time.
+	UnitOfWork repository.UnitOfWork
 }
 
 func NewTaskService(Repo repository.TaskRepository) DefaultTaskService {
@@ -28,18 +40,21 @@ func NewTaskService(Repo repository.TaskRepository) DefaultTaskService {
 	}
 }
 
-func (t DefaultTaskService) TaskInsert(task models.Task) error {
-	_, err := t.Repo.Insert(task)
+func (t DefaultTaskService) TaskInsert(ctx context.Context, task models.Task) (int64, error) {
+	if task.Status == "" {
+		task.Status = models.TaskStatusTodo
+	}
+	id, err := t.Repo.Insert(ctx, task)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while inserting task: %s", err))
-		return err
+		return 0, err
 	}
 	loggerx.Info("Task inserted successfully")
-	return nil
+	return id, nil
 }
 
-func (t DefaultTaskService) TaskGetAll() ([]models.Task, error) {
-	result, err := t.Repo.GetAll()
+func (t DefaultTaskService) TaskGetAll(ctx context.Context, ownerID int64) ([]models.Task, error) {
+	result, err := t.Repo.GetAll(ctx, ownerID)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while getting all tasks: %s", err))
 		return nil, err
@@ -48,28 +63,143 @@ func (t DefaultTaskService) TaskGetAll() ([]models.Task, error) {
 	return result, nil
 }
 
-func (t DefaultTaskService) TaskDelete(id int) error {
-	err := t.Repo.Delete(id)
+// TaskDelete deletes a task and unblocks its dependents in one transaction.
+// A version other than 0 has to match the task, otherwise
+// repository.ErrVersionConflict is returned.
+func (t DefaultTaskService) TaskDelete(ctx context.Context, ownerID int64, id int, version int) error {
+	return t.inTx(ctx, func(t DefaultTaskService) error {
+		return t.deleteTask(ctx, ownerID, id, version)
+	})
+}
+
+func (t DefaultTaskService) deleteTask(ctx context.Context, ownerID int64, id int, version int) error {
+	dependents, err := t.Repo.GetDependents(ctx, ownerID, id)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while deleting task: %s", err))
 		return err
 	}
+	err = t.Repo.Delete(ctx, ownerID, id, version)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while deleting task: %s", err))
+		return err
+	}
+	// A deleted task no longer holds back its dependents
+	for _, dependent := range dependents {
+		if _, err := t.unblockIfReady(ctx, dependent); err != nil {
+			return err
+		}
+	}
 	loggerx.Info("Task deleted successfully")
 	return nil
 }
 
-func (t DefaultTaskService) TaskUpdate(task models.Task) error {
-	err := t.Repo.Update(task)
+// TaskUpdate updates a task and the status of its dependents in one
+// transaction and returns the updated task. A task.Version other than 0 has
+// to match the stored task. The task is only written if it still has the
+// version the update was checked against, otherwise
+// repository.ErrVersionConflict is returned.
+func (t DefaultTaskService) TaskUpdate(ctx context.Context, task models.Task) (models.Task, error) {
+	var updated models.Task
+	err := t.inTx(ctx, func(t DefaultTaskService) error {
+		var err error
+		updated, err = t.updateTask(ctx, task)
+		return err
+	})
+	return updated, err
+}
+
+func (t DefaultTaskService) updateTask(ctx context.Context, task models.Task) (models.Task, error) {
+	current, err := t.Repo.GetByID(ctx, task.OwnerID, task.Id)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while updating task: %s", err))
+		return models.Task{}, err
+	}
+	return t.replaceTask(ctx, current, task)
+}
+
+// TaskPatch stores what patch makes of the current task like TaskUpdate, in
+// one transaction. Only title, content and status of the patched task are
+// written. A version other than 0 has to match the task before patch runs;
+// either way the patched task is only written over the task patch was given.
+// Errors of patch are returned as is.
+func (t DefaultTaskService) TaskPatch(ctx context.Context, ownerID int64, id int, version int, patch func(task models.Task) (models.Task, error)) (models.Task, error) {
+	var updated models.Task
+	err := t.inTx(ctx, func(t DefaultTaskService) error {
+		var err error
+		updated, err = t.patchTask(ctx, ownerID, id, version, patch)
 		return err
+	})
+	return updated, err
+}
+
+func (t DefaultTaskService) patchTask(ctx context.Context, ownerID int64, id int, version int, patch func(task models.Task) (models.Task, error)) (models.Task, error) {
+	current, err := t.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while patching task: %s", err))
+		return models.Task{}, err
+	}
+	if err := checkVersion(current, version); err != nil {
+		return models.Task{}, err
+	}
+	task, err := patch(current)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while patching task: %s", err))
+		return models.Task{}, err
+	}
+	task.Id, task.OwnerID, task.Version = current.Id, current.OwnerID, version
+	return t.replaceTask(ctx, current, task)
+}
+
+// replaceTask writes title, content and status of task over current. Without
+// a version of its own, task is written against the version of current.
+func (t DefaultTaskService) replaceTask(ctx context.Context, current, task models.Task) (models.Task, error) {
+	if err := checkVersion(current, task.Version); err != nil {
+		return models.Task{}, err
+	}
+	if task.Status == "" {
+		task.Status = current.Status
+	}
+	if !CanTransition(current.Status, task.Status) {
+		loggerx.Error(fmt.Sprintf("Illegal task status transition from %s to %s", current.Status, task.Status))
+		return models.Task{}, &InvalidTransitionError{From: current.Status, To: task.Status}
+	}
+	if task.Status == models.TaskStatusDone && current.Status != models.TaskStatusDone {
+		if err := t.checkPrerequisites(ctx, task.OwnerID, task.Id); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while updating task: %s", err))
+			return models.Task{}, err
+		}
+	}
+
+	// A write in between voids the state that was checked
+	if task.Version == 0 {
+		task.Version = current.Version
+	}
+	err := t.Repo.Update(ctx, task)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while updating task: %s", err))
+		return models.Task{}, err
+	}
+	if err := t.propagateStatus(ctx, task.OwnerID, task.Id, current.Status, task.Status); err != nil {
+		return models.Task{}, err
 	}
+	current.Title, current.Content, current.Status = task.Title, task.Content, task.Status
+	current.Version++
 	loggerx.Info("Task updated successfully")
+	return current, nil
+}
+
+// checkVersion rejects a write with a version other than 0 that current no
+// longer has, before any transition rule is checked.
+func checkVersion(current models.Task, version int) error {
+	if version != 0 && version != current.Version {
+		loggerx.Error(fmt.Sprintf("Task %d has version %d, not %d", current.Id, current.Version, version))
+		return repository.ErrVersionConflict
+	}
 	return nil
 }
 
-func (t DefaultTaskService) TaskGetByID(id int) (models.Task, error) {
-	task, err := t.Repo.GetByID(id)
+func (t DefaultTaskService) TaskGetByID(ctx context.Context, ownerID int64, id int) (models.Task, error) {
+	task, err := t.Repo.GetByID(ctx, ownerID, id)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while getting task by ID: %s", err))
 		return models.Task{}, err
@@ -78,14 +208,112 @@ func (t DefaultTaskService) TaskGetByID(id int) (models.Task, error) {
 	return task, nil
 }
 
-func (s DefaultTaskService) GetAllTaskWithPagination(page, pageSize int) ([]models.Task, error) {
-	offset := (page - 1) * pageSize
-	limit := pageSize
-	tasks, err := s.Repo.GetTasksWithPagination(offset, limit)
+// TaskTransition moves a task to status and updates the status of its
+// dependents in one transaction. A version other than 0 has to match the
+// task. The status is only written if the task still has the version the
+// transition was checked against, otherwise repository.ErrVersionConflict is
+// returned.
+func (t DefaultTaskService) TaskTransition(ctx context.Context, ownerID int64, id int, version int, status models.TaskStatus) (models.Task, error) {
+	var task models.Task
+	err := t.inTx(ctx, func(t DefaultTaskService) error {
+		var err error
+		task, err = t.transitionTask(ctx, ownerID, id, version, status)
+		return err
+	})
+	return task, err
+}
+
+func (t DefaultTaskService) transitionTask(ctx context.Context, ownerID int64, id int, version int, status models.TaskStatus) (models.Task, error) {
+	task, err := t.Repo.GetByID(ctx, ownerID, id)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while transitioning task: %s", err))
+		return models.Task{}, err
+	}
+	if err := checkVersion(task, version); err != nil {
+		return models.Task{}, err
+	}
+	if !CanTransition(task.Status, status) {
+		loggerx.Error(fmt.Sprintf("Illegal task status transition from %s to %s", task.Status, status))
+		return models.Task{}, &InvalidTransitionError{From: task.Status, To: status}
+	}
+	if status == models.TaskStatusDone && task.Status != models.TaskStatusDone {
+		if err := t.checkPrerequisites(ctx, ownerID, id); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while transitioning task: %s", err))
+			return models.Task{}, err
+		}
+	}
+
+	// A write in between voids the state that was checked
+	if err := t.Repo.UpdateStatus(ctx, ownerID, id, status, task.Version); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while transitioning task: %s", err))
+		return models.Task{}, err
+	}
+	if err := t.propagateStatus(ctx, ownerID, id, task.Status, status); err != nil {
+		return models.Task{}, err
+	}
+	task.Status = status
+	task.Version++
+	loggerx.Info("Task transitioned successfully")
+	return task, nil
+}
+
+// TaskSearch returns the tasks that match filter and how many tasks match it
+// in total, regardless of filter.Offset and filter.Limit.
+func (t DefaultTaskService) TaskSearch(ctx context.Context, ownerID int64, filter models.TaskFilter) ([]models.Task, int64, error) {
+	tasks, err := t.Repo.FindTasks(ctx, ownerID, filter)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while searching tasks: %s", err))
+		return nil, 0, err
+	}
+	total := int64(len(tasks))
+	if filter.Offset > 0 || (filter.Limit > 0 && len(tasks) == filter.Limit) {
+		total, err = t.Repo.CountTasks(ctx, ownerID, filter)
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while counting tasks: %s", err))
+			return nil, 0, err
+		}
+	}
+	loggerx.Info("Searched tasks successfully")
+	return tasks, total, nil
+}
+
+// GetAllTaskWithPagination returns a page by offset. Pages start at 1 and the
+// page size is limited to MaxPageSize.
+func (s DefaultTaskService) GetAllTaskWithPagination(ctx context.Context, ownerID int64, filter models.TaskFilter, page, size int) (models.TaskPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	size = pageSize(size)
+	filter.Offset = (page - 1) * size
+	filter.Limit = size
+	tasks, total, err := s.TaskSearch(ctx, ownerID, filter)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while getting tasks with pagination: %s", err))
-		return nil, err
+		return models.TaskPage{}, err
+	}
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+
+	result := models.TaskPage{
+		Tasks:      tasks,
+		Pagination: models.Pagination{Page: page, PageSize: size, Total: total},
 	}
+	result.Pagination.TotalPages = int((total + int64(size) - 1) / int64(size))
 	loggerx.Info("Retrieved tasks with pagination successfully")
-	return tasks, nil
+	return result, nil
+}
+
+// inTx runs fn with a copy of t whose Repo is bound to one transaction of
+// t.UnitOfWork. Without a unit of work fn gets t itself.
+func (t DefaultTaskService) inTx(ctx context.Context, fn func(t DefaultTaskService) error) error {
+	if t.UnitOfWork == nil {
+		return fn(t)
+	}
+	return t.UnitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		tx := t
+		tx.Repo = repos.Tasks
+		tx.UnitOfWork = nil
+		return fn(tx)
+	})
 }
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"konzek-jun/mocks/repository"
 	"konzek-jun/models"
+	taskrepo "konzek-jun/repository"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -13,9 +16,9 @@ var mockRepo *repository.MockTaskRepository
 var service TaskService
 
 var FakeData = []models.Task{
-	{Id: 1, Title: "Task 1", Content: "Description 1", Status: true},
-	{Id: 2, Title: "Task 2", Content: "Description 2", Status: false},
-	{Id: 3, Title: "Task 3", Content: "Description 3", Status: true},
+	{Id: 1, Title: "Task 1", Content: "Description 1", Status: models.TaskStatusDone},
+	{Id: 2, Title: "Task 2", Content: "Description 2", Status: models.TaskStatusTodo},
+	{Id: 3, Title: "Task 3", Content: "Description 3", Status: models.TaskStatusDone},
 }
 
 func setup(t *testing.T) func() {
@@ -35,10 +38,10 @@ func TestDefaultTaskService_TaskGetAll_Success(t *testing.T) {
 	defer td()
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockRepo.EXPECT().GetAll().Return(FakeData, nil)
+	mockRepo.EXPECT().GetAll(gomock.Any(), int64(1)).Return(FakeData, nil)
 
 	// Servis fonksiyonunun çağrılması
-	result, err := service.TaskGetAll()
+	result, err := service.TaskGetAll(context.Background(), 1)
 
 	// Hata kontrolü
 	if err != nil {
@@ -53,13 +56,15 @@ func TestDefaultTaskService_TaskInsert_Success(t *testing.T) {
 
 	// Mock repository'den beklenen değerlerin ayarlanması
 	task := models.Task{Id: 1, Title: "Test Task", Content: "Test Description"}
-	mockRepo.EXPECT().Insert(task).Return(int64(1), nil)
+	// Status verilmezse task todo olarak oluşturulur
+	mockRepo.EXPECT().Insert(gomock.Any(), models.Task{Id: 1, Title: "Test Task", Content: "Test Description", Status: models.TaskStatusTodo}).Return(int64(1), nil)
 
 	// Servis fonksiyonunun çağrılması
-	err := service.TaskInsert(task)
+	id, err := service.TaskInsert(context.Background(), task)
 
 	// Hata kontrolü
 	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
 }
 
 func TestDefaultTaskService_TaskDelete_Success(t *testing.T) {
@@ -68,10 +73,11 @@ func TestDefaultTaskService_TaskDelete_Success(t *testing.T) {
 
 	// Mock repository'den beklenen değerlerin ayarlanması
 	taskID := 1
-	mockRepo.EXPECT().Delete(taskID).Return(nil)
+	mockRepo.EXPECT().GetDependents(gomock.Any(), int64(1), taskID).Return(nil, nil)
+	mockRepo.EXPECT().Delete(gomock.Any(), int64(1), taskID, 0).Return(nil)
 
 	// Servis fonksiyonunun çağrılması
-	err := service.TaskDelete(taskID)
+	err := service.TaskDelete(context.Background(), 1, taskID, 0)
 
 	// Hata kontrolü
 	assert.NoError(t, err)
@@ -82,14 +88,92 @@ func TestDefaultTaskService_TaskUpdate_Success(t *testing.T) {
 	defer setup(t)()
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	task := models.Task{Id: 1, Title: "Test Task", Content: "Test Description"}
-	mockRepo.EXPECT().Update(task).Return(nil)
+	task := models.Task{Id: 1, Title: "Test Task", Content: "Test Description", Status: models.TaskStatusInProgress}
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(0), 1).Return(models.Task{Id: 1, Status: models.TaskStatusTodo, Version: 3}, nil)
+	// If-Match olmadan da okunan sürüme karşı yazılır
+	stored := task
+	stored.Version = 3
+	mockRepo.EXPECT().Update(gomock.Any(), stored).Return(nil)
 
 	// Servis fonksiyonunun çağrılması
-	err := service.TaskUpdate(task)
+	updated, err := service.TaskUpdate(context.Background(), task)
 
 	// Hata kontrolü
 	assert.NoError(t, err)
+	assert.Equal(t, "Test Task", updated.Title)
+	assert.Equal(t, models.TaskStatusInProgress, updated.Status)
+	assert.Equal(t, 4, updated.Version)
+}
+
+func TestDefaultTaskService_TaskUpdate_StaleVersion(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Task okunduktan sonra başka biri tarafından değiştirilmiş
+	task := models.Task{Id: 1, OwnerID: 1, Title: "Test Task", Content: "Test Description", Status: models.TaskStatusDone, Version: 2}
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusTodo, Version: 3}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskUpdate(context.Background(), task)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrVersionConflict)
+}
+
+func TestDefaultTaskService_TaskUpdate_ConcurrentChange(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Okunan sürüm yazmadan önce değişmişse güncelleme yazılmaz
+	task := models.Task{Id: 1, OwnerID: 1, Title: "Test Task", Content: "Test Description", Status: models.TaskStatusInProgress}
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusTodo, Version: 3}, nil)
+	stored := task
+	stored.Version = 3
+	mockRepo.EXPECT().Update(gomock.Any(), stored).Return(taskrepo.ErrVersionConflict)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskUpdate(context.Background(), task)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrVersionConflict)
+}
+
+func TestDefaultTaskService_TaskPatch(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Yama mevcut task'e uygulanır, id ve owner yamayla değişmez
+	current := models.Task{Id: 1, OwnerID: 1, Title: "Old", Content: "Content", Status: models.TaskStatusTodo, Version: 2}
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(current, nil)
+	mockRepo.EXPECT().Update(gomock.Any(), models.Task{Id: 1, OwnerID: 1, Title: "New", Content: "Content", Status: models.TaskStatusTodo, Version: 2}).Return(nil)
+
+	// Servis fonksiyonunun çağrılması
+	updated, err := service.TaskPatch(context.Background(), 1, 1, 2, func(task models.Task) (models.Task, error) {
+		task.Id, task.OwnerID, task.Title = 7, 7, "New"
+		return task, nil
+	})
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, "New", updated.Title)
+	assert.Equal(t, 3, updated.Version)
+}
+
+func TestDefaultTaskService_TaskPatch_StaleVersion(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Eski sürümde yama hiç çalıştırılmaz
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusTodo, Version: 3}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskPatch(context.Background(), 1, 1, 2, func(task models.Task) (models.Task, error) {
+		t.Fatal("patch should not run")
+		return task, nil
+	})
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrVersionConflict)
 }
 
 func TestDefaultTaskService_TaskGetByID_Success(t *testing.T) {
@@ -99,10 +183,10 @@ func TestDefaultTaskService_TaskGetByID_Success(t *testing.T) {
 	// Mock repository'den beklenen değerlerin ayarlanması
 	taskID := 1
 	fakeTask := models.Task{Id: taskID, Title: "Test Task", Content: "Test Description"}
-	mockRepo.EXPECT().GetByID(taskID).Return(fakeTask, nil)
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), taskID).Return(fakeTask, nil)
 
 	// Servis fonksiyonunun çağrılması
-	task, err := service.TaskGetByID(taskID)
+	task, err := service.TaskGetByID(context.Background(), 1, taskID)
 
 	// Hata kontrolü
 	assert.NoError(t, err)
@@ -110,3 +194,234 @@ func TestDefaultTaskService_TaskGetByID_Success(t *testing.T) {
 	// Sonuç kontrolü
 	assert.Equal(t, fakeTask, task)
 }
+
+func TestDefaultTaskService_TaskGetByID_OtherOwner(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Başka bir kullanıcıya ait task repository tarafından bulunamaz
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(2), 1).Return(models.Task{}, taskrepo.ErrTaskNotFound)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskGetByID(context.Background(), 2, 1)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrTaskNotFound)
+}
+
+func TestDefaultTaskService_TaskUpdate_IllegalTransition(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// done durumundaki bir task doğrudan blocked olamaz
+	task := models.Task{Id: 1, OwnerID: 1, Title: "Test Task", Content: "Test Description", Status: models.TaskStatusBlocked}
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusDone}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskUpdate(context.Background(), task)
+
+	// Hata kontrolü
+	var transitionErr *InvalidTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, models.TaskStatusDone, transitionErr.From)
+	assert.Equal(t, models.TaskStatusBlocked, transitionErr.To)
+}
+
+func TestDefaultTaskService_TaskTransition_Success(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusTodo, Version: 4}, nil)
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), int64(1), 1, models.TaskStatusInProgress, 4).Return(nil)
+
+	// Servis fonksiyonunun çağrılması
+	task, err := service.TaskTransition(context.Background(), 1, 1, 0, models.TaskStatusInProgress)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, models.TaskStatusInProgress, task.Status)
+	assert.Equal(t, 5, task.Version)
+}
+
+func TestDefaultTaskService_TaskTransition_ConcurrentChange(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Okunan sürüm yazmadan önce değişmişse geçiş yazılmaz
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusTodo, Version: 4}, nil)
+	mockRepo.EXPECT().UpdateStatus(gomock.Any(), int64(1), 1, models.TaskStatusInProgress, 4).Return(taskrepo.ErrVersionConflict)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskTransition(context.Background(), 1, 1, 0, models.TaskStatusInProgress)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrVersionConflict)
+}
+
+func TestDefaultTaskService_TaskTransition_StaleVersion(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// If-Match'teki sürüm eskiyse geçiş kuralı kontrol edilmeden reddedilir
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusTodo, Version: 4}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskTransition(context.Background(), 1, 1, 3, models.TaskStatusInProgress)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, taskrepo.ErrVersionConflict)
+}
+
+func TestDefaultTaskService_TaskTransition_Illegal(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// cancelled bir task sadece todo'ya geri açılabilir
+	mockRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusCancelled}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := service.TaskTransition(context.Background(), 1, 1, 0, models.TaskStatusDone)
+
+	// Hata kontrolü
+	var transitionErr *InvalidTransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(models.TaskStatusTodo, models.TaskStatusInProgress))
+	assert.True(t, CanTransition(models.TaskStatusInProgress, models.TaskStatusDone))
+	assert.True(t, CanTransition(models.TaskStatusDone, models.TaskStatusTodo))
+	assert.True(t, CanTransition(models.TaskStatusBlocked, models.TaskStatusBlocked))
+	assert.False(t, CanTransition(models.TaskStatusDone, models.TaskStatusInProgress))
+	assert.False(t, CanTransition(models.TaskStatusBlocked, models.TaskStatusDone))
+	assert.False(t, CanTransition(models.TaskStatusCancelled, models.TaskStatusDone))
+}
+
+func TestDefaultTaskService_GetAllTaskWithPagination(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Mock repository'den beklenen değerlerin ayarlanması
+	filter := models.TaskFilter{Statuses: []models.TaskStatus{models.TaskStatusDone}}
+	paged := filter
+	paged.Offset = 2
+	paged.Limit = 2
+	mockRepo.EXPECT().FindTasks(gomock.Any(), int64(1), paged).Return(FakeData[:2], nil)
+	mockRepo.EXPECT().CountTasks(gomock.Any(), int64(1), paged).Return(int64(5), nil)
+
+	// Servis fonksiyonunun çağrılması
+	page, err := service.GetAllTaskWithPagination(context.Background(), 1, filter, 2, 2)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+	assert.Equal(t, models.Pagination{Page: 2, PageSize: 2, Total: 5, TotalPages: 3}, page.Pagination)
+}
+
+func TestDefaultTaskService_TaskSearch_SkipsCountOnLastPage(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Limit dolmadıysa toplam sayı için ikinci sorgu atılmaz
+	filter := models.TaskFilter{Search: "report", Limit: 10}
+	mockRepo.EXPECT().FindTasks(gomock.Any(), int64(1), filter).Return(FakeData, nil)
+
+	// Servis fonksiyonunun çağrılması
+	tasks, total, err := service.TaskSearch(context.Background(), 1, filter)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 3)
+	assert.Equal(t, int64(3), total)
+}
+
+// fakeUnitOfWork hands fn its repositories and remembers what fn returned
+type fakeUnitOfWork struct {
+	repos taskrepo.Repositories
+	calls int
+	err   error
+}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(repos taskrepo.Repositories) error) error {
+	f.calls++
+	f.err = fn(f.repos)
+	return f.err
+}
+
+func TestDefaultTaskService_TaskTransition_UsesUnitOfWork(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+	txRepo := repository.NewMockTaskRepository(gomock.NewController(t))
+	unitOfWork := &fakeUnitOfWork{repos: taskrepo.Repositories{Tasks: txRepo}}
+	transactional := NewTaskService(mockRepo)
+	transactional.UnitOfWork = unitOfWork
+
+	// Durum değişir ama bağımlı task'ler okunamazsa bütün işlem geri alınmalı
+	failure := errors.New("connection reset")
+	txRepo.EXPECT().GetByID(gomock.Any(), int64(1), 1).Return(models.Task{Id: 1, OwnerID: 1, Status: models.TaskStatusInProgress}, nil)
+	txRepo.EXPECT().GetPrerequisites(gomock.Any(), int64(1), 1).Return(nil, nil)
+	txRepo.EXPECT().UpdateStatus(gomock.Any(), int64(1), 1, models.TaskStatusDone, 0).Return(nil)
+	txRepo.EXPECT().GetDependents(gomock.Any(), int64(1), 1).Return(nil, failure)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := transactional.TaskTransition(context.Background(), 1, 1, 0, models.TaskStatusDone)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorIs(t, unitOfWork.err, failure)
+	assert.Equal(t, 1, unitOfWork.calls)
+}
+
+func TestDefaultTaskService_TaskBulk_BestEffort(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+
+	// Yeni task'ler tek seferde eklenir, hatalı silme diğerlerini etkilemez
+	mockRepo.EXPECT().InsertMany(gomock.Any(), []models.Task{
+		{OwnerID: 1, Title: "First", Content: "Content", Status: models.TaskStatusTodo},
+		{OwnerID: 1, Title: "Second", Content: "Content", Status: models.TaskStatusDone},
+	}).Return([]int64{10, 11}, nil)
+	mockRepo.EXPECT().GetDependents(gomock.Any(), int64(1), 5).Return(nil, nil)
+	mockRepo.EXPECT().Delete(gomock.Any(), int64(1), 5, 0).Return(taskrepo.ErrTaskNotFound)
+
+	// Servis fonksiyonunun çağrılması
+	results := service.TaskBulk(context.Background(), 1, []BulkTaskOperation{
+		{Op: BulkCreate, Task: models.Task{Title: "First", Content: "Content"}},
+		{Op: BulkDelete, Task: models.Task{Id: 5}},
+		{Op: BulkCreate, Task: models.Task{Title: "Second", Content: "Content", Status: models.TaskStatusDone}},
+	}, false)
+
+	// Hata kontrolü
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, 10, results[0].Task.Id)
+	assert.ErrorIs(t, results[1].Err, taskrepo.ErrTaskNotFound)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 11, results[2].Task.Id)
+}
+
+func TestDefaultTaskService_TaskBulk_Atomic(t *testing.T) {
+	// Test için hazırlıkları yap
+	defer setup(t)()
+	txRepo := repository.NewMockTaskRepository(gomock.NewController(t))
+	unitOfWork := &fakeUnitOfWork{repos: taskrepo.Repositories{Tasks: txRepo}}
+	transactional := NewTaskService(mockRepo)
+	transactional.UnitOfWork = unitOfWork
+
+	// Güncelleme eski sürümle geldiği için hepsi geri alınır
+	txRepo.EXPECT().InsertMany(gomock.Any(), gomock.Any()).Return([]int64{10}, nil)
+	txRepo.EXPECT().GetByID(gomock.Any(), int64(1), 3).Return(models.Task{Id: 3, OwnerID: 1, Status: models.TaskStatusTodo, Version: 4}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	results := transactional.TaskBulk(context.Background(), 1, []BulkTaskOperation{
+		{Op: BulkCreate, Task: models.Task{Title: "First", Content: "Content"}},
+		{Op: BulkUpdate, Task: models.Task{Id: 3, Title: "Stale", Content: "Content", Version: 2}},
+		{Op: BulkDelete, Task: models.Task{Id: 5}},
+	}, true)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, unitOfWork.err, taskrepo.ErrVersionConflict)
+	assert.ErrorIs(t, results[0].Err, ErrBulkRolledBack)
+	assert.ErrorIs(t, results[1].Err, taskrepo.ErrVersionConflict)
+	assert.ErrorIs(t, results[2].Err, ErrBulkRolledBack)
+}
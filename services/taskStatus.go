@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"konzek-jun/models"
+)
+
+// taskTransitions lists the statuses a task may move to from each status.
+var taskTransitions = map[models.TaskStatus][]models.TaskStatus{
+	models.TaskStatusTodo:       {models.TaskStatusInProgress, models.TaskStatusBlocked, models.TaskStatusDone, models.TaskStatusCancelled},
+	models.TaskStatusInProgress: {models.TaskStatusTodo, models.TaskStatusBlocked, models.TaskStatusDone, models.TaskStatusCancelled},
+	models.TaskStatusBlocked:    {models.TaskStatusTodo, models.TaskStatusInProgress, models.TaskStatusCancelled},
+	models.TaskStatusDone:       {models.TaskStatusTodo},
+	models.TaskStatusCancelled:  {models.TaskStatusTodo},
+}
+
+// InvalidTransitionError is returned when a status change is not allowed by taskTransitions.
+type InvalidTransitionError struct {
+	From models.TaskStatus
+	To   models.TaskStatus
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot transition task from %s to %s", e.From, e.To)
+}
+
+// CanTransition reports whether a task in status from may move to status to.
+// Staying in the same status is always allowed.
+func CanTransition(from, to models.TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range taskTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidRefreshToken is returned for refresh tokens that are unknown,
+// expired, revoked or were already used.
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+
+// TokenPair is a short-lived access token and the opaque refresh token that
+// renews it.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is the lifetime of AccessToken.
+	ExpiresIn time.Duration
+}
+
+// Session is the access token a request was authenticated with. SessionID
+// is the refresh token family the token was issued for.
+type Session struct {
+	UserID    int64
+	TokenID   string
+	SessionID string
+	ExpiresAt time.Time
+}
+
+//go:generate mockgen -destination=../mocks//service/mockTokenservice.go -package=services konzek-jun/services TokenService
+type TokenService interface {
+	// IssueTokens starts a new token family for userID, e.g. on login.
+	IssueTokens(ctx context.Context, userID int64) (TokenPair, error)
+	// RefreshTokens exchanges refreshToken for a new pair. Every refresh token
+	// can be used once; using it again revokes all tokens of its family,
+	// including the access tokens issued for it.
+	RefreshTokens(ctx context.Context, refreshToken string) (TokenPair, error)
+	// Logout revokes the access token of session and every token issued for
+	// the same session.
+	Logout(ctx context.Context, session Session) error
+	// LogoutAll revokes the tokens of every session of userID.
+	LogoutAll(ctx context.Context, userID int64) error
+}
+
+type DefaultTokenService struct {
+	JWT  JWTService
+	Repo repository.RefreshTokenRepository
+	// Users is read on every issue and refresh, so that a changed role is in
+	// the next access token.
+	Users       repository.UserRepository
+	Revocations *RevocationList
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+}
+
+func NewTokenService(jwtService JWTService, repo repository.RefreshTokenRepository, users repository.UserRepository, revocations *RevocationList, accessTTL, refreshTTL time.Duration) DefaultTokenService {
+	return DefaultTokenService{
+		JWT:         jwtService,
+		Repo:        repo,
+		Users:       users,
+		Revocations: revocations,
+		AccessTTL:   accessTTL,
+		RefreshTTL:  refreshTTL,
+	}
+}
+
+func (s DefaultTokenService) IssueTokens(ctx context.Context, userID int64) (TokenPair, error) {
+	role, err := s.role(ctx, userID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	familyID, err := randomToken(16)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	_, err = s.Repo.Insert(ctx, models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.RefreshTTL),
+	})
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while storing refresh token: %s", err))
+		return TokenPair{}, err
+	}
+	return s.pair(userID, familyID, role, refreshToken), nil
+}
+
+func (s DefaultTokenService) RefreshTokens(ctx context.Context, refreshToken string) (TokenPair, error) {
+	next, err := randomToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	rotated, err := s.Repo.Rotate(ctx, hashToken(refreshToken), models.RefreshToken{
+		TokenHash: hashToken(next),
+		ExpiresAt: time.Now().Add(s.RefreshTTL),
+	})
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		// The access tokens of the family end after AccessTTL at the latest
+		err := s.Revocations.Revoke(ctx, []models.TokenRevocation{
+			{TokenID: rotated.FamilyID, UserID: rotated.UserID, ExpiresAt: time.Now().Add(s.AccessTTL)},
+		})
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while revoking session of reused refresh token: %s", err))
+			return TokenPair{}, err
+		}
+		loggerx.Error("Refresh token reused, all tokens of its family are revoked")
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while rotating refresh token: %s", err))
+		return TokenPair{}, err
+	}
+	role, err := s.role(ctx, rotated.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+	loggerx.Info("Refresh token rotated successfully")
+	return s.pair(rotated.UserID, rotated.FamilyID, role, next), nil
+}
+
+func (s DefaultTokenService) Logout(ctx context.Context, session Session) error {
+	if err := s.Repo.RevokeFamily(ctx, session.SessionID); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking refresh tokens: %s", err))
+		return err
+	}
+	// Access tokens issued earlier for the session end after AccessTTL at the latest
+	err := s.Revocations.Revoke(ctx, []models.TokenRevocation{
+		{TokenID: session.TokenID, UserID: session.UserID, ExpiresAt: session.ExpiresAt},
+		{TokenID: session.SessionID, UserID: session.UserID, ExpiresAt: time.Now().Add(s.AccessTTL)},
+	})
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking session: %s", err))
+		return err
+	}
+	loggerx.Info("Session logged out successfully")
+	return nil
+}
+
+func (s DefaultTokenService) LogoutAll(ctx context.Context, userID int64) error {
+	families, err := s.Repo.RevokeUser(ctx, userID)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking refresh tokens: %s", err))
+		return err
+	}
+	expires := time.Now().Add(s.AccessTTL)
+	revocations := make([]models.TokenRevocation, len(families))
+	for i, familyID := range families {
+		revocations[i] = models.TokenRevocation{TokenID: familyID, UserID: userID, ExpiresAt: expires}
+	}
+	if err := s.Revocations.Revoke(ctx, revocations); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while revoking sessions: %s", err))
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("Logged out %d sessions of user %d", len(families), userID))
+	return nil
+}
+
+func (s DefaultTokenService) role(ctx context.Context, userID int64) (models.Role, error) {
+	user, err := s.Users.FindByUserID(ctx, strconv.FormatInt(userID, 10))
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while reading role of user %d: %s", userID, err))
+		return "", err
+	}
+	return user.Role, nil
+}
+
+func (s DefaultTokenService) pair(userID int64, sessionID string, role models.Role, refreshToken string) TokenPair {
+	return TokenPair{
+		AccessToken:  s.JWT.GenerateToken(strconv.FormatInt(userID, 10), sessionID, role),
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.AccessTTL,
+	}
+}
+
+// randomToken returns n random bytes encoded for use in URLs and headers.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is the form a refresh token is stored in. The tokens are random,
+// so a plain SHA-256 is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"konzek-jun/configs"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestTokenService returns a token service and the id of a user with role.
+func newTestTokenService(t *testing.T, role models.Role) (DefaultTokenService, int64) {
+	jwtService, err := NewJWTService(configs.JWTConfig{Secret: "0123456789abcdef0123456789abcdef", Issuer: "test", TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revocations := NewRevocationList(repository.NewMemoryTokenRevocationRepository())
+	users := repository.NewMemoryUserRepository()
+	user, err := users.InsertUser(context.Background(), models.User{Name: "Token User", Email: "token@example.com", Password: "testpass", Role: role})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTokenService(jwtService, repository.NewMemoryRefreshTokenRepository(), users, revocations, time.Minute, time.Hour), user.ID
+}
+
+func TestDefaultTokenService_IssueTokens(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, userID := newTestTokenService(t, models.RoleMember)
+
+	// Servis fonksiyonunun çağrılması
+	pair, err := tokenService.IssueTokens(context.Background(), userID)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.NotEmpty(t, pair.RefreshToken)
+	assert.Equal(t, time.Minute, pair.ExpiresIn)
+	token := tokenService.JWT.ValidateToken(pair.AccessToken)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, strconv.FormatInt(userID, 10), token.Claims.(jwt.MapClaims)["user_id"])
+	}
+}
+
+func TestDefaultTokenService_RefreshTokens_Rotates(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, userID := newTestTokenService(t, models.RoleMember)
+	first, err := tokenService.IssueTokens(context.Background(), userID)
+	assert.NoError(t, err)
+
+	// Servis fonksiyonunun çağrılması
+	second, err := tokenService.RefreshTokens(context.Background(), first.RefreshToken)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	token := tokenService.JWT.ValidateToken(second.AccessToken)
+	if assert.NotNil(t, token) {
+		assert.Equal(t, strconv.FormatInt(userID, 10), token.Claims.(jwt.MapClaims)["user_id"])
+	}
+	_, err = tokenService.RefreshTokens(context.Background(), second.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestDefaultTokenService_RefreshTokens_ReadsRole(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, userID := newTestTokenService(t, models.RoleViewer)
+	first, _ := tokenService.IssueTokens(context.Background(), userID)
+	_, err := tokenService.Users.SetRole(context.Background(), userID, models.RoleAdmin)
+	assert.NoError(t, err)
+
+	// Servis fonksiyonunun çağrılması
+	second, err := tokenService.RefreshTokens(context.Background(), first.RefreshToken)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	// Rol değişikliği bir sonraki access token'da görülür
+	assert.Equal(t, "viewer", tokenService.JWT.ValidateToken(first.AccessToken).Claims.(jwt.MapClaims)["role"])
+	assert.Equal(t, "admin", tokenService.JWT.ValidateToken(second.AccessToken).Claims.(jwt.MapClaims)["role"])
+}
+
+func TestDefaultTokenService_RefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, userID := newTestTokenService(t, models.RoleMember)
+	first, _ := tokenService.IssueTokens(context.Background(), userID)
+	second, _ := tokenService.RefreshTokens(context.Background(), first.RefreshToken)
+	other, _ := tokenService.IssueTokens(context.Background(), userID)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := tokenService.RefreshTokens(context.Background(), first.RefreshToken)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	// Çalınmış olabilecek ailenin en yeni token'ı da geçersizdir
+	_, err = tokenService.RefreshTokens(context.Background(), second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	// Ailenin access token'ları da reddedilir
+	for _, pair := range []TokenPair{first, second} {
+		current := session(t, tokenService, pair)
+		assert.True(t, tokenService.Revocations.IsRevoked(current.TokenID, current.SessionID))
+	}
+	otherSession := session(t, tokenService, other)
+	assert.False(t, tokenService.Revocations.IsRevoked(otherSession.TokenID, otherSession.SessionID))
+	// Başka bir girişin token'ları etkilenmez
+	_, err = tokenService.RefreshTokens(context.Background(), other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestDefaultTokenService_RefreshTokens_Unknown(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, _ := newTestTokenService(t, models.RoleMember)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := tokenService.RefreshTokens(context.Background(), "unknown")
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+// session, middleware'in pair.AccessToken için oluşturduğu oturumdur
+func session(t *testing.T, tokenService DefaultTokenService, pair TokenPair) Session {
+	token := tokenService.JWT.ValidateToken(pair.AccessToken)
+	if token == nil {
+		t.Fatal("access token is invalid")
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	userID, _ := strconv.ParseInt(claims["user_id"].(string), 10, 64)
+	return Session{
+		UserID:    userID,
+		TokenID:   claims["jti"].(string),
+		SessionID: claims["sid"].(string),
+		ExpiresAt: time.Unix(int64(claims["exp"].(float64)), 0),
+	}
+}
+
+func TestDefaultTokenService_Logout(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, userID := newTestTokenService(t, models.RoleMember)
+	first, _ := tokenService.IssueTokens(context.Background(), userID)
+	second, _ := tokenService.RefreshTokens(context.Background(), first.RefreshToken)
+	other, _ := tokenService.IssueTokens(context.Background(), userID)
+	current := session(t, tokenService, second)
+
+	// Servis fonksiyonunun çağrılması
+	err := tokenService.Logout(context.Background(), current)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.True(t, tokenService.Revocations.IsRevoked(current.TokenID))
+	// Aynı oturumun önceki access token'ı ve refresh token'ı da geçersizdir
+	assert.True(t, tokenService.Revocations.IsRevoked(session(t, tokenService, first).SessionID))
+	_, err = tokenService.RefreshTokens(context.Background(), second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	// Diğer oturum etkilenmez
+	otherSession := session(t, tokenService, other)
+	assert.False(t, tokenService.Revocations.IsRevoked(otherSession.TokenID, otherSession.SessionID))
+	_, err = tokenService.RefreshTokens(context.Background(), other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestDefaultTokenService_LogoutAll(t *testing.T) {
+	// Test için hazırlıkları yap
+	tokenService, userID := newTestTokenService(t, models.RoleMember)
+	laptop, _ := tokenService.IssueTokens(context.Background(), userID)
+	phone, _ := tokenService.IssueTokens(context.Background(), userID)
+
+	// Servis fonksiyonunun çağrılması
+	err := tokenService.LogoutAll(context.Background(), userID)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	for _, pair := range []TokenPair{laptop, phone} {
+		assert.True(t, tokenService.Revocations.IsRevoked(session(t, tokenService, pair).SessionID))
+		_, err = tokenService.RefreshTokens(context.Background(), pair.RefreshToken)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestRevocationList_Sync(t *testing.T) {
+	// Test için hazırlıkları yap
+	repo := repository.NewMemoryTokenRevocationRepository()
+	local := NewRevocationList(repo)
+	remote := NewRevocationList(repo)
+	err := remote.Revoke(context.Background(), []models.TokenRevocation{
+		{TokenID: "revoked", UserID: 7, ExpiresAt: time.Now().Add(time.Minute)},
+		{TokenID: "expired", UserID: 7, ExpiresAt: time.Now().Add(-time.Second)},
+	})
+	assert.NoError(t, err)
+
+	// Başka bir sunucunun iptalleri Sync ile görülür
+	assert.False(t, local.IsRevoked("revoked"))
+	assert.NoError(t, local.Sync(context.Background()))
+	assert.True(t, local.IsRevoked("other", "revoked"))
+	assert.False(t, local.IsRevoked("expired"))
+	assert.False(t, remote.IsRevoked("expired"))
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"konzek-jun/dto"
@@ -12,23 +13,34 @@ import (
 )
 
 type UserService interface {
-	CreateUser(registerRequest dto.RegisterRequest) (*dto.UserResponse, error)
-	UpdateUser(updateUserRequest dto.UpdateUserRequest) (*dto.UserResponse, error)
-	FindUserByEmail(email string) (*dto.UserResponse, error)
-	FindUserByID(userID string) (*dto.UserResponse, error)
+	CreateUser(ctx context.Context, registerRequest dto.RegisterRequest) (*dto.UserResponse, error)
+	UpdateUser(ctx context.Context, updateUserRequest dto.UpdateUserRequest) (*dto.UserResponse, error)
+	FindUserByEmail(ctx context.Context, email string) (*dto.UserResponse, error)
+	FindUserByID(ctx context.Context, userID string) (*dto.UserResponse, error)
+	ListUsers(ctx context.Context) ([]dto.UserResponse, error)
+	// SetUserRole changes the role of userID. Tokens issued before keep the
+	// old role until they are refreshed.
+	SetUserRole(ctx context.Context, userID int64, role models.Role) (*dto.UserResponse, error)
 }
 
+// ErrUserExists is returned when a user registers with an email that is already in use.
+var ErrUserExists = errors.New("user already exists")
+
 type userService struct {
 	userRepo repository.UserRepository
+	// unitOfWork runs the email check and the insert of CreateUser in one
+	// transaction. Without it they run one after the other.
+	unitOfWork repository.UnitOfWork
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, unitOfWork repository.UnitOfWork) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:   userRepo,
+		unitOfWork: unitOfWork,
 	}
 }
 
-func (c *userService) UpdateUser(updateUserRequest dto.UpdateUserRequest) (*dto.UserResponse, error) {
+func (c *userService) UpdateUser(ctx context.Context, updateUserRequest dto.UpdateUserRequest) (*dto.UserResponse, error) {
 	loggerx.Info("UpdateUser function called")
 
 	user := models.User{}
@@ -38,7 +50,7 @@ func (c *userService) UpdateUser(updateUserRequest dto.UpdateUserRequest) (*dto.
 		return nil, err
 	}
 
-	user, err = c.userRepo.UpdateUser(user)
+	user, err = c.userRepo.UpdateUser(ctx, user)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while updating user: %s", err))
 		return nil, err
@@ -49,31 +61,46 @@ func (c *userService) UpdateUser(updateUserRequest dto.UpdateUserRequest) (*dto.
 	return &res, nil
 }
 
-func (c *userService) CreateUser(registerRequest dto.RegisterRequest) (*dto.UserResponse, error) {
+func (c *userService) CreateUser(ctx context.Context, registerRequest dto.RegisterRequest) (*dto.UserResponse, error) {
 	loggerx.Info("CreateUser function called")
 
-	user, err := c.userRepo.FindByEmail(registerRequest.Email)
-	if err == nil {
-		loggerx.Error("User already exists")
-		return nil, errors.New("user already exists")
-	}
-
-	err = smapping.FillStruct(&user, smapping.MapFields(&registerRequest))
+	var user models.User
+	err := c.inTx(ctx, func(users repository.UserRepository) error {
+		if _, err := users.FindByEmail(ctx, registerRequest.Email); err == nil {
+			loggerx.Error("User already exists")
+			return ErrUserExists
+		}
+
+		user = models.User{}
+		if err := smapping.FillStruct(&user, smapping.MapFields(&registerRequest)); err != nil {
+			loggerx.Error(fmt.Sprintf("Failed to map user: %s", err))
+			return err
+		}
+
+		var err error
+		user, err = users.InsertUser(ctx, user)
+		if errors.Is(err, repository.ErrEmailTaken) {
+			// A concurrent registration with the same email
+			loggerx.Error("User already exists")
+			return ErrUserExists
+		}
+		if err != nil {
+			loggerx.Error(fmt.Sprintf("Error while inserting user: %s", err))
+		}
+		return err
+	})
 	if err != nil {
-		loggerx.Error(fmt.Sprintf("Failed to map user: %s", err))
 		return nil, err
 	}
-
-	user, _ = c.userRepo.InsertUser(user)
 	res := dto.NewUserResponse(user)
 	loggerx.Info("User created successfully")
 	return &res, nil
 }
 
-func (c *userService) FindUserByEmail(email string) (*dto.UserResponse, error) {
+func (c *userService) FindUserByEmail(ctx context.Context, email string) (*dto.UserResponse, error) {
 	loggerx.Info("FindUserByEmail function called")
 
-	user, err := c.userRepo.FindByEmail(email)
+	user, err := c.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while finding user by email: %s", err))
 		return nil, err
@@ -84,10 +111,10 @@ func (c *userService) FindUserByEmail(email string) (*dto.UserResponse, error) {
 	return &userResponse, nil
 }
 
-func (c *userService) FindUserByID(userID string) (*dto.UserResponse, error) {
+func (c *userService) FindUserByID(ctx context.Context, userID string) (*dto.UserResponse, error) {
 	loggerx.Info("FindUserByID function called")
 
-	user, err := c.userRepo.FindByUserID(userID)
+	user, err := c.userRepo.FindByUserID(ctx, userID)
 	if err != nil {
 		loggerx.Error(fmt.Sprintf("Error while finding user by ID: %s", err))
 		return nil, err
@@ -103,3 +130,75 @@ func (c *userService) FindUserByID(userID string) (*dto.UserResponse, error) {
 	loggerx.Info("User found by ID successfully")
 	return &userResponse, nil
 }
+
+func (c *userService) ListUsers(ctx context.Context) ([]dto.UserResponse, error) {
+	loggerx.Info("ListUsers function called")
+
+	users, err := c.userRepo.ListUsers(ctx)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while listing users: %s", err))
+		return nil, err
+	}
+
+	res := make([]dto.UserResponse, len(users))
+	for i, user := range users {
+		res[i] = dto.NewUserResponse(user)
+	}
+	return res, nil
+}
+
+func (c *userService) SetUserRole(ctx context.Context, userID int64, role models.Role) (*dto.UserResponse, error) {
+	loggerx.Info("SetUserRole function called")
+
+	user, err := c.userRepo.SetRole(ctx, userID, role)
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while setting role of user %d: %s", userID, err))
+		return nil, err
+	}
+
+	res := dto.NewUserResponse(user)
+	loggerx.Info(fmt.Sprintf("Role of user %d set to %s", userID, role))
+	return &res, nil
+}
+
+// inTx runs fn with the user repository of one transaction of c.unitOfWork,
+// or with c.userRepo when there is no unit of work.
+func (c *userService) inTx(ctx context.Context, fn func(users repository.UserRepository) error) error {
+	if c.unitOfWork == nil {
+		return fn(c.userRepo)
+	}
+	return c.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		return fn(repos.Users)
+	})
+}
+
+// SeedAdmin makes the user with email an admin, so that a new installation
+// has someone to manage roles. A user that does not exist yet is created
+// with password; an existing user keeps its password.
+func SeedAdmin(ctx context.Context, users repository.UserRepository, email, password string) error {
+	user, err := users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		if password == "" {
+			return fmt.Errorf("user %s does not exist and no password was given to create it", email)
+		}
+		if _, err := users.InsertUser(ctx, models.User{Name: "Admin", Email: email, Password: password, Role: models.RoleAdmin}); err != nil {
+			loggerx.Error(fmt.Sprintf("Error while creating admin %s: %s", email, err))
+			return err
+		}
+		loggerx.Info(fmt.Sprintf("Admin %s created", email))
+		return nil
+	}
+	if err != nil {
+		loggerx.Error(fmt.Sprintf("Error while finding admin %s: %s", email, err))
+		return err
+	}
+	if user.Role == models.RoleAdmin {
+		return nil
+	}
+	if _, err := users.SetRole(ctx, user.ID, models.RoleAdmin); err != nil {
+		loggerx.Error(fmt.Sprintf("Error while promoting %s to admin: %s", email, err))
+		return err
+	}
+	loggerx.Info(fmt.Sprintf("User %s promoted to admin", email))
+	return nil
+}
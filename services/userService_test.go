@@ -1,10 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"konzek-jun/dto"
 	"konzek-jun/mocks/repository"
 	"konzek-jun/models"
+	taskrepo "konzek-jun/repository"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -23,7 +25,7 @@ var FakeUser = dto.RegisterRequest{
 func setupUser(t *testing.T) func() {
 	ctrl := gomock.NewController(t)
 	mockRepository = repository.NewMockUserRepository(ctrl)
-	mockService = NewUserService(mockRepository)
+	mockService = NewUserService(mockRepository, nil)
 
 	return func() {
 		service = nil
@@ -37,13 +39,13 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 	defer td()
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockRepository.EXPECT().FindByEmail(FakeUser.Email).Return(models.User{}, errors.New("some error"))
-	mockRepository.EXPECT().InsertUser(gomock.Any()).Return(models.User{
+	mockRepository.EXPECT().FindByEmail(gomock.Any(), FakeUser.Email).Return(models.User{}, errors.New("some error"))
+	mockRepository.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(models.User{
 		Name:  "John Doe",
 		Email: "john@example.com"}, nil)
 
 	// Servis fonksiyonunun çağrılması
-	result, err := mockService.CreateUser(FakeUser)
+	result, err := mockService.CreateUser(context.Background(), FakeUser)
 
 	// Hata kontrolü
 	assert.NoError(t, err)
@@ -56,10 +58,10 @@ func TestUserService_FindUserByEmail_Success(t *testing.T) {
 	defer td()
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockRepository.EXPECT().FindByEmail(gomock.Any()).Return(models.User{ID: 1, Email: "x@x.com"}, nil)
+	mockRepository.EXPECT().FindByEmail(gomock.Any(), gomock.Any()).Return(models.User{ID: 1, Email: "x@x.com"}, nil)
 
 	// Servis fonksiyonunun çağrılması
-	result, err := mockService.FindUserByEmail("x@x.com")
+	result, err := mockService.FindUserByEmail(context.Background(), "x@x.com")
 
 	// Hata kontrolü
 	assert.NoError(t, err)
@@ -72,10 +74,10 @@ func TestUserService_FindUserById_Success(t *testing.T) {
 	defer td()
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockRepository.EXPECT().FindByUserID(gomock.Any()).Return(models.User{ID: 1, Email: "x@x.com"}, nil)
+	mockRepository.EXPECT().FindByUserID(gomock.Any(), gomock.Any()).Return(models.User{ID: 1, Email: "x@x.com"}, nil)
 
 	// Servis fonksiyonunun çağrılması
-	result, err := mockService.FindUserByID("1")
+	result, err := mockService.FindUserByID(context.Background(), "1")
 
 	// Hata kontrolü
 	assert.NoError(t, err)
@@ -88,12 +90,92 @@ func TestUserService_UserUpdate_Success(t *testing.T) {
 	defer td()
 
 	// Mock repository'den beklenen değerlerin ayarlanması
-	mockRepository.EXPECT().UpdateUser(gomock.Any()).Return(models.User{ID: 1, Email: "x@x.com"}, nil)
+	mockRepository.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(models.User{ID: 1, Email: "x@x.com"}, nil)
 
 	// Servis fonksiyonunun çağrılması
-	result, err := mockService.UpdateUser(dto.UpdateUserRequest{ID: 1, Email: "x@x.com"})
+	result, err := mockService.UpdateUser(context.Background(), dto.UpdateUserRequest{ID: 1, Email: "x@x.com"})
 
 	// Hata kontrolü
 	assert.NoError(t, err)
 	assert.Equal(t, result.Email, "x@x.com")
 }
+
+func TestUserService_CreateUser_InsertError(t *testing.T) {
+	// Test için hazırlıkları yap
+	td := setupUser(t)
+	defer td()
+
+	// Kayıt hatası artık yutulmaz
+	mockRepository.EXPECT().FindByEmail(gomock.Any(), FakeUser.Email).Return(models.User{}, taskrepo.ErrUserNotFound)
+	mockRepository.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(models.User{}, errors.New("connection refused"))
+
+	// Servis fonksiyonunun çağrılması
+	result, err := mockService.CreateUser(context.Background(), FakeUser)
+
+	// Hata kontrolü
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Nil(t, result)
+}
+
+func TestUserService_CreateUser_EmailTaken(t *testing.T) {
+	// Test için hazırlıkları yap
+	td := setupUser(t)
+	defer td()
+
+	// Aynı email ile eşzamanlı kayıt kontrolden sonra da yakalanır
+	mockRepository.EXPECT().FindByEmail(gomock.Any(), FakeUser.Email).Return(models.User{}, taskrepo.ErrUserNotFound)
+	mockRepository.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(models.User{}, taskrepo.ErrEmailTaken)
+
+	// Servis fonksiyonunun çağrılması
+	_, err := mockService.CreateUser(context.Background(), FakeUser)
+
+	// Hata kontrolü
+	assert.ErrorIs(t, err, ErrUserExists)
+}
+
+func TestUserService_CreateUser_UsesUnitOfWork(t *testing.T) {
+	// Test için hazırlıkları yap
+	ctrl := gomock.NewController(t)
+	txRepository := repository.NewMockUserRepository(ctrl)
+	unitOfWork := &fakeUnitOfWork{repos: taskrepo.Repositories{Users: txRepository}}
+	userService := NewUserService(repository.NewMockUserRepository(ctrl), unitOfWork)
+
+	// Bütün çağrılar transaction'a bağlı repository'ye gider
+	txRepository.EXPECT().FindByEmail(gomock.Any(), FakeUser.Email).Return(models.User{}, taskrepo.ErrUserNotFound)
+	txRepository.EXPECT().InsertUser(gomock.Any(), gomock.Any()).Return(models.User{ID: 7, Email: FakeUser.Email}, nil)
+
+	// Servis fonksiyonunun çağrılması
+	result, err := userService.CreateUser(context.Background(), FakeUser)
+
+	// Hata kontrolü
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), result.ID)
+	assert.Equal(t, 1, unitOfWork.calls)
+}
+
+func TestSeedAdmin(t *testing.T) {
+	ctx := context.Background()
+	users := taskrepo.NewMemoryUserRepository()
+
+	// Kullanıcı yoksa şifre olmadan oluşturulamaz
+	assert.Error(t, SeedAdmin(ctx, users, "admin@example.com", ""))
+
+	assert.NoError(t, SeedAdmin(ctx, users, "admin@example.com", "secret1"))
+	admin, err := users.FindByEmail(ctx, "admin@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, admin.Role)
+
+	// Sonraki açılışlarda aynı kullanıcı tekrar oluşturulmaz
+	assert.NoError(t, SeedAdmin(ctx, users, "admin@example.com", "secret1"))
+	all, err := users.ListUsers(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// Var olan kullanıcı admin yapılır
+	member, err := users.InsertUser(ctx, models.User{Name: "Jane", Email: "jane@example.com", Password: "secret2"})
+	assert.NoError(t, err)
+	assert.NoError(t, SeedAdmin(ctx, users, "jane@example.com", ""))
+	member, err = users.FindByEmail(ctx, member.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleAdmin, member.Role)
+}
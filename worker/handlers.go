@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"konzek-jun/retry"
+)
+
+// RegisterBuiltins adds the job types that ship with the service.
+func RegisterBuiltins(r *Registry) {
+	r.Register("echo", echoHandler)
+	r.Register("sleep", sleepHandler)
+}
+
+// echoHandler returns its payload unchanged.
+func echoHandler(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+	return payload, nil
+}
+
+type sleepPayload struct {
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// sleepHandler waits for duration_ms milliseconds or until the job is
+// cancelled. An invalid payload fails the same way on every attempt, so it is
+// not retried.
+func sleepHandler(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+	var p sleepPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, retry.Permanent(fmt.Errorf("invalid sleep payload: %w", err))
+	}
+	if p.DurationMs < 0 {
+		return nil, retry.Permanent(fmt.Errorf("invalid sleep payload: duration_ms must not be negative, got %d", p.DurationMs))
+	}
+
+	select {
+	case <-time.After(time.Duration(p.DurationMs) * time.Millisecond):
+		return json.RawMessage(fmt.Sprintf(`{"slept_ms":%d}`, p.DurationMs)), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"konzek-jun/loggerx"
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/retry"
+	"os"
+	"sync"
+	"time"
+)
+
+// Pool runs jobs leased from a JobQueue with a fixed number of workers. Pools
+// in different processes can share the same queue.
+type Pool struct {
+	Queue    repository.JobQueue
+	Registry *Registry
+	Workers  int
+	// PollInterval is how long an idle worker waits before asking for a job again.
+	PollInterval time.Duration
+	// VisibilityTimeout is how long a leased job stays hidden from other
+	// workers without a heartbeat.
+	VisibilityTimeout time.Duration
+	// HeartbeatInterval must be well below VisibilityTimeout.
+	HeartbeatInterval time.Duration
+	// ReclaimInterval is how often expired leases of crashed workers are released.
+	ReclaimInterval time.Duration
+	// Name identifies this process in lease_owner.
+	Name string
+	// Retry sets the backoff between attempts of a failed job and which
+	// errors are retried. The number of attempts comes from the job itself.
+	Retry retry.Policy
+}
+
+// DefaultJobRetryPolicy retries every job error that is not marked
+// retry.Permanent, waiting 5s, 10s, 20s... up to 5 minutes between attempts.
+var DefaultJobRetryPolicy = retry.Policy{
+	InitialBackoff: 5 * time.Second,
+	MaxBackoff:     5 * time.Minute,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+func NewPool(queue repository.JobQueue, registry *Registry, workers int) *Pool {
+	hostname, _ := os.Hostname()
+	return &Pool{
+		Queue:             queue,
+		Registry:          registry,
+		Workers:           workers,
+		PollInterval:      time.Second,
+		VisibilityTimeout: 30 * time.Second,
+		HeartbeatInterval: 10 * time.Second,
+		ReclaimInterval:   30 * time.Second,
+		Name:              fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		Retry:             DefaultJobRetryPolicy,
+	}
+}
+
+// Run starts the workers and blocks until ctx is cancelled and every
+// in-flight job has been recorded.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.Workers; i++ {
+		wg.Add(1)
+		go func(workerID string) {
+			defer wg.Done()
+			p.work(ctx, workerID)
+		}(fmt.Sprintf("%s-%d", p.Name, i))
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.reclaim(ctx)
+	}()
+
+	wg.Wait()
+	loggerx.Info("Job worker pool stopped")
+}
+
+func (p *Pool) work(ctx context.Context, workerID string) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := p.Queue.Lease(ctx, workerID, p.VisibilityTimeout)
+		if err != nil {
+			if !errors.Is(err, repository.ErrNoPendingJob) && ctx.Err() == nil {
+				loggerx.Error(fmt.Sprintf("Worker %s could not lease a job: %v", workerID, err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.PollInterval):
+			}
+			continue
+		}
+
+		p.execute(ctx, workerID, job)
+	}
+}
+
+func (p *Pool) reclaim(ctx context.Context) {
+	ticker := time.NewTicker(p.ReclaimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.Queue.ReclaimExpired(ctx); err != nil && ctx.Err() == nil {
+				loggerx.Error(fmt.Sprintf("Could not reclaim expired job leases: %v", err))
+			}
+		}
+	}
+}
+
+func (p *Pool) execute(ctx context.Context, workerID string, job models.Job) {
+	jobCtx, cancelJob := context.WithCancel(ctx)
+	defer cancelJob()
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		p.heartbeat(jobCtx, cancelJob, workerID, job.ID)
+	}()
+
+	start := time.Now()
+	result, err := p.runHandler(jobCtx, job)
+	duration := time.Since(start)
+
+	cancelJob()
+	<-heartbeatDone
+
+	// The job outcome is recorded even when the pool is shutting down. A job
+	// that failed because of the shutdown is released for the next worker.
+	recordCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var recordErr error
+	if err != nil && ctx.Err() != nil {
+		// The job was cut short by the pool shutting down, so no attempt is used up
+		recordErr = p.Queue.Release(recordCtx, job.ID, workerID)
+	} else if err != nil {
+		recordErr = p.fail(recordCtx, workerID, job, err, duration)
+	} else {
+		recordErr = p.Queue.Complete(recordCtx, job.ID, workerID, result, duration)
+	}
+	if errors.Is(recordErr, repository.ErrLeaseLost) {
+		loggerx.Error(fmt.Sprintf("Lease of job %d was lost before its outcome could be recorded", job.ID))
+	} else if recordErr != nil {
+		loggerx.Error(fmt.Sprintf("Could not record outcome of job %d: %v", job.ID, recordErr))
+	}
+}
+
+// fail schedules another attempt of a failed job, or dead-letters it when the
+// error is not retryable or the job has no attempts left.
+func (p *Pool) fail(ctx context.Context, workerID string, job models.Job, jobErr error, duration time.Duration) error {
+	if p.Retry.IsRetryable(jobErr) && job.Attempts < job.MaxAttempts {
+		runAfter := time.Now().Add(p.Retry.Backoff(job.Attempts))
+		return p.Queue.Retry(ctx, job.ID, workerID, jobErr.Error(), duration, runAfter)
+	}
+	return p.Queue.DeadLetter(ctx, job.ID, workerID, jobErr.Error(), duration)
+}
+
+// heartbeat keeps the lease of a running job alive. If the lease is lost the
+// job is cancelled, since another worker may already be running it.
+func (p *Pool) heartbeat(ctx context.Context, cancelJob context.CancelFunc, workerID string, jobID int) {
+	ticker := time.NewTicker(p.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.Queue.Heartbeat(ctx, jobID, workerID, p.VisibilityTimeout)
+			if errors.Is(err, repository.ErrLeaseLost) {
+				loggerx.Error(fmt.Sprintf("Lease of job %d lost, cancelling it", jobID))
+				cancelJob()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				loggerx.Error(fmt.Sprintf("Could not extend lease of job %d: %v", jobID, err))
+			}
+		}
+	}
+}
+
+func (p *Pool) runHandler(ctx context.Context, job models.Job) (result []byte, err error) {
+	handler, ok := p.Registry.Lookup(job.Type)
+	if !ok {
+		return nil, retry.Permanent(fmt.Errorf("no handler registered for job type %q", job.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
@@ -0,0 +1,227 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"konzek-jun/models"
+	"konzek-jun/repository"
+	"konzek-jun/retry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPool(queue repository.JobQueue, registry *Registry, name string) *Pool {
+	pool := NewPool(queue, registry, 2)
+	pool.Name = name
+	pool.PollInterval = 5 * time.Millisecond
+	pool.HeartbeatInterval = 10 * time.Millisecond
+	pool.VisibilityTimeout = 50 * time.Millisecond
+	pool.ReclaimInterval = 20 * time.Millisecond
+	pool.Retry = retry.Policy{InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Multiplier: 2}
+	return pool
+}
+
+// runUntilFinished havuzları tüm job'lar bitene kadar çalıştırır
+func runUntilFinished(t *testing.T, queue *repository.MemoryJobQueue, ids []int, pools ...*Pool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, pool := range pools {
+		wg.Add(1)
+		go func(pool *Pool) {
+			defer wg.Done()
+			pool.Run(ctx)
+		}(pool)
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for _, id := range ids {
+		for {
+			job, _ := queue.GetJob(context.Background(), 1, id)
+			if job.Status == models.JobStatusSucceeded || job.Status == models.JobStatusDead {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("job %d zamanında bitmedi, durum: %s", id, job.Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+}
+
+func TestPool_RecordsSuccess(t *testing.T) {
+	registry := NewRegistry()
+	RegisterBuiltins(registry)
+	queue := repository.NewMemoryJobQueue()
+	id := queue.Enqueue(models.Job{OwnerID: 1, Type: "echo", Payload: json.RawMessage(`{"hello":"world"}`)})
+
+	runUntilFinished(t, queue, []int{id}, newTestPool(queue, registry, "test"))
+
+	job, _ := queue.GetJob(context.Background(), 1, id)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.JSONEq(t, `{"hello":"world"}`, string(job.Result))
+}
+
+func TestPool_RecordsFailures(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("fail", func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("boom")
+	})
+	registry.Register("panic", func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+		panic("unexpected")
+	})
+	queue := repository.NewMemoryJobQueue()
+	failID := queue.Enqueue(models.Job{OwnerID: 1, Type: "fail"})
+	panicID := queue.Enqueue(models.Job{OwnerID: 1, Type: "panic"})
+	unknownID := queue.Enqueue(models.Job{OwnerID: 1, Type: "unknown"})
+
+	runUntilFinished(t, queue, []int{failID, panicID, unknownID}, newTestPool(queue, registry, "test"))
+
+	failed, _ := queue.GetJob(context.Background(), 1, failID)
+	assert.Equal(t, models.JobStatusDead, failed.Status)
+	assert.Equal(t, models.DefaultJobMaxAttempts, failed.Attempts)
+	assert.Equal(t, "boom", failed.LastError)
+
+	panicked, _ := queue.GetJob(context.Background(), 1, panicID)
+	assert.Equal(t, models.JobStatusDead, panicked.Status)
+	assert.Contains(t, panicked.LastError, "panicked")
+
+	// Bilinmeyen job tipi tekrar denenmeden dead-letter'a düşer
+	unknown, _ := queue.GetJob(context.Background(), 1, unknownID)
+	assert.Equal(t, models.JobStatusDead, unknown.Status)
+	assert.Equal(t, 1, unknown.Attempts)
+	assert.Contains(t, unknown.LastError, "no handler registered")
+}
+
+func TestPool_RetriesUntilSuccess(t *testing.T) {
+	var calls int64
+	registry := NewRegistry()
+	registry.Register("flaky", func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+		if atomic.AddInt64(&calls, 1) < 3 {
+			return nil, errors.New("temporary")
+		}
+		return json.RawMessage(`"ok"`), nil
+	})
+	queue := repository.NewMemoryJobQueue()
+	id := queue.Enqueue(models.Job{OwnerID: 1, Type: "flaky", MaxAttempts: 5})
+
+	runUntilFinished(t, queue, []int{id}, newTestPool(queue, registry, "test"))
+
+	job, _ := queue.GetJob(context.Background(), 1, id)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Empty(t, job.LastError)
+}
+
+func TestPool_SharedQueueRunsEachJobOnce(t *testing.T) {
+	// Aynı kuyruğu paylaşan iki replika her job'ı tek bir kez çalıştırmalı
+	var runs sync.Map
+	var total int64
+	registry := NewRegistry()
+	registry.Register("count", func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+		var id int
+		json.Unmarshal(payload, &id)
+		if _, loaded := runs.LoadOrStore(id, true); loaded {
+			return nil, errors.New("job ran twice")
+		}
+		atomic.AddInt64(&total, 1)
+		return nil, nil
+	})
+
+	queue := repository.NewMemoryJobQueue()
+	var ids []int
+	for i := 1; i <= 20; i++ {
+		payload, _ := json.Marshal(i)
+		ids = append(ids, queue.Enqueue(models.Job{OwnerID: 1, Type: "count", Payload: payload}))
+	}
+
+	runUntilFinished(t, queue, ids, newTestPool(queue, registry, "replica-a"), newTestPool(queue, registry, "replica-b"))
+
+	assert.Equal(t, int64(20), atomic.LoadInt64(&total))
+	for _, id := range ids {
+		job, _ := queue.GetJob(context.Background(), 1, id)
+		assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	}
+}
+
+func TestPool_HeartbeatKeepsLongJobLeased(t *testing.T) {
+	// Job visibility timeout'tan uzun sürse de heartbeat sayesinde geri alınmamalı
+	registry := NewRegistry()
+	RegisterBuiltins(registry)
+	queue := repository.NewMemoryJobQueue()
+	id := queue.Enqueue(models.Job{OwnerID: 1, Type: "sleep", Payload: json.RawMessage(`{"duration_ms":200}`)})
+
+	runUntilFinished(t, queue, []int{id}, newTestPool(queue, registry, "test"))
+
+	job, _ := queue.GetJob(context.Background(), 1, id)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+func TestPool_ShutdownReleasesJobOnLastAttempt(t *testing.T) {
+	// Son denemesindeki job kapanışta dead-letter'a düşmemeli, sırası korunmalı
+	started := make(chan struct{})
+	registry := NewRegistry()
+	registry.Register("block", func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	queue := repository.NewMemoryJobQueue()
+	id := queue.Enqueue(models.Job{OwnerID: 1, Type: "block", MaxAttempts: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		newTestPool(queue, registry, "test").Run(ctx)
+	}()
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job zamanında başlamadı")
+	}
+	cancel()
+	<-stopped
+
+	job, _ := queue.GetJob(context.Background(), 1, id)
+	assert.Equal(t, models.JobStatusPending, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Empty(t, job.LastError)
+
+	// Sonraki açılışta job tam deneme hakkıyla tekrar çalışır
+	registry.Register("block", func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`"done"`), nil
+	})
+	runUntilFinished(t, queue, []int{id}, newTestPool(queue, registry, "test"))
+	job, _ = queue.GetJob(context.Background(), 1, id)
+	assert.Equal(t, models.JobStatusSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+func TestSleepHandler_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := sleepHandler(ctx, json.RawMessage(`{"duration_ms":10000}`))
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSleepHandler_InvalidPayload(t *testing.T) {
+	// Geçersiz payload her denemede aynı hatayı verir, tekrar denenmez
+	for _, payload := range []string{`{"duration_ms":"soon"}`, `{"duration_ms":-1}`} {
+		_, err := sleepHandler(context.Background(), json.RawMessage(payload))
+		assert.True(t, retry.IsPermanent(err), payload)
+	}
+}
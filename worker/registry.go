@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// HandlerFunc runs a single job. The returned result is stored on the task row.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) (json.RawMessage, error)
+
+// Registry maps job types to the Go handlers that execute them.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[string]HandlerFunc),
+	}
+}
+
+func (r *Registry) Register(jobType string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[jobType] = handler
+}
+
+func (r *Registry) Lookup(jobType string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[jobType]
+	return handler, ok
+}
+
+func (r *Registry) Has(jobType string) bool {
+	_, ok := r.Lookup(jobType)
+	return ok
+}